@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/guilhermegouw/matrix-cli/internal/provider"
+)
+
+func newBenchCmd() *cobra.Command {
+	var (
+		runs    int
+		timeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Time a short prompt against each configured tier",
+		Long: `Build each configured tier's model and time a short fixed prompt round
+trip several times, reporting min/avg/max latency and output tokens per
+second. A tier that fails is reported without stopping the others.
+
+Each run is a real request and is billed by the provider.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if runs < 1 {
+				return fmt.Errorf("--runs must be at least 1, got %d", runs)
+			}
+
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return fmt.Errorf("loading config: %w", err)
+			}
+
+			results := provider.NewBuilder(cfg).Bench(cmd.Context(), runs, timeout)
+			printBenchResults(cmd.OutOrStdout(), results)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&runs, "runs", 3, "round trips per tier")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "timeout for each round trip")
+
+	return cmd
+}
+
+// printBenchResults writes one line per benchmarked tier.
+func printBenchResults(out io.Writer, results []provider.BenchResult) {
+	for i := range results {
+		r := &results[i]
+		name := r.Provider + "/" + r.Model
+		if r.Err != nil {
+			fmt.Fprintf(out, "%-6s %-32s failed: %v\n", r.Tier, name, r.Err)
+			continue
+		}
+		fmt.Fprintf(out, "%-6s %-32s min %-8v avg %-8v max %-8v %6.1f tok/s\n",
+			r.Tier, name,
+			r.Min.Round(time.Millisecond), r.Avg.Round(time.Millisecond), r.Max.Round(time.Millisecond),
+			r.TokensPerSec)
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+func newCompletionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "completion [bash|zsh|fish|powershell]",
+		Short: "Generate the shell completion script",
+		Long: `Generate the completion script for the given shell.
+
+To load completions in the current bash session:
+
+  source <(matrix completion bash)
+
+For zsh, fish and powershell, write the output to a file loaded by your
+shell's startup configuration.`,
+		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+		Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := cmd.Root()
+			out := cmd.OutOrStdout()
+
+			switch args[0] {
+			case "bash":
+				return root.GenBashCompletionV2(out, true)
+			case "zsh":
+				return root.GenZshCompletion(out)
+			case "fish":
+				return root.GenFishCompletion(out, true)
+			case "powershell":
+				return root.GenPowerShellCompletionWithDesc(out)
+			default:
+				return fmt.Errorf("unsupported shell %q", args[0])
+			}
+		},
+	}
+}
+
+// completeProviderIDs completes the IDs of the configured providers.
+func completeProviderIDs(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return providerIDCompletions(cfg, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// providerIDCompletions returns the configured provider IDs matching prefix.
+func providerIDCompletions(cfg *config.Config, prefix string) []string {
+	return filterPrefix(cfg.ProviderIDs(), prefix)
+}
+
+// completeTiers completes the model tier names not already given.
+func completeTiers(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	names := slices.DeleteFunc(tierNames(), func(name string) bool {
+		return slices.Contains(args, name)
+	})
+	return filterPrefix(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// filterPrefix returns the values starting with prefix.
+func filterPrefix(values []string, prefix string) []string {
+	var matches []string
+	for _, v := range values {
+		if strings.HasPrefix(v, prefix) {
+			matches = append(matches, v)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+func TestCompletionCmd_Shells(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		t.Run(shell, func(t *testing.T) {
+			root := newRootCmd()
+			var out bytes.Buffer
+			root.SetOut(&out)
+			root.SetArgs([]string{"completion", shell})
+
+			if err := root.Execute(); err != nil {
+				t.Fatalf("completion %s error = %v", shell, err)
+			}
+			if out.Len() == 0 {
+				t.Errorf("completion %s produced no output", shell)
+			}
+		})
+	}
+}
+
+func TestCompletionCmd_UnknownShell(t *testing.T) {
+	root := newRootCmd()
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"completion", "tcsh"})
+
+	if err := root.Execute(); err == nil {
+		t.Error("completion tcsh should fail")
+	}
+}
+
+func TestProviderIDCompletions(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Providers["openai"] = &config.ProviderConfig{ID: "openai"}
+	cfg.Providers["openrouter"] = &config.ProviderConfig{ID: "openrouter"}
+	cfg.Providers["anthropic"] = &config.ProviderConfig{ID: "anthropic"}
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"", []string{"anthropic", "openai", "openrouter"}},
+		{"open", []string{"openai", "openrouter"}},
+		{"x", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			got := providerIDCompletions(cfg, tt.prefix)
+			if len(got) != len(tt.want) {
+				t.Fatalf("providerIDCompletions(%q) = %v, want %v", tt.prefix, got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("providerIDCompletions(%q)[%d] = %q, want %q", tt.prefix, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCompleteTiers(t *testing.T) {
+	got, _ := completeTiers(nil, nil, "l")
+	if len(got) != 1 || got[0] != string(config.SelectedModelTypeLarge) {
+		t.Errorf("completeTiers(%q) = %v, want [large]", "l", got)
+	}
+
+	// Tiers already given are not offered again.
+	got, _ = completeTiers(nil, []string{"large"}, "")
+	if len(got) != 1 || got[0] != string(config.SelectedModelTypeSmall) {
+		t.Errorf("completeTiers() after large = %v, want [small]", got)
+	}
+}
+
+func TestModelsListCmd_CompletesTiers(t *testing.T) {
+	cmd, _, err := newRootCmd().Find([]string{"models", "list"})
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if cmd.ValidArgsFunction == nil {
+		t.Fatal("models list should complete tier arguments")
+	}
+	got, _ := cmd.ValidArgsFunction(cmd, nil, "s")
+	if len(got) != 1 || got[0] != string(config.SelectedModelTypeSmall) {
+		t.Errorf("ValidArgsFunction(%q) = %v, want [small]", "s", got)
+	}
+}
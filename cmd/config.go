@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the global config file",
+	}
+
+	cmd.AddCommand(newConfigImportEnvCmd())
+	cmd.AddCommand(newConfigCheckEnvCmd())
+	cmd.AddCommand(newConfigWhichCmd())
+	cmd.AddCommand(newConfigPathCmd())
+	cmd.AddCommand(newConfigDumpCmd())
+
+	return cmd
+}
+
+func newConfigImportEnvCmd() *cobra.Command {
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "import-env",
+		Short: "Import API key references from a shell rc file",
+		Long: `Scan a shell rc file for "export KEY=VALUE" lines and configure every
+known provider whose API key variable is exported there.
+
+Only the "$KEY" reference is written to the config, never the value, so the
+secret stays in your shell environment.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if file == "" {
+				home, err := os.UserHomeDir()
+				if err != nil {
+					return fmt.Errorf("finding home directory: %w", err)
+				}
+				file = filepath.Join(home, ".bashrc")
+			}
+
+			f, err := os.Open(file) //nolint:gosec // The rc file path is chosen by the user.
+			if err != nil {
+				return fmt.Errorf("opening %s: %w", file, err)
+			}
+			defer f.Close() //nolint:errcheck
+
+			names, err := config.ParseShellExports(f)
+			if err != nil {
+				return err
+			}
+
+			providers, err := config.LoadProviders(config.NewConfig())
+			if err != nil {
+				return fmt.Errorf("loading providers: %w", err)
+			}
+
+			out := cmd.OutOrStdout()
+			path := config.GlobalConfigPath()
+			applied, err := config.ImportEnvReferences(path, config.MatchEnvProviders(names, providers))
+			if err != nil {
+				return err
+			}
+			if len(applied) == 0 {
+				fmt.Fprintf(out, "No new provider keys found in %s\n", file)
+				return nil
+			}
+
+			for _, imp := range applied {
+				fmt.Fprintf(out, "%-16s api_key = %s\n", imp.ProviderID, imp.Reference)
+			}
+			fmt.Fprintf(out, "Updated %s\n", path)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&file, "file", "", "shell rc file to scan (default ~/.bashrc)")
+
+	return cmd
+}
+
+func newConfigCheckEnvCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "check-env",
+		Short: "Fail if any config value references an unset environment variable",
+		Long: `Resolve every provider API key, base URL and header, and the default
+headers, against the current environment, and exit non-zero listing each
+unresolved variable.
+
+Loading the config tolerates an unresolvable API key by dropping the
+provider; this check is stricter and suits CI gating. It checks the file
+given with --config, or the global and project config files.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			files := config.SourceFiles()
+			if location, _ := cmd.Flags().GetString("config"); location != "" {
+				if config.IsConfigURL(location) {
+					return fmt.Errorf("check-env needs a local config file, got %s", location)
+				}
+				files = []string{location}
+			}
+			if len(files) == 0 {
+				return fmt.Errorf("no config file found")
+			}
+
+			issues, err := config.CheckEnv(files, config.NewResolver())
+			if err != nil {
+				return err
+			}
+			return printEnvIssues(cmd.OutOrStdout(), issues)
+		},
+	}
+}
+
+// printEnvIssues lists the unresolved references and returns an error when
+// there are any, so the command exits non-zero.
+func printEnvIssues(out io.Writer, issues []config.EnvIssue) error {
+	if len(issues) == 0 {
+		fmt.Fprintln(out, "All environment references resolve")
+		return nil
+	}
+	for _, issue := range issues {
+		fmt.Fprintf(out, "%s: %s: %v\n", issue.File, issue.Field, issue.Err)
+	}
+	return fmt.Errorf("%d config value(s) reference unset environment variables", len(issues))
+}
+
+func newConfigWhichCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "which",
+		Short: "Show which config files apply here and which one wins",
+		Long: `Print the config precedence chain for the current directory: the global
+file, then the project file found in this directory or a parent, then the
+environment variables that override them. Later entries win, so a setting
+in the project file shadows the same setting in the global file.
+
+With --config, that file is used instead of the global and project files.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			out := cmd.OutOrStdout()
+			chain := config.SourceChain()
+			if location, _ := cmd.Flags().GetString("config"); location != "" {
+				chain = slices.DeleteFunc(chain, func(s config.Source) bool {
+					return s.Kind != config.SourceEnv
+				})
+				chain = slices.Insert(chain, 0, config.Source{Kind: config.SourceFile, Path: location, Active: true})
+			}
+
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("getting working directory: %w", err)
+			}
+			fmt.Fprintf(out, "Config precedence for %s (later entries win):\n", cwd)
+			printSourceChain(out, chain)
+			return nil
+		},
+	}
+}
+
+func newConfigPathCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "path",
+		Short: "Print the config file paths",
+		Long: `Print the global config path, noting when the file doesn't exist yet,
+and the project config found in this directory or a parent, if any.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			out := cmd.OutOrStdout()
+			global := config.GlobalConfigPath()
+			if _, err := os.Stat(global); err != nil {
+				fmt.Fprintf(out, "global   %s (not found)\n", global)
+			} else {
+				fmt.Fprintf(out, "global   %s\n", global)
+			}
+			if project := config.ProjectConfigPath(); project != "" {
+				fmt.Fprintf(out, "project  %s\n", project)
+			}
+			return nil
+		},
+	}
+}
+
+func newConfigDumpCmd() *cobra.Command {
+	var resolved bool
+
+	cmd := &cobra.Command{
+		Use:   "dump",
+		Short: "Print the loaded config as JSON",
+		Long: `Print the config matrix runs with, after merging the global and project
+files and filling in provider defaults, as JSON.
+
+API keys and extra headers are shown as written, so environment references
+stay unexpanded; keys written literally and OAuth tokens are redacted. With
+--resolved they are printed with their real values. That output holds your
+secrets: don't paste it into issues or logs.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return fmt.Errorf("loading config: %w", err)
+			}
+
+			if resolved {
+				fmt.Fprintln(cmd.ErrOrStderr(), "Warning: --resolved prints API keys and tokens in plain text; don't share this output.")
+			} else {
+				cfg = cfg.Unresolved()
+			}
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(cfg)
+		},
+	}
+
+	cmd.Flags().BoolVar(&resolved, "resolved", false, "show API keys with environment variables expanded (reveals secrets)")
+
+	return cmd
+}
+
+// printSourceChain lists the config layers, noting those that don't apply,
+// and which file wins where they overlap.
+func printSourceChain(out io.Writer, chain []config.Source) {
+	var files []string
+	for i, source := range chain {
+		var line string
+		switch {
+		case source.Kind == config.SourceEnv && source.Active:
+			line = fmt.Sprintf("%s=%s", source.Path, source.Value)
+		case source.Kind == config.SourceEnv:
+			line = fmt.Sprintf("%s (not set)", source.Path)
+		case source.Path == "":
+			line = "(none found in this directory or its parents)"
+		case !source.Active:
+			line = fmt.Sprintf("%s (not found)", source.Path)
+		default:
+			line = source.Path
+			files = append(files, source.Path)
+		}
+		fmt.Fprintf(out, "  %d. %-8s %s\n", i+1, source.Kind, line)
+	}
+
+	switch len(files) {
+	case 0:
+		fmt.Fprintln(out, "No config file applies here.")
+	case 1:
+		fmt.Fprintf(out, "Only %s applies here.\n", files[0])
+	default:
+		fmt.Fprintf(out, "Settings in %s override the same settings in %s.\n", files[len(files)-1], files[len(files)-2])
+	}
+}
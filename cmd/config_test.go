@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adrg/xdg"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+func TestConfigCheckEnv(t *testing.T) {
+	tests := []struct { //nolint:govet // Test struct alignment is not critical.
+		name     string
+		env      map[string]string
+		wantErr  bool
+		wantText string
+	}{
+		{
+			name:     "all resolvable",
+			env:      map[string]string{"MATRIX_TEST_CHECK_KEY": "sk-test"},
+			wantText: "All environment references resolve",
+		},
+		{
+			name:     "missing variable",
+			wantErr:  true,
+			wantText: "MATRIX_TEST_CHECK_KEY",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			path := filepath.Join(t.TempDir(), "matrix.json")
+			content := `{"providers": {"openai": {"api_key": "$MATRIX_TEST_CHECK_KEY"}}}`
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+
+			var out bytes.Buffer
+			root := newRootCmd()
+			root.SetOut(&out)
+			root.SetErr(&out)
+			root.SetArgs([]string{"config", "check-env", "--config", path})
+
+			err := root.Execute()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !strings.Contains(out.String(), tt.wantText) {
+				t.Errorf("output = %q, want it to contain %q", out.String(), tt.wantText)
+			}
+		})
+	}
+}
+
+func TestConfigWhich(t *testing.T) {
+	tempDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("EvalSymlinks() error = %v", err)
+	}
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+	t.Setenv("MATRIX_READONLY", "1")
+	xdg.Reload()
+
+	globalPath := config.GlobalConfigPath()
+	projectPath := filepath.Join(tempDir, "repo", "matrix.json")
+	nested := filepath.Join(tempDir, "repo", "a", "b")
+	for _, dir := range []string{filepath.Dir(globalPath), nested} {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+	}
+	for _, path := range []string{globalPath, projectPath} {
+		if err := os.WriteFile(path, []byte(`{}`), 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	t.Chdir(nested)
+
+	var out bytes.Buffer
+	root := newRootCmd()
+	root.SetOut(&out)
+	root.SetArgs([]string{"config", "which"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	// Global, then project, then the environment, in that order.
+	got := out.String()
+	global := strings.Index(got, "1. global   "+globalPath)
+	project := strings.Index(got, "2. project  "+projectPath)
+	env := strings.Index(got, "3. env      MATRIX_READONLY=1")
+	if global < 0 || project < global || env < project {
+		t.Errorf("output = %q, want global, project and env layers in order", got)
+	}
+	if want := "Settings in " + projectPath + " override the same settings in " + globalPath; !strings.Contains(got, want) {
+		t.Errorf("output = %q, want it to contain %q", got, want)
+	}
+}
+
+func TestConfigPath(t *testing.T) {
+	tempDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("EvalSymlinks() error = %v", err)
+	}
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+	xdg.Reload()
+	t.Chdir(tempDir)
+
+	run := func() string {
+		t.Helper()
+		var out bytes.Buffer
+		root := newRootCmd()
+		root.SetOut(&out)
+		root.SetArgs([]string{"config", "path"})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		return out.String()
+	}
+
+	globalPath := config.GlobalConfigPath()
+	if got, want := run(), "global   "+globalPath+" (not found)\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	projectPath := filepath.Join(tempDir, ".matrix.json")
+	if err := os.WriteFile(projectPath, []byte(`{}`), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if got, want := run(), "global   "+globalPath+" (not found)\nproject  "+projectPath+"\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestConfigDump_Resolved(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+	t.Setenv("MATRIX_TEST_DUMP_KEY", "sk-dump-secret")
+	xdg.Reload()
+	t.Chdir(tempDir)
+
+	// Keep catwalk offline so the embedded providers are used.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("CATWALK_URL", server.URL)
+
+	path := filepath.Join(tempDir, "matrix.json")
+	if err := os.WriteFile(path, []byte(`{"providers": {"openai": {"api_key": "$MATRIX_TEST_DUMP_KEY"}}}`), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	dump := func(args ...string) (stdout, stderr string) {
+		t.Helper()
+		var out, errOut bytes.Buffer
+		root := newRootCmd()
+		root.SetOut(&out)
+		root.SetErr(&errOut)
+		root.SetArgs(append([]string{"config", "dump", "--config", path}, args...))
+		if err := root.Execute(); err != nil {
+			t.Fatalf("Execute(%v) error = %v", args, err)
+		}
+		var cfg config.Config
+		if err := json.Unmarshal(out.Bytes(), &cfg); err != nil {
+			t.Fatalf("dump is not a JSON config: %v\n%s", err, out.String())
+		}
+		return out.String(), errOut.String()
+	}
+
+	plain, plainErr := dump()
+	if !strings.Contains(plain, `"api_key": "$MATRIX_TEST_DUMP_KEY"`) || strings.Contains(plain, "sk-dump-secret") {
+		t.Errorf("dump = %s, want the key reference unexpanded", plain)
+	}
+	if plainErr != "" {
+		t.Errorf("dump stderr = %q, want no warning", plainErr)
+	}
+
+	resolved, resolvedErr := dump("--resolved")
+	if resolved == plain {
+		t.Error("--resolved dump matches the unresolved one")
+	}
+	if !strings.Contains(resolved, `"api_key": "sk-dump-secret"`) {
+		t.Errorf("--resolved dump = %s, want the expanded key", resolved)
+	}
+	if !strings.Contains(resolvedErr, "Warning") {
+		t.Errorf("--resolved stderr = %q, want a warning about secrets", resolvedErr)
+	}
+}
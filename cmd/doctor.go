@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/spf13/cobra"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+	"github.com/guilhermegouw/matrix-cli/internal/provider"
+)
+
+// errNoLargeModel is returned by doctor when the config can't produce a
+// usable large model.
+var errNoLargeModel = errors.New("no usable large model")
+
+func newDoctorCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose the config, providers and data directory",
+		Long: `Load the config and explain what matrix would run with: for each provider
+in the config files, whether its API key resolves, its base URL is valid
+and its type is supported; whether the selected large and small models are
+offered by their providers; and the state of the data directory and the
+providers cache.
+
+Loading skips providers it can't use, often without a word; doctor says why.
+It exits non-zero when the config can't produce a usable large model.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			out := cmd.OutOrStdout()
+			cfg, loadErr := loadConfig(cmd)
+
+			files := config.SourceFiles()
+			if location, _ := cmd.Flags().GetString("config"); location != "" {
+				files = nil
+				if !config.IsConfigURL(location) {
+					files = []string{location}
+				}
+			}
+
+			var known []catwalk.Provider
+			if cfg != nil {
+				known = cfg.KnownProviders()
+			} else if providers, err := config.LoadProviders(config.NewConfig()); err == nil {
+				known = providers
+			}
+			diagnoses, err := config.DiagnoseProviders(files, known, provider.SupportedTypes())
+			if err != nil {
+				return err
+			}
+
+			printProviderDiagnoses(out, diagnoses)
+			largeErr := printTierDiagnoses(out, cfg, loadErr)
+			printDirDiagnosis(out, config.DiagnoseDataDir(cfg))
+
+			if largeErr != nil {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%w: %w", errNoLargeModel, largeErr)
+			}
+			return nil
+		},
+	}
+}
+
+// printProviderDiagnoses lists each configured provider with its problems.
+func printProviderDiagnoses(out io.Writer, diagnoses []config.ProviderDiagnosis) {
+	fmt.Fprintln(out, "Providers:")
+	if len(diagnoses) == 0 {
+		fmt.Fprintln(out, "  (none configured)")
+	}
+	for i := range diagnoses {
+		d := &diagnoses[i]
+		status := "ok"
+		switch {
+		case len(d.Problems) > 0:
+			status = "FAIL"
+		case d.Disabled:
+			status = "off"
+		}
+		fmt.Fprintf(out, "  %-4s  %-16s %-14s %s\n", status, d.ID, d.Type, d.File)
+		for _, problem := range d.Problems {
+			fmt.Fprintf(out, "        %s\n", problem)
+		}
+	}
+}
+
+// printTierDiagnoses reports whether each tier's model is usable, and
+// returns why the large one isn't, if so. loadErr is the error loading cfg.
+func printTierDiagnoses(out io.Writer, cfg *config.Config, loadErr error) error {
+	fmt.Fprintln(out, "Models:")
+	if loadErr != nil {
+		fmt.Fprintf(out, "  config can't be loaded: %v\n", loadErr)
+		return loadErr
+	}
+
+	var largeErr error
+	for _, tier := range provider.AllTiers() {
+		model := cfg.Models[tier]
+		err := cfg.ValidateTier(tier)
+		if err != nil {
+			fmt.Fprintf(out, "  %-6s FAIL  %v\n", tier, err)
+		} else {
+			fmt.Fprintf(out, "  %-6s ok    %s/%s\n", tier, model.Provider, model.Model)
+		}
+		if tier == config.SelectedModelTypeLarge {
+			largeErr = err
+		}
+	}
+	for _, warning := range cfg.Warnings() {
+		fmt.Fprintf(out, "  warning: %s\n", warning)
+	}
+	return largeErr
+}
+
+// printDirDiagnosis reports the data directory and providers cache state.
+func printDirDiagnosis(out io.Writer, d config.DirDiagnosis) {
+	fmt.Fprintln(out, "Data:")
+	switch {
+	case d.Err != nil:
+		fmt.Fprintf(out, "  %s: %v\n", d.DataDir, d.Err)
+	case !d.DataDirExists:
+		fmt.Fprintf(out, "  %s (not created yet)\n", d.DataDir)
+	case d.CacheUpdated.IsZero():
+		fmt.Fprintf(out, "  %s (no providers cache)\n", d.DataDir)
+	default:
+		fmt.Fprintf(out, "  %s (providers cache: %d providers, updated %s ago)\n",
+			d.DataDir, d.CacheProviders, time.Since(d.CacheUpdated).Round(time.Second))
+	}
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adrg/xdg"
+)
+
+func TestDoctorCmd(t *testing.T) {
+	tests := []struct { //nolint:govet // Test struct alignment is not critical.
+		name     string
+		env      map[string]string
+		wantErr  bool
+		wantText []string
+	}{
+		{
+			name: "healthy",
+			env:  map[string]string{"MATRIX_TEST_DOCTOR_KEY": "sk-test"},
+			wantText: []string{
+				"ok    openai",
+				"large  ok    openai/",
+				"small  ok    openai/",
+			},
+		},
+		{
+			name:    "unresolved api key",
+			wantErr: true,
+			wantText: []string{
+				"FAIL  openai",
+				"MATRIX_TEST_DOCTOR_KEY",
+				"config can't be loaded",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			t.Cleanup(xdg.Reload)
+			t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+			t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+			t.Setenv("MATRIX_TEST_DOCTOR_KEY", "")
+			os.Unsetenv("MATRIX_TEST_DOCTOR_KEY") //nolint:errcheck,gosec // Restored by t.Setenv.
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			xdg.Reload()
+			t.Chdir(tempDir)
+
+			// Keep catwalk offline so the embedded providers are used.
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			t.Cleanup(server.Close)
+			t.Setenv("CATWALK_URL", server.URL)
+
+			path := filepath.Join(tempDir, "matrix.json")
+			if err := os.WriteFile(path, []byte(`{"providers": {"openai": {"api_key": "$MATRIX_TEST_DOCTOR_KEY"}}}`), 0o600); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+
+			var out bytes.Buffer
+			root := newRootCmd()
+			root.SetOut(&out)
+			root.SetErr(&bytes.Buffer{})
+			root.SetArgs([]string{"doctor", "--config", path})
+
+			err := root.Execute()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v; output:\n%s", err, tt.wantErr, out.String())
+			}
+			if err != nil && !errors.Is(err, errNoLargeModel) {
+				t.Errorf("Execute() error = %v, want errNoLargeModel", err)
+			}
+			for _, want := range tt.wantText {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output = %q, want it to contain %q", out.String(), want)
+				}
+			}
+		})
+	}
+}
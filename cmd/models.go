@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+	"github.com/guilhermegouw/matrix-cli/internal/provider"
+)
+
+func newModelsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "models",
+		Short: "Inspect the configured models",
+	}
+
+	cmd.AddCommand(newModelsListCmd())
+	cmd.AddCommand(newModelsCostCmd())
+
+	return cmd
+}
+
+func newModelsListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list [tier...]",
+		Short: "Show the provider and model used by each tier",
+		Long: `Show the provider and model used by each tier, or only by the tiers
+given as arguments.`,
+		ValidArgsFunction: completeTiers,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tiers, err := parseTiers(args)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return fmt.Errorf("loading config: %w", err)
+			}
+			printTierModels(cmd.OutOrStdout(), cmd.ErrOrStderr(), cfg, tiers)
+			return nil
+		},
+	}
+}
+
+// parseTiers returns the tiers named by args, or all tiers when there are
+// none.
+func parseTiers(args []string) ([]config.SelectedModelType, error) {
+	if len(args) == 0 {
+		return provider.AllTiers(), nil
+	}
+	tiers := make([]config.SelectedModelType, 0, len(args))
+	for _, arg := range args {
+		tier := config.SelectedModelType(arg)
+		if !slices.Contains(provider.AllTiers(), tier) {
+			return nil, fmt.Errorf("unknown tier %q (want one of %s)", arg, strings.Join(tierNames(), ", "))
+		}
+		tiers = append(tiers, tier)
+	}
+	return tiers, nil
+}
+
+// tierNames returns the names of all tiers.
+func tierNames() []string {
+	tiers := provider.AllTiers()
+	names := make([]string, 0, len(tiers))
+	for _, tier := range tiers {
+		names = append(names, string(tier))
+	}
+	return names
+}
+
+// printTierModels lists the provider and model of each of tiers, warning
+// on errOut when the tiers share a model ID across providers.
+func printTierModels(out, errOut io.Writer, cfg *config.Config, tiers []config.SelectedModelType) {
+	for _, tier := range tiers {
+		model, ok := cfg.Models[tier]
+		if !ok {
+			fmt.Fprintf(out, "%-6s (not configured)\n", tier)
+			continue
+		}
+		fmt.Fprintf(out, "%-6s %s/%s\n", tier, model.Provider, model.Model)
+	}
+	if warning := provider.SharedModelWarning(cfg); warning != "" {
+		fmt.Fprintf(errOut, "Warning: %s\n", warning)
+	}
+}
+
+func newModelsCostCmd() *cobra.Command {
+	var tokensPerDay int64
+
+	cmd := &cobra.Command{
+		Use:   "cost",
+		Short: "Estimate the monthly cost of the configured models",
+		Long: `Estimate the monthly cost of each configured model tier using catwalk
+pricing. Each tier is assumed to process --tokens-per-day tokens, split
+evenly between input and output, over a 30-day month.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if tokensPerDay <= 0 {
+				return fmt.Errorf("--tokens-per-day must be positive")
+			}
+
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return fmt.Errorf("loading config: %w", err)
+			}
+
+			printCostEstimate(cmd.OutOrStdout(), provider.EstimateMonthlyCost(cfg, tokensPerDay))
+			return nil
+		},
+	}
+
+	cmd.Flags().Int64Var(&tokensPerDay, "tokens-per-day", 1_000_000, "estimated tokens processed per tier each day")
+
+	return cmd
+}
+
+// printCostEstimate prints each tier's monthly cost and the total.
+func printCostEstimate(out io.Writer, estimate provider.CostEstimate) {
+	for _, tc := range estimate.Tiers {
+		cost := "unknown"
+		if tc.Known {
+			cost = fmt.Sprintf("$%.2f", tc.Monthly)
+		}
+		fmt.Fprintf(out, "%-6s %s/%s: %s\n", tc.Tier, tc.Provider, tc.Model, cost)
+	}
+
+	total := fmt.Sprintf("$%.2f", estimate.Total)
+	if estimate.Partial {
+		total += " (excluding models with unknown pricing)"
+	}
+	fmt.Fprintf(out, "total: %s per month\n", total)
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+	"github.com/guilhermegouw/matrix-cli/internal/provider"
+)
+
+func TestPrintTierModels(t *testing.T) {
+	tests := []struct {
+		name        string
+		smallID     string
+		wantWarning bool
+	}{
+		{name: "same model across providers warns", smallID: "gpt-4o", wantWarning: true},
+		{name: "distinct models", smallID: "gpt-4o-mini", wantWarning: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{Model: "gpt-4o", Provider: "openai"}
+			cfg.Models[config.SelectedModelTypeSmall] = config.SelectedModel{Model: tt.smallID, Provider: "openrouter"}
+
+			var out, errOut bytes.Buffer
+			printTierModels(&out, &errOut, cfg, provider.AllTiers())
+
+			want := "large  openai/gpt-4o\nsmall  openrouter/" + tt.smallID + "\n"
+			if out.String() != want {
+				t.Errorf("output = %q, want %q", out.String(), want)
+			}
+			if got := strings.Contains(errOut.String(), "Warning:"); got != tt.wantWarning {
+				t.Errorf("warning printed = %v, want %v; stderr: %q", got, tt.wantWarning, errOut.String())
+			}
+		})
+	}
+}
+
+func TestParseTiers(t *testing.T) {
+	tiers, err := parseTiers(nil)
+	if err != nil || len(tiers) != len(provider.AllTiers()) {
+		t.Errorf("parseTiers(nil) = %v, %v, want all tiers", tiers, err)
+	}
+
+	tiers, err = parseTiers([]string{"small"})
+	if err != nil || len(tiers) != 1 || tiers[0] != config.SelectedModelTypeSmall {
+		t.Errorf("parseTiers([small]) = %v, %v, want [small]", tiers, err)
+	}
+
+	if _, err := parseTiers([]string{"medium"}); err == nil || !strings.Contains(err.Error(), "large, small") {
+		t.Errorf("parseTiers([medium]) error = %v, want one listing the tiers", err)
+	}
+}
+
+func TestPrintTierModels_SelectedTiers(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{Model: "gpt-4o", Provider: "openai"}
+	cfg.Models[config.SelectedModelTypeSmall] = config.SelectedModel{Model: "gpt-4o-mini", Provider: "openai"}
+
+	var out, errOut bytes.Buffer
+	printTierModels(&out, &errOut, cfg, []config.SelectedModelType{config.SelectedModelTypeSmall})
+
+	if want := "small  openai/gpt-4o-mini\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestPrintCostEstimate(t *testing.T) {
+	estimate := provider.CostEstimate{
+		Tiers: []provider.TierCost{
+			{Tier: config.SelectedModelTypeLarge, Provider: "openai", Model: "gpt-4o", Monthly: 187.5, Known: true},
+			{Tier: config.SelectedModelTypeSmall, Provider: "local", Model: "qwen3"},
+		},
+		Total:   187.5,
+		Partial: true,
+	}
+
+	var out bytes.Buffer
+	printCostEstimate(&out, estimate)
+
+	want := "large  openai/gpt-4o: $187.50\n" +
+		"small  local/qwen3: unknown\n" +
+		"total: $187.50 (excluding models with unknown pricing) per month\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
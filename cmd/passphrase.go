@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// promptPassphrase asks for the config passphrase on the terminal without
+// echoing it. Without a terminal it returns no passphrase, so loading an
+// encrypted config fails asking for MATRIX_CONFIG_PASSPHRASE instead.
+func promptPassphrase() (string, error) {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return "", nil
+	}
+	fmt.Fprint(os.Stderr, "Config passphrase: ")
+	pass, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(pass), nil
+}
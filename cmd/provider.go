@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/spf13/cobra"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+	"github.com/guilhermegouw/matrix-cli/internal/provider"
+)
+
+func newProviderCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "provider",
+		Short: "Manage configured providers",
+	}
+
+	cmd.AddCommand(newProviderAddCmd())
+	cmd.AddCommand(newProviderDisableCmd())
+	cmd.AddCommand(newProviderEnableCmd())
+	cmd.AddCommand(newProviderRefreshModelsCmd())
+
+	return cmd
+}
+
+func newProviderAddCmd() *cobra.Command {
+	var (
+		providerType string
+		update       config.ProviderUpdate
+	)
+
+	cmd := &cobra.Command{
+		Use:   "add <id>",
+		Short: "Add or update a provider from flags",
+		Long: `Add a provider to the global config, or to the file given with --config,
+without running the wizard. An existing provider with the same ID is updated;
+only the settings given as flags change. Other providers are left untouched.
+
+The API key may be an environment reference such as '$MY_API_KEY', which is
+kept unresolved in the config file.`,
+		Example: `  matrix provider add local --type openai-compat --base-url http://localhost:11434/v1 \
+    --large-model qwen3-coder --small-model qwen3`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if providerType != "" {
+				update.Type = catwalk.Type(providerType)
+				if !slices.Contains(provider.SupportedTypes(), update.Type) {
+					return fmt.Errorf("unsupported provider type %q (supported: %s)", providerType, supportedTypeList())
+				}
+			}
+
+			path, err := writableConfigPath(cmd)
+			if err != nil {
+				return err
+			}
+			if err := config.SetProvider(path, args[0], update); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Saved provider %q to %s\n", args[0], path)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&providerType, "type", "", "provider type ("+supportedTypeList()+")")
+	cmd.Flags().StringVar(&update.BaseURL, "base-url", "", "API endpoint URL")
+	cmd.Flags().StringVar(&update.APIKey, "api-key", "", "API key or environment reference, e.g. '$MY_API_KEY'")
+	cmd.Flags().StringVar(&update.LargeModel, "large-model", "", "use this provider's model for the large tier")
+	cmd.Flags().StringVar(&update.SmallModel, "small-model", "", "use this provider's model for the small tier")
+
+	return cmd
+}
+
+// supportedTypeList lists the supported provider types for messages.
+func supportedTypeList() string {
+	types := provider.SupportedTypes()
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ", ")
+}
+
+// writableConfigPath returns the config file named by --config, or the
+// global config when the flag is not set. A URL can't be written to.
+func writableConfigPath(cmd *cobra.Command) (string, error) {
+	location, _ := cmd.Flags().GetString("config")
+	if location == "" {
+		return config.GlobalConfigPath(), nil
+	}
+	if config.IsConfigURL(location) {
+		return "", fmt.Errorf("cannot write to a config loaded from a URL: %s", location)
+	}
+	return location, nil
+}
+
+// resolveProviderArg returns the ID of the provider in the config file at
+// path that arg identifies. Besides the exact ID, arg may be a unique part
+// of the provider's ID or name.
+func resolveProviderArg(path, arg string) (string, error) {
+	choices, err := config.FileProviders(path)
+	if err != nil {
+		return "", err
+	}
+	return config.MatchProvider(choices, arg)
+}
+
+func newProviderDisableCmd() *cobra.Command {
+	var reason string
+
+	cmd := &cobra.Command{
+		Use:               "disable <provider>",
+		Short:             "Disable a provider in the global config, or the file given with --config",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeProviderIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := writableConfigPath(cmd)
+			if err != nil {
+				return err
+			}
+			id, err := resolveProviderArg(path, args[0])
+			if err != nil {
+				return err
+			}
+			if err := config.SetProviderDisabled(path, id, true, reason); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Disabled provider %q\n", id)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&reason, "reason", "", "why the provider is disabled")
+
+	return cmd
+}
+
+func newProviderEnableCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "enable <provider>",
+		Short:             "Re-enable a disabled provider in the global config, or the file given with --config",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeProviderIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := writableConfigPath(cmd)
+			if err != nil {
+				return err
+			}
+			id, err := resolveProviderArg(path, args[0])
+			if err != nil {
+				return err
+			}
+			if err := config.SetProviderDisabled(path, id, false, ""); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Enabled provider %q\n", id)
+			return nil
+		},
+	}
+}
+
+func newProviderRefreshModelsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "refresh-models <provider>",
+		Short: "Re-fetch a provider's models and store them in the global config",
+		Long: `Fetch the current model list for a configured provider, from its
+metadata_url when set or from catwalk otherwise, and replace the models stored
+in the global config, or the file given with --config. Added and removed model
+IDs are reported.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeProviderIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := writableConfigPath(cmd)
+			if err != nil {
+				return err
+			}
+			id, err := resolveProviderArg(path, args[0])
+			if err != nil {
+				return err
+			}
+			diff, err := config.RefreshProviderModels(cmd.Context(), path, id)
+			if err != nil {
+				return err
+			}
+			printModelDiff(cmd.OutOrStdout(), id, diff)
+			return nil
+		},
+	}
+}
+
+// printModelDiff reports the models a refresh added and removed.
+func printModelDiff(out io.Writer, providerID string, diff config.ModelDiff) {
+	if diff.Empty() {
+		fmt.Fprintf(out, "Models for %q are up to date\n", providerID)
+		return
+	}
+	for _, id := range diff.Added {
+		fmt.Fprintf(out, "+ %s\n", id)
+	}
+	for _, id := range diff.Removed {
+		fmt.Fprintf(out, "- %s\n", id)
+	}
+	fmt.Fprintf(out, "Updated models for %q: %d added, %d removed\n", providerID, len(diff.Added), len(diff.Removed))
+}
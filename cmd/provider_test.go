@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+func TestPrintModelDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		diff config.ModelDiff
+		want string
+	}{
+		{
+			name: "changed",
+			diff: config.ModelDiff{Added: []string{"gpt-5"}, Removed: []string{"gpt-4"}},
+			want: "+ gpt-5\n- gpt-4\nUpdated models for \"openai\": 1 added, 1 removed\n",
+		},
+		{
+			name: "unchanged",
+			want: "Models for \"openai\" are up to date\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			printModelDiff(&out, "openai", tt.diff)
+			if out.String() != tt.want {
+				t.Errorf("printModelDiff() = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestProviderAdd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matrix.json")
+	content := `{
+  "providers": {
+    "anthropic": {"api_key": "$ANTHROPIC_API_KEY"}
+  },
+  "models": {
+    "small": {"provider": "anthropic", "model": "claude-haiku"}
+  }
+}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	root := newRootCmd()
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs([]string{
+		"provider", "add", "local", "--config", path,
+		"--type", "openai-compat",
+		"--base-url", "http://localhost:11434/v1",
+		"--api-key", "$LOCAL_API_KEY",
+		"--large-model", "qwen3-coder",
+	})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v; output: %s", err, out.String())
+	}
+	if !strings.Contains(out.String(), `Saved provider "local"`) {
+		t.Errorf("output = %q, want a saved confirmation", out.String())
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // Test file path.
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var saved config.Config
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	local := saved.Providers["local"]
+	if local == nil {
+		t.Fatalf("provider %q not saved; file: %s", "local", data)
+	}
+	if local.Type != catwalk.TypeOpenAICompat || local.BaseURL != "http://localhost:11434/v1" || local.APIKey != "$LOCAL_API_KEY" {
+		t.Errorf("saved provider = %+v, want the flag values", local)
+	}
+	if existing := saved.Providers["anthropic"]; existing == nil || existing.APIKey != "$ANTHROPIC_API_KEY" {
+		t.Errorf("existing provider = %+v, want it kept as written", existing)
+	}
+	if large := saved.Models[config.SelectedModelTypeLarge]; large.Provider != "local" || large.Model != "qwen3-coder" {
+		t.Errorf("large model = %+v, want local/qwen3-coder", large)
+	}
+	if small := saved.Models[config.SelectedModelTypeSmall]; small.Provider != "anthropic" {
+		t.Errorf("small model = %+v, want it unchanged", small)
+	}
+}
+
+func TestProviderAdd_UnsupportedType(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matrix.json")
+
+	root := newRootCmd()
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"provider", "add", "azure", "--config", path, "--type", "azure"})
+
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), `unsupported provider type "azure"`) {
+		t.Fatalf("Execute() error = %v, want an unsupported type error", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("config file was written for an unsupported type")
+	}
+}
+
+func TestProviderDisableEnable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matrix.json")
+	if err := os.WriteFile(path, []byte(`{"providers": {"openai": {"api_key": "$OPENAI_API_KEY"}}}`), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		root := newRootCmd()
+		root.SetOut(&out)
+		root.SetErr(&out)
+		root.SetArgs(append(args, "--config", path))
+		if err := root.Execute(); err != nil {
+			t.Fatalf("Execute(%v) error = %v; output: %s", args, err, out.String())
+		}
+		return out.String()
+	}
+	disabled := func() bool {
+		t.Helper()
+		data, err := os.ReadFile(path) //nolint:gosec // Test file path.
+		if err != nil {
+			t.Fatalf("ReadFile() error = %v", err)
+		}
+		var saved config.Config
+		if err := json.Unmarshal(data, &saved); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		return saved.Providers["openai"].Disable
+	}
+
+	if out := run("provider", "disable", "openai", "--reason", "quota"); out != "Disabled provider \"openai\"\n" {
+		t.Errorf("disable output = %q", out)
+	}
+	if !disabled() {
+		t.Error("provider not disabled in the --config file")
+	}
+	if out := run("provider", "enable", "openai"); out != "Enabled provider \"openai\"\n" {
+		t.Errorf("enable output = %q", out)
+	}
+	if disabled() {
+		t.Error("provider not re-enabled in the --config file")
+	}
+}
+
+func TestProviderDisable_PartialID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matrix.json")
+	content := `{"providers": {"openai": {"api_key": "$OPENAI_API_KEY"}, "openrouter": {"api_key": "$OPENROUTER_API_KEY"}}}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		root := newRootCmd()
+		root.SetOut(&out)
+		root.SetErr(&bytes.Buffer{})
+		root.SetArgs(append(args, "--config", path))
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	out, err := run("provider", "disable", "router")
+	if err != nil {
+		t.Fatalf("disable router error = %v", err)
+	}
+	if out != "Disabled provider \"openrouter\"\n" {
+		t.Errorf("disable output = %q", out)
+	}
+
+	_, err = run("provider", "enable", "open")
+	if err == nil || !strings.Contains(err.Error(), "openai, openrouter") {
+		t.Errorf("enable open error = %v, want the ambiguity with both candidates", err)
+	}
+}
@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/spf13/cobra"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+	"github.com/guilhermegouw/matrix-cli/internal/provider"
+)
+
+func newProvidersCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "providers",
+		Short: "Inspect the providers known to catwalk",
+	}
+
+	cmd.AddCommand(newProvidersListCmd())
+	cmd.AddCommand(newProvidersUpdateCmd())
+	cmd.AddCommand(newProvidersExportCmd())
+	cmd.AddCommand(newProvidersImportCmd())
+	cmd.AddCommand(newProvidersTestCmd())
+
+	return cmd
+}
+
+func newProvidersListCmd() *cobra.Command {
+	var providerType string
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List known providers and their configuration status",
+		Long: `List the providers matrix knows about, from catwalk, the cache or the
+embedded data, with their type, model count and configuration status. With
+--json the full provider metadata is printed instead, for scripts.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			var filter catwalk.Type
+			if providerType != "" {
+				t, err := config.ParseProviderType(providerType)
+				if err != nil {
+					return err
+				}
+				filter = t
+			}
+
+			// Fall back to bare metadata when nothing is configured yet.
+			cfg, err := loadConfig(cmd)
+			switch {
+			case errors.Is(err, config.ErrNeedsSetup):
+				cfg = config.NewConfig()
+				providers, loadErr := config.LoadProviders(cfg)
+				if loadErr != nil {
+					return fmt.Errorf("loading providers: %w", loadErr)
+				}
+				cfg.SetKnownProviders(providers)
+			case err != nil:
+				return fmt.Errorf("loading config: %w", err)
+			}
+
+			providers := cfg.KnownProviders()
+			if filter != "" {
+				providers = config.FilterProvidersByType(providers, filter)
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(providers)
+			}
+			for i := range providers {
+				p := &providers[i]
+				fmt.Fprintf(cmd.OutOrStdout(), "%-16s %-14s %3d models  %s%s\n",
+					p.ID, p.Type, len(p.Models), p.Name, providerStatus(cfg, string(p.ID)))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&providerType, "type", "", "only list providers of this type (e.g. openai-compat, anthropic)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the providers' catwalk metadata as JSON")
+	_ = cmd.RegisterFlagCompletionFunc("type", completeProviderTypes) //nolint:errcheck // Flag is defined above.
+
+	return cmd
+}
+
+func newProvidersUpdateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "update [source]",
+		Short: "Refresh the providers cache",
+		Long: `Refresh the cached provider metadata from source: "embedded" for the
+data built into matrix, an http(s) URL of a catwalk service, or a local JSON
+file holding a list of providers. Without a source it fetches from catwalk,
+honoring CATWALK_URL.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var source string
+			if len(args) == 1 {
+				source = args[0]
+			}
+
+			cfg, err := loadCacheConfig(cmd)
+			if err != nil {
+				return err
+			}
+			count, err := config.UpdateProviders(cfg, source)
+			if err != nil {
+				return fmt.Errorf("updating providers: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Cached %d providers in %s\n", count, cfg.DataDir())
+			return nil
+		},
+	}
+}
+
+func newProvidersTestCmd() *cobra.Command {
+	var (
+		workers int
+		timeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Check that every configured provider accepts a request",
+		Long: `Build each enabled provider with credentials and send it one short
+authenticated request, printing whether it passed and how long it took.
+Providers are checked concurrently, each with its own timeout. The command
+fails when any provider does, so it can gate scripts and CI.
+
+Each check is a real request and is billed by the provider.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if workers < 1 {
+				return fmt.Errorf("--workers must be at least 1, got %d", workers)
+			}
+
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return fmt.Errorf("loading config: %w", err)
+			}
+
+			results := provider.NewBuilder(cfg).CheckProviders(cmd.Context(), workers, timeout)
+			if len(results) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No enabled providers with credentials to test")
+				return nil
+			}
+			if failed := printCheckResults(cmd.OutOrStdout(), results); failed > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d of %d providers failed", failed, len(results))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&workers, "workers", 4, "providers checked at the same time")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "timeout for each provider")
+
+	return cmd
+}
+
+// printCheckResults writes one line per checked provider followed by a
+// summary, and returns how many failed.
+func printCheckResults(out io.Writer, results []provider.CheckResult) int {
+	failed := 0
+	for i := range results {
+		r := &results[i]
+		if r.Err != nil {
+			failed++
+			fmt.Fprintf(out, "FAIL  %-16s %-32s %v\n", r.Provider, r.Model, r.Err)
+			continue
+		}
+		fmt.Fprintf(out, "PASS  %-16s %-32s %v\n", r.Provider, r.Model, r.Latency.Round(time.Millisecond))
+	}
+	fmt.Fprintf(out, "%d passed, %d failed\n", len(results)-failed, failed)
+	return failed
+}
+
+func newProvidersExportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "export <file>",
+		Short: "Write the providers cache to a file with a checksum",
+		Long: `Write the cached provider metadata to a file, with a checksum so that
+"providers import" can detect corruption or tampering. Use it to give other
+machines the same provider list.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadCacheConfig(cmd)
+			if err != nil {
+				return err
+			}
+			if err := config.ExportProviders(cfg, args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Exported providers to %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newProvidersImportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import <file>",
+		Short: "Replace the providers cache with an exported file",
+		Long: `Replace the cached provider metadata with a file written by
+"providers export". The file's checksum is verified first; a corrupt or
+modified file is rejected and the cache left untouched.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadCacheConfig(cmd)
+			if err != nil {
+				return err
+			}
+			if err := config.ImportProviders(cfg, args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Imported providers from %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+// loadCacheConfig loads the config locating the providers cache. Nothing
+// needs to be configured yet, so a config without providers falls back to
+// the defaults.
+func loadCacheConfig(cmd *cobra.Command) (*config.Config, error) {
+	cfg, err := loadConfig(cmd)
+	switch {
+	case errors.Is(err, config.ErrNeedsSetup):
+		return config.NewConfig(), nil
+	case err != nil:
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	return cfg, nil
+}
+
+// providerStatus describes whether a provider is configured or disabled.
+func providerStatus(cfg *config.Config, id string) string {
+	p, ok := cfg.Providers[id]
+	switch {
+	case !ok:
+		return ""
+	case p.Disable:
+		return " (" + p.DisabledDescription() + ")"
+	default:
+		return " (configured)"
+	}
+}
+
+// completeProviderTypes completes the provider types known to catwalk.
+func completeProviderTypes(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	types := catwalk.KnownProviderTypes()
+	names := make([]string, 0, len(types))
+	for _, t := range types {
+		names = append(names, string(t))
+	}
+	return filterPrefix(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
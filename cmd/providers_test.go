@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+	"github.com/guilhermegouw/matrix-cli/internal/provider"
+)
+
+func TestProvidersListCmd_InvalidType(t *testing.T) {
+	root := newRootCmd()
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"providers", "list", "--type", "bogus"})
+
+	err := root.Execute()
+	if err == nil {
+		t.Fatal("providers list --type bogus should fail")
+	}
+	if !strings.Contains(err.Error(), "openai-compat") {
+		t.Errorf("error = %q, want it to list the valid types", err)
+	}
+}
+
+func TestProvidersListCmd_InvalidConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matrix.json")
+	if err := os.WriteFile(path, []byte(`{not json`), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	root := newRootCmd()
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"providers", "list", "--config", path})
+
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "loading config") {
+		t.Errorf("Execute() error = %v, want the config load error", err)
+	}
+}
+
+func TestProvidersListCmd_JSON(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+	xdg.Reload()
+	t.Chdir(tempDir)
+
+	// Keep catwalk offline so the embedded providers are listed.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("CATWALK_URL", server.URL)
+
+	var out bytes.Buffer
+	root := newRootCmd()
+	root.SetOut(&out)
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"providers", "list", "--json"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("providers list --json error = %v", err)
+	}
+
+	var providers []catwalk.Provider
+	if err := json.Unmarshal(out.Bytes(), &providers); err != nil {
+		t.Fatalf("output is not a JSON provider list: %v\n%s", err, out.String())
+	}
+	if len(providers) == 0 {
+		t.Fatal("providers list --json printed no providers")
+	}
+	for i := range providers {
+		if providers[i].ID == "" || len(providers[i].Models) == 0 {
+			t.Errorf("providers[%d] = %q with %d models, want the full metadata", i, providers[i].ID, len(providers[i].Models))
+		}
+	}
+}
+
+func TestCompleteProviderTypes(t *testing.T) {
+	got, _ := completeProviderTypes(nil, nil, "openai")
+	if len(got) != 2 {
+		t.Errorf("completeProviderTypes(%q) = %v, want openai and openai-compat", "openai", got)
+	}
+}
+
+func TestProvidersUpdateCmd_Embedded(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+	xdg.Reload()
+	t.Chdir(tempDir)
+
+	var out bytes.Buffer
+	root := newRootCmd()
+	root.SetOut(&out)
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"providers", "update", "embedded"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("providers update error = %v", err)
+	}
+
+	if !strings.HasPrefix(out.String(), "Cached ") || strings.HasPrefix(out.String(), "Cached 0 ") {
+		t.Errorf("output = %q, want the number of cached providers", out.String())
+	}
+	cachePath := filepath.Join(config.DefaultDataDir(), "providers.json")
+	if _, err := os.Stat(cachePath); err != nil {
+		t.Errorf("providers cache not written: %v", err)
+	}
+}
+
+func TestProvidersExportImport(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+	xdg.Reload()
+	t.Chdir(tempDir)
+
+	// Keep catwalk offline so the cache written below is the one exported.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("CATWALK_URL", server.URL)
+
+	if _, err := config.UpdateProviders(config.NewConfig(), "embedded"); err != nil {
+		t.Fatalf("UpdateProviders() error = %v", err)
+	}
+
+	run := func(args ...string) error {
+		root := newRootCmd()
+		root.SetOut(&bytes.Buffer{})
+		root.SetErr(&bytes.Buffer{})
+		root.SetArgs(args)
+		return root.Execute()
+	}
+
+	exported := filepath.Join(tempDir, "providers-export.json")
+	if err := run("providers", "export", exported); err != nil {
+		t.Fatalf("providers export error = %v", err)
+	}
+	if err := run("providers", "import", exported); err != nil {
+		t.Fatalf("providers import error = %v", err)
+	}
+
+	data, err := os.ReadFile(exported) //nolint:gosec // Test file path.
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	tampered := filepath.Join(tempDir, "tampered.json")
+	if err := os.WriteFile(tampered, bytes.Replace(data, []byte(`"Anthropic"`), []byte(`"Tampered"`), 1), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := run("providers", "import", tampered); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("importing a tampered file error = %v, want a checksum error", err)
+	}
+}
+
+func TestPrintCheckResults(t *testing.T) {
+	results := []provider.CheckResult{
+		{Provider: "anthropic", Model: "claude-haiku", Latency: 412300 * time.Microsecond},
+		{Provider: "openai", Model: "gpt-4o-mini", Err: errors.New("401 Unauthorized")},
+	}
+
+	var out bytes.Buffer
+	failed := printCheckResults(&out, results)
+	if failed != 1 {
+		t.Errorf("printCheckResults() = %d failed, want 1", failed)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("output = %q, want a line per provider and a summary", out.String())
+	}
+	if !strings.HasPrefix(lines[0], "PASS  anthropic") || !strings.HasSuffix(lines[0], "412ms") {
+		t.Errorf("line 0 = %q, want the passing provider with its latency", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "FAIL  openai") || !strings.HasSuffix(lines[1], "401 Unauthorized") {
+		t.Errorf("line 1 = %q, want the failing provider with its error", lines[1])
+	}
+	if lines[2] != "1 passed, 1 failed" {
+		t.Errorf("summary = %q", lines[2])
+	}
+}
+
+func TestProvidersTestCmd_InvalidWorkers(t *testing.T) {
+	root := newRootCmd()
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"providers", "test", "--workers", "0"})
+
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "--workers") {
+		t.Errorf("Execute() error = %v, want the invalid --workers error", err)
+	}
+}
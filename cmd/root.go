@@ -2,13 +2,23 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
+	"time"
 
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/spf13/cobra"
 
 	"github.com/guilhermegouw/matrix-cli/internal/config"
+	"github.com/guilhermegouw/matrix-cli/internal/logging"
 	"github.com/guilhermegouw/matrix-cli/internal/tui"
+	"github.com/guilhermegouw/matrix-cli/internal/tui/components/wizard"
+	"github.com/guilhermegouw/matrix-cli/internal/tui/styles"
 )
 
 func newRootCmd() *cobra.Command {
@@ -25,30 +35,292 @@ It supports multiple phases of development:
 		RunE: runTUI,
 	}
 
+	cmd.PersistentFlags().String("config", "", "config file path or http(s) URL to load instead of the standard locations")
+	cmd.Flags().Bool("debug", false, "log at debug level")
+	cmd.Flags().String("log-file", "", "write the application log to this file (overrides options.log_file)")
+	cmd.Flags().Bool("inline", false, "render without the alternate screen or mouse support")
+	cmd.Flags().Bool("simulate-first-run", false, "preview first-run setup with a temporary config that is discarded on exit")
+	cmd.Flags().Bool("quick", false, "run setup with --provider and optional --large/--small, prompting only for the API key")
+	cmd.Flags().String("provider", "", "provider ID for --quick setup")
+	cmd.Flags().String("large", "", "large model ID for --quick setup (default: provider default)")
+	cmd.Flags().String("small", "", "small model ID for --quick setup (default: provider default)")
+
 	cmd.AddCommand(newVersionCmd())
+	cmd.AddCommand(newModelsCmd())
+	cmd.AddCommand(newProviderCmd())
+	cmd.AddCommand(newProvidersCmd())
+	cmd.AddCommand(newUsageCmd())
+	cmd.AddCommand(newConfigCmd())
+	cmd.AddCommand(newBenchCmd())
+	cmd.AddCommand(newStatusCmd())
+	cmd.AddCommand(newDoctorCmd())
+	cmd.AddCommand(newCompletionCmd())
 
 	return cmd
 }
 
 // runTUI launches the terminal user interface.
-func runTUI(_ *cobra.Command, _ []string) error {
-	// Check if this is first run.
-	isFirstRun := config.IsFirstRun()
+func runTUI(cmd *cobra.Command, _ []string) error {
+	inline, err := cmd.Flags().GetBool("inline")
+	if err != nil {
+		return err
+	}
+	inline = inline || tui.DetectInline()
+
+	var opts []tui.Option
+	quick, err := quickSelection(cmd)
+	if err != nil {
+		return err
+	}
+	if quick != nil {
+		opts = append(opts, tui.WithQuickSetup(*quick))
+	}
+
+	// Open the --log-file log before anything else runs so migration and
+	// config loading are logged too. A log file named only in the config
+	// is opened once the config is loaded.
+	closeLog, err := startLog(cmd, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = closeLog() }()
+
+	if simulate, _ := cmd.Flags().GetBool("simulate-first-run"); simulate {
+		if location, _ := cmd.Flags().GetString("config"); location != "" {
+			return errors.New("--simulate-first-run can't be combined with --config")
+		}
+		return runSimulatedFirstRun(cmd, append(opts, tui.WithInline(inline)), tui.Run)
+	}
+
+	warnLegacyMigration()
+
+	if location, _ := cmd.Flags().GetString("config"); location != "" {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return fmt.Errorf("loading config: %w", err)
+		}
+		if err := startConfigLog(cmd, cfg, &closeLog); err != nil {
+			return err
+		}
+		return runWithConfig(cfg, false, inline, append(opts, configWatch(cmd, cfg)...))
+	}
+
+	// Load config and providers once; the first-run decision and the
+	// wizard both reuse the result.
+	startup := loadStartup(cmd)
+	if err := startConfigLog(cmd, startup.Config, &closeLog); err != nil {
+		return err
+	}
+	if startup.Err == nil {
+		opts = append(opts, selectionMemory(cmd, startup.Config)...)
+	} else if startup.FirstRun {
+		opts = append(opts, selectionMemory(cmd, nil)...)
+	}
+	switch {
+	case startup.Err == nil:
+		return runWithConfig(startup.Config, startup.FirstRun, inline, append(opts, configWatch(cmd, startup.Config)...))
+	case !startup.FirstRun:
+		// A config that exists but can't be loaded must not be replaced by
+		// the wizard's.
+		return fmt.Errorf("loading config: %w", startup.Err)
+	}
+	// Nothing usable is configured yet, so the wizard will run.
+	for _, warning := range config.SetupWarnings(startup.Err) {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+
+	if startup.ProvidersErr == nil {
+		return tui.Run(startup.Providers, startup.FirstRun, append(opts, tui.WithInline(inline))...)
+	}
+
+	// The startup fetch failed, so retry while the welcome screen is shown,
+	// where a slow fetch can be canceled.
+	return tui.RunLoading(func(ctx context.Context) ([]catwalk.Provider, error) {
+		return config.LoadProvidersContext(ctx, config.NewConfig())
+	}, startup.FirstRun, append(opts, tui.WithInline(inline))...)
+}
+
+// runWithConfig launches the TUI for a successfully loaded config.
+func runWithConfig(cfg *config.Config, firstRun, inline bool, opts []tui.Option) error {
+	for _, warning := range cfg.Warnings() {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	styles.SetDefaultManager(styles.NewManagerWithThemeFile(cfg.Options.ThemeFile))
+	inline = inline || cfg.Options.Inline
+	if cfg.Options.Debug {
+		trace, err := openTrace(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not open TUI trace: %v\n", err)
+		} else {
+			defer trace.Close() //nolint:errcheck
+			opts = append(opts, tui.WithTrace(trace))
+		}
+	}
+	return tui.Run(cfg.KnownProviders(), firstRun, append(opts, tui.WithInline(inline))...)
+}
+
+// startLog routes the application log to the file named by --log-file or
+// options.log_file, at debug level when --debug or options.debug is set. cfg
+// may be nil when no config could be loaded. Without a log file the default
+// logger is left alone and the returned function does nothing.
+func startLog(cmd *cobra.Command, cfg *config.Config) (func() error, error) {
+	path, err := cmd.Flags().GetString("log-file")
+	if err != nil {
+		return nil, err
+	}
+	debug, err := cmd.Flags().GetBool("debug")
+	if err != nil {
+		return nil, err
+	}
+	var mode config.LogMode
+	if cfg != nil {
+		if path == "" {
+			path = cfg.Options.LogFile
+		}
+		mode = cfg.Options.LogMode
+		debug = debug || cfg.Options.Debug
+	}
+	if path == "" {
+		return func() error { return nil }, nil
+	}
+	return logging.Open(path, mode, debug)
+}
+
+// startConfigLog opens the log file named by options.log_file once cfg is
+// loaded and stores its close function in closeLog. It does nothing when
+// --log-file already opened a log or cfg names no log file.
+func startConfigLog(cmd *cobra.Command, cfg *config.Config, closeLog *func() error) error {
+	if path, _ := cmd.Flags().GetString("log-file"); path != "" || cfg == nil || cfg.Options.LogFile == "" {
+		return nil
+	}
+	opened, err := startLog(cmd, cfg)
+	if err != nil {
+		return err
+	}
+	*closeLog = opened
+	return nil
+}
+
+// traceFile is the TUI message trace written in debug mode, in the data
+// directory.
+const traceFile = "tui-trace.log"
+
+// openTrace opens the TUI message trace for appending.
+func openTrace(cfg *config.Config) (*os.File, error) {
+	if err := os.MkdirAll(cfg.DataDir(), 0o750); err != nil {
+		return nil, fmt.Errorf("creating data directory: %w", err)
+	}
+	path := filepath.Join(cfg.DataDir(), traceFile)
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) //nolint:gosec // Path is derived from the data directory.
+}
+
+// configWatch returns the TUI option reloading cfg when its config files
+// change, or nothing when options.watch is off. A config loaded from a URL
+// has no local file to watch.
+func configWatch(cmd *cobra.Command, cfg *config.Config) []tui.Option {
+	if !cfg.Options.Watch {
+		return nil
+	}
+	paths := config.SourceFiles()
+	if location, _ := cmd.Flags().GetString("config"); location != "" {
+		if config.IsConfigURL(location) {
+			return nil
+		}
+		paths = []string{location}
+	}
+	return []tui.Option{tui.WithConfigWatch(cfg, func() (*config.Config, error) {
+		return loadConfig(cmd)
+	}, paths...)}
+}
 
-	// Load providers from catwalk (for the wizard).
-	cfg := config.NewConfig()
+// startupProvidersTimeout bounds how long startup waits for catwalk. A slow
+// or unreachable catwalk then costs seconds, not the fetch's full timeout.
+const startupProvidersTimeout = 5 * time.Second
 
-	// Try to load providers even if config doesn't exist.
-	providers, err := config.LoadProviders(cfg)
+// loadStartup loads the config for startup, waiting at most
+// startupProvidersTimeout for catwalk before using cached or embedded
+// provider data.
+func loadStartup(cmd *cobra.Command) *config.Startup {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, startupProvidersTimeout)
+	defer cancel()
+	return config.LoadStartup(ctx)
+}
+
+// loadConfig loads the config named by the --config flag, a file path or an
+// http(s) URL, or from the standard locations when the flag is not set.
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	location, err := cmd.Flags().GetString("config")
+	if err != nil || location == "" {
+		return config.Load()
+	}
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return config.LoadFromLocation(ctx, location)
+}
+
+// warnLegacyMigration copies data from the legacy ~/.matrix directory to the
+// XDG locations and tells the user what was moved.
+func warnLegacyMigration() {
+	migration, err := config.MigrateLegacyDataDir()
 	if err != nil {
-		// If we can't load providers, show an error.
-		fmt.Fprintf(os.Stderr, "Warning: Failed to load providers: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Warning: could not migrate legacy data directory: %v\n", err)
+		return
+	}
+	if migration == nil {
+		return
+	}
+	for _, src := range slices.Sorted(maps.Keys(migration.Copied)) {
+		fmt.Fprintf(os.Stderr, "Warning: copied legacy %s to %s\n", src, migration.Copied[src])
 	}
+	fmt.Fprintf(os.Stderr, "Warning: %s is no longer used; remove it once the migrated files look right.\n",
+		migration.LegacyDir)
+}
 
-	return tui.Run(providers, isFirstRun)
+// quickSelection reads the --quick setup flags. It returns nil when quick
+// setup was not requested.
+func quickSelection(cmd *cobra.Command) (*wizard.QuickSelection, error) {
+	flags := cmd.Flags()
+	quick, err := flags.GetBool("quick")
+	if err != nil {
+		return nil, err
+	}
+	providerID, err := flags.GetString("provider")
+	if err != nil {
+		return nil, err
+	}
+	large, err := flags.GetString("large")
+	if err != nil {
+		return nil, err
+	}
+	small, err := flags.GetString("small")
+	if err != nil {
+		return nil, err
+	}
+
+	if !quick {
+		if providerID != "" || large != "" || small != "" {
+			return nil, errors.New("--provider, --large and --small require --quick")
+		}
+		return nil, nil
+	}
+	if providerID == "" {
+		return nil, errors.New("--quick requires --provider")
+	}
+	return &wizard.QuickSelection{
+		ProviderID:   providerID,
+		LargeModelID: large,
+		SmallModelID: small,
+	}, nil
 }
 
 // Execute runs the root command.
 func Execute() error {
+	config.SetPassphrasePrompt(promptPassphrase)
 	return newRootCmd().Execute()
 }
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+func TestQuickSelection(t *testing.T) {
+	//nolint:govet // Field order optimized for test readability.
+	tests := []struct {
+		name     string
+		args     []string
+		wantNil  bool
+		wantErr  bool
+		provider string
+	}{
+		{name: "not requested", args: nil, wantNil: true},
+		{name: "provider only", args: []string{"--quick", "--provider", "openai"}, provider: "openai"},
+		{name: "missing provider", args: []string{"--quick"}, wantErr: true},
+		{name: "flags without quick", args: []string{"--provider", "openai"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newRootCmd()
+			if err := root.ParseFlags(tt.args); err != nil {
+				t.Fatalf("ParseFlags() error = %v", err)
+			}
+
+			sel, err := quickSelection(root)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("quickSelection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (sel == nil) != tt.wantNil {
+				t.Fatalf("quickSelection() = %v, wantNil %v", sel, tt.wantNil)
+			}
+			if sel != nil && sel.ProviderID != tt.provider {
+				t.Errorf("ProviderID = %q, want %q", sel.ProviderID, tt.provider)
+			}
+		})
+	}
+}
+
+func TestStartLog(t *testing.T) {
+	dir := t.TempDir()
+	flagPath := filepath.Join(dir, "flag.log")
+	cfg := config.NewConfig()
+	cfg.Options.LogFile = filepath.Join(dir, "config.log")
+
+	root := newRootCmd()
+	if err := root.ParseFlags([]string{"--log-file", flagPath, "--debug"}); err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	closeLog, err := startLog(root, cfg)
+	if err != nil {
+		t.Fatalf("startLog() error = %v", err)
+	}
+	slog.Debug("from the flag")
+	if err := closeLog(); err != nil {
+		t.Fatalf("close error = %v", err)
+	}
+
+	data, err := os.ReadFile(flagPath) //nolint:gosec // Test file path.
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(data), "level=DEBUG msg=\"from the flag\"") {
+		t.Errorf("log = %q, want the debug line", data)
+	}
+	if _, err := os.Stat(cfg.Options.LogFile); !os.IsNotExist(err) {
+		t.Errorf("--log-file should override options.log_file, stat error = %v", err)
+	}
+}
+
+func TestStartLog_NoFile(t *testing.T) {
+	previous := slog.Default()
+	closeLog, err := startLog(newRootCmd(), nil)
+	if err != nil {
+		t.Fatalf("startLog() error = %v", err)
+	}
+	if slog.Default() != previous {
+		t.Error("startLog() without a log file replaced the default logger")
+	}
+	if err := closeLog(); err != nil {
+		t.Fatalf("close error = %v", err)
+	}
+}
+
+func TestStartConfigLog(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.NewConfig()
+	cfg.Options.LogFile = filepath.Join(dir, "config.log")
+
+	root := newRootCmd()
+	closeLog := func() error { return nil }
+	if err := startConfigLog(root, cfg, &closeLog); err != nil {
+		t.Fatalf("startConfigLog() error = %v", err)
+	}
+	slog.Info("from the config")
+	if err := closeLog(); err != nil {
+		t.Fatalf("close error = %v", err)
+	}
+	data, err := os.ReadFile(cfg.Options.LogFile)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(data), "from the config") {
+		t.Errorf("log = %q, want the config log line", data)
+	}
+
+	// A log opened from --log-file is kept.
+	root = newRootCmd()
+	if err := root.ParseFlags([]string{"--log-file", filepath.Join(dir, "flag.log")}); err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	kept := false
+	closeLog = func() error { kept = true; return nil }
+	if err := startConfigLog(root, cfg, &closeLog); err != nil {
+		t.Fatalf("startConfigLog() error = %v", err)
+	}
+	if err := closeLog(); err != nil || !kept {
+		t.Error("startConfigLog() replaced the --log-file log")
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+	"github.com/guilhermegouw/matrix-cli/internal/tui"
+	"github.com/guilhermegouw/matrix-cli/internal/tui/components/wizard"
+)
+
+// selectionMemory returns the TUI option offering the model selection last
+// used with the project config in effect, and records cfg's models as the
+// latest one. cfg is nil when no config could be loaded. Without a project
+// config, or with --config, nothing is remembered.
+func selectionMemory(cmd *cobra.Command, cfg *config.Config) []tui.Option {
+	project := config.ProjectConfigPath()
+	if location, _ := cmd.Flags().GetString("config"); location != "" || project == "" {
+		return nil
+	}
+	dataDir := config.DefaultDataDir()
+	if cfg != nil {
+		dataDir = cfg.DataDir()
+	}
+
+	var previous *wizard.QuickSelection
+	last, ok, err := config.LastProjectSelection(dataDir, project)
+	switch {
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Warning: could not read the last model selection for %s: %v\n", project, err)
+	case ok:
+		previous = wizardSelection(last.Models)
+	}
+	if cfg != nil {
+		if err := config.RecordProjectSelection(dataDir, project, cfg.Models); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not remember the model selection for %s: %v\n", project, err)
+		}
+	}
+
+	record := func(sel wizard.QuickSelection) error {
+		return config.RecordProjectSelection(dataDir, project, map[config.SelectedModelType]config.SelectedModel{
+			config.SelectedModelTypeLarge: {Provider: sel.ProviderID, Model: sel.LargeModelID},
+			config.SelectedModelTypeSmall: {Provider: sel.ProviderID, Model: sel.SmallModelID},
+		})
+	}
+	return []tui.Option{tui.WithSelectionMemory(previous, record)}
+}
+
+// wizardSelection turns remembered tiers into a wizard selection, or nil
+// without a large model. The wizard sets up a single provider, so a small
+// model from another provider is left to the provider's default.
+func wizardSelection(models map[config.SelectedModelType]config.SelectedModel) *wizard.QuickSelection {
+	large, ok := models[config.SelectedModelTypeLarge]
+	if !ok || large.Provider == "" {
+		return nil
+	}
+	sel := &wizard.QuickSelection{ProviderID: large.Provider, LargeModelID: large.Model}
+	if small := models[config.SelectedModelTypeSmall]; small.Provider == large.Provider {
+		sel.SmallModelID = small.Model
+	}
+	return sel
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+	"github.com/guilhermegouw/matrix-cli/internal/tui/components/wizard"
+)
+
+func TestWizardSelection(t *testing.T) {
+	large := config.SelectedModelTypeLarge
+	small := config.SelectedModelTypeSmall
+
+	tests := []struct { //nolint:govet // Test struct alignment is not critical.
+		name   string
+		models map[config.SelectedModelType]config.SelectedModel
+		want   *wizard.QuickSelection
+	}{
+		{
+			name: "same provider",
+			models: map[config.SelectedModelType]config.SelectedModel{
+				large: {Provider: "openai", Model: "gpt-4o"},
+				small: {Provider: "openai", Model: "gpt-4o-mini"},
+			},
+			want: &wizard.QuickSelection{ProviderID: "openai", LargeModelID: "gpt-4o", SmallModelID: "gpt-4o-mini"},
+		},
+		{
+			name: "small from another provider",
+			models: map[config.SelectedModelType]config.SelectedModel{
+				large: {Provider: "anthropic", Model: "claude-sonnet-4"},
+				small: {Provider: "openai", Model: "gpt-4o-mini"},
+			},
+			want: &wizard.QuickSelection{ProviderID: "anthropic", LargeModelID: "claude-sonnet-4"},
+		},
+		{
+			name: "no large model",
+			models: map[config.SelectedModelType]config.SelectedModel{
+				small: {Provider: "openai", Model: "gpt-4o-mini"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wizardSelection(tt.models)
+			switch {
+			case tt.want == nil && got != nil:
+				t.Errorf("wizardSelection() = %+v, want nil", got)
+			case tt.want != nil && (got == nil || *got != *tt.want):
+				t.Errorf("wizardSelection() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
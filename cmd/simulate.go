@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/spf13/cobra"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+	"github.com/guilhermegouw/matrix-cli/internal/tui"
+)
+
+// tuiRunner starts the TUI; it matches tui.Run.
+type tuiRunner func(providers []catwalk.Provider, isFirstRun bool, opts ...tui.Option) error
+
+// runSimulatedFirstRun runs first-run setup against a temporary config and
+// data directory, removed on return, so setup can be previewed without
+// touching the real config.
+func runSimulatedFirstRun(cmd *cobra.Command, opts []tui.Option, run tuiRunner) error {
+	dir, err := os.MkdirTemp("", "matrix-simulate-*")
+	if err != nil {
+		return fmt.Errorf("creating simulation directory: %w", err)
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck // Best effort; it is a temp dir.
+
+	restore, err := redirectXDG(dir)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, startupProvidersTimeout)
+	providers, err := config.LoadProvidersContext(ctx, config.NewConfig())
+	cancel()
+	if err != nil {
+		return fmt.Errorf("loading providers: %w", err)
+	}
+
+	if err := run(providers, true, append(opts, tui.WithSimulatedFirstRun())...); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Simulated first run finished; discarded %s\n", config.GlobalConfigPath())
+	return nil
+}
+
+// redirectXDG points the XDG config and data directories into dir, returning
+// a function restoring the previous environment.
+func redirectXDG(dir string) (func(), error) {
+	dirs := map[string]string{
+		"XDG_CONFIG_HOME": filepath.Join(dir, "config"),
+		"XDG_DATA_HOME":   filepath.Join(dir, "data"),
+	}
+
+	previous := make(map[string]string, len(dirs))
+	unset := make(map[string]bool, len(dirs))
+	for name := range dirs {
+		value, ok := os.LookupEnv(name)
+		previous[name], unset[name] = value, !ok
+	}
+	restore := func() {
+		for name := range dirs {
+			if unset[name] {
+				_ = os.Unsetenv(name) //nolint:errcheck // Restoring is best effort.
+			} else {
+				_ = os.Setenv(name, previous[name]) //nolint:errcheck // Restoring is best effort.
+			}
+		}
+		xdg.Reload()
+	}
+
+	for name, path := range dirs {
+		if err := os.Setenv(name, path); err != nil {
+			restore()
+			return nil, fmt.Errorf("redirecting %s: %w", name, err)
+		}
+	}
+	xdg.Reload()
+	return restore, nil
+}
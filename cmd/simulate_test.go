@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adrg/xdg"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+	"github.com/guilhermegouw/matrix-cli/internal/tui"
+)
+
+func TestRunSimulatedFirstRun(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+	xdg.Reload()
+	realPath := config.GlobalConfigPath()
+
+	// Keep catwalk offline so the embedded providers are used.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("CATWALK_URL", server.URL)
+
+	var simulatedPath string
+	run := func(providers []catwalk.Provider, isFirstRun bool, _ ...tui.Option) error {
+		if !isFirstRun {
+			t.Error("the simulation should run as a first run")
+		}
+		if len(providers) == 0 {
+			t.Error("the simulation should have providers for the wizard")
+		}
+		// Save as the wizard would.
+		simulatedPath = config.GlobalConfigPath()
+		if err := config.SaveWizardResult("openai", "$OPENAI_API_KEY", "gpt-4o", "gpt-4o-mini"); err != nil {
+			t.Fatalf("SaveWizardResult() error = %v", err)
+		}
+		if _, err := os.Stat(simulatedPath); err != nil {
+			t.Errorf("the wizard's save should reach the temporary config: %v", err)
+		}
+		return nil
+	}
+
+	var stderr bytes.Buffer
+	root := newRootCmd()
+	root.SetErr(&stderr)
+	if err := runSimulatedFirstRun(root, nil, run); err != nil {
+		t.Fatalf("runSimulatedFirstRun() error = %v", err)
+	}
+
+	if simulatedPath == "" || simulatedPath == realPath {
+		t.Errorf("simulated config path = %q, want a temporary path apart from %q", simulatedPath, realPath)
+	}
+	if _, err := os.Stat(realPath); !os.IsNotExist(err) {
+		t.Errorf("the real config should not be written, stat error = %v", err)
+	}
+	if _, err := os.Stat(simulatedPath); !os.IsNotExist(err) {
+		t.Errorf("the temporary config should be removed, stat error = %v", err)
+	}
+	if got := config.GlobalConfigPath(); got != realPath {
+		t.Errorf("GlobalConfigPath() after the simulation = %q, want %q restored", got, realPath)
+	}
+	if !strings.Contains(stderr.String(), "discarded") {
+		t.Errorf("stderr = %q, want the discarded notice", stderr.String())
+	}
+}
+
+func TestSimulateFirstRun_RejectsConfigFlag(t *testing.T) {
+	root := newRootCmd()
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"--simulate-first-run", "--config", "matrix.json"})
+
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--config") {
+		t.Errorf("Execute() error = %v, want the --config conflict", err)
+	}
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/spf13/cobra"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+	"github.com/guilhermegouw/matrix-cli/internal/provider"
+)
+
+// setupStatus is the machine-readable setup state printed by status --json.
+//
+//nolint:govet // Field order optimized for JSON readability over memory.
+type setupStatus struct {
+	FirstRun   bool                  `json:"first_run"`
+	NeedsSetup bool                  `json:"needs_setup"`
+	ConfigPath string                `json:"config_path"`
+	Error      string                `json:"error,omitempty"`
+	Tiers      map[string]tierStatus `json:"tiers"`
+	Providers  []providerState       `json:"providers"`
+}
+
+// tierStatus is the model selected for a tier.
+type tierStatus struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+}
+
+// providerState is the configuration state of one provider.
+//
+//nolint:govet // Field order optimized for JSON readability over memory.
+type providerState struct {
+	ID         string `json:"id"`
+	Type       string `json:"type,omitempty"`
+	AuthSource string `json:"auth_source,omitempty"`
+	Configured bool   `json:"configured"`
+	Keyless    bool   `json:"keyless"`
+	Disabled   bool   `json:"disabled"`
+}
+
+func newStatusCmd() *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Report whether setup is needed and what is configured",
+		Long: `Report whether setup is needed, the model of each tier and the state of
+each configured provider. It always exits 0, so wrapper scripts can read
+the result instead of the exit code; use --json for a stable format.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			var (
+				cfg      *config.Config
+				err      error
+				firstRun bool
+			)
+			path := config.GlobalConfigPath()
+			if location, _ := cmd.Flags().GetString("config"); location != "" {
+				path = location
+				cfg, err = loadConfig(cmd)
+				firstRun = configFirstRun(err)
+			} else {
+				startup := loadStartup(cmd)
+				cfg, err, firstRun = startup.Config, startup.Err, startup.FirstRun
+			}
+
+			status := buildSetupStatus(cfg, err, firstRun, path)
+			if asJSON {
+				return writeStatusJSON(cmd.OutOrStdout(), status)
+			}
+			printSetupStatus(cmd.OutOrStdout(), status)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the status as JSON")
+
+	return cmd
+}
+
+// configFirstRun reports whether loading the --config file failed because
+// setup hasn't happened yet: the file is missing or configures no usable
+// provider. As for the default location, a config that exists but can't be
+// loaded is not a first run, so scripts don't run the wizard over it.
+func configFirstRun(err error) bool {
+	return errors.Is(err, fs.ErrNotExist) || errors.Is(err, config.ErrNeedsSetup)
+}
+
+// buildSetupStatus summarizes a config load. A failed load needs setup and
+// reports its error.
+func buildSetupStatus(cfg *config.Config, loadErr error, firstRun bool, path string) setupStatus {
+	status := setupStatus{
+		FirstRun:   firstRun,
+		NeedsSetup: true,
+		ConfigPath: path,
+		Tiers:      map[string]tierStatus{},
+		Providers:  []providerState{},
+	}
+	if loadErr != nil {
+		status.Error = loadErr.Error()
+		return status
+	}
+
+	status.NeedsSetup = cfg.NeedsSetup()
+	for _, tier := range provider.AllTiers() {
+		if model, ok := cfg.Models[tier]; ok {
+			status.Tiers[string(tier)] = tierStatus{Provider: model.Provider, Model: model.Model}
+		}
+	}
+	for _, id := range cfg.ProviderIDs() {
+		p := cfg.Providers[id]
+		status.Providers = append(status.Providers, providerState{
+			ID:         id,
+			Type:       string(p.Type),
+			AuthSource: string(p.AuthSource),
+			Configured: p.HasCredentials(),
+			Keyless:    p.Keyless(),
+			Disabled:   p.Disable,
+		})
+	}
+	return status
+}
+
+// writeStatusJSON prints status as indented JSON.
+func writeStatusJSON(out io.Writer, status setupStatus) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(status)
+}
+
+// printSetupStatus prints status for people.
+func printSetupStatus(out io.Writer, status setupStatus) {
+	setup := "no"
+	if status.NeedsSetup {
+		setup = "yes"
+	}
+	fmt.Fprintf(out, "Setup needed: %s\n", setup)
+	if status.Error != "" {
+		fmt.Fprintf(out, "Config error: %s\n", status.Error)
+	}
+	for _, tier := range provider.AllTiers() {
+		if t, ok := status.Tiers[string(tier)]; ok {
+			fmt.Fprintf(out, "%-6s %s/%s\n", tier, t.Provider, t.Model)
+		}
+	}
+}
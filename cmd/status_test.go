@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adrg/xdg"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+// decodeStatus writes status as JSON and decodes it generically, so the
+// test sees the field names scripts rely on.
+func decodeStatus(t *testing.T, status setupStatus) map[string]any {
+	t.Helper()
+	var out bytes.Buffer
+	if err := writeStatusJSON(&out, status); err != nil {
+		t.Fatalf("writeStatusJSON() error = %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+	return got
+}
+
+func TestSetupStatus_Configured(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Providers["openai"] = &config.ProviderConfig{
+		ID:         "openai",
+		Type:       catwalk.TypeOpenAI,
+		APIKey:     "sk-test",
+		AuthSource: config.AuthSourceAPIKey,
+	}
+	cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{Provider: "openai", Model: "gpt-4o"}
+	cfg.Models[config.SelectedModelTypeSmall] = config.SelectedModel{Provider: "openai", Model: "gpt-4o-mini"}
+
+	got := decodeStatus(t, buildSetupStatus(cfg, nil, false, "/tmp/matrix.json"))
+
+	if got["first_run"] != false || got["needs_setup"] != false {
+		t.Errorf("first_run = %v, needs_setup = %v, want both false", got["first_run"], got["needs_setup"])
+	}
+	if _, ok := got["error"]; ok {
+		t.Errorf("error should be omitted, got %v", got["error"])
+	}
+	tiers, _ := got["tiers"].(map[string]any)
+	large, _ := tiers["large"].(map[string]any)
+	if large["provider"] != "openai" || large["model"] != "gpt-4o" {
+		t.Errorf("tiers.large = %v, want openai/gpt-4o", large)
+	}
+	providers, _ := got["providers"].([]any)
+	if len(providers) != 1 {
+		t.Fatalf("providers = %v, want one entry", providers)
+	}
+	p, _ := providers[0].(map[string]any)
+	want := map[string]any{
+		"id": "openai", "type": "openai", "auth_source": "api_key",
+		"configured": true, "keyless": false, "disabled": false,
+	}
+	for k, v := range want {
+		if p[k] != v {
+			t.Errorf("providers[0].%s = %v, want %v", k, p[k], v)
+		}
+	}
+}
+
+func TestSetupStatus_Unconfigured(t *testing.T) {
+	got := decodeStatus(t, buildSetupStatus(nil, config.ErrNeedsSetup, true, "/tmp/matrix.json"))
+
+	if got["first_run"] != true || got["needs_setup"] != true {
+		t.Errorf("first_run = %v, needs_setup = %v, want both true", got["first_run"], got["needs_setup"])
+	}
+	if got["error"] != config.ErrNeedsSetup.Error() {
+		t.Errorf("error = %v, want %q", got["error"], config.ErrNeedsSetup.Error())
+	}
+	if tiers, ok := got["tiers"].(map[string]any); !ok || len(tiers) != 0 {
+		t.Errorf("tiers = %v, want an empty object", got["tiers"])
+	}
+	if providers, ok := got["providers"].([]any); !ok || len(providers) != 0 {
+		t.Errorf("providers = %v, want an empty array", got["providers"])
+	}
+	if got["config_path"] != "/tmp/matrix.json" {
+		t.Errorf("config_path = %v, want /tmp/matrix.json", got["config_path"])
+	}
+}
+
+func TestStatusCmd_ConfigFirstRun(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+	xdg.Reload()
+	t.Chdir(tempDir)
+
+	// Keep catwalk offline so the embedded providers are used.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("CATWALK_URL", server.URL)
+
+	tests := []struct {
+		name     string
+		content  string
+		firstRun bool
+	}{
+		{name: "missing", firstRun: true},
+		{name: "no providers", content: `{}`, firstRun: true},
+		{name: "broken", content: `{"providers": `, firstRun: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "matrix.json")
+			if tt.content != "" {
+				if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+					t.Fatalf("WriteFile() error = %v", err)
+				}
+			}
+
+			var out bytes.Buffer
+			root := newRootCmd()
+			root.SetOut(&out)
+			root.SetErr(&bytes.Buffer{})
+			root.SetArgs([]string{"status", "--json", "--config", path})
+			if err := root.Execute(); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			var got map[string]any
+			if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+				t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+			}
+			if got["first_run"] != tt.firstRun {
+				t.Errorf("first_run = %v, want %v", got["first_run"], tt.firstRun)
+			}
+			if got["needs_setup"] != true {
+				t.Errorf("needs_setup = %v, want true", got["needs_setup"])
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/guilhermegouw/matrix-cli/internal/provider"
+)
+
+func newUsageCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "usage",
+		Short: "Summarize the local model usage log",
+		Long: `Summarize which models you use most, from the local usage log.
+
+The log is off by default. Enable it with "usage_log": true in the config
+options. It is kept in the data directory and never sent anywhere.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return fmt.Errorf("loading config: %w", err)
+			}
+
+			out := cmd.OutOrStdout()
+			entries, err := provider.ReadUsage(provider.UsageLogPath(cfg))
+			if errors.Is(err, os.ErrNotExist) {
+				if !cfg.Options.UsageLog {
+					fmt.Fprintln(out, "Usage logging is disabled. Set \"usage_log\": true in the config options to enable it.")
+					return nil
+				}
+				fmt.Fprintln(out, "No usage recorded yet.")
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			for _, s := range provider.SummarizeUsage(entries) {
+				fmt.Fprintf(out, "%5d  %s/%s (last used %s)\n", s.Count, s.Provider, s.Model, s.LastUsed.Format("2006-01-02"))
+			}
+			return nil
+		},
+	}
+}
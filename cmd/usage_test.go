@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adrg/xdg"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+	"github.com/guilhermegouw/matrix-cli/internal/provider"
+)
+
+func TestUsageCmd(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+	t.Setenv("MATRIX_TEST_USAGE_KEY", "sk-usage")
+	xdg.Reload()
+	t.Chdir(tempDir)
+
+	// Keep catwalk offline so the embedded providers are used.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("CATWALK_URL", server.URL)
+
+	dataDir := filepath.Join(tempDir, "usage")
+	writeConfig := func(usageLog bool) string {
+		t.Helper()
+		path := filepath.Join(tempDir, "matrix.json")
+		cfg := fmt.Sprintf(`{"providers": {"openai": {"api_key": "$MATRIX_TEST_USAGE_KEY"}}, "options": {"data_directory": %q, "usage_log": %t}}`,
+			dataDir, usageLog)
+		if err := os.WriteFile(path, []byte(cfg), 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		return path
+	}
+	run := func(path string) string {
+		t.Helper()
+		var out, errOut bytes.Buffer
+		root := newRootCmd()
+		root.SetOut(&out)
+		root.SetErr(&errOut)
+		root.SetArgs([]string{"usage", "--config", path})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		return out.String()
+	}
+
+	if got, want := run(writeConfig(false)), "Usage logging is disabled. Set \"usage_log\": true in the config options to enable it.\n"; got != want {
+		t.Errorf("disabled output = %q, want %q", got, want)
+	}
+
+	path := writeConfig(true)
+	if got, want := run(path), "No usage recorded yet.\n"; got != want {
+		t.Errorf("empty output = %q, want %q", got, want)
+	}
+
+	used := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	entries := []provider.UsageEntry{
+		{Time: used, Tier: config.SelectedModelTypeLarge, Provider: "openai", Model: "gpt-4o"},
+		{Time: used, Tier: config.SelectedModelTypeLarge, Provider: "openai", Model: "gpt-4o"},
+	}
+	if err := provider.AppendUsage(filepath.Join(dataDir, "usage.jsonl"), entries...); err != nil {
+		t.Fatalf("AppendUsage() error = %v", err)
+	}
+	if got, want := run(path), "    2  openai/gpt-4o (last used 2026-03-01)\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
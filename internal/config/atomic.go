@@ -0,0 +1,67 @@
+package config
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic writes data to name like os.WriteFile, but through a
+// temporary file in the same directory renamed into place, so a crash or a
+// failed write never leaves a truncated file behind. A symlinked name is
+// written through to its target. Like os.WriteFile, an existing file keeps
+// its mode, and its owner where possible; perm applies to new files only.
+func writeFileAtomic(name string, data []byte, perm os.FileMode) error {
+	return writeFileAtomicWith(name, perm, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// writeFileAtomicWith is writeFileAtomic with the contents produced by
+// write; name is left untouched when write fails.
+func writeFileAtomicWith(name string, perm os.FileMode, write func(io.Writer) error) (err error) {
+	if target, evalErr := filepath.EvalSymlinks(name); evalErr == nil {
+		name = target
+	} else if !errors.Is(evalErr, fs.ErrNotExist) {
+		return evalErr
+	}
+
+	existing, statErr := os.Stat(name)
+	switch {
+	case statErr == nil:
+		perm = existing.Mode().Perm()
+	case !errors.Is(statErr, fs.ErrNotExist):
+		return statErr
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()           //nolint:errcheck // Already failing.
+			_ = os.Remove(tmp.Name()) //nolint:errcheck // Best effort cleanup.
+		}
+	}()
+
+	if err := write(tmp); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if existing != nil {
+		keepOwner(tmp, existing)
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
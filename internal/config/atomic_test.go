@@ -0,0 +1,140 @@
+package config
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matrix.json")
+	if err := os.WriteFile(path, []byte(`{"old":true}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(path, []byte(`{"new":true}`), 0o600); err != nil {
+		t.Fatalf("writeFileAtomic() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // Test reads its own temp file.
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"new":true}` {
+		t.Errorf("file = %s, want the new contents", data)
+	}
+	assertNoTempFiles(t, filepath.Dir(path))
+}
+
+func TestWriteFileAtomic_FailedWriteKeepsOriginal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "providers.json")
+	valid := []byte(`{"providers":[]}`)
+	if err := os.WriteFile(path, valid, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fail halfway through, as an interrupted write would.
+	errWrite := errors.New("disk went away")
+	err := writeFileAtomicWith(path, 0o600, func(w io.Writer) error {
+		if _, err := w.Write([]byte(`{"provi`)); err != nil {
+			return err
+		}
+		return errWrite
+	})
+	if !errors.Is(err, errWrite) {
+		t.Fatalf("writeFileAtomicWith() error = %v, want %v", err, errWrite)
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // Test reads its own temp file.
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(valid) {
+		t.Errorf("file = %s, want the original %s", data, valid)
+	}
+	assertNoTempFiles(t, filepath.Dir(path))
+}
+
+func TestWriteFileAtomic_WritesThroughSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "matrix.json")
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "matrix.json")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	if err := writeFileAtomic(link, []byte(`{"new":true}`), 0o600); err != nil {
+		t.Fatalf("writeFileAtomic() error = %v", err)
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("the symlink should be kept, Lstat() = %v, %v", info, err)
+	}
+	data, err := os.ReadFile(target) //nolint:gosec // Test reads its own temp file.
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"new":true}` {
+		t.Errorf("target = %s, want the new contents", data)
+	}
+}
+
+// assertNoTempFiles fails the test if a temporary file was left in dir.
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("dir holds %v, want only the written file", names)
+	}
+}
+
+func TestWriteFileAtomic_KeepsExistingMode(t *testing.T) {
+	dir := t.TempDir()
+
+	// A file the user tightened keeps its mode.
+	existing := filepath.Join(dir, "matrix.json")
+	if err := os.WriteFile(existing, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(existing, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(existing, []byte(`{"new":true}`), 0o644); err != nil {
+		t.Fatalf("writeFileAtomic() error = %v", err)
+	}
+	info, err := os.Stat(existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("existing file mode = %v, want 0600 kept", info.Mode().Perm())
+	}
+
+	// A new file gets perm.
+	created := filepath.Join(dir, "new.json")
+	if err := writeFileAtomic(created, []byte("{}"), 0o640); err != nil {
+		t.Fatalf("writeFileAtomic() error = %v", err)
+	}
+	info, err = os.Stat(created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("new file mode = %v, want 0640", info.Mode().Perm())
+	}
+}
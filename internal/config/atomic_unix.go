@@ -0,0 +1,18 @@
+//go:build unix
+
+package config
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// keepOwner gives f the owner and group of the file described by info.
+// Only root can hand a file to another user, so failures are ignored and
+// the file keeps the writer's ownership.
+func keepOwner(f *os.File, info fs.FileInfo) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		_ = f.Chown(int(st.Uid), int(st.Gid)) //nolint:errcheck // Best effort, see above.
+	}
+}
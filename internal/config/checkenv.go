@@ -0,0 +1,76 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+)
+
+// EnvIssue is a config value referencing an environment variable that is not
+// set.
+type EnvIssue struct {
+	// Err is the resolver error naming the missing variables.
+	Err error
+	// File is the config file holding the value.
+	File string
+	// Field is the dotted path of the value, e.g. "providers.openai.api_key".
+	Field string
+}
+
+// SourceFiles returns the config files Load reads, global then project,
+// skipping those that don't exist.
+func SourceFiles() []string {
+	var files []string
+	if _, err := os.Stat(GlobalConfigPath()); err == nil {
+		files = append(files, GlobalConfigPath())
+	}
+	if project := findProjectConfig(); project != "" {
+		files = append(files, project)
+	}
+	return files
+}
+
+// CheckEnv resolves every provider API key, base URL and header, and the
+// default headers, in the given config files against resolver. Unlike Load,
+// which drops providers whose key can't be resolved, it reports every
+// unresolved reference.
+func CheckEnv(files []string, resolver *Resolver) ([]EnvIssue, error) {
+	var issues []EnvIssue
+	for _, file := range files {
+		cfg := NewConfig()
+		if err := loadFile(file, cfg); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("config file %s not found", file)
+			}
+			return nil, fmt.Errorf("loading %s: %w", file, err)
+		}
+
+		check := func(field, value string) {
+			if _, err := resolver.Resolve(value); err != nil {
+				issues = append(issues, EnvIssue{Err: err, File: file, Field: field})
+			}
+		}
+
+		for _, id := range cfg.ProviderIDs() {
+			p := cfg.Providers[id]
+			if p == nil {
+				continue
+			}
+			prefix := "providers." + id
+			check(prefix+".api_key", p.APIKey)
+			check(prefix+".base_url", p.BaseURL)
+			for _, name := range slices.Sorted(maps.Keys(p.ExtraHeaders)) {
+				check(prefix+".extra_headers."+name, p.ExtraHeaders[name])
+			}
+		}
+		if cfg.Options != nil {
+			for _, name := range slices.Sorted(maps.Keys(cfg.Options.DefaultHeaders)) {
+				check("options.default_headers."+name, cfg.Options.DefaultHeaders[name])
+			}
+		}
+	}
+	return issues, nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matrix.json")
+	content := `{
+		"providers": {
+			"openai": {
+				"api_key": "$OPENAI_API_KEY",
+				"base_url": "${OPENAI_BASE_URL}",
+				"extra_headers": {"X-Team": "$TEAM"}
+			},
+			"anthropic": {"api_key": "$ANTHROPIC_API_KEY"}
+		},
+		"options": {"default_headers": {"X-Trace": "$TRACE_ID"}}
+	}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	resolver := NewResolverWithEnv(map[string]string{
+		"OPENAI_API_KEY":    "sk-openai",
+		"ANTHROPIC_API_KEY": "sk-ant",
+		"TRACE_ID":          "abc",
+	})
+	issues, err := CheckEnv([]string{path}, resolver)
+	if err != nil {
+		t.Fatalf("CheckEnv() error = %v", err)
+	}
+
+	var fields []string
+	for _, issue := range issues {
+		fields = append(fields, issue.Field)
+	}
+	want := []string{"providers.openai.base_url", "providers.openai.extra_headers.X-Team"}
+	if strings.Join(fields, ",") != strings.Join(want, ",") {
+		t.Errorf("CheckEnv() fields = %v, want %v", fields, want)
+	}
+	if len(issues) > 0 && !strings.Contains(issues[0].Err.Error(), "OPENAI_BASE_URL") {
+		t.Errorf("issue error = %v, want it to name OPENAI_BASE_URL", issues[0].Err)
+	}
+}
+
+func TestCheckEnv_MissingFile(t *testing.T) {
+	_, err := CheckEnv([]string{filepath.Join(t.TempDir(), "missing.json")}, NewResolver())
+	if err == nil {
+		t.Error("CheckEnv() should fail for a missing file")
+	}
+}
@@ -2,7 +2,11 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 
@@ -24,6 +28,26 @@ const (
 	SelectedModelTypeSmall SelectedModelType = "small"
 )
 
+// AuthSource records where a provider's API key comes from.
+type AuthSource string
+
+const (
+	// AuthSourceAPIKey means the API key was entered by the user.
+	AuthSourceAPIKey AuthSource = "api_key"
+	// AuthSourceOAuth means the API key is derived from the stored OAuth token.
+	AuthSourceOAuth AuthSource = "oauth"
+)
+
+// AuthScheme is how an Anthropic provider sends its API key.
+type AuthScheme string
+
+const (
+	// AuthSchemeBearer sends the key in an "Authorization: Bearer" header.
+	AuthSchemeBearer AuthScheme = "bearer"
+	// AuthSchemeAPIKey sends the key in the "x-api-key" header.
+	AuthSchemeAPIKey AuthScheme = "api-key"
+)
+
 // SelectedModel defines which model to use for a tier.
 //
 //nolint:govet // Field order optimized for JSON readability over memory.
@@ -48,8 +72,34 @@ type SelectedModel struct {
 	TopK *int64 `json:"top_k,omitempty"`
 	// MaxTokens overrides the default max tokens for responses.
 	MaxTokens int64 `json:"max_tokens,omitempty"`
+	// Stop lists sequences that end generation, for providers that support it.
+	Stop []string `json:"stop,omitempty"`
 	// Think enables thinking mode for Anthropic models that support reasoning.
-	Think bool `json:"think,omitempty"`
+	// When unset, the provider's DefaultThink applies.
+	Think *bool `json:"think,omitempty"`
+	// PromptFile is a file whose content is appended to the global context
+	// to form this tier's system prompt.
+	PromptFile string `json:"prompt_file,omitempty"`
+	// MaxRetries is how many times a model call failing with a transient
+	// error is retried. When unset, Options.MaxRetries applies.
+	MaxRetries *int `json:"max_retries,omitempty"`
+}
+
+// Retries returns how many times the tier's model calls are retried: the
+// tier's MaxRetries when set, otherwise the one in opts, which may be nil.
+func (m SelectedModel) Retries(opts *Options) int {
+	switch {
+	case m.MaxRetries != nil:
+		return max(*m.MaxRetries, 0)
+	case opts != nil:
+		return max(opts.MaxRetries, 0)
+	}
+	return 0
+}
+
+// ThinkEnabled reports whether thinking mode is on for the model.
+func (m SelectedModel) ThinkEnabled() bool {
+	return m.Think != nil && *m.Think
 }
 
 // ProviderConfig holds provider authentication and settings.
@@ -74,10 +124,116 @@ type ProviderConfig struct {
 	BaseURL string `json:"base_url,omitempty"`
 	// APIKey is the authentication key.
 	APIKey string `json:"api_key,omitempty"`
+	// AuthSource records whether APIKey was entered by the user or derived
+	// from OAuthToken. Inferred on load when unset.
+	AuthSource AuthSource `json:"auth_source,omitempty"`
+	// AuthHeader is a custom header name that carries the API key instead of
+	// the default "Authorization: Bearer" header (OpenAI-compatible only).
+	AuthHeader string `json:"auth_header,omitempty"`
+	// AuthScheme forces how the key is sent (Anthropic only). When unset, a
+	// "Bearer " prefixed key uses bearer auth and any other key x-api-key.
+	AuthScheme AuthScheme `json:"auth_scheme,omitempty"`
+	// PreserveHeaderCase sends ExtraHeaders with their exact names instead of
+	// canonicalizing them (e.g. "x-api-key" rather than "X-Api-Key").
+	PreserveHeaderCase bool `json:"preserve_header_case,omitempty"`
+	// DefaultThink turns on thinking mode for tiers using this provider
+	// that don't set think themselves.
+	DefaultThink bool `json:"default_think,omitempty"`
+	// MetadataURL points at a catwalk-style provider document used to refresh
+	// Models instead of catwalk itself.
+	MetadataURL string `json:"metadata_url,omitempty"`
 	// SystemPromptPrefix is prepended to system prompts for this provider.
 	SystemPromptPrefix string `json:"-"`
+	// SourcePath is the config file the provider was read from, where
+	// refreshed OAuth tokens are saved. Empty when it came from elsewhere.
+	SourcePath string `json:"-"`
+	// DisableReason explains why the provider is disabled.
+	DisableReason string `json:"disable_reason,omitempty"`
 	// Disable marks the provider as disabled.
 	Disable bool `json:"disable,omitempty"`
+	// keyResolved is set once APIKey holds the resolved key rather than a
+	// template, so it isn't resolved again.
+	keyResolved bool
+	// apiKeyTemplate and headerTemplates keep the API key and extra headers
+	// as written in the config, before environment references are resolved.
+	apiKeyTemplate  string
+	headerTemplates map[string]string
+}
+
+// Keyless reports whether the provider is usable without credentials: an
+// OpenAI-compatible provider pointed at a base URL, such as a local server.
+func (pc *ProviderConfig) Keyless() bool {
+	return pc.APIKey == "" && pc.OAuthToken == nil &&
+		pc.Type == catwalk.TypeOpenAICompat && pc.BaseURL != ""
+}
+
+// HasCredentials reports whether the provider has an API key or OAuth token,
+// or is keyless. Bedrock providers without a key use the standard AWS
+// credential chain.
+func (pc *ProviderConfig) HasCredentials() bool {
+	return pc.APIKey != "" || pc.OAuthToken != nil || pc.Keyless() || pc.Type == catwalk.TypeBedrock
+}
+
+// DisabledDescription describes the disabled state for listings and errors,
+// including the reason when one was given.
+func (pc *ProviderConfig) DisabledDescription() string {
+	if pc.DisableReason == "" {
+		return "disabled"
+	}
+	return fmt.Sprintf("disabled: %s", pc.DisableReason)
+}
+
+// IsOAuth returns true if the provider authenticates with an OAuth token.
+func (pc *ProviderConfig) IsOAuth() bool {
+	return pc.AuthSource == AuthSourceOAuth
+}
+
+// validateAuth infers AuthSource when unset and enforces that API key and
+// OAuth authentication are mutually exclusive. For OAuth providers the API
+// key is derived from the access token when missing.
+func (pc *ProviderConfig) validateAuth() error {
+	switch pc.AuthScheme {
+	case "", AuthSchemeBearer, AuthSchemeAPIKey:
+	default:
+		return fmt.Errorf("unknown auth scheme %q (want %q or %q)", pc.AuthScheme, AuthSchemeBearer, AuthSchemeAPIKey)
+	}
+
+	if pc.AuthSource == "" {
+		pc.AuthSource = inferAuthSource(pc)
+	}
+
+	switch pc.AuthSource {
+	case AuthSourceOAuth:
+		if pc.OAuthToken == nil {
+			return fmt.Errorf("auth source is %q but no OAuth token is stored", AuthSourceOAuth)
+		}
+		if pc.APIKey == "" {
+			pc.APIKey = pc.OAuthToken.AccessToken
+		}
+	case AuthSourceAPIKey:
+		if pc.OAuthToken != nil {
+			return fmt.Errorf("has both a user API key and an OAuth token")
+		}
+	default:
+		return fmt.Errorf("unknown auth source %q", pc.AuthSource)
+	}
+	return nil
+}
+
+// inferAuthSource guesses the auth source of configs saved before
+// AuthSource existed, where OAuth providers stored the access token as the
+// API key.
+func inferAuthSource(pc *ProviderConfig) AuthSource {
+	if pc.OAuthToken == nil {
+		return AuthSourceAPIKey
+	}
+	switch pc.APIKey {
+	case "", pc.OAuthToken.AccessToken, "Bearer " + pc.OAuthToken.AccessToken:
+		return AuthSourceOAuth
+	}
+	// Both are set and the key isn't derived from the token, leave it to
+	// validation to reject.
+	return AuthSourceAPIKey
 }
 
 // SetupClaudeCode configures the provider for Claude Code OAuth authentication.
@@ -105,6 +261,10 @@ type Config struct {
 
 	// knownProviders holds the catwalk provider metadata.
 	knownProviders []catwalk.Provider
+	// warnings holds non-fatal problems found while loading.
+	warnings []string
+	// sources holds the layers that contributed to the config, see Sources.
+	sources []Source
 }
 
 // Options holds application settings.
@@ -115,8 +275,158 @@ type Options struct {
 	ContextPaths []string `json:"context_paths,omitempty"`
 	// DataDir is the directory for application data.
 	DataDir string `json:"data_directory,omitempty"`
+	// DefaultHeaders are HTTP headers added to requests for every provider,
+	// e.g. a tracing or cost-center header. Values may reference environment
+	// variables. A provider's extra_headers win on conflict.
+	DefaultHeaders map[string]string `json:"default_headers,omitempty"`
 	// Debug enables debug mode.
 	Debug bool `json:"debug,omitempty"`
+	// IndentStyle is the indentation used when saving the config file:
+	// IndentTab, or a number of spaces such as "4". When unset, two spaces
+	// are used. YAML files can't use tabs and keep two spaces.
+	IndentStyle string `json:"indent_style,omitempty"`
+	// Inline renders the TUI in the normal terminal buffer without the
+	// alternate screen or mouse tracking.
+	Inline bool `json:"inline,omitempty"`
+	// LogFile is a file receiving the application log, for bug reports.
+	LogFile string `json:"log_file,omitempty"`
+	// LogMode controls what happens to an existing log file at startup.
+	// When unset, LogModeAppend is used.
+	LogMode LogMode `json:"log_mode,omitempty"`
+	// MaxRetries is how many times a model call failing with a transient
+	// error is retried, for tiers that don't set their own. Zero means no
+	// retries.
+	MaxRetries int `json:"max_retries,omitempty"`
+	// PreferredProvider is the provider that supplies default models when
+	// none are selected, as long as it is configured and enabled. Otherwise
+	// the first usable provider is used.
+	PreferredProvider string `json:"preferred_provider,omitempty"`
+	// Proxy is the URL of an HTTP proxy for provider requests. It may
+	// reference environment variables. When unset, HTTP_PROXY, HTTPS_PROXY
+	// and NO_PROXY apply.
+	Proxy string `json:"proxy,omitempty"`
+	// ProvidersCacheMaxAge is how long cached provider metadata is used when
+	// catwalk can't be reached. Zero means 24 hours.
+	ProvidersCacheMaxAge Duration `json:"providers_cache_max_age,omitempty"`
+	// RequestTimeout is how many seconds to wait for a provider to start
+	// responding. Zero means no limit. Streamed responses are not cut off
+	// once they have started.
+	RequestTimeout int `json:"request_timeout,omitempty"`
+	// SmallFallback controls what happens when the small tier's model can't
+	// be built. When unset, SmallFallbackNone is used.
+	SmallFallback SmallFallback `json:"small_fallback,omitempty"`
+	// ReadOnly refuses every write to the config file, for managed
+	// environments. MATRIX_READONLY has the same effect.
+	ReadOnly bool `json:"read_only,omitempty"`
+	// ThemeFile is the path to a JSON file defining a custom TUI theme.
+	ThemeFile string `json:"theme_file,omitempty"`
+	// UsageLog enables a local log of the models used, kept in the data
+	// directory. Nothing is sent anywhere.
+	UsageLog bool `json:"usage_log,omitempty"`
+	// Watch reloads the config while the TUI runs when a config file is
+	// edited externally.
+	Watch bool `json:"watch,omitempty"`
+	// TierTemperatures sets the temperature used by tiers that don't specify
+	// one. When unset, DefaultTierTemperatures is used. Models whose catwalk
+	// metadata shows they reject a temperature are left without one.
+	TierTemperatures map[SelectedModelType]float64 `json:"tier_temperatures,omitempty"`
+}
+
+// Config file indentation, see Options.IndentStyle.
+const (
+	// IndentTab indents the saved config with tabs.
+	IndentTab = "tab"
+
+	defaultIndent   = "  "
+	maxIndentSpaces = 8
+)
+
+// LogMode controls how an existing log file is treated when a run starts.
+type LogMode string
+
+const (
+	// LogModeAppend adds to the existing log.
+	LogModeAppend LogMode = "append"
+	// LogModeTruncate starts each run with an empty log.
+	LogModeTruncate LogMode = "truncate"
+	// LogModeRotate moves the previous run's log to a ".1" file first.
+	LogModeRotate LogMode = "rotate"
+)
+
+// SmallFallback controls how the small tier recovers from a model that can't
+// be built, e.g. because its provider is misconfigured.
+type SmallFallback string
+
+const (
+	// SmallFallbackNone fails the build, so the problem is fixed first.
+	SmallFallbackNone SmallFallback = "none"
+	// SmallFallbackLarge uses the large model for the small tier instead,
+	// with a warning.
+	SmallFallbackLarge SmallFallback = "large"
+)
+
+// Duration is a time.Duration written in config files as a Go duration
+// string such as "36h" or "90m", or as a number of seconds.
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	return d.set(v)
+}
+
+// MarshalJSON implements json.Marshaler, writing a duration string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalYAML implements yaml.InterfaceUnmarshaler.
+func (d *Duration) UnmarshalYAML(unmarshal func(any) error) error {
+	var v any
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	return d.set(v)
+}
+
+// MarshalYAML implements yaml.InterfaceMarshaler, writing a duration string.
+func (d Duration) MarshalYAML() (any, error) {
+	return time.Duration(d).String(), nil
+}
+
+// set decodes a duration string or a number of seconds.
+func (d *Duration) set(v any) error {
+	switch v := v.(type) {
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", v, err)
+		}
+		*d = Duration(parsed)
+	case float64:
+		*d = Duration(v * float64(time.Second))
+	case uint64:
+		*d = Duration(time.Duration(v) * time.Second) //nolint:gosec // Config durations are small.
+	case int64:
+		*d = Duration(time.Duration(v) * time.Second)
+	case nil:
+		*d = 0
+	default:
+		return fmt.Errorf("invalid duration %v: want a string such as \"36h\" or a number of seconds", v)
+	}
+	return nil
+}
+
+// DefaultTierTemperatures returns the per-tier temperature defaults. Small
+// tier tasks like summaries and routing benefit from deterministic output,
+// while the large tier is left to the provider's default.
+func DefaultTierTemperatures() map[SelectedModelType]float64 {
+	return map[SelectedModelType]float64{
+		SelectedModelTypeSmall: 0.0,
+	}
 }
 
 // NewConfig creates a Config with initialized maps.
@@ -129,19 +439,72 @@ func NewConfig() *Config {
 }
 
 // GetModel finds a model by ID within a provider's model list.
+// Abbreviated IDs are accepted as long as they resolve to a single model.
 func (c *Config) GetModel(providerID, modelID string) *catwalk.Model {
 	provider, ok := c.Providers[providerID]
 	if !ok {
 		return nil
 	}
+	fullID, err := c.ResolveModelID(providerID, modelID)
+	if err != nil {
+		return nil
+	}
 	for i := range provider.Models {
-		if provider.Models[i].ID == modelID {
+		if provider.Models[i].ID == fullID {
 			return &provider.Models[i]
 		}
 	}
 	return nil
 }
 
+// ResolveModelID resolves a possibly abbreviated model ID to the full ID
+// listed by the provider. An exact match always wins; otherwise the ID may
+// match a namespaced model (e.g. "gpt-4o" for "openai/gpt-4o") or be a prefix
+// of a dated variant (e.g. "gpt-4o" for "gpt-4o-2024-08-06").
+// The ID is returned unchanged when nothing matches, and an error is returned
+// when the abbreviation matches more than one model.
+func (c *Config) ResolveModelID(providerID, modelID string) (string, error) {
+	provider, ok := c.Providers[providerID]
+	if !ok || modelID == "" {
+		return modelID, nil
+	}
+
+	var matches []string
+	for i := range provider.Models {
+		id := provider.Models[i].ID
+		if id == modelID {
+			return id, nil
+		}
+		name := id
+		if idx := strings.LastIndex(id, "/"); idx != -1 {
+			name = id[idx+1:]
+		}
+		if strings.HasPrefix(id, modelID) || strings.HasPrefix(name, modelID) {
+			matches = append(matches, id)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return modelID, nil
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("model %q is ambiguous for provider %q: matches %s",
+			modelID, providerID, strings.Join(matches, ", "))
+	}
+}
+
+// ProviderIDs returns the IDs of all configured providers in sorted order.
+func (c *Config) ProviderIDs() []string {
+	ids := make([]string, 0, len(c.Providers))
+	for id := range c.Providers {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
 // KnownProviders returns the catwalk provider metadata.
 func (c *Config) KnownProviders() []catwalk.Provider {
 	return c.knownProviders
@@ -151,3 +514,13 @@ func (c *Config) KnownProviders() []catwalk.Provider {
 func (c *Config) SetKnownProviders(providers []catwalk.Provider) {
 	c.knownProviders = providers
 }
+
+// Warnings returns the non-fatal problems found while loading the config.
+func (c *Config) Warnings() []string {
+	return c.warnings
+}
+
+// addWarning records a non-fatal problem found while loading the config.
+func (c *Config) addWarning(format string, args ...any) {
+	c.warnings = append(c.warnings, fmt.Sprintf(format, args...))
+}
@@ -2,9 +2,13 @@
 package config
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/oauth"
 )
 
 func TestSelectedModelType_Constants(t *testing.T) {
@@ -138,6 +142,194 @@ func TestConfig_GetModel(t *testing.T) {
 	}
 }
 
+func TestConfig_ResolveModelID(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Providers["openai"] = &ProviderConfig{
+		ID: "openai",
+		Models: []catwalk.Model{
+			{ID: "gpt-4o", Name: "GPT-4o"},
+			{ID: "gpt-4o-mini", Name: "GPT-4o Mini"},
+			{ID: "o1-2024-12-17", Name: "o1"},
+			{ID: "openai/gpt-4.1", Name: "GPT-4.1"},
+		},
+	}
+
+	//nolint:govet // Test struct field order optimized for readability.
+	tests := []struct {
+		name    string
+		modelID string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "exact match wins over prefix matches",
+			modelID: "gpt-4o",
+			want:    "gpt-4o",
+		},
+		{
+			name:    "unique prefix of dated variant",
+			modelID: "o1",
+			want:    "o1-2024-12-17",
+		},
+		{
+			name:    "namespaced model without prefix",
+			modelID: "gpt-4.1",
+			want:    "openai/gpt-4.1",
+		},
+		{
+			name:    "ambiguous abbreviation",
+			modelID: "gpt-4",
+			wantErr: true,
+		},
+		{
+			name:    "no match returns input",
+			modelID: "claude-3-opus",
+			want:    "claude-3-opus",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cfg.ResolveModelID("openai", tt.modelID)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ResolveModelID() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveModelID() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveModelID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_GetModel_Abbreviated(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Providers["openai"] = &ProviderConfig{
+		ID: "openai",
+		Models: []catwalk.Model{
+			{ID: "gpt-4o-2024-08-06", Name: "GPT-4o"},
+			{ID: "gpt-4o-mini-2024-07-18", Name: "GPT-4o Mini"},
+		},
+	}
+
+	if got := cfg.GetModel("openai", "gpt-4o-mini"); got == nil || got.Name != "GPT-4o Mini" {
+		t.Errorf("GetModel() = %v, want GPT-4o Mini", got)
+	}
+	if got := cfg.GetModel("openai", "gpt-4o"); got != nil {
+		t.Errorf("GetModel() = %v, want nil for ambiguous abbreviation", got)
+	}
+}
+
+func TestConfig_ProviderIDs(t *testing.T) {
+	cfg := NewConfig()
+
+	if got := cfg.ProviderIDs(); len(got) != 0 {
+		t.Errorf("ProviderIDs() = %v, want empty", got)
+	}
+
+	cfg.Providers["openai"] = &ProviderConfig{ID: "openai"}
+	cfg.Providers["anthropic"] = &ProviderConfig{ID: "anthropic"}
+	cfg.Providers["gateway"] = &ProviderConfig{ID: "gateway", Disable: true}
+
+	got := cfg.ProviderIDs()
+	want := []string{"anthropic", "gateway", "openai"}
+	if len(got) != len(want) {
+		t.Fatalf("ProviderIDs() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ProviderIDs()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestProviderConfig_ValidateAuth(t *testing.T) {
+	token := &oauth.Token{AccessToken: "access"}
+
+	//nolint:govet // Test struct field order is for readability.
+	tests := []struct {
+		name       string
+		pc         ProviderConfig
+		wantSource AuthSource
+		wantAPIKey string
+		wantErr    bool
+	}{
+		{
+			name:       "user key inferred",
+			pc:         ProviderConfig{APIKey: "sk-user"},
+			wantSource: AuthSourceAPIKey,
+			wantAPIKey: "sk-user",
+		},
+		{
+			name:       "legacy oauth with token as key",
+			pc:         ProviderConfig{APIKey: "access", OAuthToken: token},
+			wantSource: AuthSourceOAuth,
+			wantAPIKey: "access",
+		},
+		{
+			name:       "oauth derives missing key",
+			pc:         ProviderConfig{OAuthToken: token, AuthSource: AuthSourceOAuth},
+			wantSource: AuthSourceOAuth,
+			wantAPIKey: "access",
+		},
+		{
+			name:    "oauth without token",
+			pc:      ProviderConfig{APIKey: "access", AuthSource: AuthSourceOAuth},
+			wantErr: true,
+		},
+		{
+			name:    "user key with token",
+			pc:      ProviderConfig{APIKey: "sk-user", OAuthToken: token, AuthSource: AuthSourceAPIKey},
+			wantErr: true,
+		},
+		{
+			name:    "inferred user key with token",
+			pc:      ProviderConfig{APIKey: "sk-user", OAuthToken: token},
+			wantErr: true,
+		},
+		{
+			name:    "unknown source",
+			pc:      ProviderConfig{APIKey: "sk-user", AuthSource: "magic"},
+			wantErr: true,
+		},
+		{
+			name:       "bearer scheme",
+			pc:         ProviderConfig{APIKey: "sk-user", AuthScheme: AuthSchemeBearer},
+			wantSource: AuthSourceAPIKey,
+			wantAPIKey: "sk-user",
+		},
+		{
+			name:    "unknown scheme",
+			pc:      ProviderConfig{APIKey: "sk-user", AuthScheme: "basic"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pc := tt.pc
+			err := pc.validateAuth()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateAuth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if pc.AuthSource != tt.wantSource {
+				t.Errorf("AuthSource = %q, want %q", pc.AuthSource, tt.wantSource)
+			}
+			if pc.APIKey != tt.wantAPIKey {
+				t.Errorf("APIKey = %q, want %q", pc.APIKey, tt.wantAPIKey)
+			}
+		})
+	}
+}
+
 func TestConfig_KnownProviders(t *testing.T) {
 	cfg := NewConfig()
 
@@ -224,6 +416,7 @@ func TestConfig_Resolve(t *testing.T) {
 }
 
 func TestSelectedModel_Fields(t *testing.T) {
+	think := true
 	temp := 0.7
 	topP := 0.9
 	topK := int64(40)
@@ -232,7 +425,7 @@ func TestSelectedModel_Fields(t *testing.T) {
 		Model:           "gpt-4o",
 		Provider:        "openai",
 		ReasoningEffort: "high",
-		Think:           true,
+		Think:           &think,
 		MaxTokens:       4096,
 		Temperature:     &temp,
 		TopP:            &topP,
@@ -249,7 +442,7 @@ func TestSelectedModel_Fields(t *testing.T) {
 	if model.ReasoningEffort != "high" {
 		t.Errorf("ReasoningEffort = %q, want %q", model.ReasoningEffort, "high")
 	}
-	if !model.Think {
+	if !model.ThinkEnabled() {
 		t.Error("Think = false, want true")
 	}
 	if model.MaxTokens != 4096 {
@@ -333,3 +526,87 @@ func TestOptions_Fields(t *testing.T) {
 		t.Error("Debug = false, want true")
 	}
 }
+
+func TestSelectedModel_Retries(t *testing.T) {
+	zero, two := 0, 2
+
+	tests := []struct { //nolint:govet // Test struct alignment is not critical.
+		name  string
+		model SelectedModel
+		opts  *Options
+		want  int
+	}{
+		{name: "unset", want: 0},
+		{name: "options default", opts: &Options{MaxRetries: 3}, want: 3},
+		{name: "tier wins", model: SelectedModel{MaxRetries: &two}, opts: &Options{MaxRetries: 3}, want: 2},
+		{name: "tier disables", model: SelectedModel{MaxRetries: &zero}, opts: &Options{MaxRetries: 3}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.model.Retries(tt.opts); got != tt.want {
+				t.Errorf("Retries() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDuration_Unmarshal(t *testing.T) {
+	//nolint:govet // Test struct alignment is not critical.
+	tests := []struct {
+		name    string
+		path    string
+		data    string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "json string", path: "matrix.json", data: `{"providers_cache_max_age": "36h"}`, want: 36 * time.Hour},
+		{name: "json seconds", path: "matrix.json", data: `{"providers_cache_max_age": 3600}`, want: time.Hour},
+		{name: "json invalid", path: "matrix.json", data: `{"providers_cache_max_age": "soon"}`, wantErr: true},
+		{name: "json wrong type", path: "matrix.json", data: `{"providers_cache_max_age": true}`, wantErr: true},
+		{name: "yaml string", path: "matrix.yaml", data: "providers_cache_max_age: 90m\n", want: 90 * time.Minute},
+		{name: "yaml seconds", path: "matrix.yaml", data: "providers_cache_max_age: 120\n", want: 2 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			err := unmarshalConfig(tt.path, []byte(tt.data), &opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("unmarshalConfig() expected error, got %v", opts.ProvidersCacheMaxAge)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unmarshalConfig() error = %v", err)
+			}
+			if got := time.Duration(opts.ProvidersCacheMaxAge); got != tt.want {
+				t.Errorf("ProvidersCacheMaxAge = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDuration_MarshalRoundTrip(t *testing.T) {
+	for _, path := range []string{"matrix.json", "matrix.yaml"} {
+		t.Run(path, func(t *testing.T) {
+			in := Options{ProvidersCacheMaxAge: Duration(36 * time.Hour)}
+			data, err := marshalConfig(path, in, "")
+			if err != nil {
+				t.Fatalf("marshalConfig() error = %v", err)
+			}
+			if !strings.Contains(string(data), "36h0m0s") {
+				t.Errorf("marshaled = %q, want a duration string", data)
+			}
+
+			var out Options
+			if err := unmarshalConfig(path, data, &out); err != nil {
+				t.Fatalf("unmarshalConfig() error = %v", err)
+			}
+			if out.ProvidersCacheMaxAge != in.ProvidersCacheMaxAge {
+				t.Errorf("round trip = %v, want %v", out.ProvidersCacheMaxAge, in.ProvidersCacheMaxAge)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+)
+
+// ProviderDiagnosis explains whether a provider from the config files can
+// be used, and if not, why.
+type ProviderDiagnosis struct {
+	// ID is the provider ID.
+	ID string
+	// File is the config file defining the provider.
+	File string
+	// Type is the provider type, from the config or catwalk.
+	Type catwalk.Type
+	// Problems lists why the provider can't be used. It is empty for a
+	// healthy provider.
+	Problems []string
+	// Disabled reports whether the provider is disabled.
+	Disabled bool
+}
+
+// DiagnoseProviders checks every provider in the given config files, later
+// files overriding earlier ones as in Load, filling in the defaults of the
+// known catwalk providers. Unlike Load, which drops providers it can't use,
+// it reports why: an API key that doesn't resolve, a missing or invalid base
+// URL, or a type missing from supported. Results are in provider ID order.
+func DiagnoseProviders(files []string, known []catwalk.Provider, supported []catwalk.Type) ([]ProviderDiagnosis, error) {
+	cfg := NewConfig()
+	for _, file := range files {
+		fileCfg := NewConfig()
+		if err := loadFile(file, fileCfg); err != nil {
+			return nil, fmt.Errorf("loading %s: %w", file, err)
+		}
+		mergeConfig(cfg, fileCfg)
+	}
+
+	resolver := NewResolver()
+	var diagnoses []ProviderDiagnosis
+	for _, id := range cfg.ProviderIDs() {
+		p := cfg.Providers[id]
+		if p == nil {
+			continue
+		}
+		var meta *catwalk.Provider
+		if i := slices.IndexFunc(known, func(k catwalk.Provider) bool { return string(k.ID) == id }); i >= 0 {
+			meta = &known[i]
+		}
+		diagnoses = append(diagnoses, diagnoseProvider(id, p, meta, supported, resolver))
+	}
+	return diagnoses, nil
+}
+
+// diagnoseProvider checks a single provider for DiagnoseProviders. meta is
+// the provider's catwalk metadata, or nil for a custom provider.
+func diagnoseProvider(id string, p *ProviderConfig, meta *catwalk.Provider, supported []catwalk.Type, resolver *Resolver) ProviderDiagnosis {
+	d := ProviderDiagnosis{ID: id, File: p.SourcePath, Type: p.Type, Disabled: p.Disable}
+	problem := func(format string, args ...any) {
+		d.Problems = append(d.Problems, fmt.Sprintf(format, args...))
+	}
+
+	if d.Type == "" && meta != nil {
+		d.Type = meta.Type
+	}
+	switch {
+	case d.Type == "":
+		problem("type: not set, and catwalk doesn't know the provider")
+	case !slices.Contains(supported, d.Type):
+		problem("type: %q is not supported", d.Type)
+	}
+
+	baseURL := p.BaseURL
+	if baseURL == "" && meta != nil {
+		baseURL = meta.APIEndpoint
+	}
+	if baseURL == "" {
+		if d.Type == catwalk.TypeOpenAICompat {
+			problem("base_url: not set, and OpenAI-compatible providers need one")
+		}
+	} else if resolved, err := resolver.Resolve(baseURL); err != nil {
+		problem("base_url: %v", err)
+	} else if u, err := url.Parse(resolved); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		problem("base_url: %q is not an http(s) URL", resolved)
+	} else {
+		baseURL = resolved
+	}
+
+	if p.APIKey != "" {
+		key, err := resolver.Resolve(p.APIKey)
+		switch {
+		case err != nil:
+			problem("api_key: %v", err)
+		case key == "":
+			problem("api_key: resolves to an empty value")
+		}
+	} else {
+		creds := ProviderConfig{Type: d.Type, BaseURL: baseURL, OAuthToken: p.OAuthToken}
+		if !creds.HasCredentials() {
+			problem("api_key: not set, and the provider has no OAuth token")
+		}
+	}
+	return d
+}
+
+// DirDiagnosis describes the data directory and the providers cache in it.
+type DirDiagnosis struct {
+	// Err is set when the data directory or the cache can't be read.
+	Err error
+	// CacheUpdated is when the providers cache was written; zero when there
+	// is no cache.
+	CacheUpdated time.Time
+	// DataDir is the data directory.
+	DataDir string
+	// CacheProviders is the number of providers in the cache.
+	CacheProviders int
+	// DataDirExists reports whether the data directory exists yet. It is
+	// created on first use.
+	DataDirExists bool
+}
+
+// DiagnoseDataDir reports the state of cfg's data directory and providers
+// cache. cfg may be nil, for the default data directory.
+func DiagnoseDataDir(cfg *Config) DirDiagnosis {
+	d := DirDiagnosis{DataDir: DefaultDataDir()}
+	if cfg != nil {
+		d.DataDir = cfg.DataDir()
+	}
+
+	info, err := os.Stat(d.DataDir)
+	switch {
+	case os.IsNotExist(err):
+		return d
+	case err != nil:
+		d.Err = err
+		return d
+	case !info.IsDir():
+		d.Err = fmt.Errorf("%s is not a directory", d.DataDir)
+		return d
+	}
+	d.DataDirExists = true
+
+	cache, err := loadProvidersCache(filepath.Join(d.DataDir, providersCacheFile))
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		d.Err = fmt.Errorf("reading providers cache: %w", err)
+	default:
+		d.CacheUpdated = cache.UpdatedAt
+		d.CacheProviders = len(cache.Providers)
+	}
+	return d
+}
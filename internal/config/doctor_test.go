@@ -0,0 +1,88 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+)
+
+func TestDiagnoseProviders(t *testing.T) {
+	t.Setenv("MATRIX_TEST_DOCTOR_KEY", "sk-test")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "matrix.json")
+	content := `{"providers": {
+		"openai": {"api_key": "$MATRIX_TEST_DOCTOR_KEY"},
+		"anthropic": {"api_key": "$MATRIX_TEST_DOCTOR_MISSING"},
+		"local": {"type": "openai-compat"},
+		"remote": {"type": "openai-compat", "base_url": "localhost:8080", "api_key": "sk-local"},
+		"azure": {"type": "azure", "base_url": "https://example.openai.azure.com", "api_key": "sk-azure"},
+		"mystery": {"api_key": "sk-mystery"}
+	}}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	known := []catwalk.Provider{
+		{ID: "openai", Type: catwalk.TypeOpenAI, APIEndpoint: "https://api.openai.com/v1"},
+		{ID: "anthropic", Type: catwalk.TypeAnthropic, APIEndpoint: "https://api.anthropic.com"},
+	}
+	supported := []catwalk.Type{catwalk.TypeOpenAI, catwalk.TypeOpenAICompat, catwalk.TypeAnthropic}
+
+	diagnoses, err := DiagnoseProviders([]string{path}, known, supported)
+	if err != nil {
+		t.Fatalf("DiagnoseProviders() error = %v", err)
+	}
+
+	want := map[string]string{
+		"openai":    "",
+		"anthropic": "MATRIX_TEST_DOCTOR_MISSING",
+		"local":     "base_url: not set",
+		"remote":    "is not an http(s) URL",
+		"azure":     `type: "azure" is not supported`,
+		"mystery":   "catwalk doesn't know the provider",
+	}
+	if len(diagnoses) != len(want) {
+		t.Fatalf("got %d diagnoses, want %d: %+v", len(diagnoses), len(want), diagnoses)
+	}
+	for _, d := range diagnoses {
+		problems := strings.Join(d.Problems, "; ")
+		if want[d.ID] == "" {
+			if problems != "" {
+				t.Errorf("%s problems = %q, want none", d.ID, problems)
+			}
+			continue
+		}
+		if !strings.Contains(problems, want[d.ID]) {
+			t.Errorf("%s problems = %q, want %q", d.ID, problems, want[d.ID])
+		}
+		if d.File != path {
+			t.Errorf("%s File = %q, want %q", d.ID, d.File, path)
+		}
+	}
+}
+
+func TestDiagnoseDataDir(t *testing.T) {
+	dataDir := filepath.Join(t.TempDir(), "data")
+	cfg := NewConfig()
+	cfg.Options = &Options{DataDir: dataDir}
+
+	if d := DiagnoseDataDir(cfg); d.DataDirExists || d.Err != nil {
+		t.Errorf("DiagnoseDataDir() = %+v, want a missing directory without error", d)
+	}
+
+	providers := []catwalk.Provider{{ID: "openai"}, {ID: "anthropic"}}
+	if err := saveProvidersCache(filepath.Join(dataDir, providersCacheFile), providers); err != nil {
+		t.Fatalf("saveProvidersCache() error = %v", err)
+	}
+	d := DiagnoseDataDir(cfg)
+	if !d.DataDirExists || d.Err != nil || d.CacheProviders != 2 {
+		t.Errorf("DiagnoseDataDir() = %+v, want the cache with 2 providers", d)
+	}
+	if time.Since(d.CacheUpdated) > time.Minute {
+		t.Errorf("CacheUpdated = %v, want the time the cache was written", d.CacheUpdated)
+	}
+}
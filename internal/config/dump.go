@@ -0,0 +1,57 @@
+package config
+
+import "maps"
+
+// redactedSecret replaces literal secrets in an unresolved config.
+const redactedSecret = "[REDACTED]"
+
+// Unresolved returns a copy of c for display, with provider API keys and
+// extra headers as written in the config files, so environment references
+// show instead of their values. API keys written literally and OAuth tokens
+// are redacted. c itself is left untouched.
+func (c *Config) Unresolved() *Config {
+	out := &Config{
+		Models:    maps.Clone(c.Models),
+		Providers: make(map[string]*ProviderConfig, len(c.Providers)),
+		Options:   c.Options,
+	}
+	for id, p := range c.Providers {
+		if p == nil {
+			continue
+		}
+		copied := *p
+		copied.APIKey = unresolvedKey(p)
+		if p.headerTemplates != nil {
+			copied.ExtraHeaders = maps.Clone(p.headerTemplates)
+		}
+		if p.OAuthToken != nil {
+			token := *p.OAuthToken
+			token.AccessToken = redact(token.AccessToken)
+			token.RefreshToken = redact(token.RefreshToken)
+			copied.OAuthToken = &token
+		}
+		out.Providers[id] = &copied
+	}
+	return out
+}
+
+// unresolvedKey returns the provider's API key as written in the config, or
+// redacted when it holds the secret itself rather than a reference.
+func unresolvedKey(p *ProviderConfig) string {
+	key := p.APIKey
+	if p.keyResolved {
+		key = p.apiKeyTemplate
+	}
+	if varPattern.MatchString(key) {
+		return key
+	}
+	return redact(key)
+}
+
+// redact returns redactedSecret for a non-empty secret.
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redactedSecret
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/oauth"
+)
+
+func TestConfig_Unresolved(t *testing.T) {
+	t.Setenv("MATRIX_TEST_DUMP_KEY", "sk-from-env")
+	t.Setenv("MATRIX_TEST_DUMP_TEAM", "team-42")
+
+	cfg := NewConfig()
+	cfg.Providers["openai"] = &ProviderConfig{
+		APIKey:       "$MATRIX_TEST_DUMP_KEY",
+		ExtraHeaders: map[string]string{"X-Team": "${MATRIX_TEST_DUMP_TEAM}"},
+	}
+	cfg.Providers["anthropic"] = &ProviderConfig{APIKey: "sk-literal"}
+	cfg.Providers["claude"] = &ProviderConfig{
+		OAuthToken: &oauth.Token{AccessToken: "access", RefreshToken: "refresh"},
+	}
+	cfg.SetKnownProviders([]catwalk.Provider{
+		{ID: "openai", Type: catwalk.TypeOpenAI},
+		{ID: "anthropic", Type: catwalk.TypeAnthropic},
+		{ID: "claude", Type: catwalk.TypeAnthropic},
+	})
+	configureProviders(cfg, NewResolver())
+
+	got := cfg.Unresolved()
+	if key := got.Providers["openai"].APIKey; key != "$MATRIX_TEST_DUMP_KEY" {
+		t.Errorf("openai APIKey = %q, want the reference as written", key)
+	}
+	if header := got.Providers["openai"].ExtraHeaders["X-Team"]; header != "${MATRIX_TEST_DUMP_TEAM}" {
+		t.Errorf("openai X-Team = %q, want the reference as written", header)
+	}
+	if key := got.Providers["anthropic"].APIKey; key != redactedSecret {
+		t.Errorf("anthropic APIKey = %q, want the literal key redacted", key)
+	}
+	if token := got.Providers["claude"].OAuthToken; token.AccessToken != redactedSecret || token.RefreshToken != redactedSecret {
+		t.Errorf("claude token = %+v, want it redacted", token)
+	}
+
+	// The loaded config keeps the resolved values.
+	if key := cfg.Providers["openai"].APIKey; key != "sk-from-env" {
+		t.Errorf("loaded openai APIKey = %q, want it still resolved", key)
+	}
+	if token := cfg.Providers["claude"].OAuthToken; token.AccessToken != "access" {
+		t.Errorf("loaded claude token = %+v, want it untouched", token)
+	}
+}
@@ -0,0 +1,230 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/guilhermegouw/matrix-cli/internal/oauth"
+)
+
+// passphraseEnv holds the passphrase encrypting secrets in config files.
+// When it is unset secrets are saved in plaintext, the default.
+const passphraseEnv = "MATRIX_CONFIG_PASSPHRASE"
+
+// encryptedPrefix marks an encrypted config value. The rest is the base64
+// encoding of the salt, the nonce and the AES-GCM sealed secret.
+const encryptedPrefix = "enc:v1:"
+
+const (
+	saltSize = 16
+	keySize  = 32
+)
+
+// passphraseIterations is the PBKDF2-SHA256 work factor deriving the key of
+// each encrypted value from the passphrase.
+var passphraseIterations = 600_000
+
+var (
+	// ErrPassphraseRequired is returned when a config file holds encrypted
+	// secrets but no passphrase is available.
+	ErrPassphraseRequired = errors.New("config holds encrypted secrets; set " + passphraseEnv)
+	// ErrWrongPassphrase is returned when an encrypted secret can't be
+	// opened with the passphrase, or was tampered with.
+	ErrWrongPassphrase = errors.New("wrong config passphrase")
+)
+
+var (
+	passphraseMu sync.Mutex
+	// passphrasePrompt asks the user for the passphrase when the
+	// environment doesn't provide one. Nil disables prompting.
+	passphrasePrompt func() (string, error)
+	// promptedPassphrase keeps a prompted passphrase, so it is asked once
+	// and saves encrypt with it too.
+	promptedPassphrase string
+)
+
+// SetPassphrasePrompt sets the function asked for the config passphrase
+// when encrypted secrets are loaded and MATRIX_CONFIG_PASSPHRASE is unset.
+// The answer is kept for the rest of the process. Nil disables prompting.
+func SetPassphrasePrompt(prompt func() (string, error)) {
+	passphraseMu.Lock()
+	defer passphraseMu.Unlock()
+	passphrasePrompt = prompt
+	promptedPassphrase = ""
+}
+
+// configPassphrase returns the passphrase from the environment or an
+// earlier prompt, or "" when there is none. With ask set, the prompt is
+// used when nothing else provides one.
+func configPassphrase(ask bool) (string, error) {
+	if pass := os.Getenv(passphraseEnv); pass != "" {
+		return pass, nil
+	}
+
+	passphraseMu.Lock()
+	defer passphraseMu.Unlock()
+	if promptedPassphrase != "" || !ask || passphrasePrompt == nil {
+		return promptedPassphrase, nil
+	}
+	pass, err := passphrasePrompt()
+	if err != nil {
+		return "", fmt.Errorf("reading config passphrase: %w", err)
+	}
+	promptedPassphrase = pass
+	return pass, nil
+}
+
+// isEncrypted reports whether value is an encrypted secret.
+func isEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// encryptSecret seals plain with a key derived from pass and a fresh salt.
+func encryptSecret(plain, pass string) (string, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	gcm, err := secretCipher(pass, salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(append(salt, nonce...), nonce, []byte(plain), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptSecret opens a value sealed by encryptSecret. It fails with
+// ErrWrongPassphrase when pass doesn't match.
+func decryptSecret(value, pass string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil || len(sealed) < saltSize {
+		return "", errors.New("malformed encrypted value")
+	}
+	gcm, err := secretCipher(pass, sealed[:saltSize])
+	if err != nil {
+		return "", err
+	}
+	rest := sealed[saltSize:]
+	if len(rest) < gcm.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+
+	plain, err := gcm.Open(nil, rest[:gcm.NonceSize()], rest[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", ErrWrongPassphrase
+	}
+	return string(plain), nil
+}
+
+// secretCipher returns the AES-GCM cipher keyed by pass and salt.
+func secretCipher(pass string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, pass, salt, passphraseIterations, keySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// decryptProviders opens the encrypted API keys and OAuth tokens of cfg's
+// providers in place, asking for the passphrase if needed. Plaintext values
+// are left as they are.
+func decryptProviders(cfg *Config) error {
+	for id, p := range cfg.Providers {
+		if p == nil {
+			continue
+		}
+		fields := []*string{&p.APIKey}
+		if p.OAuthToken != nil {
+			fields = append(fields, &p.OAuthToken.AccessToken, &p.OAuthToken.RefreshToken)
+		}
+		for _, field := range fields {
+			if !isEncrypted(*field) {
+				continue
+			}
+			plain, err := openSecret(*field)
+			if err != nil {
+				return fmt.Errorf("provider %q: %w", id, err)
+			}
+			*field = plain
+		}
+	}
+	return nil
+}
+
+// openSecret returns value decrypted when it is encrypted, and as is
+// otherwise.
+func openSecret(value string) (string, error) {
+	if !isEncrypted(value) {
+		return value, nil
+	}
+	pass, err := configPassphrase(true)
+	if err != nil {
+		return "", err
+	}
+	if pass == "" {
+		return "", ErrPassphraseRequired
+	}
+	return decryptSecret(value, pass)
+}
+
+// sealSecret returns value encrypted when a passphrase is available, and
+// as is otherwise. Empty values and environment references are not secret
+// and stay readable.
+func sealSecret(value string) (string, error) {
+	if value == "" || isEncrypted(value) || varPattern.MatchString(value) {
+		return value, nil
+	}
+	pass, err := configPassphrase(false)
+	if err != nil || pass == "" {
+		return value, err
+	}
+	return encryptSecret(value, pass)
+}
+
+// sealToken returns a copy of token, which must not be nil, with its access
+// and refresh tokens sealed by sealSecret.
+func sealToken(token *oauth.Token) (*oauth.Token, error) {
+	sealed := *token
+	var err error
+	if sealed.AccessToken, err = sealSecret(token.AccessToken); err != nil {
+		return nil, err
+	}
+	if sealed.RefreshToken, err = sealSecret(token.RefreshToken); err != nil {
+		return nil, err
+	}
+	return &sealed, nil
+}
+
+// sealProvider seals the API key and OAuth token of a provider about to be
+// saved. The token is replaced by a sealed copy, leaving the loaded one
+// untouched.
+func sealProvider(saved *SaveProviderConfig) error {
+	var err error
+	if saved.APIKey, err = sealSecret(saved.APIKey); err != nil {
+		return err
+	}
+	if saved.OAuthToken != nil {
+		if saved.OAuthToken, err = sealToken(saved.OAuthToken); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+//nolint:goconst // Test file uses repeated string literals.
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/guilhermegouw/matrix-cli/internal/oauth"
+)
+
+// fastPassphrase lowers the key derivation cost for the test and sets the
+// passphrase in the environment.
+func fastPassphrase(t *testing.T, pass string) {
+	t.Helper()
+	iterations := passphraseIterations
+	passphraseIterations = 1000
+	t.Cleanup(func() { passphraseIterations = iterations })
+	t.Setenv(passphraseEnv, pass)
+}
+
+func TestEncryptSecret_RoundTrip(t *testing.T) {
+	fastPassphrase(t, "")
+
+	sealed, err := encryptSecret("sk-secret", "hunter2")
+	if err != nil {
+		t.Fatalf("encryptSecret() error = %v", err)
+	}
+	if !isEncrypted(sealed) || strings.Contains(sealed, "sk-secret") {
+		t.Errorf("encryptSecret() = %q, want an encrypted value hiding the secret", sealed)
+	}
+
+	plain, err := decryptSecret(sealed, "hunter2")
+	if err != nil {
+		t.Fatalf("decryptSecret() error = %v", err)
+	}
+	if plain != "sk-secret" {
+		t.Errorf("decryptSecret() = %q, want %q", plain, "sk-secret")
+	}
+
+	again, err := encryptSecret("sk-secret", "hunter2")
+	if err != nil {
+		t.Fatalf("encryptSecret() error = %v", err)
+	}
+	if again == sealed {
+		t.Error("encrypting twice should use a fresh salt and nonce")
+	}
+}
+
+func TestDecryptSecret_WrongPassphrase(t *testing.T) {
+	fastPassphrase(t, "")
+
+	sealed, err := encryptSecret("sk-secret", "hunter2")
+	if err != nil {
+		t.Fatalf("encryptSecret() error = %v", err)
+	}
+	if _, err := decryptSecret(sealed, "hunter3"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("decryptSecret() error = %v, want ErrWrongPassphrase", err)
+	}
+}
+
+func TestSaveToFile_EncryptsSecrets(t *testing.T) {
+	fastPassphrase(t, "hunter2")
+	path := filepath.Join(t.TempDir(), "matrix.json")
+
+	cfg := NewConfig()
+	cfg.Providers["openai"] = &ProviderConfig{ID: "openai", APIKey: "sk-literal"}
+	cfg.Providers["groq"] = &ProviderConfig{ID: "groq", APIKey: "$GROQ_API_KEY"}
+	token := &oauth.Token{AccessToken: "access", RefreshToken: "refresh", ExpiresAt: 42}
+	cfg.Providers["anthropic"] = &ProviderConfig{ID: "anthropic", OAuthToken: token}
+
+	if err := SaveToFile(cfg, path); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+	if token.AccessToken != "access" {
+		t.Error("SaveToFile() should not encrypt the in-memory token")
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // Test reads its own temp file.
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"sk-literal", `"access"`, `"refresh"`} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("saved config holds %s in plaintext:\n%s", secret, data)
+		}
+	}
+	if !strings.Contains(string(data), "$GROQ_API_KEY") {
+		t.Errorf("environment references should stay readable:\n%s", data)
+	}
+
+	loaded := NewConfig()
+	if err := loadFile(path, loaded); err != nil {
+		t.Fatalf("loadFile() error = %v", err)
+	}
+	if got := loaded.Providers["openai"].APIKey; got != "sk-literal" {
+		t.Errorf("loaded API key = %q, want %q", got, "sk-literal")
+	}
+	if got := loaded.Providers["anthropic"].OAuthToken; got == nil ||
+		got.AccessToken != "access" || got.RefreshToken != "refresh" || got.ExpiresAt != 42 {
+		t.Errorf("loaded OAuth token = %+v, want the saved token", got)
+	}
+}
+
+func TestLoadFile_EncryptedSecretsNeedPassphrase(t *testing.T) {
+	fastPassphrase(t, "hunter2")
+	path := filepath.Join(t.TempDir(), "matrix.json")
+
+	cfg := NewConfig()
+	cfg.Providers["openai"] = &ProviderConfig{ID: "openai", APIKey: "sk-literal"}
+	if err := SaveToFile(cfg, path); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		pass string
+		want error
+	}{
+		{name: "wrong passphrase", pass: "hunter3", want: ErrWrongPassphrase},
+		{name: "no passphrase", pass: "", want: ErrPassphraseRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(passphraseEnv, tt.pass)
+			if err := loadFile(path, NewConfig()); !errors.Is(err, tt.want) {
+				t.Errorf("loadFile() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFile_PromptsForPassphrase(t *testing.T) {
+	fastPassphrase(t, "hunter2")
+	path := filepath.Join(t.TempDir(), "matrix.json")
+
+	cfg := NewConfig()
+	cfg.Providers["openai"] = &ProviderConfig{ID: "openai", APIKey: "sk-literal"}
+	if err := SaveToFile(cfg, path); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+
+	t.Setenv(passphraseEnv, "")
+	var prompts int
+	SetPassphrasePrompt(func() (string, error) {
+		prompts++
+		return "hunter2", nil
+	})
+	t.Cleanup(func() { SetPassphrasePrompt(nil) })
+
+	for range 2 {
+		loaded := NewConfig()
+		if err := loadFile(path, loaded); err != nil {
+			t.Fatalf("loadFile() error = %v", err)
+		}
+		if got := loaded.Providers["openai"].APIKey; got != "sk-literal" {
+			t.Errorf("loaded API key = %q, want %q", got, "sk-literal")
+		}
+	}
+	if prompts != 1 {
+		t.Errorf("prompts = %d, want the passphrase asked once", prompts)
+	}
+}
+
+func TestSaveToFile_PlaintextWithoutPassphrase(t *testing.T) {
+	fastPassphrase(t, "")
+	path := filepath.Join(t.TempDir(), "matrix.json")
+
+	cfg := NewConfig()
+	cfg.Providers["openai"] = &ProviderConfig{ID: "openai", APIKey: "sk-literal"}
+	if err := SaveToFile(cfg, path); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // Test reads its own temp file.
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "sk-literal") || strings.Contains(string(data), encryptedPrefix) {
+		t.Errorf("without a passphrase the config should stay plaintext:\n%s", data)
+	}
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+)
+
+// ParseProviderType validates a provider type name against the types known
+// to catwalk.
+func ParseProviderType(s string) (catwalk.Type, error) {
+	known := catwalk.KnownProviderTypes()
+	names := make([]string, 0, len(known))
+	for _, t := range known {
+		if string(t) == s {
+			return t, nil
+		}
+		names = append(names, string(t))
+	}
+	return "", fmt.Errorf("invalid provider type %q, valid types: %s", s, strings.Join(names, ", "))
+}
+
+// FilterProvidersByType returns the providers of the given type.
+func FilterProvidersByType(providers []catwalk.Provider, t catwalk.Type) []catwalk.Provider {
+	var filtered []catwalk.Provider
+	for i := range providers {
+		if providers[i].Type == t {
+			filtered = append(filtered, providers[i])
+		}
+	}
+	return filtered
+}
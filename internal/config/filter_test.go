@@ -0,0 +1,51 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+)
+
+func TestParseProviderType(t *testing.T) {
+	got, err := ParseProviderType("anthropic")
+	if err != nil {
+		t.Fatalf("ParseProviderType(anthropic) error = %v", err)
+	}
+	if got != catwalk.TypeAnthropic {
+		t.Errorf("ParseProviderType(anthropic) = %q, want %q", got, catwalk.TypeAnthropic)
+	}
+}
+
+func TestParseProviderType_Invalid(t *testing.T) {
+	_, err := ParseProviderType("openia")
+	if err == nil {
+		t.Fatal("ParseProviderType(openia) expected error")
+	}
+	for _, valid := range []string{"openai", "openai-compat", "anthropic"} {
+		if !strings.Contains(err.Error(), valid) {
+			t.Errorf("ParseProviderType() error = %q, want it to list %q", err, valid)
+		}
+	}
+}
+
+func TestFilterProvidersByType(t *testing.T) {
+	providers := []catwalk.Provider{
+		{ID: "openai", Type: catwalk.TypeOpenAI},
+		{ID: "anthropic", Type: catwalk.TypeAnthropic},
+		{ID: "groq", Type: catwalk.TypeOpenAICompat},
+		{ID: "cerebras", Type: catwalk.TypeOpenAICompat},
+	}
+
+	got := FilterProvidersByType(providers, catwalk.TypeOpenAICompat)
+	if len(got) != 2 {
+		t.Fatalf("FilterProvidersByType() returned %d providers, want 2", len(got))
+	}
+	if got[0].ID != "groq" || got[1].ID != "cerebras" {
+		t.Errorf("FilterProvidersByType() = %v, %v, want groq, cerebras", got[0].ID, got[1].ID)
+	}
+
+	if got := FilterProvidersByType(providers, catwalk.TypeBedrock); len(got) != 0 {
+		t.Errorf("FilterProvidersByType(bedrock) returned %d providers, want 0", len(got))
+	}
+}
@@ -1,11 +1,67 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"os"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
 )
 
+// Startup is the result of loading config and providers once at startup.
+type Startup struct {
+	// Config is the loaded config, or nil if loading failed.
+	Config *Config
+	// Err is the error from loading the config.
+	Err error
+	// ProvidersErr is set when providers could not be loaded for the wizard.
+	ProvidersErr error
+	// Providers are the known providers, reused by the wizard on failure.
+	Providers []catwalk.Provider
+	// FirstRun reports whether setup should be offered, as IsFirstRun does:
+	// there is no global config file or nothing in the config is usable.
+	// Other load errors leave it false so a broken config isn't replaced by
+	// the wizard's.
+	FirstRun bool
+}
+
+// LoadStartup loads the config and decides whether this is the first run,
+// fetching provider metadata at most once. When the config cannot be loaded
+// the providers are still returned so the wizard can use them.
+func LoadStartup(ctx context.Context) *Startup {
+	return loadStartup(ctx, LoadProvidersContext)
+}
+
+func loadStartup(ctx context.Context, loadProviders ProvidersLoader) *Startup {
+	s := &Startup{}
+	loaded := false
+	once := func(ctx context.Context, cfg *Config) ([]catwalk.Provider, error) {
+		if !loaded {
+			loaded = true
+			s.Providers, s.ProvidersErr = loadProviders(ctx, cfg)
+		}
+		return s.Providers, s.ProvidersErr
+	}
+
+	_, statErr := os.Stat(GlobalConfigPath())
+	noConfig := os.IsNotExist(statErr)
+
+	s.Config, s.Err = loadContext(ctx, once)
+	if s.Err != nil {
+		// Config errors before the fetch still need providers for the wizard.
+		_, _ = once(ctx, NewConfig()) //nolint:errcheck // Recorded in s.ProvidersErr.
+		s.FirstRun = noConfig || errors.Is(s.Err, ErrNeedsSetup)
+		return s
+	}
+
+	s.FirstRun = noConfig || !hasConfiguredProviders(s.Config)
+	return s
+}
+
 // IsFirstRun checks if this is the first time running Matrix.
-// Returns true if no config file exists or if no providers have API keys.
+// Returns true if no config file exists or if no providers have API keys. A
+// config that fails to load for any other reason is not a first run.
+// Callers that go on to load the config should use LoadStartup instead.
 func IsFirstRun() bool {
 	// Check if global config file exists.
 	configPath := GlobalConfigPath()
@@ -16,18 +72,19 @@ func IsFirstRun() bool {
 	// Try to load config and check for valid providers.
 	cfg, err := Load()
 	if err != nil {
-		// If config fails to load (e.g., no valid API keys), it's effectively first run.
-		return true
+		// Without valid API keys it's effectively a first run.
+		return errors.Is(err, ErrNeedsSetup)
 	}
 
 	// Check if any providers have API keys configured.
 	return !hasConfiguredProviders(cfg)
 }
 
-// hasConfiguredProviders checks if any providers have API keys set.
+// hasConfiguredProviders checks if any providers have API keys set or are
+// keyless.
 func hasConfiguredProviders(cfg *Config) bool {
 	for _, provider := range cfg.Providers {
-		if provider.APIKey != "" && !provider.Disable {
+		if provider.HasCredentials() && !provider.Disable {
 			return true
 		}
 	}
@@ -41,16 +98,21 @@ func NeedsSetup() bool {
 	if err != nil {
 		return true
 	}
+	return cfg.NeedsSetup()
+}
 
+// NeedsSetup reports whether the loaded config lacks a model selection or
+// selects a model whose provider is missing, unusable or disabled.
+func (c *Config) NeedsSetup() bool {
 	// Check if we have at least one configured model.
-	if len(cfg.Models) == 0 {
+	if len(c.Models) == 0 {
 		return true
 	}
 
 	// Check if the configured models reference valid providers.
-	for _, model := range cfg.Models {
-		provider, ok := cfg.Providers[model.Provider]
-		if !ok || provider.APIKey == "" || provider.Disable {
+	for _, model := range c.Models {
+		provider, ok := c.Providers[model.Provider]
+		if !ok || !provider.HasCredentials() || provider.Disable {
 			return true
 		}
 	}
@@ -2,7 +2,14 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/adrg/xdg"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
 )
 
 // Note: IsFirstRun() and NeedsSetup() use xdg.ConfigHome which is cached at init time.
@@ -99,3 +106,119 @@ func TestHasConfiguredProviders_WithOAuthToken(t *testing.T) {
 		t.Error("hasConfiguredProviders() = false, want true when OAuth token provides API key")
 	}
 }
+
+// countingLoader returns a ProvidersLoader stub and a pointer to its call count.
+func countingLoader() (ProvidersLoader, *int) {
+	calls := 0
+	return func(_ context.Context, _ *Config) ([]catwalk.Provider, error) {
+		calls++
+		return []catwalk.Provider{{
+			ID:                  "openai",
+			Name:                "OpenAI",
+			Type:                catwalk.TypeOpenAI,
+			DefaultLargeModelID: "gpt-4o",
+			DefaultSmallModelID: "gpt-4o-mini",
+			Models:              []catwalk.Model{{ID: "gpt-4o"}, {ID: "gpt-4o-mini"}},
+		}}, nil
+	}, &calls
+}
+
+func TestLoadStartup_LoadsProvidersOnce(t *testing.T) {
+	//nolint:govet // Field order optimized for test readability.
+	tests := []struct {
+		name         string
+		config       string // Empty means no config file.
+		wantErr      bool
+		wantFirstRun bool
+	}{
+		{
+			name:         "no config file",
+			wantErr:      true,
+			wantFirstRun: true,
+		},
+		{
+			name:         "configured provider",
+			config:       `{"providers": {"openai": {"api_key": "sk-test"}}}`,
+			wantErr:      false,
+			wantFirstRun: false,
+		},
+		{
+			name:         "provider without key",
+			config:       `{"providers": {"openai": {"api_key": "$MATRIX_TEST_UNSET_KEY"}}}`,
+			wantErr:      true,
+			wantFirstRun: true,
+		},
+		{
+			name:         "invalid config file",
+			config:       `{not json`,
+			wantErr:      true,
+			wantFirstRun: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+
+			t.Cleanup(xdg.Reload)
+			t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+			t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+			xdg.Reload()
+			t.Chdir(tempDir)
+
+			if tt.config != "" {
+				path := GlobalConfigPath()
+				if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+					t.Fatalf("MkdirAll() error = %v", err)
+				}
+				//nolint:gosec // Test file, permissions not critical.
+				if err := os.WriteFile(path, []byte(tt.config), 0o644); err != nil {
+					t.Fatalf("WriteFile() error = %v", err)
+				}
+			}
+
+			load, calls := countingLoader()
+			s := loadStartup(context.Background(), load)
+
+			if *calls != 1 {
+				t.Errorf("providers loaded %d times, want 1", *calls)
+			}
+			if (s.Err != nil) != tt.wantErr {
+				t.Errorf("Startup.Err = %v, wantErr %v", s.Err, tt.wantErr)
+			}
+			if s.FirstRun != tt.wantFirstRun {
+				t.Errorf("Startup.FirstRun = %v, want %v", s.FirstRun, tt.wantFirstRun)
+			}
+			if len(s.Providers) != 1 {
+				t.Errorf("Startup.Providers = %v, want the loaded provider", s.Providers)
+			}
+		})
+	}
+}
+
+func TestLoadStartup_ProvidersError(t *testing.T) {
+	tempDir := t.TempDir()
+
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+	xdg.Reload()
+	t.Chdir(tempDir)
+
+	fetchErr := errors.New("network down")
+	calls := 0
+	s := loadStartup(context.Background(), func(context.Context, *Config) ([]catwalk.Provider, error) {
+		calls++
+		return nil, fetchErr
+	})
+
+	if calls != 1 {
+		t.Errorf("providers loaded %d times, want 1", calls)
+	}
+	if !errors.Is(s.ProvidersErr, fetchErr) {
+		t.Errorf("Startup.ProvidersErr = %v, want %v", s.ProvidersErr, fetchErr)
+	}
+	if !s.FirstRun {
+		t.Error("Startup.FirstRun = false, want true when loading fails")
+	}
+}
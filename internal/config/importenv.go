@@ -0,0 +1,144 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+)
+
+// exportPattern matches "export KEY=VALUE" lines in shell rc files.
+var exportPattern = regexp.MustCompile(`^\s*export\s+([a-zA-Z_][a-zA-Z0-9_]*)=(.*)$`)
+
+// EnvImport maps a provider to the environment variable that holds its key.
+type EnvImport struct {
+	ProviderID string
+	// Reference is the "$VAR" form written to the config.
+	Reference string
+}
+
+// ParseShellExports returns the names of variables exported with a
+// non-empty value in a shell rc file. Values are never returned, so secrets
+// found in the file are not kept around.
+func ParseShellExports(r io.Reader) ([]string, error) {
+	var names []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		m := exportPattern.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		value := strings.Trim(strings.TrimSpace(m[2]), `"'`)
+		if value == "" || slices.Contains(names, m[1]) {
+			continue
+		}
+		names = append(names, m[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading shell exports: %w", err)
+	}
+	return names, nil
+}
+
+// MatchEnvProviders returns an import for each provider whose catwalk API key
+// template references one of the exported names, sorted by provider ID.
+func MatchEnvProviders(names []string, providers []catwalk.Provider) []EnvImport {
+	var imports []EnvImport
+	for i := range providers {
+		name := envVarName(providers[i].APIKey)
+		if name == "" || !slices.Contains(names, name) {
+			continue
+		}
+		imports = append(imports, EnvImport{
+			ProviderID: string(providers[i].ID),
+			Reference:  "$" + name,
+		})
+	}
+	slices.SortFunc(imports, func(a, b EnvImport) int {
+		return strings.Compare(a.ProviderID, b.ProviderID)
+	})
+	return imports
+}
+
+// envVarName returns VAR for a "$VAR" or "${VAR}" template, or "" when the
+// template is not a single variable reference without a default.
+func envVarName(template string) string {
+	m := varPattern.FindStringSubmatch(template)
+	if m == nil || m[0] != template {
+		return ""
+	}
+	ref := parseVarRef(m)
+	if ref.op != "" {
+		return ""
+	}
+	return ref.name
+}
+
+// ImportEnvReferences writes the API key references into the config file at
+// path, creating it if needed. Providers that already have an API key or
+// OAuth token are left untouched. It returns the imports that were applied.
+func ImportEnvReferences(path string, imports []EnvImport) ([]EnvImport, error) {
+	raw := make(map[string]any)
+	data, err := os.ReadFile(path) //nolint:gosec // Config file paths are trusted.
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("reading config file: %w", err)
+	default:
+		if err := unmarshalConfig(path, data, &raw); err != nil {
+			return nil, fmt.Errorf("parsing config file: %w", err)
+		}
+	}
+
+	providers, _ := raw["providers"].(map[string]any)
+	if providers == nil {
+		providers = make(map[string]any)
+	}
+
+	var applied []EnvImport
+	for _, imp := range imports {
+		entry, _ := providers[imp.ProviderID].(map[string]any)
+		if entry == nil {
+			entry = make(map[string]any)
+		}
+		if _, ok := entry["api_key"]; ok {
+			continue
+		}
+		if _, ok := entry["oauth"]; ok {
+			continue
+		}
+		entry["api_key"] = imp.Reference
+		providers[imp.ProviderID] = entry
+		applied = append(applied, imp)
+	}
+	if len(applied) == 0 {
+		return nil, nil
+	}
+	raw["providers"] = providers
+
+	if err := checkWritable(nil, path); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("creating config directory: %w", err)
+	}
+
+	out, err := marshalConfig(path, raw, rawIndentStyle(raw))
+	if err != nil {
+		return nil, fmt.Errorf("marshaling config: %w", err)
+	}
+
+	if err := writeFileAtomic(path, out, 0o644); err != nil {
+		return nil, fmt.Errorf("writing config file: %w", err)
+	}
+
+	return applied, nil
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+)
+
+const sampleRC = `# ~/.bashrc
+export PATH="$HOME/bin:$PATH"
+export OPENAI_API_KEY=sk-literal-openai-secret
+  export ANTHROPIC_API_KEY="sk-ant-literal-secret"
+# export GROQ_API_KEY=commented-out
+export EMPTY_API_KEY=
+OPENROUTER_API_KEY=not-exported
+alias ll='ls -la'
+`
+
+func TestParseShellExports(t *testing.T) {
+	names, err := ParseShellExports(strings.NewReader(sampleRC))
+	if err != nil {
+		t.Fatalf("ParseShellExports() error = %v", err)
+	}
+
+	want := []string{"PATH", "OPENAI_API_KEY", "ANTHROPIC_API_KEY"}
+	if !slices.Equal(names, want) {
+		t.Errorf("ParseShellExports() = %v, want %v", names, want)
+	}
+}
+
+func TestMatchEnvProviders(t *testing.T) {
+	providers := []catwalk.Provider{
+		{ID: "openai", APIKey: "$OPENAI_API_KEY"},
+		{ID: "anthropic", APIKey: "${ANTHROPIC_API_KEY}"},
+		{ID: "groq", APIKey: "$GROQ_API_KEY"},
+		{ID: "custom", APIKey: "Bearer $OPENAI_API_KEY"},
+	}
+
+	got := MatchEnvProviders([]string{"OPENAI_API_KEY", "ANTHROPIC_API_KEY"}, providers)
+	want := []EnvImport{
+		{ProviderID: "anthropic", Reference: "$ANTHROPIC_API_KEY"},
+		{ProviderID: "openai", Reference: "$OPENAI_API_KEY"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("MatchEnvProviders() = %v, want %v", got, want)
+	}
+}
+
+func TestImportEnvReferences(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matrix.json")
+	existing := `{"providers": {"anthropic": {"api_key": "$MY_OWN_KEY"}}, "options": {"debug": true}}`
+	if err := os.WriteFile(path, []byte(existing), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	names, err := ParseShellExports(strings.NewReader(sampleRC))
+	if err != nil {
+		t.Fatalf("ParseShellExports() error = %v", err)
+	}
+	imports := MatchEnvProviders(names, []catwalk.Provider{
+		{ID: "openai", APIKey: "$OPENAI_API_KEY"},
+		{ID: "anthropic", APIKey: "$ANTHROPIC_API_KEY"},
+	})
+
+	applied, err := ImportEnvReferences(path, imports)
+	if err != nil {
+		t.Fatalf("ImportEnvReferences() error = %v", err)
+	}
+	if len(applied) != 1 || applied[0].ProviderID != "openai" {
+		t.Errorf("ImportEnvReferences() applied = %v, want only openai", applied)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	content := string(data)
+	if !strings.Contains(content, `"api_key": "$OPENAI_API_KEY"`) {
+		t.Errorf("config should reference $OPENAI_API_KEY, got:\n%s", content)
+	}
+	if !strings.Contains(content, `"api_key": "$MY_OWN_KEY"`) {
+		t.Errorf("existing anthropic key should be kept, got:\n%s", content)
+	}
+	if !strings.Contains(content, `"debug": true`) {
+		t.Errorf("other settings should be kept, got:\n%s", content)
+	}
+	if strings.Contains(content, "literal") {
+		t.Errorf("config must never contain literal secrets, got:\n%s", content)
+	}
+}
+
+func TestImportEnvReferences_CreatesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matrix", "matrix.yaml")
+
+	applied, err := ImportEnvReferences(path, []EnvImport{{ProviderID: "openai", Reference: "$OPENAI_API_KEY"}})
+	if err != nil {
+		t.Fatalf("ImportEnvReferences() error = %v", err)
+	}
+	if len(applied) != 1 {
+		t.Fatalf("ImportEnvReferences() applied = %v, want 1 import", applied)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(data), "api_key: $OPENAI_API_KEY") {
+		t.Errorf("config should reference $OPENAI_API_KEY, got:\n%s", data)
+	}
+}
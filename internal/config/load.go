@@ -1,26 +1,80 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/adrg/xdg"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/goccy/go-yaml"
 )
 
 const configFileName = "matrix.json"
 
+// ErrNeedsSetup is returned by Load when no provider is configured with a
+// usable API key, meaning the setup wizard should run.
+var ErrNeedsSetup = errors.New("no providers configured with valid API keys")
+
+// needsSetupError is ErrNeedsSetup carrying the warnings recorded while
+// loading, which explain why configured providers were skipped.
+type needsSetupError struct {
+	warnings []string
+}
+
+func (e *needsSetupError) Error() string {
+	return ErrNeedsSetup.Error() + ": " + strings.Join(e.warnings, "; ")
+}
+
+func (e *needsSetupError) Unwrap() error {
+	return ErrNeedsSetup
+}
+
+// SetupWarnings returns the warnings explaining an ErrNeedsSetup from Load,
+// such as providers skipped because their API key references an unset
+// variable. It returns nil for other errors and when nothing was skipped.
+func SetupWarnings(err error) []string {
+	var setupErr *needsSetupError
+	if errors.As(err, &setupErr) {
+		return setupErr.warnings
+	}
+	return nil
+}
+
 // Load finds and loads configuration from standard locations.
 // It merges global config with project config (project takes precedence),
 // then configures providers using catwalk metadata.
 func Load() (*Config, error) {
+	return LoadContext(context.Background())
+}
+
+// LoadContext is like Load but aborts fetching provider metadata when ctx
+// is canceled.
+func LoadContext(ctx context.Context) (*Config, error) {
+	return loadContext(ctx, LoadProvidersContext)
+}
+
+// ProvidersLoader fetches the known providers for cfg.
+type ProvidersLoader func(ctx context.Context, cfg *Config) ([]catwalk.Provider, error)
+
+// loadContext implements LoadContext with a pluggable provider loader.
+func loadContext(ctx context.Context, loadProviders ProvidersLoader) (*Config, error) {
 	cfg := NewConfig()
 	resolver := NewResolver()
 
 	// Load global config.
 	globalPath := filepath.Join(xdg.ConfigHome, appName, configFileName)
-	if err := loadFile(globalPath, cfg); err != nil && !os.IsNotExist(err) {
+	switch err := loadFile(globalPath, cfg); {
+	case err == nil:
+		cfg.addSource(SourceGlobal, globalPath)
+	case !os.IsNotExist(err):
 		return nil, fmt.Errorf("loading global config: %w", err)
 	}
 
@@ -32,13 +86,15 @@ func Load() (*Config, error) {
 			return nil, fmt.Errorf("loading project config: %w", err)
 		}
 		mergeConfig(cfg, projectCfg)
+		cfg.addSource(SourceProject, projectPath)
 	}
+	cfg.addEnvSources()
 
 	// Apply defaults before loading providers.
 	applyDefaults(cfg)
 
 	// Load known providers from catwalk.
-	providers, err := LoadProviders(cfg)
+	providers, err := loadProviders(ctx, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("loading providers: %w", err)
 	}
@@ -46,48 +102,97 @@ func Load() (*Config, error) {
 
 	// Configure providers (merge user config with catwalk metadata).
 	configureProviders(cfg, resolver)
+	if err := validateProviderAuth(cfg); err != nil {
+		return nil, err
+	}
+	flagStaleOAuthTokens(cfg, time.Now())
 
 	// Configure default model selections if not set.
 	if err := configureDefaultModels(cfg); err != nil {
 		return nil, fmt.Errorf("configuring models: %w", err)
 	}
+	applyRecommendedSampling(cfg)
+	applyTierTemperatures(cfg)
 
 	return cfg, nil
 }
 
 // LoadFromFile loads configuration from a specific file path.
 func LoadFromFile(path string) (*Config, error) {
+	return loadFromFileContext(context.Background(), path)
+}
+
+// loadFromFileContext is like LoadFromFile but aborts fetching provider
+// metadata when ctx is canceled.
+func loadFromFileContext(ctx context.Context, path string) (*Config, error) {
 	cfg := NewConfig()
 	resolver := NewResolver()
 
 	if err := loadFile(path, cfg); err != nil {
 		return nil, err
 	}
+	cfg.addSource(SourceFile, path)
+	cfg.addEnvSources()
 
 	applyDefaults(cfg)
 
-	providers, err := LoadProviders(cfg)
+	providers, err := LoadProvidersContext(ctx, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("loading providers: %w", err)
 	}
 	cfg.SetKnownProviders(providers)
 
 	configureProviders(cfg, resolver)
+	if err := validateProviderAuth(cfg); err != nil {
+		return nil, err
+	}
+	flagStaleOAuthTokens(cfg, time.Now())
 
 	if err := configureDefaultModels(cfg); err != nil {
 		return nil, fmt.Errorf("configuring models: %w", err)
 	}
+	applyRecommendedSampling(cfg)
+	applyTierTemperatures(cfg)
 
 	return cfg, nil
 }
 
-// loadFile reads and unmarshals a JSON config file.
+// loadFile reads and unmarshals a JSON or YAML config file, recording path
+// as the source of the providers it defines.
 func loadFile(path string, cfg *Config) error {
 	data, err := os.ReadFile(path) //nolint:gosec // Config file paths are trusted.
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(data, cfg)
+	if err := unmarshalConfig(path, data, cfg); err != nil {
+		return err
+	}
+	if err := decryptProviders(cfg); err != nil {
+		return err
+	}
+	for _, providerCfg := range cfg.Providers {
+		if providerCfg != nil && providerCfg.SourcePath == "" {
+			providerCfg.SourcePath = path
+		}
+	}
+	return nil
+}
+
+// unmarshalConfig decodes config data in the format implied by path.
+func unmarshalConfig(path string, data []byte, v any) error {
+	if isYAMLPath(path) {
+		return yaml.Unmarshal(data, v)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// isYAMLPath reports whether path has a YAML file extension.
+func isYAMLPath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
 }
 
 // findProjectConfig searches for config file in current and parent directories.
@@ -140,17 +245,80 @@ func mergeConfig(dst, src *Config) {
 			dst.Options = &Options{}
 		}
 		if len(src.Options.ContextPaths) > 0 {
-			dst.Options.ContextPaths = src.Options.ContextPaths
+			dst.Options.ContextPaths = appendUnique(dst.Options.ContextPaths, src.Options.ContextPaths)
 		}
 		if src.Options.DataDir != "" {
 			dst.Options.DataDir = src.Options.DataDir
 		}
+		if len(src.Options.DefaultHeaders) > 0 {
+			if dst.Options.DefaultHeaders == nil {
+				dst.Options.DefaultHeaders = make(map[string]string)
+			}
+			maps.Copy(dst.Options.DefaultHeaders, src.Options.DefaultHeaders)
+		}
 		if src.Options.Debug {
 			dst.Options.Debug = true
 		}
+		if src.Options.ProvidersCacheMaxAge != 0 {
+			dst.Options.ProvidersCacheMaxAge = src.Options.ProvidersCacheMaxAge
+		}
+		if src.Options.IndentStyle != "" {
+			dst.Options.IndentStyle = src.Options.IndentStyle
+		}
+		if src.Options.Inline {
+			dst.Options.Inline = true
+		}
+		if src.Options.LogFile != "" {
+			dst.Options.LogFile = src.Options.LogFile
+		}
+		if src.Options.LogMode != "" {
+			dst.Options.LogMode = src.Options.LogMode
+		}
+		if src.Options.MaxRetries != 0 {
+			dst.Options.MaxRetries = src.Options.MaxRetries
+		}
+		if src.Options.PreferredProvider != "" {
+			dst.Options.PreferredProvider = src.Options.PreferredProvider
+		}
+		if src.Options.Proxy != "" {
+			dst.Options.Proxy = src.Options.Proxy
+		}
+		if src.Options.ReadOnly {
+			dst.Options.ReadOnly = true
+		}
+		if src.Options.RequestTimeout != 0 {
+			dst.Options.RequestTimeout = src.Options.RequestTimeout
+		}
+		if src.Options.SmallFallback != "" {
+			dst.Options.SmallFallback = src.Options.SmallFallback
+		}
+		if src.Options.ThemeFile != "" {
+			dst.Options.ThemeFile = src.Options.ThemeFile
+		}
+		if src.Options.UsageLog {
+			dst.Options.UsageLog = true
+		}
+		if src.Options.Watch {
+			dst.Options.Watch = true
+		}
+		if src.Options.TierTemperatures != nil {
+			dst.Options.TierTemperatures = src.Options.TierTemperatures
+		}
 	}
 }
 
+// appendUnique returns the entries of dst followed by those of src, keeping
+// the first occurrence of each. dst is not modified.
+func appendUnique(dst, src []string) []string {
+	merged := make([]string, 0, len(dst)+len(src))
+	for _, s := range slices.Concat(dst, src) {
+		if !slices.Contains(merged, s) {
+			merged = append(merged, s)
+		}
+	}
+	return merged
+}
+
 // configureProviders merges user config with catwalk provider metadata.
 func configureProviders(cfg *Config, resolver *Resolver) {
 	knownProviders := cfg.KnownProviders()
@@ -163,15 +331,9 @@ func configureProviders(cfg *Config, resolver *Resolver) {
 			continue
 		}
 
-		// Resolve API key from environment.
-		if userConfig.APIKey != "" {
-			resolved, err := resolver.Resolve(userConfig.APIKey)
-			if err != nil {
-				// Skip provider if API key can't be resolved.
-				delete(cfg.Providers, string(p.ID))
-				continue
-			}
-			userConfig.APIKey = resolved
+		if !resolveProviderKey(cfg, resolver, string(p.ID), userConfig) ||
+			!resolveProviderHeaders(cfg, resolver, string(p.ID), userConfig) {
+			continue
 		}
 
 		// Resolve base URL from environment.
@@ -180,8 +342,9 @@ func configureProviders(cfg *Config, resolver *Resolver) {
 			if err == nil {
 				userConfig.BaseURL = resolved
 			}
-		} else {
-			// Use catwalk default endpoint.
+		} else if userConfig.APIKey != "" || userConfig.OAuthToken != nil {
+			// Use catwalk default endpoint. Entries without credentials are
+			// left without one so they aren't mistaken for keyless providers.
 			userConfig.BaseURL = p.APIEndpoint
 		}
 
@@ -190,6 +353,8 @@ func configureProviders(cfg *Config, resolver *Resolver) {
 		if userConfig.Name == "" {
 			userConfig.Name = p.Name
 		}
+		// An explicit user type always wins, so mislabeled catwalk entries
+		// can be overridden.
 		if userConfig.Type == "" {
 			userConfig.Type = p.Type
 		}
@@ -215,17 +380,139 @@ func configureProviders(cfg *Config, resolver *Resolver) {
 			userConfig.ExtraHeaders = make(map[string]string)
 		}
 	}
+
+	configureCustomProviders(cfg, resolver)
 }
 
-// configureDefaultModels sets default model selections if not configured.
+// configureCustomProviders sets up user providers catwalk doesn't know, such
+// as ones added with "provider add". With no catwalk metadata to fall back
+// on, they need a type, and OpenAI-compatible ones a base URL. Without
+// models listed in the config, any model ID is accepted.
+func configureCustomProviders(cfg *Config, resolver *Resolver) {
+	known := make(map[string]bool, len(cfg.KnownProviders()))
+	for _, p := range cfg.KnownProviders() {
+		known[string(p.ID)] = true
+	}
+
+	for _, id := range cfg.ProviderIDs() {
+		userConfig := cfg.Providers[id]
+		if known[id] || userConfig == nil {
+			continue
+		}
+
+		switch {
+		case userConfig.Type == "":
+			cfg.addWarning("provider %q skipped: catwalk doesn't know it, so it needs a type", id)
+			delete(cfg.Providers, id)
+			continue
+		case userConfig.Type == catwalk.TypeOpenAICompat && userConfig.BaseURL == "":
+			cfg.addWarning("provider %q skipped: OpenAI-compatible providers need a base_url", id)
+			delete(cfg.Providers, id)
+			continue
+		}
+
+		if !resolveProviderKey(cfg, resolver, id, userConfig) ||
+			!resolveProviderHeaders(cfg, resolver, id, userConfig) {
+			continue
+		}
+		if userConfig.BaseURL != "" {
+			if resolved, err := resolver.Resolve(userConfig.BaseURL); err == nil {
+				userConfig.BaseURL = resolved
+			}
+		}
+
+		userConfig.ID = id
+		if userConfig.Name == "" {
+			userConfig.Name = id
+		}
+		if userConfig.ExtraHeaders == nil {
+			userConfig.ExtraHeaders = make(map[string]string)
+		}
+	}
+}
+
+// resolveProviderKey resolves the provider's API key from the environment.
+// When it can't be resolved the provider is removed and false returned, with
+// a warning naming the missing variables and passing on any guidance the
+// config gives for a required one.
+func resolveProviderKey(cfg *Config, resolver *Resolver, id string, userConfig *ProviderConfig) bool {
+	if userConfig.APIKey == "" {
+		return true
+	}
+	userConfig.apiKeyTemplate = userConfig.APIKey
+	resolved, err := resolver.Resolve(userConfig.APIKey)
+	if err != nil {
+		cfg.addWarning("provider %q skipped: API key: %v", id, err)
+		delete(cfg.Providers, id)
+		return false
+	}
+	userConfig.APIKey = resolved
+	userConfig.keyResolved = true
+	if looksLikePlaceholder(resolved) {
+		cfg.addWarning("provider %q: API key looks like a placeholder; check the config or export the variable it references", id)
+	}
+	return true
+}
+
+// resolveProviderHeaders resolves environment references in the provider's
+// extra headers. Like an unresolved API key, a header that can't be resolved
+// removes the provider and returns false, since sending it without the header
+// could fail in confusing ways.
+func resolveProviderHeaders(cfg *Config, resolver *Resolver, id string, userConfig *ProviderConfig) bool {
+	userConfig.headerTemplates = maps.Clone(userConfig.ExtraHeaders)
+	for _, name := range slices.Sorted(maps.Keys(userConfig.ExtraHeaders)) {
+		resolved, err := resolver.Resolve(userConfig.ExtraHeaders[name])
+		if err != nil {
+			cfg.addWarning("provider %q skipped: extra header %q: %v", id, name, err)
+			delete(cfg.Providers, id)
+			return false
+		}
+		userConfig.ExtraHeaders[name] = resolved
+	}
+	return true
+}
+
+// validateProviderAuth checks that each provider uses exactly one source of
+// authentication.
+func validateProviderAuth(cfg *Config) error {
+	for _, id := range cfg.ProviderIDs() {
+		if err := cfg.Providers[id].validateAuth(); err != nil {
+			return fmt.Errorf("provider %q: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// flagStaleOAuthTokens warns about OAuth tokens expired for too long to be
+// refreshed, so the user signs in again before a request fails. PKCE state
+// is never persisted, so there are no resume files to sweep alongside.
+func flagStaleOAuthTokens(cfg *Config, now time.Time) {
+	for _, id := range cfg.ProviderIDs() {
+		token := cfg.Providers[id].OAuthToken
+		if token == nil || !token.NeedsReauth(now) {
+			continue
+		}
+		expired := time.Unix(token.ExpiresAt, 0).Format(time.DateOnly)
+		cfg.addWarning("provider %q: OAuth token expired on %s and can no longer be refreshed; run setup again to sign in", id, expired)
+	}
+}
+
+// configureDefaultModels sets default model selections if not configured,
+// then seeds provider defaults into the selected tiers.
 func configureDefaultModels(cfg *Config) error {
 	// If models are already configured, validate them.
 	if len(cfg.Models) > 0 {
-		return validateModels(cfg)
+		defaultSmallFromLarge(cfg)
+		if err := validateModels(cfg); err != nil {
+			return err
+		}
+		applyProviderThinkDefaults(cfg)
+		return nil
 	}
 
-	// Find first available provider with default models.
-	knownProviders := cfg.KnownProviders()
+	// Find first available provider with default models, starting with the
+	// preferred one.
+	knownProviders := defaultProviderOrder(cfg)
 	for i := range knownProviders {
 		p := &knownProviders[i]
 		providerCfg, ok := cfg.Providers[string(p.ID)]
@@ -233,8 +520,8 @@ func configureDefaultModels(cfg *Config) error {
 			continue
 		}
 
-		// Check if provider has API key configured.
-		if providerCfg.APIKey == "" {
+		// Check if provider has API key configured or needs none.
+		if !providerCfg.HasCredentials() {
 			continue
 		}
 
@@ -261,22 +548,90 @@ func configureDefaultModels(cfg *Config) error {
 	}
 
 	if len(cfg.Models) == 0 {
-		return fmt.Errorf("no providers configured with valid API keys")
+		if len(cfg.warnings) > 0 {
+			return &needsSetupError{warnings: cfg.warnings}
+		}
+		return ErrNeedsSetup
 	}
 
+	applyProviderThinkDefaults(cfg)
 	return nil
 }
 
-// validateModels checks that selected models reference valid providers.
-func validateModels(cfg *Config) error {
+// defaultSmallFromLarge selects the large tier provider's default small
+// model when only the large tier is configured. Providers without a default
+// small model leave the tier unset, so it falls back to the large model.
+func defaultSmallFromLarge(cfg *Config) {
+	large, ok := cfg.Models[SelectedModelTypeLarge]
+	if !ok {
+		return
+	}
+	if _, ok := cfg.Models[SelectedModelTypeSmall]; ok {
+		return
+	}
+
+	known := cfg.KnownProviders()
+	idx := slices.IndexFunc(known, func(p catwalk.Provider) bool {
+		return string(p.ID) == large.Provider
+	})
+	if idx < 0 || known[idx].DefaultSmallModelID == "" {
+		return
+	}
+	cfg.Models[SelectedModelTypeSmall] = SelectedModel{
+		Model:    known[idx].DefaultSmallModelID,
+		Provider: large.Provider,
+	}
+}
+
+// defaultProviderOrder returns the known providers in the order they are
+// tried for default models: the preferred provider first, then the rest in
+// their known order.
+func defaultProviderOrder(cfg *Config) []catwalk.Provider {
+	known := cfg.KnownProviders()
+	preferred := ""
+	if cfg.Options != nil {
+		preferred = cfg.Options.PreferredProvider
+	}
+	idx := slices.IndexFunc(known, func(p catwalk.Provider) bool {
+		return string(p.ID) == preferred
+	})
+	if preferred == "" || idx < 0 {
+		return known
+	}
+
+	ordered := make([]catwalk.Provider, 0, len(known))
+	ordered = append(ordered, known[idx])
+	ordered = append(ordered, known[:idx]...)
+	return append(ordered, known[idx+1:]...)
+}
+
+// applyProviderThinkDefaults sets Think from the provider's DefaultThink on
+// tiers that don't specify it.
+func applyProviderThinkDefaults(cfg *Config) {
 	for tier, model := range cfg.Models {
 		provider, ok := cfg.Providers[model.Provider]
-		if !ok {
-			return fmt.Errorf("tier %s: provider %q not configured", tier, model.Provider)
+		if !ok || model.Think != nil || !provider.DefaultThink {
+			continue
 		}
-		if provider.Disable {
-			return fmt.Errorf("tier %s: provider %q is disabled", tier, model.Provider)
+		think := true
+		model.Think = &think
+		cfg.Models[tier] = model
+	}
+}
+
+// validateModels checks the model selection with Validate, then expands
+// abbreviated model IDs to the provider's full IDs.
+func validateModels(cfg *Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	for tier, model := range cfg.Models {
+		fullID, err := cfg.ResolveModelID(model.Provider, model.Model)
+		if err != nil {
+			return fmt.Errorf("tier %s: %w", tier, err)
 		}
+		model.Model = fullID
+		cfg.Models[tier] = model
 	}
 	return nil
 }
@@ -291,6 +646,65 @@ func applyDefaults(cfg *Config) {
 	if cfg.Options.DataDir == "" {
 		cfg.Options.DataDir = filepath.Join(xdg.DataHome, appName)
 	}
+
+	// Set default tier temperatures. An explicit empty map disables them.
+	if cfg.Options.TierTemperatures == nil {
+		cfg.Options.TierTemperatures = DefaultTierTemperatures()
+	}
+}
+
+// applyRecommendedSampling fills sampling parameters the user left unset
+// from the selected catwalk model's recommended options. It runs before
+// applyTierTemperatures so a model-specific recommendation wins over the
+// generic per-tier default.
+func applyRecommendedSampling(cfg *Config) {
+	for tier, model := range cfg.Models {
+		m := cfg.GetModel(model.Provider, model.Model)
+		if m == nil {
+			continue
+		}
+		opts := m.Options
+		model.Temperature = orDefault(model.Temperature, opts.Temperature)
+		model.TopP = orDefault(model.TopP, opts.TopP)
+		model.TopK = orDefault(model.TopK, opts.TopK)
+		model.FrequencyPenalty = orDefault(model.FrequencyPenalty, opts.FrequencyPenalty)
+		model.PresencePenalty = orDefault(model.PresencePenalty, opts.PresencePenalty)
+		cfg.Models[tier] = model
+	}
+}
+
+// orDefault returns v if set, otherwise a copy of def.
+func orDefault[T any](v, def *T) *T {
+	if v != nil || def == nil {
+		return v
+	}
+	d := *def
+	return &d
+}
+
+// applyTierTemperatures sets the tier's default temperature on each
+// selected model that doesn't specify one, skipping models that reject a
+// temperature.
+func applyTierTemperatures(cfg *Config) {
+	for tier, model := range cfg.Models {
+		if model.Temperature != nil || rejectsTemperature(cfg.GetModel(model.Provider, model.Model)) {
+			continue
+		}
+		temp, ok := cfg.Options.TierTemperatures[tier]
+		if !ok {
+			continue
+		}
+		model.Temperature = &temp
+		cfg.Models[tier] = model
+	}
+}
+
+// rejectsTemperature reports whether catwalk metadata shows m rejects a
+// temperature. Models with reasoning levels, such as OpenAI's o-series and
+// GPT-5, take a reasoning effort instead of sampling settings, unless
+// catwalk recommends a temperature for them.
+func rejectsTemperature(m *catwalk.Model) bool {
+	return m != nil && len(m.ReasoningLevels) > 0 && m.Options.Temperature == nil
 }
 
 // GlobalConfigPath returns the path to the global config file.
@@ -298,6 +712,12 @@ func GlobalConfigPath() string {
 	return filepath.Join(xdg.ConfigHome, appName, configFileName)
 }
 
+// ProjectConfigPath returns the project config file found in the current
+// directory or one of its parents, or "" when there is none.
+func ProjectConfigPath() string {
+	return findProjectConfig()
+}
+
 // DataDir returns the data directory path from config or default.
 func (c *Config) DataDir() string {
 	if c.Options != nil && c.Options.DataDir != "" {
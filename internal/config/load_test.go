@@ -1,11 +1,20 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/adrg/xdg"
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/oauth"
 )
 
 func TestLoadFile(t *testing.T) {
@@ -55,6 +64,9 @@ func TestLoadFile(t *testing.T) {
 	if cfg.Providers["openai"].APIKey != "$OPENAI_API_KEY" {
 		t.Errorf("APIKey = %q, want %q", cfg.Providers["openai"].APIKey, "$OPENAI_API_KEY")
 	}
+	if cfg.Providers["openai"].SourcePath != configPath {
+		t.Errorf("SourcePath = %q, want %q", cfg.Providers["openai"].SourcePath, configPath)
+	}
 
 	// Verify options.
 	if !cfg.Options.Debug {
@@ -103,6 +115,7 @@ func TestMergeConfig(t *testing.T) {
 	src.Options = &Options{
 		ContextPaths: []string{"SRC.md"},
 		Debug:        true,
+		ReadOnly:     true,
 	}
 
 	mergeConfig(dst, src)
@@ -125,9 +138,9 @@ func TestMergeConfig(t *testing.T) {
 		t.Error("Provider 'anthropic' missing after merge")
 	}
 
-	// Context paths should be from src.
-	if len(dst.Options.ContextPaths) != 1 || dst.Options.ContextPaths[0] != "SRC.md" {
-		t.Errorf("ContextPaths = %v, want [SRC.md]", dst.Options.ContextPaths)
+	// Context paths should be appended to dst's.
+	if want := []string{"DST.md", "SRC.md"}; !slices.Equal(dst.Options.ContextPaths, want) {
+		t.Errorf("ContextPaths = %v, want %v", dst.Options.ContextPaths, want)
 	}
 
 	// DataDir should remain from dst (src was empty).
@@ -139,6 +152,59 @@ func TestMergeConfig(t *testing.T) {
 	if !dst.Options.Debug {
 		t.Error("Debug = false, want true")
 	}
+
+	// ReadOnly should be true (from src).
+	if !dst.Options.ReadOnly {
+		t.Error("ReadOnly = false, want true")
+	}
+}
+
+func TestMergeConfig_ContextPaths(t *testing.T) {
+	//nolint:govet // Test struct alignment is not critical.
+	tests := []struct {
+		name    string
+		global  []string
+		project []string
+		want    []string
+	}{
+		{
+			name:    "project appended to global",
+			global:  []string{"GLOBAL.md"},
+			project: []string{"PROJECT.md"},
+			want:    []string{"GLOBAL.md", "PROJECT.md"},
+		},
+		{
+			name:    "duplicates collapse",
+			global:  []string{"AGENTS.md", "GLOBAL.md"},
+			project: []string{"PROJECT.md", "AGENTS.md", "PROJECT.md"},
+			want:    []string{"AGENTS.md", "GLOBAL.md", "PROJECT.md"},
+		},
+		{
+			name:    "project only",
+			project: []string{"PROJECT.md"},
+			want:    []string{"PROJECT.md"},
+		},
+		{
+			name:   "global only",
+			global: []string{"GLOBAL.md"},
+			want:   []string{"GLOBAL.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := NewConfig()
+			dst.Options = &Options{ContextPaths: tt.global}
+			src := NewConfig()
+			src.Options = &Options{ContextPaths: tt.project}
+
+			mergeConfig(dst, src)
+
+			if !slices.Equal(dst.Options.ContextPaths, tt.want) {
+				t.Errorf("ContextPaths = %v, want %v", dst.Options.ContextPaths, tt.want)
+			}
+		})
+	}
 }
 
 func TestMergeConfig_NilOptions(t *testing.T) {
@@ -258,6 +324,49 @@ func TestConfigureProviders_UnresolvedAPIKey(t *testing.T) {
 	}
 }
 
+func TestConfigureProviders_CustomProvider(t *testing.T) {
+	t.Setenv("LOCAL_API_KEY", "local-key-0123456789")
+
+	cfg := NewConfig()
+	cfg.SetKnownProviders([]catwalk.Provider{{ID: "openai", Name: "OpenAI"}})
+	cfg.Providers["local"] = &ProviderConfig{
+		Type:    catwalk.TypeOpenAICompat,
+		BaseURL: "http://localhost:11434/v1",
+		APIKey:  "$LOCAL_API_KEY",
+	}
+	cfg.Providers["untyped"] = &ProviderConfig{APIKey: "sk-test"}
+	cfg.Providers["no-url"] = &ProviderConfig{Type: catwalk.TypeOpenAICompat, APIKey: "sk-test"}
+
+	configureProviders(cfg, NewResolver())
+
+	local := cfg.Providers["local"]
+	if local == nil {
+		t.Fatal("custom provider 'local' should be configured")
+	}
+	if local.ID != "local" || local.Name != "local" {
+		t.Errorf("ID, Name = %q, %q, want %q, %q", local.ID, local.Name, "local", "local")
+	}
+	if local.APIKey != "local-key-0123456789" {
+		t.Errorf("APIKey = %q, want %q", local.APIKey, "local-key-0123456789")
+	}
+	if local.ExtraHeaders == nil {
+		t.Error("ExtraHeaders should be initialized")
+	}
+	cfg.Models[SelectedModelTypeLarge] = SelectedModel{Provider: "local", Model: "llama3"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want any model accepted", err)
+	}
+
+	for _, id := range []string{"untyped", "no-url"} {
+		if cfg.Providers[id] != nil {
+			t.Errorf("provider %q should be skipped", id)
+		}
+	}
+	if len(cfg.Warnings()) != 2 {
+		t.Errorf("Warnings() = %v, want one per skipped provider", cfg.Warnings())
+	}
+}
+
 func TestConfigureProviders_CustomBaseURL(t *testing.T) {
 	t.Setenv("TEST_KEY", "key")
 	t.Setenv("CUSTOM_URL", "https://custom.api.com")
@@ -282,6 +391,57 @@ func TestConfigureProviders_CustomBaseURL(t *testing.T) {
 	}
 }
 
+func TestConfigureProviders_UserTypeOverridesCatwalk(t *testing.T) {
+	t.Setenv("TEST_KEY", "key")
+
+	cfg := NewConfig()
+	cfg.Providers["gateway"] = &ProviderConfig{
+		APIKey: "$TEST_KEY",
+		Type:   catwalk.TypeOpenAICompat,
+	}
+
+	providers := []catwalk.Provider{
+		{ID: "gateway", Name: "Gateway", Type: catwalk.TypeAnthropic},
+	}
+	cfg.SetKnownProviders(providers)
+
+	resolver := NewResolver()
+	configureProviders(cfg, resolver)
+
+	if got := cfg.Providers["gateway"].Type; got != catwalk.TypeOpenAICompat {
+		t.Errorf("Type = %q, want user-specified %q", got, catwalk.TypeOpenAICompat)
+	}
+}
+
+func TestLoadFromFile_UserTypeOverridesCatwalk(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Set CATWALK_URL to invalid to use embedded fallback.
+	t.Setenv("CATWALK_URL", "http://invalid.invalid.invalid")
+	t.Setenv("TEST_KEY", "key")
+
+	configPath := filepath.Join(tempDir, "config.json")
+	configContent := `{
+		"providers": {
+			"openai": {"api_key": "$TEST_KEY", "type": "openai-compat"}
+		},
+		"options": {"data_directory": "` + tempDir + `"}
+	}`
+	//nolint:gosec // Test file, permissions not critical.
+	if err := os.WriteFile(configPath, []byte(configContent), 0o644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := LoadFromFile(configPath)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+
+	if got := cfg.Providers["openai"].Type; got != catwalk.TypeOpenAICompat {
+		t.Errorf("Type = %q, want %q", got, catwalk.TypeOpenAICompat)
+	}
+}
+
 func TestConfigureProviders_MergeModels(t *testing.T) {
 	t.Setenv("TEST_KEY", "key")
 
@@ -358,7 +518,7 @@ func TestConfigureDefaultModels(t *testing.T) {
 func TestConfigureDefaultModels_WithExistingModels(t *testing.T) {
 	cfg := NewConfig()
 	cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "existing", Provider: "test"}
-	cfg.Providers["test"] = &ProviderConfig{ID: "test"}
+	cfg.Providers["test"] = &ProviderConfig{ID: "test", APIKey: "key"}
 
 	providers := []catwalk.Provider{
 		{ID: "test", DefaultLargeModelID: "default"},
@@ -376,6 +536,103 @@ func TestConfigureDefaultModels_WithExistingModels(t *testing.T) {
 	}
 }
 
+func TestConfigureDefaultModels_SmallFromLargeProvider(t *testing.T) {
+	tests := []struct { //nolint:govet // Test struct alignment is not critical.
+		name      string
+		small     *SelectedModel
+		wantSmall SelectedModel
+	}{
+		{
+			name:      "small unset uses the provider default",
+			wantSmall: SelectedModel{Model: "claude-haiku", Provider: "anthropic"},
+		},
+		{
+			name:      "configured small is kept",
+			small:     &SelectedModel{Model: "gpt-4o-mini", Provider: "openai"},
+			wantSmall: SelectedModel{Model: "gpt-4o-mini", Provider: "openai"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			cfg.Providers["anthropic"] = &ProviderConfig{ID: "anthropic", APIKey: "key"}
+			cfg.Providers["openai"] = &ProviderConfig{ID: "openai", APIKey: "key"}
+			cfg.SetKnownProviders([]catwalk.Provider{
+				{ID: "openai", DefaultLargeModelID: "gpt-4o", DefaultSmallModelID: "gpt-4o-mini"},
+				{ID: "anthropic", DefaultLargeModelID: "claude-sonnet", DefaultSmallModelID: "claude-haiku"},
+			})
+			cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "claude-opus", Provider: "anthropic"}
+			if tt.small != nil {
+				cfg.Models[SelectedModelTypeSmall] = *tt.small
+			}
+
+			if err := configureDefaultModels(cfg); err != nil {
+				t.Fatalf("configureDefaultModels() error = %v", err)
+			}
+
+			if got := cfg.Models[SelectedModelTypeLarge]; got.Model != "claude-opus" {
+				t.Errorf("Large model = %q, want it kept", got.Model)
+			}
+			small := cfg.Models[SelectedModelTypeSmall]
+			if small.Model != tt.wantSmall.Model || small.Provider != tt.wantSmall.Provider {
+				t.Errorf("Small model = %s/%s, want %s/%s", small.Provider, small.Model, tt.wantSmall.Provider, tt.wantSmall.Model)
+			}
+		})
+	}
+}
+
+func TestConfigureDefaultModels_SmallUnsetWithoutProviderDefault(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Providers["local"] = &ProviderConfig{ID: "local", APIKey: "key"}
+	cfg.SetKnownProviders([]catwalk.Provider{{ID: "local", DefaultLargeModelID: "qwen"}})
+	cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "qwen", Provider: "local"}
+
+	if err := configureDefaultModels(cfg); err != nil {
+		t.Fatalf("configureDefaultModels() error = %v", err)
+	}
+	if small, ok := cfg.Models[SelectedModelTypeSmall]; ok {
+		t.Errorf("Small model = %+v, want it unset so it falls back to the large model", small)
+	}
+}
+
+func TestConfigureDefaultModels_PreferredProvider(t *testing.T) {
+	tests := []struct {
+		name             string
+		preferred        string
+		disablePreferred bool
+		wantProvider     string
+	}{
+		{name: "no preference uses known order", preferred: "", wantProvider: "openai"},
+		{name: "preferred provider first", preferred: "anthropic", wantProvider: "anthropic"},
+		{name: "unconfigured preference falls back", preferred: "groq", wantProvider: "openai"},
+		{name: "disabled preference falls back", preferred: "anthropic", disablePreferred: true, wantProvider: "openai"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			cfg.Options.PreferredProvider = tt.preferred
+			cfg.Providers["openai"] = &ProviderConfig{ID: "openai", APIKey: "sk-openai"}
+			cfg.Providers["anthropic"] = &ProviderConfig{ID: "anthropic", APIKey: "sk-ant", Disable: tt.disablePreferred}
+			cfg.SetKnownProviders([]catwalk.Provider{
+				{ID: "openai", DefaultLargeModelID: "gpt-4o", DefaultSmallModelID: "gpt-4o-mini"},
+				{ID: "groq", DefaultLargeModelID: "llama", DefaultSmallModelID: "llama"},
+				{ID: "anthropic", DefaultLargeModelID: "claude-opus-4", DefaultSmallModelID: "claude-haiku"},
+			})
+
+			if err := configureDefaultModels(cfg); err != nil {
+				t.Fatalf("configureDefaultModels() error = %v", err)
+			}
+			for _, tier := range []SelectedModelType{SelectedModelTypeLarge, SelectedModelTypeSmall} {
+				if got := cfg.Models[tier].Provider; got != tt.wantProvider {
+					t.Errorf("%s tier provider = %q, want %q", tier, got, tt.wantProvider)
+				}
+			}
+		})
+	}
+}
+
 func TestConfigureDefaultModels_NoValidProviders(t *testing.T) {
 	cfg := NewConfig()
 	// No providers configured.
@@ -386,8 +643,106 @@ func TestConfigureDefaultModels_NoValidProviders(t *testing.T) {
 	cfg.SetKnownProviders(providers)
 
 	err := configureDefaultModels(cfg)
-	if err == nil {
-		t.Error("configureDefaultModels() expected error when no valid providers")
+	if !errors.Is(err, ErrNeedsSetup) {
+		t.Errorf("configureDefaultModels() error = %v, want ErrNeedsSetup", err)
+	}
+}
+
+func TestLoad_EmptyEnvironmentNeedsSetup(t *testing.T) {
+	tempDir := t.TempDir()
+
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+	t.Setenv("CATWALK_URL", "http://invalid.invalid.invalid")
+	xdg.Reload()
+	t.Chdir(tempDir)
+
+	_, err := Load()
+	if !errors.Is(err, ErrNeedsSetup) {
+		t.Errorf("Load() error = %v, want ErrNeedsSetup", err)
+	}
+}
+
+func TestLoadContext_Canceled(t *testing.T) {
+	tempDir := t.TempDir()
+
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+	xdg.Reload()
+	t.Chdir(tempDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := LoadContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("LoadContext() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestLoadFromLocation_FileCanceled(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+	xdg.Reload()
+
+	configPath := filepath.Join(tempDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{}`), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := LoadFromLocation(ctx, configPath)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("LoadFromLocation() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestLoadFromFile_NoProvidersNeedsSetup(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("CATWALK_URL", "http://invalid.invalid.invalid")
+
+	configPath := filepath.Join(tempDir, "config.json")
+	content := `{"options": {"data_directory": "` + tempDir + `"}}`
+	//nolint:gosec // Test file, permissions not critical.
+	if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	_, err := LoadFromFile(configPath)
+	if !errors.Is(err, ErrNeedsSetup) {
+		t.Errorf("LoadFromFile() error = %v, want ErrNeedsSetup", err)
+	}
+}
+
+func TestLoadFromFile_SkippedProvidersExplainNeedsSetup(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("CATWALK_URL", "http://invalid.invalid.invalid")
+
+	configPath := filepath.Join(tempDir, "config.json")
+	content := `{
+		"providers": {"openai": {"api_key": "$MATRIX_TEST_UNSET_KEY"}},
+		"options": {"data_directory": "` + tempDir + `"}
+	}`
+	//nolint:gosec // Test file, permissions not critical.
+	if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	_, err := LoadFromFile(configPath)
+	if !errors.Is(err, ErrNeedsSetup) {
+		t.Fatalf("LoadFromFile() error = %v, want ErrNeedsSetup", err)
+	}
+	warnings := SetupWarnings(err)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], `"openai"`) || !strings.Contains(warnings[0], "MATRIX_TEST_UNSET_KEY") {
+		t.Errorf("SetupWarnings() = %v, want one naming the provider and the variable", warnings)
+	}
+	if !strings.Contains(err.Error(), "MATRIX_TEST_UNSET_KEY") {
+		t.Errorf("error = %v, want it to name the variable", err)
 	}
 }
 
@@ -428,9 +783,57 @@ func TestConfigureDefaultModels_NoAPIKey(t *testing.T) {
 	}
 }
 
+func TestConfigureDefaultModels_ProviderDefaultThink(t *testing.T) {
+	off := false
+
+	cfg := NewConfig()
+	cfg.Providers["anthropic"] = &ProviderConfig{ID: "anthropic", APIKey: "key", DefaultThink: true}
+	cfg.Providers["openai"] = &ProviderConfig{ID: "openai", APIKey: "key"}
+	cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "claude-sonnet-4", Provider: "anthropic"}
+	cfg.Models[SelectedModelTypeSmall] = SelectedModel{Model: "gpt-4o-mini", Provider: "openai"}
+
+	if err := configureDefaultModels(cfg); err != nil {
+		t.Fatalf("configureDefaultModels() error = %v", err)
+	}
+	if !cfg.Models[SelectedModelTypeLarge].ThinkEnabled() {
+		t.Error("large tier should inherit think from the provider default")
+	}
+	if cfg.Models[SelectedModelTypeSmall].Think != nil {
+		t.Error("small tier should not get think from a provider without a default")
+	}
+
+	// An explicit tier value wins over the provider default.
+	cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "claude-sonnet-4", Provider: "anthropic", Think: &off}
+	if err := configureDefaultModels(cfg); err != nil {
+		t.Fatalf("configureDefaultModels() error = %v", err)
+	}
+	if cfg.Models[SelectedModelTypeLarge].ThinkEnabled() {
+		t.Error("explicit think: false should override the provider default")
+	}
+}
+
+func TestConfigureDefaultModels_DefaultThinkForAutoSelectedModels(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Providers["anthropic"] = &ProviderConfig{ID: "anthropic", APIKey: "key", DefaultThink: true}
+	cfg.SetKnownProviders([]catwalk.Provider{{
+		ID:                  "anthropic",
+		DefaultLargeModelID: "claude-sonnet-4",
+		DefaultSmallModelID: "claude-haiku",
+	}})
+
+	if err := configureDefaultModels(cfg); err != nil {
+		t.Fatalf("configureDefaultModels() error = %v", err)
+	}
+	for _, tier := range []SelectedModelType{SelectedModelTypeLarge, SelectedModelTypeSmall} {
+		if !cfg.Models[tier].ThinkEnabled() {
+			t.Errorf("%s tier should inherit think from the provider default", tier)
+		}
+	}
+}
+
 func TestValidateModels(t *testing.T) {
 	cfg := NewConfig()
-	cfg.Providers["openai"] = &ProviderConfig{ID: "openai"}
+	cfg.Providers["openai"] = &ProviderConfig{ID: "openai", APIKey: "key"}
 	cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "gpt-4o", Provider: "openai"}
 
 	err := validateModels(cfg)
@@ -460,6 +863,118 @@ func TestValidateModels_DisabledProvider(t *testing.T) {
 	}
 }
 
+func TestValidateModels_DisabledProviderReason(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Providers["openai"] = &ProviderConfig{ID: "openai", Disable: true, DisableReason: "quota exceeded"}
+	cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "gpt-4o", Provider: "openai"}
+
+	err := validateModels(cfg)
+	if err == nil {
+		t.Fatal("validateModels() expected error for disabled provider")
+	}
+	if !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("validateModels() error = %q, want it to contain the disable reason", err)
+	}
+}
+
+func TestValidateModels_AbbreviatedModel(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Providers["openai"] = &ProviderConfig{
+		ID:     "openai",
+		APIKey: "key",
+		Models: []catwalk.Model{{ID: "gpt-4o-2024-08-06"}, {ID: "gpt-4o-mini"}},
+	}
+	cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "gpt-4o-2024", Provider: "openai"}
+
+	if err := validateModels(cfg); err != nil {
+		t.Fatalf("validateModels() error = %v", err)
+	}
+	if got := cfg.Models[SelectedModelTypeLarge].Model; got != "gpt-4o-2024-08-06" {
+		t.Errorf("Large model = %q, want %q", got, "gpt-4o-2024-08-06")
+	}
+}
+
+func TestValidateModels_AmbiguousModel(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Providers["openai"] = &ProviderConfig{
+		ID:     "openai",
+		Models: []catwalk.Model{{ID: "gpt-4o-2024-08-06"}, {ID: "gpt-4o-mini"}},
+	}
+	cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "gpt-4o", Provider: "openai"}
+
+	if err := validateModels(cfg); err == nil {
+		t.Error("validateModels() expected error for ambiguous model")
+	}
+}
+
+func TestValidateModels_ReportsAllProblems(t *testing.T) {
+	//nolint:govet // Field order optimized for test readability.
+	tests := []struct {
+		name  string
+		setup func(*Config)
+		want  []string
+	}{
+		{
+			name: "unknown and disabled providers",
+			setup: func(cfg *Config) {
+				cfg.Providers["openai"] = &ProviderConfig{ID: "openai", APIKey: "key", Disable: true, DisableReason: "quota"}
+				cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "claude", Provider: "missing"}
+				cfg.Models[SelectedModelTypeSmall] = SelectedModel{Model: "gpt-4o", Provider: "openai"}
+			},
+			want: []string{
+				"2 problems with model selection",
+				`tier large: provider "missing" not configured`,
+				`tier small: provider "openai" is disabled: quota`,
+			},
+		},
+		{
+			name: "missing key and vanished model",
+			setup: func(cfg *Config) {
+				cfg.Providers["anthropic"] = &ProviderConfig{ID: "anthropic"}
+				cfg.Providers["openai"] = &ProviderConfig{
+					ID:     "openai",
+					APIKey: "key",
+					Models: []catwalk.Model{{ID: "gpt-4o"}},
+				}
+				cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "claude", Provider: "anthropic"}
+				cfg.Models[SelectedModelTypeSmall] = SelectedModel{Model: "gpt-3.5-turbo", Provider: "openai"}
+			},
+			want: []string{
+				"2 problems with model selection",
+				`tier large: provider "anthropic" has no API key`,
+				`tier small: model "gpt-3.5-turbo" is no longer offered by provider "openai"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			tt.setup(cfg)
+
+			err := validateModels(cfg)
+			if err == nil {
+				t.Fatal("validateModels() expected an error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("validateModels() error = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateModels_VanishedModelWithoutMetadata(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Providers["local"] = &ProviderConfig{ID: "local", APIKey: "key"}
+	cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "anything", Provider: "local"}
+
+	if err := validateModels(cfg); err != nil {
+		t.Errorf("validateModels() error = %v, want nil for providers without model metadata", err)
+	}
+}
+
 func TestApplyDefaults(t *testing.T) {
 	cfg := NewConfig()
 	cfg.Options = nil
@@ -485,6 +1000,151 @@ func TestApplyDefaults_PreservesExisting(t *testing.T) {
 	}
 }
 
+func TestApplyDefaults_TierTemperatures(t *testing.T) {
+	cfg := NewConfig()
+	applyDefaults(cfg)
+
+	temp, ok := cfg.Options.TierTemperatures[SelectedModelTypeSmall]
+	if !ok || temp != 0.0 {
+		t.Errorf("TierTemperatures[small] = %v (set: %v), want 0.0", temp, ok)
+	}
+	if _, ok := cfg.Options.TierTemperatures[SelectedModelTypeLarge]; ok {
+		t.Error("TierTemperatures[large] should be unset by default")
+	}
+
+	// An explicit empty map disables the defaults.
+	cfg = NewConfig()
+	cfg.Options.TierTemperatures = map[SelectedModelType]float64{}
+	applyDefaults(cfg)
+	if len(cfg.Options.TierTemperatures) != 0 {
+		t.Errorf("TierTemperatures = %v, want empty", cfg.Options.TierTemperatures)
+	}
+}
+
+func TestApplyTierTemperatures_SkipsModelsRejectingTemperature(t *testing.T) {
+	recommended := 1.0
+
+	tests := []struct {
+		name     string
+		model    catwalk.Model
+		wantTemp bool
+	}{
+		{name: "reasoning model", model: catwalk.Model{ID: "o3-mini", CanReason: true, ReasoningLevels: []string{"low", "medium", "high"}}},
+		{name: "reasoning model with a recommended temperature", model: catwalk.Model{ID: "gpt-oss", ReasoningLevels: []string{"low"}, Options: catwalk.ModelOptions{Temperature: &recommended}}, wantTemp: true},
+		{name: "sampling model", model: catwalk.Model{ID: "gpt-4o-mini"}, wantTemp: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			applyDefaults(cfg)
+			cfg.Providers["openai"] = &ProviderConfig{ID: "openai", Models: []catwalk.Model{tt.model}}
+			cfg.Models[SelectedModelTypeSmall] = SelectedModel{Model: tt.model.ID, Provider: "openai"}
+
+			applyTierTemperatures(cfg)
+
+			if temp := cfg.Models[SelectedModelTypeSmall].Temperature; (temp != nil) != tt.wantTemp {
+				t.Errorf("small Temperature = %v, want set: %v", temp, tt.wantTemp)
+			}
+		})
+	}
+}
+
+func TestApplyTierTemperatures(t *testing.T) {
+	explicit := 0.7
+
+	cfg := NewConfig()
+	cfg.Options.TierTemperatures = map[SelectedModelType]float64{
+		SelectedModelTypeLarge: 0.5,
+		SelectedModelTypeSmall: 0.0,
+	}
+	cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "gpt-4o", Provider: "openai", Temperature: &explicit}
+	cfg.Models[SelectedModelTypeSmall] = SelectedModel{Model: "gpt-4o-mini", Provider: "openai"}
+
+	applyTierTemperatures(cfg)
+
+	large := cfg.Models[SelectedModelTypeLarge].Temperature
+	if large == nil || *large != 0.7 {
+		t.Errorf("large Temperature = %v, want explicit 0.7", large)
+	}
+	small := cfg.Models[SelectedModelTypeSmall].Temperature
+	if small == nil || *small != 0.0 {
+		t.Errorf("small Temperature = %v, want default 0.0", small)
+	}
+}
+
+func TestApplyTierTemperatures_NoDefaultForTier(t *testing.T) {
+	cfg := NewConfig()
+	applyDefaults(cfg)
+	cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "gpt-4o", Provider: "openai"}
+
+	applyTierTemperatures(cfg)
+
+	if temp := cfg.Models[SelectedModelTypeLarge].Temperature; temp != nil {
+		t.Errorf("large Temperature = %v, want nil", *temp)
+	}
+}
+
+func TestApplyRecommendedSampling(t *testing.T) {
+	recommended := 1.0
+	topP := 0.95
+	userTemp := 0.2
+
+	newCfg := func() *Config {
+		cfg := NewConfig()
+		applyDefaults(cfg)
+		cfg.Providers["zai"] = &ProviderConfig{
+			ID: "zai",
+			Models: []catwalk.Model{{
+				ID:      "glm-4.6",
+				Options: catwalk.ModelOptions{Temperature: &recommended, TopP: &topP},
+			}},
+		}
+		return cfg
+	}
+
+	t.Run("applied without user override", func(t *testing.T) {
+		cfg := newCfg()
+		cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "glm-4.6", Provider: "zai"}
+
+		applyRecommendedSampling(cfg)
+
+		model := cfg.Models[SelectedModelTypeLarge]
+		if model.Temperature == nil || *model.Temperature != recommended {
+			t.Errorf("Temperature = %v, want recommended %v", model.Temperature, recommended)
+		}
+		if model.TopP == nil || *model.TopP != topP {
+			t.Errorf("TopP = %v, want recommended %v", model.TopP, topP)
+		}
+		if model.TopK != nil {
+			t.Errorf("TopK = %v, want nil when catwalk has no recommendation", *model.TopK)
+		}
+	})
+
+	t.Run("user override kept", func(t *testing.T) {
+		cfg := newCfg()
+		cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "glm-4.6", Provider: "zai", Temperature: &userTemp}
+
+		applyRecommendedSampling(cfg)
+
+		if temp := cfg.Models[SelectedModelTypeLarge].Temperature; temp == nil || *temp != userTemp {
+			t.Errorf("Temperature = %v, want user override %v", temp, userTemp)
+		}
+	})
+
+	t.Run("wins over tier default", func(t *testing.T) {
+		cfg := newCfg()
+		cfg.Models[SelectedModelTypeSmall] = SelectedModel{Model: "glm-4.6", Provider: "zai"}
+
+		applyRecommendedSampling(cfg)
+		applyTierTemperatures(cfg)
+
+		if temp := cfg.Models[SelectedModelTypeSmall].Temperature; temp == nil || *temp != recommended {
+			t.Errorf("Temperature = %v, want recommended %v over the tier default", temp, recommended)
+		}
+	})
+}
+
 func TestGlobalConfigPath(t *testing.T) {
 	path := GlobalConfigPath()
 	if path == "" {
@@ -752,3 +1412,252 @@ func TestConfigureDefaultModels_ProviderWithOnlyLargeModel(t *testing.T) {
 		t.Error("Large model should be configured")
 	}
 }
+
+func TestLoad_KeylessLocalProviderRoundTrip(t *testing.T) {
+	tempDir := t.TempDir()
+
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+	t.Setenv("CATWALK_URL", "http://invalid.invalid.invalid")
+	xdg.Reload()
+	t.Chdir(tempDir)
+
+	cfg := NewConfig()
+	cfg.Providers["local"] = &ProviderConfig{
+		ID:      "local",
+		Type:    catwalk.TypeOpenAICompat,
+		BaseURL: "http://localhost:11434/v1",
+	}
+	cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "llama3", Provider: "local"}
+	cfg.Models[SelectedModelTypeSmall] = SelectedModel{Model: "llama3", Provider: "local"}
+	if err := Save(cfg); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	local, ok := loaded.Providers["local"]
+	if !ok {
+		t.Fatal("keyless provider was not preserved through save/load")
+	}
+	if local.BaseURL != "http://localhost:11434/v1" || local.Type != catwalk.TypeOpenAICompat {
+		t.Errorf("local provider = %+v, want base URL and openai-compat type kept", local)
+	}
+	if !local.Keyless() {
+		t.Error("local provider should be keyless")
+	}
+	if loaded.Models[SelectedModelTypeLarge].Provider != "local" {
+		t.Errorf("large tier = %+v, want the local provider", loaded.Models[SelectedModelTypeLarge])
+	}
+	if !hasConfiguredProviders(loaded) {
+		t.Error("a keyless provider should count as configured")
+	}
+}
+
+func TestProviderConfig_Keyless(t *testing.T) {
+	tests := []struct {
+		name string
+		pc   ProviderConfig
+		want bool
+	}{
+		{name: "compat with base URL", pc: ProviderConfig{Type: catwalk.TypeOpenAICompat, BaseURL: "http://localhost:8080"}, want: true},
+		{name: "compat without base URL", pc: ProviderConfig{Type: catwalk.TypeOpenAICompat}, want: false},
+		{name: "has API key", pc: ProviderConfig{Type: catwalk.TypeOpenAICompat, BaseURL: "http://localhost:8080", APIKey: "sk"}, want: false},
+		{name: "other type", pc: ProviderConfig{Type: catwalk.TypeAnthropic, BaseURL: "http://localhost:8080"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pc.Keyless(); got != tt.want {
+				t.Errorf("Keyless() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigureProviders_NoCredentialsNotKeyless(t *testing.T) {
+	cfg := NewConfig()
+	cfg.SetKnownProviders([]catwalk.Provider{{
+		ID:          "groq",
+		Type:        catwalk.TypeOpenAICompat,
+		APIEndpoint: "https://api.groq.com/openai/v1",
+	}})
+	cfg.Providers["groq"] = &ProviderConfig{}
+
+	configureProviders(cfg, NewResolver())
+
+	if cfg.Providers["groq"].HasCredentials() {
+		t.Error("a catwalk provider with no key should not become keyless via its default endpoint")
+	}
+}
+
+func TestConfigureProviders_PlaceholderKeyWarning(t *testing.T) {
+	tests := []struct { //nolint:govet // Test struct alignment is not critical.
+		name        string
+		apiKey      string
+		wantWarning bool
+	}{
+		{name: "docs example", apiKey: "sk-your-api-key-here", wantWarning: true},
+		{name: "masked", apiKey: "sk-xxxxxxxxxxxxxxxx", wantWarning: true},
+		{name: "too short", apiKey: "sk-123", wantWarning: true},
+		{name: "resolved from env", apiKey: "$OPENAI_API_KEY", wantWarning: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			cfg.SetKnownProviders([]catwalk.Provider{{
+				ID:          "openai",
+				Type:        catwalk.TypeOpenAI,
+				APIEndpoint: "https://api.openai.com/v1",
+			}})
+			cfg.Providers["openai"] = &ProviderConfig{APIKey: tt.apiKey}
+
+			resolver := NewResolverWithEnv(map[string]string{"OPENAI_API_KEY": "sk-proj-4f9a8b7c6d5e4f3a2b1c"})
+			configureProviders(cfg, resolver)
+
+			if got := len(cfg.Warnings()) > 0; got != tt.wantWarning {
+				t.Errorf("Warnings() = %v, want warning: %v", cfg.Warnings(), tt.wantWarning)
+			}
+			for _, w := range cfg.Warnings() {
+				if strings.Contains(w, tt.apiKey) {
+					t.Errorf("warning should not include the key, got %q", w)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigureProviders_RequiredKeyMessage(t *testing.T) {
+	tests := []struct { //nolint:govet // Test struct alignment is not critical.
+		name        string
+		apiKey      string
+		wantWarning string
+		wantKept    bool
+	}{
+		{name: "set", apiKey: "${OPENAI_API_KEY:?export OPENAI_API_KEY}", wantKept: true},
+		{name: "unset with message", apiKey: "${TEAM_KEY:?ask #platform for a key}", wantWarning: "TEAM_KEY: ask #platform for a key"},
+		{name: "unset without message", apiKey: "$TEAM_KEY", wantWarning: "undefined environment variables: TEAM_KEY"},
+		{name: "partly unset", apiKey: "${TEAM_PREFIX}-$OPENAI_API_KEY", wantWarning: "TEAM_PREFIX"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			cfg.SetKnownProviders([]catwalk.Provider{{ID: "openai", Type: catwalk.TypeOpenAI}})
+			cfg.Providers["openai"] = &ProviderConfig{APIKey: tt.apiKey}
+
+			resolver := NewResolverWithEnv(map[string]string{"OPENAI_API_KEY": "sk-proj-4f9a8b7c6d5e4f3a2b1c"})
+			configureProviders(cfg, resolver)
+
+			if _, ok := cfg.Providers["openai"]; ok != tt.wantKept {
+				t.Errorf("provider kept = %v, want %v", ok, tt.wantKept)
+			}
+			warnings := cfg.Warnings()
+			if tt.wantWarning == "" {
+				if len(warnings) != 0 {
+					t.Errorf("Warnings() = %v, want none", warnings)
+				}
+				return
+			}
+			if len(warnings) != 1 || !strings.Contains(warnings[0], tt.wantWarning) {
+				t.Errorf("Warnings() = %v, want one containing %q", warnings, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestConfigureProviders_ExtraHeaders(t *testing.T) {
+	//nolint:govet // Test struct alignment is not critical.
+	tests := []struct {
+		name        string
+		id          string
+		headers     map[string]string
+		wantHeaders map[string]string
+		wantWarning string
+	}{
+		{
+			name:        "set variable",
+			id:          "openai",
+			headers:     map[string]string{"X-Org": "$MY_ORG_ID", "X-Static": "plain"},
+			wantHeaders: map[string]string{"X-Org": "org-123", "X-Static": "plain"},
+		},
+		{
+			name:        "unset variable",
+			id:          "openai",
+			headers:     map[string]string{"X-Org": "$UNSET_ORG_ID"},
+			wantWarning: `extra header "X-Org"`,
+		},
+		{
+			name:        "custom provider with set variable",
+			id:          "gateway",
+			headers:     map[string]string{"X-Org": "${MY_ORG_ID}"},
+			wantHeaders: map[string]string{"X-Org": "org-123"},
+		},
+		{
+			name:        "custom provider with unset variable",
+			id:          "gateway",
+			headers:     map[string]string{"X-Org": "$UNSET_ORG_ID"},
+			wantWarning: `extra header "X-Org"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			cfg.SetKnownProviders([]catwalk.Provider{{ID: "openai", Type: catwalk.TypeOpenAI}})
+			cfg.Providers[tt.id] = &ProviderConfig{
+				APIKey:       "sk-test-0123456789",
+				Type:         catwalk.TypeOpenAICompat,
+				BaseURL:      "https://gateway.example.com/v1",
+				ExtraHeaders: tt.headers,
+			}
+
+			resolver := NewResolverWithEnv(map[string]string{"MY_ORG_ID": "org-123"})
+			configureProviders(cfg, resolver)
+
+			pc, ok := cfg.Providers[tt.id]
+			if tt.wantWarning != "" {
+				if ok {
+					t.Errorf("provider %q kept, want it skipped", tt.id)
+				}
+				warnings := cfg.Warnings()
+				if len(warnings) != 1 || !strings.Contains(warnings[0], tt.wantWarning) {
+					t.Errorf("Warnings() = %v, want one containing %q", warnings, tt.wantWarning)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("provider %q skipped", tt.id)
+			}
+			if !maps.Equal(pc.ExtraHeaders, tt.wantHeaders) {
+				t.Errorf("ExtraHeaders = %v, want %v", pc.ExtraHeaders, tt.wantHeaders)
+			}
+		})
+	}
+}
+
+func TestFlagStaleOAuthTokens(t *testing.T) {
+	now := time.Now()
+	cfg := NewConfig()
+	cfg.Providers["anthropic"] = &ProviderConfig{
+		OAuthToken: &oauth.Token{AccessToken: "old", ExpiresAt: now.Add(-oauth.RefreshWindow - time.Hour).Unix()},
+	}
+	cfg.Providers["fresh"] = &ProviderConfig{
+		OAuthToken: &oauth.Token{AccessToken: "new", ExpiresAt: now.Add(-time.Hour).Unix()},
+	}
+	cfg.Providers["openai"] = &ProviderConfig{APIKey: "sk-proj-4f9a8b7c6d5e4f3a2b1c"}
+
+	flagStaleOAuthTokens(cfg, now)
+
+	warnings := cfg.Warnings()
+	if len(warnings) != 1 {
+		t.Fatalf("Warnings() = %v, want one warning", warnings)
+	}
+	if !strings.Contains(warnings[0], `"anthropic"`) {
+		t.Errorf("warning = %q, want it to name the anthropic provider", warnings[0])
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ProviderChoice is a provider a partial identifier can be matched against.
+type ProviderChoice struct {
+	ID   string
+	Name string
+}
+
+// MatchProvider returns the ID of the provider query identifies, ignoring
+// case: an exact ID or name, otherwise the one provider whose ID or name
+// starts with query, otherwise the one containing it. A query matching no
+// provider, or several at the closest level, is an error listing the
+// candidates.
+func MatchProvider(choices []ProviderChoice, query string) (string, error) {
+	q := strings.ToLower(query)
+	levels := []func(id, name string) bool{
+		func(id, name string) bool { return id == q || name == q },
+		func(id, name string) bool { return strings.HasPrefix(id, q) || strings.HasPrefix(name, q) },
+		func(id, name string) bool { return strings.Contains(id, q) || strings.Contains(name, q) },
+	}
+
+	for _, matches := range levels {
+		var found []string
+		for _, c := range choices {
+			if matches(strings.ToLower(c.ID), strings.ToLower(c.Name)) {
+				found = append(found, c.ID)
+			}
+		}
+		slices.Sort(found)
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			return found[0], nil
+		default:
+			return "", fmt.Errorf("provider %q is ambiguous, it matches: %s", query, strings.Join(found, ", "))
+		}
+	}
+
+	ids := make([]string, 0, len(choices))
+	for _, c := range choices {
+		ids = append(ids, c.ID)
+	}
+	slices.Sort(ids)
+	return "", fmt.Errorf("no provider matches %q, configured: %s", query, strings.Join(ids, ", "))
+}
+
+// FileProviders returns the providers defined in the config file at path,
+// named by their name setting or, for providers catwalk knows, their
+// embedded name.
+func FileProviders(path string) ([]ProviderChoice, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // Config file paths are trusted.
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+	var fileCfg Config
+	if err := unmarshalConfig(path, data, &fileCfg); err != nil {
+		return nil, fmt.Errorf("parsing config file: %w", err)
+	}
+
+	names := make(map[string]string)
+	for _, p := range embeddedProviders() {
+		names[string(p.ID)] = p.Name
+	}
+	choices := make([]ProviderChoice, 0, len(fileCfg.Providers))
+	for _, id := range fileCfg.ProviderIDs() {
+		name := names[id]
+		if p := fileCfg.Providers[id]; p != nil && p.Name != "" {
+			name = p.Name
+		}
+		choices = append(choices, ProviderChoice{ID: id, Name: name})
+	}
+	return choices, nil
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchProvider(t *testing.T) {
+	choices := []ProviderChoice{
+		{ID: "openai", Name: "OpenAI"},
+		{ID: "openrouter", Name: "OpenRouter"},
+		{ID: "anthropic", Name: "Anthropic"},
+		{ID: "local", Name: "Ollama on my laptop"},
+	}
+
+	//nolint:govet // Test struct alignment is not critical.
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr []string
+	}{
+		{name: "exact ID", query: "openai", want: "openai"},
+		{name: "exact ID ignores case", query: "OpenAI", want: "openai"},
+		{name: "unique ID prefix", query: "anth", want: "anthropic"},
+		{name: "unique name prefix", query: "ollama", want: "local"},
+		{name: "unique substring", query: "router", want: "openrouter"},
+		{name: "prefix wins over substring", query: "lo", want: "local"},
+		{
+			name:    "ambiguous prefix",
+			query:   "open",
+			wantErr: []string{`provider "open" is ambiguous`, "openai, openrouter"},
+		},
+		{
+			name:    "no match",
+			query:   "gemini",
+			wantErr: []string{`no provider matches "gemini"`, "anthropic, local, openai, openrouter"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MatchProvider(choices, tt.query)
+			if len(tt.wantErr) > 0 {
+				if err == nil {
+					t.Fatalf("MatchProvider(%q) = %q, want an error", tt.query, got)
+				}
+				for _, want := range tt.wantErr {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("MatchProvider(%q) error = %q, want it to contain %q", tt.query, err, want)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MatchProvider(%q) error = %v", tt.query, err)
+			}
+			if got != tt.want {
+				t.Errorf("MatchProvider(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileProviders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matrix.json")
+	content := `{"providers": {
+  "local": {"name": "Ollama", "type": "openai-compat", "base_url": "http://localhost:11434/v1"},
+  "custom": {"type": "openai-compat", "base_url": "http://localhost:8080/v1"}
+}}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	choices, err := FileProviders(path)
+	if err != nil {
+		t.Fatalf("FileProviders() error = %v", err)
+	}
+	want := []ProviderChoice{{ID: "custom"}, {ID: "local", Name: "Ollama"}}
+	if len(choices) != len(want) {
+		t.Fatalf("FileProviders() = %v, want %v", choices, want)
+	}
+	for i := range want {
+		if choices[i] != want[i] {
+			t.Errorf("FileProviders()[%d] = %+v, want %+v", i, choices[i], want[i])
+		}
+	}
+}
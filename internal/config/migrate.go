@@ -0,0 +1,82 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+)
+
+// LegacyMigration describes the files copied out of the legacy data
+// directory.
+type LegacyMigration struct {
+	// LegacyDir is the legacy directory the files were copied from.
+	LegacyDir string
+	// Copied maps each copied legacy file to its new location.
+	Copied map[string]string
+}
+
+// LegacyDataDir returns the data directory used before the XDG layout.
+func LegacyDataDir() string {
+	return filepath.Join(xdg.Home, defaultDataDirectory)
+}
+
+// MigrateLegacyDataDir copies the providers cache and config from the legacy
+// ~/.matrix directory to their XDG locations when those are missing. The
+// legacy directory is left untouched. It returns nil when there was nothing
+// to migrate.
+func MigrateLegacyDataDir() (*LegacyMigration, error) {
+	return migrateLegacyDataDir(LegacyDataDir(), DefaultDataDir(), GlobalConfigPath())
+}
+
+func migrateLegacyDataDir(legacyDir, dataDir, configPath string) (*LegacyMigration, error) {
+	targets := map[string]string{
+		filepath.Join(legacyDir, providersCacheFile): filepath.Join(dataDir, providersCacheFile),
+		filepath.Join(legacyDir, configFileName):     configPath,
+	}
+
+	copied := make(map[string]string)
+	for src, dst := range targets {
+		ok, err := copyIfMissing(src, dst)
+		if err != nil {
+			return nil, fmt.Errorf("migrating %s: %w", src, err)
+		}
+		if ok {
+			copied[src] = dst
+		}
+	}
+
+	if len(copied) == 0 {
+		return nil, nil
+	}
+	return &LegacyMigration{LegacyDir: legacyDir, Copied: copied}, nil
+}
+
+// copyIfMissing copies src to dst when src exists and dst does not. It
+// reports whether a copy was made.
+func copyIfMissing(src, dst string) (bool, error) {
+	data, err := os.ReadFile(src) //nolint:gosec // Legacy paths are derived from the home directory.
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := os.Stat(dst); err == nil {
+		return false, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(dst, data, 0o600); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func TestMigrateLegacyDataDir_CopiesCache(t *testing.T) {
+	root := t.TempDir()
+	legacyDir := filepath.Join(root, ".matrix")
+	dataDir := filepath.Join(root, "data", "matrix")
+	configPath := filepath.Join(root, "config", "matrix", configFileName)
+
+	cache := `{"providers":[{"id":"openai"}]}`
+	writeTestFile(t, filepath.Join(legacyDir, providersCacheFile), cache)
+
+	migration, err := migrateLegacyDataDir(legacyDir, dataDir, configPath)
+	if err != nil {
+		t.Fatalf("migrateLegacyDataDir() error = %v", err)
+	}
+	if migration == nil {
+		t.Fatal("migrateLegacyDataDir() = nil, want a migration")
+	}
+
+	newCache := filepath.Join(dataDir, providersCacheFile)
+	got, err := os.ReadFile(newCache) //nolint:gosec // Test path.
+	if err != nil {
+		t.Fatalf("cache was not copied: %v", err)
+	}
+	if string(got) != cache {
+		t.Errorf("copied cache = %q, want %q", got, cache)
+	}
+	if migration.Copied[filepath.Join(legacyDir, providersCacheFile)] != newCache {
+		t.Errorf("Copied = %v, want cache mapped to %s", migration.Copied, newCache)
+	}
+	if _, err := os.Stat(configPath); err == nil {
+		t.Error("config should not be created when the legacy dir has none")
+	}
+	if _, err := os.Stat(filepath.Join(legacyDir, providersCacheFile)); err != nil {
+		t.Errorf("legacy cache should be left in place: %v", err)
+	}
+}
+
+func TestMigrateLegacyDataDir_CopiesConfig(t *testing.T) {
+	root := t.TempDir()
+	legacyDir := filepath.Join(root, ".matrix")
+	configPath := filepath.Join(root, "config", "matrix", configFileName)
+	writeTestFile(t, filepath.Join(legacyDir, configFileName), `{"providers":{}}`)
+
+	migration, err := migrateLegacyDataDir(legacyDir, filepath.Join(root, "data"), configPath)
+	if err != nil {
+		t.Fatalf("migrateLegacyDataDir() error = %v", err)
+	}
+	if migration == nil || len(migration.Copied) != 1 {
+		t.Fatalf("migrateLegacyDataDir() = %+v, want one copied file", migration)
+	}
+	if _, err := os.Stat(configPath); err != nil {
+		t.Errorf("config was not copied: %v", err)
+	}
+}
+
+func TestMigrateLegacyDataDir_KeepsExisting(t *testing.T) {
+	root := t.TempDir()
+	legacyDir := filepath.Join(root, ".matrix")
+	dataDir := filepath.Join(root, "data")
+	writeTestFile(t, filepath.Join(legacyDir, providersCacheFile), "legacy")
+	writeTestFile(t, filepath.Join(dataDir, providersCacheFile), "current")
+
+	migration, err := migrateLegacyDataDir(legacyDir, dataDir, filepath.Join(root, "config.json"))
+	if err != nil {
+		t.Fatalf("migrateLegacyDataDir() error = %v", err)
+	}
+	if migration != nil {
+		t.Errorf("migrateLegacyDataDir() = %+v, want nil", migration)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dataDir, providersCacheFile)) //nolint:gosec // Test path.
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(got) != "current" {
+		t.Errorf("existing cache was overwritten: %q", got)
+	}
+}
+
+func TestMigrateLegacyDataDir_NoLegacyDir(t *testing.T) {
+	root := t.TempDir()
+
+	migration, err := migrateLegacyDataDir(filepath.Join(root, ".matrix"), filepath.Join(root, "data"),
+		filepath.Join(root, "config.json"))
+	if err != nil {
+		t.Fatalf("migrateLegacyDataDir() error = %v", err)
+	}
+	if migration != nil {
+		t.Errorf("migrateLegacyDataDir() = %+v, want nil", migration)
+	}
+}
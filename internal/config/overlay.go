@@ -0,0 +1,86 @@
+package config
+
+import (
+	_ "embed"
+	"encoding/json"
+	"log/slog"
+	"slices"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/catwalk/pkg/embedded"
+)
+
+// providersOverlay is a curated list of provider patches applied on top of
+// catwalk's embedded providers, used to add recent models before they land
+// upstream. Entries are merged by provider ID and model ID.
+//
+//go:embed providers_overlay.json
+var providersOverlay []byte
+
+// embeddedProviders returns catwalk's embedded providers with the bundled
+// overlay applied.
+func embeddedProviders() []catwalk.Provider {
+	var overlay []catwalk.Provider
+	if err := json.Unmarshal(providersOverlay, &overlay); err != nil {
+		slog.Warn("Ignoring invalid providers overlay", "error", err)
+		return embedded.GetAll()
+	}
+	return applyProviderOverlay(embedded.GetAll(), overlay)
+}
+
+// applyProviderOverlay merges overlay into base by provider ID. Unknown
+// providers are appended. For known ones, non-empty overlay fields replace
+// the base values and models are merged by ID, with overlay models winning.
+// The base slice is not modified.
+func applyProviderOverlay(base, overlay []catwalk.Provider) []catwalk.Provider {
+	result := slices.Clone(base)
+
+	index := make(map[catwalk.InferenceProvider]int, len(result))
+	for i := range result {
+		index[result[i].ID] = i
+	}
+
+	for _, o := range overlay {
+		i, ok := index[o.ID]
+		if !ok {
+			index[o.ID] = len(result)
+			result = append(result, o)
+			continue
+		}
+
+		p := &result[i]
+		if o.Name != "" {
+			p.Name = o.Name
+		}
+		if o.APIEndpoint != "" {
+			p.APIEndpoint = o.APIEndpoint
+		}
+		if o.Type != "" {
+			p.Type = o.Type
+		}
+		if o.DefaultLargeModelID != "" {
+			p.DefaultLargeModelID = o.DefaultLargeModelID
+		}
+		if o.DefaultSmallModelID != "" {
+			p.DefaultSmallModelID = o.DefaultSmallModelID
+		}
+		p.Models = mergeModels(p.Models, o.Models)
+	}
+
+	return result
+}
+
+// mergeModels returns base with overlay models replacing those with the
+// same ID and new ones appended.
+func mergeModels(base, overlay []catwalk.Model) []catwalk.Model {
+	merged := slices.Clone(base)
+	for _, m := range overlay {
+		i := slices.IndexFunc(merged, func(b catwalk.Model) bool { return b.ID == m.ID })
+		if i >= 0 {
+			merged[i] = m
+		} else {
+			merged = append(merged, m)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/catwalk/pkg/embedded"
+)
+
+func TestApplyProviderOverlay(t *testing.T) {
+	base := []catwalk.Provider{
+		{
+			ID:                  "openai",
+			Name:                "OpenAI",
+			Type:                catwalk.TypeOpenAI,
+			DefaultLargeModelID: "gpt-4o",
+			Models: []catwalk.Model{
+				{ID: "gpt-4o", Name: "GPT-4o", ContextWindow: 128_000},
+				{ID: "gpt-4o-mini", Name: "GPT-4o Mini"},
+			},
+		},
+		{ID: "anthropic", Name: "Anthropic"},
+	}
+	overlay := []catwalk.Provider{
+		{
+			ID:                  "openai",
+			DefaultLargeModelID: "gpt-5",
+			Models: []catwalk.Model{
+				{ID: "gpt-4o", Name: "GPT-4o", ContextWindow: 256_000},
+				{ID: "gpt-5", Name: "GPT-5"},
+			},
+		},
+		{ID: "newcomer", Name: "Newcomer", Type: catwalk.TypeOpenAICompat},
+	}
+
+	got := applyProviderOverlay(base, overlay)
+
+	if len(got) != 3 {
+		t.Fatalf("applyProviderOverlay() returned %d providers, want 3", len(got))
+	}
+
+	openai := got[0]
+	if openai.Name != "OpenAI" {
+		t.Errorf("Name = %q, want base value kept", openai.Name)
+	}
+	if openai.DefaultLargeModelID != "gpt-5" {
+		t.Errorf("DefaultLargeModelID = %q, want %q", openai.DefaultLargeModelID, "gpt-5")
+	}
+	if len(openai.Models) != 3 {
+		t.Fatalf("openai has %d models, want 3", len(openai.Models))
+	}
+	if openai.Models[0].ContextWindow != 256_000 {
+		t.Errorf("gpt-4o ContextWindow = %d, want overlay value", openai.Models[0].ContextWindow)
+	}
+	if openai.Models[2].ID != "gpt-5" {
+		t.Errorf("Models[2].ID = %q, want %q", openai.Models[2].ID, "gpt-5")
+	}
+
+	if got[1].Name != "Anthropic" {
+		t.Errorf("untouched provider changed: %+v", got[1])
+	}
+	if got[2].ID != "newcomer" {
+		t.Errorf("got[2].ID = %q, want %q", got[2].ID, "newcomer")
+	}
+
+	// The base set must not be modified.
+	if len(base[0].Models) != 2 || base[0].Models[0].ContextWindow != 128_000 {
+		t.Error("applyProviderOverlay() modified the base providers")
+	}
+}
+
+func TestProvidersOverlay_Valid(t *testing.T) {
+	var overlay []catwalk.Provider
+	if err := json.Unmarshal(providersOverlay, &overlay); err != nil {
+		t.Fatalf("bundled providers overlay is invalid: %v", err)
+	}
+	for _, p := range overlay {
+		if p.ID == "" {
+			t.Error("bundled providers overlay has an entry without an ID")
+		}
+	}
+}
+
+func TestEmbeddedProviders_IncludesEmbedded(t *testing.T) {
+	got := embeddedProviders()
+	if len(got) < len(embedded.GetAll()) {
+		t.Errorf("embeddedProviders() returned %d providers, fewer than embedded %d", len(got), len(embedded.GetAll()))
+	}
+}
+
+func TestEmbeddedProviders_AppliesBundledOverlay(t *testing.T) {
+	var overlay []catwalk.Provider
+	if err := json.Unmarshal(providersOverlay, &overlay); err != nil {
+		t.Fatalf("bundled providers overlay is invalid: %v", err)
+	}
+	if len(overlay) == 0 {
+		t.Fatal("bundled providers overlay is empty")
+	}
+
+	got := embeddedProviders()
+	for _, o := range overlay {
+		i := slices.IndexFunc(got, func(p catwalk.Provider) bool { return p.ID == o.ID })
+		if i < 0 {
+			t.Errorf("provider %q from the overlay is missing", o.ID)
+			continue
+		}
+		for _, m := range o.Models {
+			j := slices.IndexFunc(got[i].Models, func(b catwalk.Model) bool { return b.ID == m.ID })
+			if j < 0 {
+				t.Errorf("model %q from the overlay is missing from provider %q", m.ID, o.ID)
+				continue
+			}
+			if got[i].Models[j].ContextWindow != m.ContextWindow {
+				t.Errorf("model %q context window = %d, want the overlay's %d",
+					m.ID, got[i].Models[j].ContextWindow, m.ContextWindow)
+			}
+		}
+	}
+}
+
+func TestProvidersOverlay_ShadowsNoEmbeddedModel(t *testing.T) {
+	var overlay []catwalk.Provider
+	if err := json.Unmarshal(providersOverlay, &overlay); err != nil {
+		t.Fatalf("bundled providers overlay is invalid: %v", err)
+	}
+
+	base := embedded.GetAll()
+	for _, o := range overlay {
+		i := slices.IndexFunc(base, func(p catwalk.Provider) bool { return p.ID == o.ID })
+		if i < 0 {
+			continue
+		}
+		for _, m := range o.Models {
+			if slices.ContainsFunc(base[i].Models, func(b catwalk.Model) bool { return b.ID == m.ID }) {
+				t.Errorf("overlay model %q replaces the embedded one in provider %q; drop it from the overlay", m.ID, o.ID)
+			}
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+import "strings"
+
+// minAPIKeyLength is the length below which an API key is considered a
+// placeholder. Real provider keys are much longer.
+const minAPIKeyLength = 12
+
+// placeholderMarkers are substrings found in example keys copied from docs.
+var placeholderMarkers = []string{"your-", "your_", "xxxx", "placeholder", "changeme", "<", "$"}
+
+// looksLikePlaceholder reports whether a resolved API key looks like a value
+// left over from an example rather than a real key.
+func looksLikePlaceholder(key string) bool {
+	if len(key) < minAPIKeyLength {
+		return true
+	}
+	lower := strings.ToLower(key)
+	for _, marker := range placeholderMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
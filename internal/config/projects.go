@@ -0,0 +1,89 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// projectsStateFile remembers the models last used in each project, in the
+// data directory.
+const projectsStateFile = "projects.json"
+
+// ProjectSelection is the model selection last used with a project config.
+type ProjectSelection struct {
+	// UpdatedAt is when the selection was recorded.
+	UpdatedAt time.Time `json:"updated_at"`
+	// Models maps each tier to its selected model.
+	Models map[SelectedModelType]SelectedModel `json:"models"`
+}
+
+// RecordProjectSelection remembers models as the selection last used with
+// the project config at projectPath, in the state file under dataDir.
+// Selections of other projects are kept.
+func RecordProjectSelection(dataDir, projectPath string, models map[SelectedModelType]SelectedModel) error {
+	key, err := projectKey(projectPath)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dataDir, projectsStateFile)
+	selections, err := loadProjectSelections(path)
+	if err != nil {
+		return err
+	}
+	selections[key] = ProjectSelection{UpdatedAt: time.Now(), Models: models}
+
+	data, err := json.MarshalIndent(selections, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dataDir, 0o750); err != nil {
+		return fmt.Errorf("creating data directory: %w", err)
+	}
+	return writeFileAtomic(path, data, 0o600)
+}
+
+// LastProjectSelection returns the selection last recorded for the project
+// config at projectPath, and false when there is none.
+func LastProjectSelection(dataDir, projectPath string) (ProjectSelection, bool, error) {
+	key, err := projectKey(projectPath)
+	if err != nil {
+		return ProjectSelection{}, false, err
+	}
+	selections, err := loadProjectSelections(filepath.Join(dataDir, projectsStateFile))
+	if err != nil {
+		return ProjectSelection{}, false, err
+	}
+	sel, ok := selections[key]
+	return sel, ok && len(sel.Models) > 0, nil
+}
+
+// projectKey returns the absolute, cleaned project config path, so the same
+// project is found from any of its subdirectories.
+func projectKey(projectPath string) (string, error) {
+	if projectPath == "" {
+		return "", errors.New("no project config path")
+	}
+	return filepath.Abs(projectPath)
+}
+
+// loadProjectSelections reads the state file, returning an empty map when it
+// doesn't exist yet.
+func loadProjectSelections(path string) (map[string]ProjectSelection, error) {
+	selections := make(map[string]ProjectSelection)
+	data, err := os.ReadFile(path) //nolint:gosec // State file path is derived from the data directory.
+	if errors.Is(err, fs.ErrNotExist) {
+		return selections, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &selections); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return selections, nil
+}
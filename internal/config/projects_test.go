@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProjectSelection_RecordedPerProject(t *testing.T) {
+	dataDir := filepath.Join(t.TempDir(), "data")
+	alpha := filepath.Join(t.TempDir(), "alpha", "matrix.json")
+	beta := filepath.Join(t.TempDir(), "beta", ".matrix.json")
+
+	alphaModels := map[SelectedModelType]SelectedModel{
+		SelectedModelTypeLarge: {Provider: "anthropic", Model: "claude-sonnet-4"},
+		SelectedModelTypeSmall: {Provider: "anthropic", Model: "claude-haiku"},
+	}
+	betaModels := map[SelectedModelType]SelectedModel{
+		SelectedModelTypeLarge: {Provider: "openai", Model: "gpt-4o"},
+		SelectedModelTypeSmall: {Provider: "openai", Model: "gpt-4o-mini"},
+	}
+
+	if _, ok, err := LastProjectSelection(dataDir, alpha); err != nil || ok {
+		t.Fatalf("LastProjectSelection() before recording = %v, %v, want none", ok, err)
+	}
+	if err := RecordProjectSelection(dataDir, alpha, alphaModels); err != nil {
+		t.Fatalf("RecordProjectSelection(alpha) error = %v", err)
+	}
+	if err := RecordProjectSelection(dataDir, beta, betaModels); err != nil {
+		t.Fatalf("RecordProjectSelection(beta) error = %v", err)
+	}
+
+	for path, want := range map[string]map[SelectedModelType]SelectedModel{alpha: alphaModels, beta: betaModels} {
+		got, ok, err := LastProjectSelection(dataDir, path)
+		if err != nil || !ok {
+			t.Fatalf("LastProjectSelection(%s) = %v, %v, want the recorded selection", path, ok, err)
+		}
+		for tier, model := range want {
+			if g := got.Models[tier]; g.Provider != model.Provider || g.Model != model.Model {
+				t.Errorf("%s %s = %+v, want %+v", path, tier, got.Models[tier], model)
+			}
+		}
+		if got.UpdatedAt.IsZero() {
+			t.Errorf("%s UpdatedAt not set", path)
+		}
+	}
+
+	// Recording again replaces only that project's selection.
+	alphaModels[SelectedModelTypeLarge] = SelectedModel{Provider: "anthropic", Model: "claude-opus-4"}
+	if err := RecordProjectSelection(dataDir, alpha, alphaModels); err != nil {
+		t.Fatalf("RecordProjectSelection(alpha) error = %v", err)
+	}
+	if got, _, _ := LastProjectSelection(dataDir, alpha); got.Models[SelectedModelTypeLarge].Model != "claude-opus-4" {
+		t.Errorf("alpha large = %+v, want the latest selection", got.Models[SelectedModelTypeLarge])
+	}
+	if got, _, _ := LastProjectSelection(dataDir, beta); got.Models[SelectedModelTypeLarge].Model != "gpt-4o" {
+		t.Errorf("beta large = %+v, want it kept", got.Models[SelectedModelTypeLarge])
+	}
+}
+
+func TestProjectSelection_RelativePath(t *testing.T) {
+	dataDir := filepath.Join(t.TempDir(), "data")
+	projectDir := t.TempDir()
+	t.Chdir(projectDir)
+
+	models := map[SelectedModelType]SelectedModel{SelectedModelTypeLarge: {Provider: "openai", Model: "gpt-4o"}}
+	if err := RecordProjectSelection(dataDir, "matrix.json", models); err != nil {
+		t.Fatalf("RecordProjectSelection() error = %v", err)
+	}
+	if _, ok, err := LastProjectSelection(dataDir, filepath.Join(projectDir, "matrix.json")); err != nil || !ok {
+		t.Errorf("LastProjectSelection() by absolute path = %v, %v, want the selection recorded by relative path", ok, err)
+	}
+}
+
+func TestProjectSelection_CorruptStateFile(t *testing.T) {
+	dataDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dataDir, projectsStateFile), []byte(`{not json`), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, _, err := LastProjectSelection(dataDir, "/repo/matrix.json"); err == nil {
+		t.Error("LastProjectSelection() expected an error for a corrupt state file")
+	}
+}
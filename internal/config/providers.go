@@ -1,91 +1,284 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"syscall"
 	"time"
 
 	"github.com/adrg/xdg"
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
-	"github.com/charmbracelet/catwalk/pkg/embedded"
 )
 
 const (
 	providersCacheFile = "providers.json"
 	defaultCatwalkURL  = "https://catwalk.charm.sh"
-	cacheMaxAge        = 24 * time.Hour
+	// defaultCacheMaxAge is how long the cache is used when
+	// options.providers_cache_max_age is unset.
+	defaultCacheMaxAge = 24 * time.Hour
+
+	// minFetchedProvidersRatio is the fraction of the cached provider count a
+	// fetch must return before it is trusted to overwrite the cache.
+	minFetchedProvidersRatio = 0.5
+
+	// cacheWriteAttempts is how many times a failed cache write is tried
+	// before giving up.
+	cacheWriteAttempts   = 3
+	cacheWriteRetryDelay = 50 * time.Millisecond
 )
 
 // ProvidersCache holds cached provider metadata from catwalk.
 type ProvidersCache struct {
 	UpdatedAt time.Time          `json:"updated_at"`
 	Providers []catwalk.Provider `json:"providers"`
+	// Checksum guards an exported cache against corruption and tampering.
+	// The local cache leaves it empty.
+	Checksum string `json:"checksum,omitempty"`
+	// ETag and LastModified are the validators catwalk sent with the cached
+	// providers, used to ask for the list only if it changed.
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
 }
 
+// cacheValidators identify a fetched response, so a later request can ask
+// for it only if it changed.
+type cacheValidators struct {
+	ETag         string
+	LastModified string
+}
+
+// errNotModified is returned by a conditional fetch when the server reports
+// the copy identified by the sent validators is still current.
+var errNotModified = errors.New("not modified")
+
 // LoadProviders loads provider metadata from catwalk.
 // It tries: 1) fetch from URL, 2) cached data, 3) embedded fallback.
+// Fetched or cached providers are layered over the embedded ones, so a
+// provider missing from them is still known.
 func LoadProviders(cfg *Config) ([]catwalk.Provider, error) {
+	return LoadProvidersContext(context.Background(), cfg)
+}
+
+// LoadProvidersContext is like LoadProviders but aborts the catwalk fetch
+// when ctx is canceled, returning the context's error instead of falling back.
+// A fetch cut short by ctx's deadline falls back to the cache or embedded
+// data instead, so callers can bound how long startup waits for catwalk.
+// Without a deadline the fetch gives up after fetchTimeout. It uses the proxy
+// and request timeout in cfg's options; an invalid proxy is returned as an
+// error.
+//
+// The fetch is conditional on the cache's validators: when catwalk reports
+// the list unchanged, the cache is kept and only its age is reset.
+func LoadProvidersContext(ctx context.Context, cfg *Config) ([]catwalk.Provider, error) {
 	dataDir := cfg.DataDir()
 	cachePath := filepath.Join(dataDir, providersCacheFile)
+	cache, cacheErr := loadProvidersCache(cachePath)
+	var since cacheValidators
+	if cacheErr == nil {
+		since = cacheValidators{ETag: cache.ETag, LastModified: cache.LastModified}
+	}
 
 	// Try to fetch from catwalk API.
-	catwalkURL := os.Getenv("CATWALK_URL")
-	if catwalkURL == "" {
-		catwalkURL = defaultCatwalkURL
+	client, err := fetchClient(cfg.Options)
+	if err != nil {
+		return nil, err
+	}
+	providers, validators, err := fetchProvidersIf(ctx, client, catwalkURL(), since)
+	switch ctxErr := ctx.Err(); {
+	case errors.Is(ctxErr, context.Canceled):
+		return nil, ctxErr
+	case ctxErr != nil:
+		slog.Debug("Fetching providers timed out, using cached or embedded data", "error", ctxErr)
+	}
+	if errors.Is(err, errNotModified) && cacheErr == nil {
+		// The cache is current; a write failure only means it ages sooner.
+		if writeErr := writeProvidersCache(cachePath, *cache, writeFileAtomic); writeErr != nil {
+			slog.Debug("Failed to write providers cache", "path", cachePath, "error", writeErr)
+		}
+		return withEmbeddedProviders(cache.Providers), nil
 	}
-
-	client := catwalk.NewWithURL(catwalkURL)
-	providers, err := client.GetProviders()
 	if err == nil {
-		// Successfully fetched, update cache (ignore cache write errors).
-		if cacheErr := saveProvidersCache(cachePath, providers); cacheErr != nil {
-			// Cache write failure is non-fatal, continue with fetched data.
-			_ = cacheErr
+		// A much smaller result is likely a catwalk hiccup, keep the richer cache.
+		if cacheErr == nil &&
+			float64(len(providers)) < float64(len(cache.Providers))*minFetchedProvidersRatio {
+			slog.Warn("Fetched providers are much fewer than cached, keeping cache",
+				"fetched", len(providers), "cached", len(cache.Providers))
+			return withEmbeddedProviders(cache.Providers), nil
 		}
-		return providers, nil
+
+		// Successfully fetched, update cache. A write failure is non-fatal,
+		// continue with fetched data.
+		fetched := ProvidersCache{
+			Providers:    providers,
+			ETag:         validators.ETag,
+			LastModified: validators.LastModified,
+		}
+		if writeErr := writeProvidersCache(cachePath, fetched, writeFileAtomic); writeErr != nil {
+			slog.Debug("Failed to write providers cache", "path", cachePath, "error", writeErr)
+		}
+		return withEmbeddedProviders(providers), nil
 	}
 
 	// Fetch failed, try cache.
-	if cache, err := loadProvidersCache(cachePath); err == nil {
-		if time.Since(cache.UpdatedAt) < cacheMaxAge {
-			return cache.Providers, nil
-		}
+	if cacheErr == nil && time.Since(cache.UpdatedAt) < cacheMaxAge(cfg.Options) {
+		return withEmbeddedProviders(cache.Providers), nil
 	}
 
-	// Fall back to embedded providers.
-	return embedded.GetAll(), nil
+	// Fall back to embedded providers with the bundled overlay.
+	return embeddedProviders(), nil
 }
 
-// UpdateProviders fetches and caches provider metadata from the given source.
-// Source can be "embedded", an HTTP URL, or a local file path.
-func UpdateProviders(cfg *Config, source string) error {
+// cacheMaxAge returns how long the providers cache is used when catwalk
+// can't be reached, from opts when set there.
+func cacheMaxAge(opts *Options) time.Duration {
+	if opts != nil && opts.ProvidersCacheMaxAge > 0 {
+		return time.Duration(opts.ProvidersCacheMaxAge)
+	}
+	return defaultCacheMaxAge
+}
+
+// withEmbeddedProviders returns providers followed by the embedded providers
+// whose ID they lack. Fetched data is newer, so it wins on conflict, but it
+// never drops below the baked-in baseline.
+func withEmbeddedProviders(providers []catwalk.Provider) []catwalk.Provider {
+	return layerProviders(embeddedProviders(), providers)
+}
+
+// layerProviders returns top followed by the base providers whose ID top
+// lacks. Providers in both are taken whole from top; unlike
+// applyProviderOverlay, their fields and models are not merged.
+func layerProviders(base, top []catwalk.Provider) []catwalk.Provider {
+	ids := make(map[catwalk.InferenceProvider]bool, len(top))
+	for i := range top {
+		ids[top[i].ID] = true
+	}
+
+	result := slices.Clone(top)
+	for i := range base {
+		if !ids[base[i].ID] {
+			result = append(result, base[i])
+		}
+	}
+	return result
+}
+
+// UpdateProviders fetches and caches provider metadata from the given source,
+// returning how many providers were cached. Source can be "embedded", an HTTP
+// URL, or a local file path; an empty source fetches from catwalk, honoring
+// CATWALK_URL.
+func UpdateProviders(cfg *Config, source string) (int, error) {
 	var providers []catwalk.Provider
-	var err error
 
+	if source == "" {
+		source = catwalkURL()
+	}
 	switch {
 	case source == "embedded":
-		providers = embedded.GetAll()
+		providers = embeddedProviders()
 	case len(source) > 4 && source[:4] == "http":
-		client := catwalk.NewWithURL(source)
-		providers, err = client.GetProviders()
+		client, err := fetchClient(cfg.Options)
 		if err != nil {
-			return err
+			return 0, err
+		}
+		providers, err = fetchProviders(context.Background(), client, source)
+		if err != nil {
+			return 0, err
 		}
 	default:
 		// Load from local file.
 		data, err := os.ReadFile(source) //nolint:gosec // User-provided file path is trusted.
 		if err != nil {
-			return err
+			return 0, err
 		}
 		if err := json.Unmarshal(data, &providers); err != nil {
-			return err
+			return 0, err
 		}
 	}
 
 	dataDir := cfg.DataDir()
 	cachePath := filepath.Join(dataDir, providersCacheFile)
-	return saveProvidersCache(cachePath, providers)
+	if err := saveProvidersCache(cachePath, providers); err != nil {
+		return 0, err
+	}
+	return len(providers), nil
+}
+
+// catwalkURL returns the catwalk base URL, honoring CATWALK_URL.
+func catwalkURL() string {
+	if url := os.Getenv("CATWALK_URL"); url != "" {
+		return url
+	}
+	return defaultCatwalkURL
+}
+
+// fetchProviders retrieves providers from the catwalk service at baseURL.
+// It mirrors catwalk.Client.GetProviders but honors ctx and uses client.
+func fetchProviders(ctx context.Context, client *http.Client, baseURL string) ([]catwalk.Provider, error) {
+	providers, _, err := fetchProvidersIf(ctx, client, baseURL, cacheValidators{})
+	return providers, err
+}
+
+// fetchProvidersIf is fetchProviders sending the validators of a cached
+// copy; see fetchJSONIf.
+func fetchProvidersIf(ctx context.Context, client *http.Client, baseURL string, since cacheValidators) ([]catwalk.Provider, cacheValidators, error) {
+	var providers []catwalk.Provider
+	validators, err := fetchJSONIf(ctx, client, baseURL+"/v2/providers", since, &providers)
+	if err != nil {
+		return nil, cacheValidators{}, err
+	}
+	return providers, validators, nil
+}
+
+// fetchJSON decodes the JSON document at url, fetched with client, into v.
+func fetchJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	_, err := fetchJSONIf(ctx, client, url, cacheValidators{}, v)
+	return err
+}
+
+// fetchJSONIf is fetchJSON sending the validators of a previously fetched
+// copy as If-None-Match and If-Modified-Since. It returns errNotModified when
+// the server reports that copy is current, and otherwise the validators of
+// the new response.
+func fetchJSONIf(ctx context.Context, client *http.Client, url string, since cacheValidators, v any) (cacheValidators, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return cacheValidators{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	if since.ETag != "" {
+		req.Header.Set("If-None-Match", since.ETag)
+	}
+	if since.LastModified != "" {
+		req.Header.Set("If-Modified-Since", since.LastModified)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return cacheValidators{}, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode == http.StatusNotModified && since != (cacheValidators{}) {
+		return cacheValidators{}, errNotModified
+	}
+	if resp.StatusCode != http.StatusOK {
+		return cacheValidators{}, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return cacheValidators{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return cacheValidators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
 }
 
 // loadProvidersCache reads cached provider data.
@@ -103,24 +296,54 @@ func loadProvidersCache(path string) (*ProvidersCache, error) {
 	return &cache, nil
 }
 
+// cacheWriter writes a cache file; it matches os.WriteFile and
+// writeFileAtomic.
+type cacheWriter func(name string, data []byte, perm os.FileMode) error
+
 // saveProvidersCache writes provider data to cache.
 func saveProvidersCache(path string, providers []catwalk.Provider) error {
+	return saveProvidersCacheWith(path, providers, writeFileAtomic)
+}
+
+// saveProvidersCacheWith writes provider data to cache using write, retrying
+// failed writes a few times since they are often transient (e.g. a file
+// briefly locked by another process). A directory that cannot be created, or
+// a write error that won't clear up on its own, fails immediately.
+func saveProvidersCacheWith(path string, providers []catwalk.Provider, write cacheWriter) error {
+	return writeProvidersCache(path, ProvidersCache{Providers: providers}, write)
+}
+
+// writeProvidersCache writes cache to path as saveProvidersCacheWith does,
+// keeping its validators and stamping it with the current time.
+func writeProvidersCache(path string, cache ProvidersCache, write cacheWriter) error {
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0o750); err != nil {
 		return err
 	}
 
-	cache := ProvidersCache{
-		Providers: providers,
-		UpdatedAt: time.Now(),
-	}
-
+	cache.UpdatedAt = time.Now()
 	data, err := json.MarshalIndent(cache, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0o600)
+	for attempt := 1; ; attempt++ {
+		err = write(path, data, 0o600)
+		if err == nil || attempt == cacheWriteAttempts || isPermanentWriteError(err) {
+			return err
+		}
+		slog.Debug("Retrying providers cache write", "attempt", attempt, "error", err)
+		time.Sleep(cacheWriteRetryDelay)
+	}
+}
+
+// isPermanentWriteError reports whether a failed write would fail the same
+// way if retried right away: missing permissions, a read-only file system or
+// a full disk.
+func isPermanentWriteError(err error) bool {
+	return errors.Is(err, fs.ErrPermission) ||
+		errors.Is(err, syscall.EROFS) ||
+		errors.Is(err, syscall.ENOSPC)
 }
 
 // DefaultDataDir returns the default data directory path.
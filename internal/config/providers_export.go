@@ -0,0 +1,82 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// checksumPrefix names the hash algorithm in an exported cache's checksum.
+const checksumPrefix = "sha256:"
+
+// ErrChecksumMismatch is returned when an imported providers cache fails its
+// integrity check.
+var ErrChecksumMismatch = errors.New("providers cache checksum mismatch")
+
+// ExportProviders writes the local providers cache to path with a checksum,
+// so it can be imported elsewhere with ImportProviders.
+func ExportProviders(cfg *Config, path string) error {
+	cachePath := filepath.Join(cfg.DataDir(), providersCacheFile)
+	cache, err := loadProvidersCache(cachePath)
+	if err != nil {
+		return fmt.Errorf("reading providers cache: %w", err)
+	}
+
+	cache.Checksum, err = providersChecksum(cache)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// ImportProviders replaces the local providers cache with the one exported
+// to path. A file without a checksum, or whose checksum doesn't match its
+// contents, is rejected with ErrChecksumMismatch and the cache left as is.
+func ImportProviders(cfg *Config, path string) error {
+	data, err := os.ReadFile(path) //nolint:gosec // User-provided file path is trusted.
+	if err != nil {
+		return err
+	}
+	var cache ProvidersCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	if cache.Checksum == "" {
+		return fmt.Errorf("%w: %s has no checksum", ErrChecksumMismatch, path)
+	}
+	want, err := providersChecksum(&cache)
+	if err != nil {
+		return err
+	}
+	if cache.Checksum != want {
+		return fmt.Errorf("%w: %s is corrupt or was modified", ErrChecksumMismatch, path)
+	}
+
+	cachePath := filepath.Join(cfg.DataDir(), providersCacheFile)
+	return saveProvidersCache(cachePath, cache.Providers)
+}
+
+// providersChecksum hashes the cache's JSON encoding, leaving out the
+// checksum itself.
+func providersChecksum(cache *ProvidersCache) (string, error) {
+	unsigned := *cache
+	unsigned.Checksum = ""
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return checksumPrefix + hex.EncodeToString(sum[:]), nil
+}
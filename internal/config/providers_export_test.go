@@ -0,0 +1,124 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+)
+
+// exportTestProviders writes a providers cache to a new data directory and
+// exports it, returning the export path.
+func exportTestProviders(t *testing.T) string {
+	t.Helper()
+	cfg := NewConfig()
+	cfg.Options = &Options{DataDir: t.TempDir()}
+	providers := []catwalk.Provider{
+		{ID: "openai", Name: "OpenAI", Models: []catwalk.Model{{ID: "gpt-4o"}}},
+		{ID: "anthropic", Name: "Anthropic"},
+	}
+	if err := saveProvidersCache(filepath.Join(cfg.DataDir(), providersCacheFile), providers); err != nil {
+		t.Fatalf("saveProvidersCache() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "providers-export.json")
+	if err := ExportProviders(cfg, path); err != nil {
+		t.Fatalf("ExportProviders() error = %v", err)
+	}
+	return path
+}
+
+func TestExportImportProviders_RoundTrip(t *testing.T) {
+	path := exportTestProviders(t)
+
+	cfg := NewConfig()
+	cfg.Options = &Options{DataDir: t.TempDir()}
+	if err := ImportProviders(cfg, path); err != nil {
+		t.Fatalf("ImportProviders() error = %v", err)
+	}
+
+	cache, err := loadProvidersCache(filepath.Join(cfg.DataDir(), providersCacheFile))
+	if err != nil {
+		t.Fatalf("loadProvidersCache() error = %v", err)
+	}
+	if len(cache.Providers) != 2 {
+		t.Fatalf("Providers length = %d, want 2", len(cache.Providers))
+	}
+	if cache.Providers[0].ID != "openai" || len(cache.Providers[0].Models) != 1 {
+		t.Errorf("Providers[0] = %+v, want openai with one model", cache.Providers[0])
+	}
+	if cache.Checksum != "" {
+		t.Errorf("local cache Checksum = %q, want empty", cache.Checksum)
+	}
+}
+
+func TestImportProviders_Rejected(t *testing.T) {
+	//nolint:govet // Test struct alignment is not critical.
+	tests := []struct {
+		name    string
+		corrupt func(string) string
+	}{
+		{
+			name: "tampered provider",
+			corrupt: func(data string) string {
+				return strings.Replace(data, `"Anthropic"`, `"Evil"`, 1)
+			},
+		},
+		{
+			name: "missing checksum",
+			corrupt: func(data string) string {
+				i := strings.Index(data, `"checksum"`)
+				return strings.TrimRight(data[:i], " \n,") + "\n}"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := exportTestProviders(t)
+			data, err := os.ReadFile(path) //nolint:gosec // Test file path.
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			if writeErr := os.WriteFile(path, []byte(tt.corrupt(string(data))), 0o600); writeErr != nil {
+				t.Fatalf("WriteFile() error = %v", writeErr)
+			}
+
+			cfg := NewConfig()
+			cfg.Options = &Options{DataDir: t.TempDir()}
+			err = ImportProviders(cfg, path)
+			if !errors.Is(err, ErrChecksumMismatch) {
+				t.Errorf("ImportProviders() error = %v, want ErrChecksumMismatch", err)
+			}
+			if _, statErr := os.Stat(filepath.Join(cfg.DataDir(), providersCacheFile)); !errors.Is(statErr, os.ErrNotExist) {
+				t.Error("a rejected import should not write the cache")
+			}
+		})
+	}
+}
+
+func TestImportProviders_InvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "providers-export.json")
+	if err := os.WriteFile(path, []byte(`{not json`), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	cfg := NewConfig()
+	cfg.Options = &Options{DataDir: t.TempDir()}
+	if err := ImportProviders(cfg, path); err == nil {
+		t.Error("ImportProviders() expected error for invalid JSON")
+	}
+}
+
+func TestExportProviders_NoCache(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Options = &Options{DataDir: t.TempDir()}
+
+	err := ExportProviders(cfg, filepath.Join(t.TempDir(), "out.json"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ExportProviders() error = %v, want os.ErrNotExist", err)
+	}
+}
@@ -1,9 +1,16 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
+	"syscall"
 	"testing"
 	"time"
 
@@ -151,7 +158,7 @@ func TestUpdateProviders_Embedded(t *testing.T) {
 	cfg := NewConfig()
 	cfg.Options = &Options{DataDir: tempDir}
 
-	err := UpdateProviders(cfg, "embedded")
+	_, err := UpdateProviders(cfg, "embedded")
 	if err != nil {
 		t.Fatalf("UpdateProviders() error = %v", err)
 	}
@@ -192,7 +199,7 @@ func TestUpdateProviders_LocalFile(t *testing.T) {
 	cfg := NewConfig()
 	cfg.Options = &Options{DataDir: tempDir}
 
-	err = UpdateProviders(cfg, localPath)
+	_, err = UpdateProviders(cfg, localPath)
 	if err != nil {
 		t.Fatalf("UpdateProviders() error = %v", err)
 	}
@@ -216,7 +223,7 @@ func TestUpdateProviders_LocalFile_NonExistent(t *testing.T) {
 	cfg := NewConfig()
 	cfg.Options = &Options{DataDir: tempDir}
 
-	err := UpdateProviders(cfg, "/non/existent/file.json")
+	_, err := UpdateProviders(cfg, "/non/existent/file.json")
 	if err == nil {
 		t.Error("UpdateProviders() expected error for non-existent file")
 	}
@@ -234,7 +241,7 @@ func TestUpdateProviders_LocalFile_InvalidJSON(t *testing.T) {
 	cfg := NewConfig()
 	cfg.Options = &Options{DataDir: tempDir}
 
-	err := UpdateProviders(cfg, localPath)
+	_, err := UpdateProviders(cfg, localPath)
 	if err == nil {
 		t.Error("UpdateProviders() expected error for invalid JSON")
 	}
@@ -349,6 +356,49 @@ func TestLoadProviders_StaleCache(t *testing.T) {
 	}
 }
 
+func TestLoadProviders_CacheMaxAge(t *testing.T) {
+	//nolint:govet // Test struct alignment is not critical.
+	tests := []struct {
+		name       string
+		cacheAge   time.Duration
+		maxAge     Duration
+		wantCached bool
+	}{
+		{name: "default keeps a recent cache", cacheAge: time.Hour, wantCached: true},
+		{name: "default expires after a day", cacheAge: 25 * time.Hour},
+		{name: "short max age expires a recent cache", cacheAge: time.Hour, maxAge: Duration(30 * time.Minute)},
+		{name: "long max age keeps an older cache", cacheAge: 48 * time.Hour, maxAge: Duration(72 * time.Hour), wantCached: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			cache := ProvidersCache{
+				UpdatedAt: time.Now().Add(-tt.cacheAge),
+				Providers: []catwalk.Provider{{ID: "cached-provider"}},
+			}
+			data, err := json.Marshal(cache)
+			if err != nil {
+				t.Fatalf("Failed to marshal cache: %v", err)
+			}
+			if writeErr := os.WriteFile(filepath.Join(tempDir, providersCacheFile), data, 0o600); writeErr != nil {
+				t.Fatalf("Failed to write cache: %v", writeErr)
+			}
+			t.Setenv("CATWALK_URL", "http://invalid.invalid.invalid")
+
+			cfg := NewConfig()
+			cfg.Options = &Options{DataDir: tempDir, ProvidersCacheMaxAge: tt.maxAge}
+			providers, err := LoadProviders(cfg)
+			if err != nil {
+				t.Fatalf("LoadProviders() error = %v", err)
+			}
+			if got := hasProvider(providers, "cached-provider"); got != tt.wantCached {
+				t.Errorf("cache used = %v, want %v", got, tt.wantCached)
+			}
+		})
+	}
+}
+
 func TestProvidersCache_JSONMarshaling(t *testing.T) {
 	original := ProvidersCache{
 		UpdatedAt: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
@@ -378,7 +428,7 @@ func TestUpdateProviders_HTTPUrl_Fails(t *testing.T) {
 	cfg.Options = &Options{DataDir: tempDir}
 
 	// Use an invalid HTTP URL to test the HTTP path (will fail to fetch).
-	err := UpdateProviders(cfg, "http://invalid.invalid.invalid/providers.json")
+	_, err := UpdateProviders(cfg, "http://invalid.invalid.invalid/providers.json")
 	if err == nil {
 		t.Error("UpdateProviders() expected error for invalid HTTP URL")
 	}
@@ -431,6 +481,100 @@ func TestSaveProvidersCache_MkdirAllError(t *testing.T) {
 	}
 }
 
+func TestSaveProvidersCacheWith_RetriesTransientWriteError(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+
+	calls := 0
+	flaky := func(name string, data []byte, perm os.FileMode) error {
+		calls++
+		if calls == 1 {
+			return errors.New("file is locked")
+		}
+		return os.WriteFile(name, data, perm)
+	}
+
+	if err := saveProvidersCacheWith(cachePath, []catwalk.Provider{{ID: "test"}}, flaky); err != nil {
+		t.Fatalf("saveProvidersCacheWith() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("write calls = %d, want 2", calls)
+	}
+	if _, err := loadProvidersCache(cachePath); err != nil {
+		t.Errorf("cache should be readable after retry: %v", err)
+	}
+}
+
+func TestSaveProvidersCacheWith_GivesUpAfterRetries(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+
+	calls := 0
+	failing := func(string, []byte, os.FileMode) error {
+		calls++
+		return errors.New("file is locked")
+	}
+
+	if err := saveProvidersCacheWith(cachePath, []catwalk.Provider{{ID: "test"}}, failing); err == nil {
+		t.Error("saveProvidersCacheWith() expected error after retries")
+	}
+	if calls != cacheWriteAttempts {
+		t.Errorf("write calls = %d, want %d", calls, cacheWriteAttempts)
+	}
+}
+
+func TestSaveProvidersCacheWith_PermanentWriteErrorNotRetried(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"permission denied", syscall.EACCES},
+		{"read-only file system", syscall.EROFS},
+		{"disk full", syscall.ENOSPC},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cachePath := filepath.Join(t.TempDir(), "cache.json")
+
+			calls := 0
+			failing := func(name string, _ []byte, _ os.FileMode) error {
+				calls++
+				return &fs.PathError{Op: "open", Path: name, Err: tt.err}
+			}
+
+			err := saveProvidersCacheWith(cachePath, []catwalk.Provider{{ID: "test"}}, failing)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("saveProvidersCacheWith() error = %v, want %v", err, tt.err)
+			}
+			if calls != 1 {
+				t.Errorf("write calls = %d, want 1 for a permanent error", calls)
+			}
+		})
+	}
+}
+
+func TestSaveProvidersCacheWith_ParentIsFileNotRetried(t *testing.T) {
+	tempDir := t.TempDir()
+	blockingFile := filepath.Join(tempDir, "notadir")
+	//nolint:gosec // Test file.
+	if err := os.WriteFile(blockingFile, []byte("block"), 0o644); err != nil {
+		t.Fatalf("Failed to create blocking file: %v", err)
+	}
+
+	calls := 0
+	counting := func(name string, data []byte, perm os.FileMode) error {
+		calls++
+		return os.WriteFile(name, data, perm)
+	}
+
+	cachePath := filepath.Join(blockingFile, "subdir", "cache.json")
+	if err := saveProvidersCacheWith(cachePath, []catwalk.Provider{{ID: "test"}}, counting); err == nil {
+		t.Error("saveProvidersCacheWith() expected error when parent is a file")
+	}
+	if calls != 0 {
+		t.Errorf("write calls = %d, want 0 for a permanent directory error", calls)
+	}
+}
+
 func TestLoadProviders_DefaultURL(t *testing.T) {
 	tempDir := t.TempDir()
 	cfg := NewConfig()
@@ -448,3 +592,404 @@ func TestLoadProviders_DefaultURL(t *testing.T) {
 		t.Error("No providers loaded")
 	}
 }
+
+// newCatwalkServer starts a test server that serves the given providers.
+func newCatwalkServer(t *testing.T, providers []catwalk.Provider) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/providers" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(providers) //nolint:errcheck // Test server.
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestLoadProviders_FewerFetchedKeepsCache(t *testing.T) {
+	tempDir := t.TempDir()
+	cachePath := filepath.Join(tempDir, providersCacheFile)
+
+	cached := []catwalk.Provider{
+		{ID: "openai"}, {ID: "anthropic"}, {ID: "gemini"}, {ID: "groq"},
+	}
+	if err := saveProvidersCache(cachePath, cached); err != nil {
+		t.Fatalf("saveProvidersCache() error = %v", err)
+	}
+
+	server := newCatwalkServer(t, []catwalk.Provider{{ID: "openai"}})
+	t.Setenv("CATWALK_URL", server.URL)
+
+	cfg := NewConfig()
+	cfg.Options = &Options{DataDir: tempDir}
+
+	providers, err := LoadProviders(cfg)
+	if err != nil {
+		t.Fatalf("LoadProviders() error = %v", err)
+	}
+	for _, p := range cached {
+		if !hasProvider(providers, p.ID) {
+			t.Errorf("LoadProviders() is missing cached provider %q", p.ID)
+		}
+	}
+
+	cache, err := loadProvidersCache(cachePath)
+	if err != nil {
+		t.Fatalf("loadProvidersCache() error = %v", err)
+	}
+	if len(cache.Providers) != len(cached) {
+		t.Errorf("cache has %d providers, want %d (should not be overwritten)", len(cache.Providers), len(cached))
+	}
+}
+
+func TestLoadProviders_SimilarFetchOverwritesCache(t *testing.T) {
+	tempDir := t.TempDir()
+	cachePath := filepath.Join(tempDir, providersCacheFile)
+
+	if err := saveProvidersCache(cachePath, []catwalk.Provider{{ID: "openai"}, {ID: "anthropic"}}); err != nil {
+		t.Fatalf("saveProvidersCache() error = %v", err)
+	}
+
+	fetched := []catwalk.Provider{{ID: "openai"}, {ID: "anthropic"}, {ID: "gemini"}}
+	server := newCatwalkServer(t, fetched)
+	t.Setenv("CATWALK_URL", server.URL)
+
+	cfg := NewConfig()
+	cfg.Options = &Options{DataDir: tempDir}
+
+	providers, err := LoadProviders(cfg)
+	if err != nil {
+		t.Fatalf("LoadProviders() error = %v", err)
+	}
+	for _, p := range fetched {
+		if !hasProvider(providers, p.ID) {
+			t.Errorf("LoadProviders() is missing fetched provider %q", p.ID)
+		}
+	}
+
+	cache, err := loadProvidersCache(cachePath)
+	if err != nil {
+		t.Fatalf("loadProvidersCache() error = %v", err)
+	}
+	if len(cache.Providers) != len(fetched) {
+		t.Errorf("cache has %d providers, want %d", len(cache.Providers), len(fetched))
+	}
+}
+
+// hasProvider reports whether providers includes one with the given ID.
+func hasProvider(providers []catwalk.Provider, id catwalk.InferenceProvider) bool {
+	return slices.ContainsFunc(providers, func(p catwalk.Provider) bool {
+		return p.ID == id
+	})
+}
+
+func TestLoadProviders_LayersOverEmbedded(t *testing.T) {
+	fetched := []catwalk.Provider{{ID: "openai", Name: "Fetched OpenAI"}, {ID: "brand-new", Name: "New"}}
+
+	//nolint:govet // Test struct alignment is not critical.
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, cachePath string)
+	}{
+		{
+			name: "fetched",
+			setup: func(t *testing.T, _ string) {
+				t.Setenv("CATWALK_URL", newCatwalkServer(t, fetched).URL)
+			},
+		},
+		{
+			name: "cached",
+			setup: func(t *testing.T, cachePath string) {
+				if err := saveProvidersCache(cachePath, fetched); err != nil {
+					t.Fatalf("saveProvidersCache() error = %v", err)
+				}
+				t.Setenv("CATWALK_URL", "http://invalid.invalid.invalid")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			tt.setup(t, filepath.Join(tempDir, providersCacheFile))
+
+			cfg := NewConfig()
+			cfg.Options = &Options{DataDir: tempDir}
+			providers, err := LoadProviders(cfg)
+			if err != nil {
+				t.Fatalf("LoadProviders() error = %v", err)
+			}
+
+			// Anthropic only exists in the embedded set.
+			for _, id := range []catwalk.InferenceProvider{"openai", "brand-new", "anthropic"} {
+				if !hasProvider(providers, id) {
+					t.Errorf("LoadProviders() is missing %q", id)
+				}
+			}
+
+			// The fetched openai entry wins, and appears once.
+			var openai []catwalk.Provider
+			for _, p := range providers {
+				if p.ID == "openai" {
+					openai = append(openai, p)
+				}
+			}
+			if len(openai) != 1 || openai[0].Name != "Fetched OpenAI" {
+				t.Errorf("openai entries = %+v, want only the fetched one", openai)
+			}
+		})
+	}
+}
+
+func TestLoadProviders_ConditionalFetch(t *testing.T) {
+	tempDir := t.TempDir()
+	cachePath := filepath.Join(tempDir, providersCacheFile)
+
+	const etag = `"v1"`
+	const lastModified = "Wed, 01 Jan 2025 00:00:00 GMT"
+	var requests int
+	var gotIfNoneMatch, gotIfModifiedSince string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		gotIfNoneMatch = r.Header.Get("If-None-Match")
+		gotIfModifiedSince = r.Header.Get("If-Modified-Since")
+		if gotIfNoneMatch == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		_ = json.NewEncoder(w).Encode([]catwalk.Provider{{ID: "fetched"}}) //nolint:errcheck // Test server.
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("CATWALK_URL", server.URL)
+
+	cfg := NewConfig()
+	cfg.Options = &Options{DataDir: tempDir}
+
+	// The first fetch is unconditional and stores the validators.
+	if _, err := LoadProviders(cfg); err != nil {
+		t.Fatalf("LoadProviders() error = %v", err)
+	}
+	if gotIfNoneMatch != "" || gotIfModifiedSince != "" {
+		t.Errorf("first fetch sent If-None-Match %q, If-Modified-Since %q, want none", gotIfNoneMatch, gotIfModifiedSince)
+	}
+	cache, err := loadProvidersCache(cachePath)
+	if err != nil {
+		t.Fatalf("loadProvidersCache() error = %v", err)
+	}
+	if cache.ETag != etag || cache.LastModified != lastModified {
+		t.Errorf("cache validators = %q, %q, want %q, %q", cache.ETag, cache.LastModified, etag, lastModified)
+	}
+
+	// Age the cache so the bump on 304 is visible.
+	cache.UpdatedAt = time.Now().Add(-12 * time.Hour)
+	data, err := json.Marshal(cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cachePath, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	providers, err := LoadProviders(cfg)
+	if err != nil {
+		t.Fatalf("LoadProviders() error = %v", err)
+	}
+	if requests != 2 {
+		t.Fatalf("requests = %d, want 2", requests)
+	}
+	if gotIfNoneMatch != etag || gotIfModifiedSince != lastModified {
+		t.Errorf("second fetch sent If-None-Match %q, If-Modified-Since %q, want %q, %q",
+			gotIfNoneMatch, gotIfModifiedSince, etag, lastModified)
+	}
+	if !hasProvider(providers, "fetched") {
+		t.Error("LoadProviders() should keep the cached providers on 304")
+	}
+
+	cache, err = loadProvidersCache(cachePath)
+	if err != nil {
+		t.Fatalf("loadProvidersCache() error = %v", err)
+	}
+	if time.Since(cache.UpdatedAt) > time.Minute {
+		t.Errorf("cache UpdatedAt = %v, want it bumped on 304", cache.UpdatedAt)
+	}
+	if len(cache.Providers) != 1 || cache.Providers[0].ID != "fetched" || cache.ETag != etag {
+		t.Errorf("cache = %+v, want the cached providers and validators kept", cache)
+	}
+}
+
+func TestLoadProviders_NotModifiedWithoutCache(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// A 304 to an unconditional request has nothing to keep.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("CATWALK_URL", server.URL)
+
+	cfg := NewConfig()
+	cfg.Options = &Options{DataDir: tempDir}
+
+	providers, err := LoadProviders(cfg)
+	if err != nil {
+		t.Fatalf("LoadProviders() error = %v", err)
+	}
+	if len(providers) != len(embeddedProviders()) {
+		t.Errorf("LoadProviders() returned %d providers, want the %d embedded ones", len(providers), len(embeddedProviders()))
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, providersCacheFile)); !os.IsNotExist(err) {
+		t.Errorf("no cache should be written, stat error = %v", err)
+	}
+}
+
+func TestLayerProviders(t *testing.T) {
+	base := []catwalk.Provider{{ID: "a", Name: "base a"}, {ID: "b", Name: "base b"}, {ID: "c", Name: "base c"}}
+	top := []catwalk.Provider{{ID: "c", Name: "top c"}, {ID: "d", Name: "top d"}}
+
+	got := layerProviders(base, top)
+
+	want := []string{"top c", "top d", "base a", "base b"}
+	if len(got) != len(want) {
+		t.Fatalf("layerProviders() returned %d providers, want %d", len(got), len(want))
+	}
+	for i, name := range want {
+		if got[i].Name != name {
+			t.Errorf("layerProviders()[%d].Name = %q, want %q", i, got[i].Name, name)
+		}
+	}
+}
+
+func TestLoadProvidersContext_Canceled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+	t.Setenv("CATWALK_URL", server.URL)
+
+	cfg := NewConfig()
+	cfg.Options = &Options{DataDir: t.TempDir()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := LoadProvidersContext(ctx, cfg)
+		done <- err
+	}()
+
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("LoadProvidersContext() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("LoadProvidersContext() did not return after cancel")
+	}
+}
+
+func TestLoadProvidersContext_DeadlineFallsBack(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+	t.Setenv("CATWALK_URL", server.URL)
+
+	//nolint:govet // Test struct alignment is not critical.
+	tests := []struct {
+		name   string
+		cached []catwalk.Provider
+		want   catwalk.InferenceProvider
+	}{
+		{name: "cache", cached: []catwalk.Provider{{ID: "cached-provider"}}, want: "cached-provider"},
+		{name: "embedded", want: "anthropic"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			if tt.cached != nil {
+				if err := saveProvidersCache(filepath.Join(tempDir, providersCacheFile), tt.cached); err != nil {
+					t.Fatalf("saveProvidersCache() error = %v", err)
+				}
+			}
+			cfg := NewConfig()
+			cfg.Options = &Options{DataDir: tempDir}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			providers, err := LoadProvidersContext(ctx, cfg)
+			if err != nil {
+				t.Fatalf("LoadProvidersContext() error = %v, want a fallback", err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("LoadProvidersContext() took %v, want it bounded by the deadline", elapsed)
+			}
+			if !hasProvider(providers, tt.want) {
+				t.Errorf("LoadProvidersContext() is missing %q", tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadProvidersContext_UsesConfiguredProxy(t *testing.T) {
+	proxied := 0
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Host != "catwalk.invalid" || r.URL.Path != "/v2/providers" {
+			http.Error(w, "unexpected request", http.StatusBadGateway)
+			return
+		}
+		proxied++
+		_ = json.NewEncoder(w).Encode([]catwalk.Provider{{ID: "proxied"}})
+	}))
+	t.Cleanup(proxy.Close)
+	t.Setenv("CATWALK_URL", "http://catwalk.invalid")
+
+	cfg := NewConfig()
+	cfg.Options = &Options{DataDir: t.TempDir(), Proxy: proxy.URL}
+
+	providers, err := LoadProvidersContext(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("LoadProvidersContext() error = %v", err)
+	}
+	if proxied != 1 || !hasProvider(providers, "proxied") {
+		t.Errorf("proxied requests = %d, has proxied provider = %v, want it fetched through the proxy", proxied, hasProvider(providers, "proxied"))
+	}
+}
+
+func TestFetchClient(t *testing.T) {
+	client, err := fetchClient(&Options{RequestTimeout: 5})
+	if err != nil {
+		t.Fatalf("fetchClient() error = %v", err)
+	}
+	if client.Timeout != fetchTimeout {
+		t.Errorf("Timeout = %v, want %v", client.Timeout, fetchTimeout)
+	}
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Transport = %T, want *http.Transport", client.Transport)
+	}
+	if transport.ResponseHeaderTimeout != 5*time.Second {
+		t.Errorf("ResponseHeaderTimeout = %v, want 5s", transport.ResponseHeaderTimeout)
+	}
+
+	if _, err := fetchClient(&Options{Proxy: "proxy.example"}); err == nil {
+		t.Error("fetchClient() expected error for an invalid proxy")
+	}
+}
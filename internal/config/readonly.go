@@ -0,0 +1,80 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+)
+
+// readOnlyEnv forces read-only mode when set to a true value.
+const readOnlyEnv = "MATRIX_READONLY"
+
+// ErrReadOnly is returned when a write is refused because the config is
+// read-only.
+var ErrReadOnly = errors.New("config is read-only")
+
+// checkWritable returns ErrReadOnly when writing the config file at path is
+// not allowed, either via MATRIX_READONLY, cfg's options, or read_only in the
+// file already at path. Writing the global or project config is also
+// refused when either of them sets read_only, as Load merges the two. cfg
+// may be nil.
+func checkWritable(cfg *Config, path string) error {
+	if envReadOnly() {
+		return fmt.Errorf("%w: %s is set, not writing %s", ErrReadOnly, readOnlyEnv, path)
+	}
+	if cfg != nil && cfg.Options != nil && cfg.Options.ReadOnly {
+		return fmt.Errorf("%w: options.read_only is set, not writing %s", ErrReadOnly, path)
+	}
+	for _, file := range readOnlyFiles(path) {
+		if fileReadOnly(file) {
+			return fmt.Errorf("%w: %s sets options.read_only, not writing %s", ErrReadOnly, file, path)
+		}
+	}
+	return nil
+}
+
+// readOnlyFiles returns the config files whose read_only option applies to
+// writing path: the global and project configs when path is one of them,
+// otherwise path alone.
+func readOnlyFiles(path string) []string {
+	loaded := []string{GlobalConfigPath()}
+	if project := findProjectConfig(); project != "" {
+		loaded = append(loaded, project)
+	}
+	if slices.Contains(loaded, filepath.Clean(path)) {
+		return loaded
+	}
+	return []string{path}
+}
+
+// envReadOnly reports whether MATRIX_READONLY enables read-only mode. Any
+// value that isn't a recognizable false counts as enabled.
+func envReadOnly() bool {
+	value := os.Getenv(readOnlyEnv)
+	if value == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(value)
+	return err != nil || enabled
+}
+
+// fileReadOnly reports whether the config file at path sets
+// options.read_only. Missing or unreadable files are not read-only.
+func fileReadOnly(path string) bool {
+	data, err := os.ReadFile(path) //nolint:gosec // Config file paths are trusted.
+	if err != nil {
+		return false
+	}
+	var file struct {
+		Options struct {
+			ReadOnly bool `json:"read_only"`
+		} `json:"options"`
+	}
+	if err := unmarshalConfig(path, data, &file); err != nil {
+		return false
+	}
+	return file.Options.ReadOnly
+}
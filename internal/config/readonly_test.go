@@ -0,0 +1,117 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adrg/xdg"
+)
+
+func TestSaveToFile_ReadOnly(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		existing string
+		readOnly bool
+		wantErr  bool
+	}{
+		{name: "writable", wantErr: false},
+		{name: "env enabled", env: "1", wantErr: true},
+		{name: "env disabled", env: "false", wantErr: false},
+		{name: "options flag", readOnly: true, wantErr: true},
+		{name: "existing file flag", existing: `{"options":{"read_only":true}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(readOnlyEnv, tt.env)
+			path := filepath.Join(t.TempDir(), "matrix.json")
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0o600); err != nil {
+					t.Fatalf("WriteFile() error = %v", err)
+				}
+			}
+
+			cfg := NewConfig()
+			cfg.Options.ReadOnly = tt.readOnly
+			cfg.Providers["openai"] = &ProviderConfig{ID: "openai", APIKey: "$OPENAI_API_KEY"}
+
+			err := SaveToFile(cfg, path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SaveToFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrReadOnly) {
+				t.Errorf("SaveToFile() error = %v, want ErrReadOnly", err)
+			}
+
+			data, readErr := os.ReadFile(path) //nolint:gosec // Test path.
+			switch {
+			case tt.wantErr && tt.existing == "" && readErr == nil:
+				t.Error("read-only save should not create the file")
+			case tt.wantErr && tt.existing != "" && string(data) != tt.existing:
+				t.Errorf("read-only save changed the file: %s", data)
+			case !tt.wantErr && readErr != nil:
+				t.Errorf("save should write the file: %v", readErr)
+			}
+		})
+	}
+}
+
+func TestSaveWizardResult_ReadOnly(t *testing.T) {
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	xdg.Reload()
+	t.Setenv(readOnlyEnv, "true")
+
+	err := SaveWizardResult("openai", "$OPENAI_API_KEY", "gpt-4o", "gpt-4o-mini")
+	if !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("SaveWizardResult() error = %v, want ErrReadOnly", err)
+	}
+	if _, statErr := os.Stat(GlobalConfigPath()); statErr == nil {
+		t.Error("read-only wizard save should not create the config file")
+	}
+}
+
+func TestSetProviderDisabled_ReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matrix.json")
+	content := `{"options":{"read_only":true},"providers":{"openai":{"api_key":"$OPENAI_API_KEY"}}}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if err := SetProviderDisabled(path, "openai", true, ""); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("SetProviderDisabled() error = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestSave_ProjectReadOnly(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+	xdg.Reload()
+	t.Setenv(readOnlyEnv, "")
+	t.Chdir(tempDir)
+
+	projectPath := filepath.Join(tempDir, ".matrix.json")
+	if err := os.WriteFile(projectPath, []byte(`{"options":{"read_only":true}}`), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if err := SaveWizardResult("openai", "$OPENAI_API_KEY", "gpt-4o", "gpt-4o-mini"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("SaveWizardResult() error = %v, want ErrReadOnly", err)
+	}
+	update := ProviderUpdate{APIKey: "$OPENAI_API_KEY"}
+	if err := SetProvider(GlobalConfigPath(), "openai", update); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("SetProvider() error = %v, want ErrReadOnly", err)
+	}
+	if _, err := os.Stat(GlobalConfigPath()); err == nil {
+		t.Error("a read-only project config should keep the global config from being written")
+	}
+
+	// Files outside the loaded configs are not affected.
+	if err := SetProvider(filepath.Join(tempDir, "other.json"), "openai", update); err != nil {
+		t.Errorf("SetProvider() on another file error = %v", err)
+	}
+}
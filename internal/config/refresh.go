@@ -0,0 +1,153 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+)
+
+// ModelDiff lists the model IDs a refresh added and removed.
+type ModelDiff struct {
+	Added   []string
+	Removed []string
+}
+
+// Empty reports whether the refresh changed nothing.
+func (d ModelDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// RefreshProviderModels re-fetches the models of providerID, from its
+// metadata_url when set or catwalk otherwise, and stores them in the config
+// file at path. Other settings are kept as written. The returned diff is
+// relative to the models previously stored in the file.
+func RefreshProviderModels(ctx context.Context, path, providerID string) (ModelDiff, error) {
+	if err := checkWritable(nil, path); err != nil {
+		return ModelDiff{}, err
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // Config file paths are trusted.
+	if err != nil {
+		return ModelDiff{}, fmt.Errorf("reading config file: %w", err)
+	}
+
+	var raw map[string]any
+	if err := unmarshalConfig(path, data, &raw); err != nil {
+		return ModelDiff{}, fmt.Errorf("parsing config file: %w", err)
+	}
+
+	providers, _ := raw["providers"].(map[string]any)
+	entry, ok := providers[providerID].(map[string]any)
+	if !ok {
+		return ModelDiff{}, fmt.Errorf("provider %q not found in %s", providerID, path)
+	}
+
+	// Fetch through the proxy and timeout the file configures.
+	var fileCfg Config
+	if err := unmarshalConfig(path, data, &fileCfg); err != nil {
+		return ModelDiff{}, fmt.Errorf("parsing config file: %w", err)
+	}
+	client, err := fetchClient(fileCfg.Options)
+	if err != nil {
+		return ModelDiff{}, err
+	}
+
+	metadataURL, _ := entry["metadata_url"].(string)
+	models, err := fetchProviderModels(ctx, client, providerID, metadataURL)
+	if err != nil {
+		return ModelDiff{}, err
+	}
+
+	diff := diffModelIDs(storedModelIDs(entry), modelIDs(models))
+
+	// Round-trip through JSON so both config formats see plain values.
+	var generic []any
+	encoded, err := json.Marshal(models)
+	if err != nil {
+		return ModelDiff{}, fmt.Errorf("encoding models: %w", err)
+	}
+	if err := json.Unmarshal(encoded, &generic); err != nil {
+		return ModelDiff{}, fmt.Errorf("encoding models: %w", err)
+	}
+	entry["models"] = generic
+
+	out, err := marshalConfig(path, raw, rawIndentStyle(raw))
+	if err != nil {
+		return ModelDiff{}, fmt.Errorf("marshaling config: %w", err)
+	}
+
+	if err := writeFileAtomic(path, out, 0o644); err != nil {
+		return ModelDiff{}, fmt.Errorf("writing config file: %w", err)
+	}
+
+	return diff, nil
+}
+
+// fetchProviderModels returns the current models for providerID. A
+// metadataURL must serve a single catwalk provider document.
+func fetchProviderModels(ctx context.Context, client *http.Client, providerID, metadataURL string) ([]catwalk.Model, error) {
+	if metadataURL != "" {
+		var p catwalk.Provider
+		if err := fetchJSON(ctx, client, metadataURL, &p); err != nil {
+			return nil, fmt.Errorf("fetching %s: %w", metadataURL, err)
+		}
+		return p.Models, nil
+	}
+
+	providers, err := fetchProviders(ctx, client, catwalkURL())
+	if err != nil {
+		return nil, fmt.Errorf("fetching providers from catwalk: %w", err)
+	}
+	for i := range providers {
+		if string(providers[i].ID) == providerID {
+			return providers[i].Models, nil
+		}
+	}
+	return nil, fmt.Errorf("provider %q is not known to catwalk", providerID)
+}
+
+// storedModelIDs returns the IDs of the models in a raw provider entry.
+func storedModelIDs(entry map[string]any) []string {
+	models, _ := entry["models"].([]any)
+	ids := make([]string, 0, len(models))
+	for _, m := range models {
+		model, _ := m.(map[string]any)
+		if id, ok := model["id"].(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// modelIDs returns the IDs of models in order.
+func modelIDs(models []catwalk.Model) []string {
+	ids := make([]string, 0, len(models))
+	for i := range models {
+		ids = append(ids, models[i].ID)
+	}
+	return ids
+}
+
+// diffModelIDs returns the sorted IDs present only in after (added) and only
+// in before (removed).
+func diffModelIDs(before, after []string) ModelDiff {
+	var diff ModelDiff
+	for _, id := range after {
+		if !slices.Contains(before, id) {
+			diff.Added = append(diff.Added, id)
+		}
+	}
+	for _, id := range before {
+		if !slices.Contains(after, id) {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+	slices.Sort(diff.Added)
+	slices.Sort(diff.Removed)
+	return diff
+}
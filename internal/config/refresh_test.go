@@ -0,0 +1,120 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+)
+
+// serveJSON starts a server that replies to every request with v.
+func serveJSON(t *testing.T, v any) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(v) //nolint:errcheck // Test server.
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func writeRefreshConfig(t *testing.T, entry string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "matrix.json")
+	content := `{"providers":{"openai":` + entry + `}}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func readStoredModelIDs(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path) //nolint:gosec // Test path.
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	return modelIDs(cfg.Providers["openai"].Models)
+}
+
+func TestRefreshProviderModels_FromCatwalk(t *testing.T) {
+	server := serveJSON(t, []catwalk.Provider{
+		{ID: "anthropic", Models: []catwalk.Model{{ID: "claude"}}},
+		{ID: "openai", Models: []catwalk.Model{{ID: "gpt-4o"}, {ID: "gpt-5"}}},
+	})
+	t.Setenv("CATWALK_URL", server.URL)
+
+	path := writeRefreshConfig(t, `{"api_key":"$OPENAI_API_KEY","models":[{"id":"gpt-4"},{"id":"gpt-4o"}]}`)
+
+	diff, err := RefreshProviderModels(context.Background(), path, "openai")
+	if err != nil {
+		t.Fatalf("RefreshProviderModels() error = %v", err)
+	}
+	if !slices.Equal(diff.Added, []string{"gpt-5"}) || !slices.Equal(diff.Removed, []string{"gpt-4"}) {
+		t.Errorf("diff = %+v, want added [gpt-5] removed [gpt-4]", diff)
+	}
+
+	if got := readStoredModelIDs(t, path); !slices.Equal(got, []string{"gpt-4o", "gpt-5"}) {
+		t.Errorf("stored models = %v, want [gpt-4o gpt-5]", got)
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // Test path.
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(data), "$OPENAI_API_KEY") {
+		t.Errorf("other settings should be kept as written, got: %s", data)
+	}
+}
+
+func TestRefreshProviderModels_FromMetadataURL(t *testing.T) {
+	t.Setenv("CATWALK_URL", "http://invalid.invalid.invalid")
+	server := serveJSON(t, catwalk.Provider{ID: "openai", Models: []catwalk.Model{{ID: "custom"}}})
+
+	path := writeRefreshConfig(t, `{"metadata_url":"`+server.URL+`"}`)
+
+	diff, err := RefreshProviderModels(context.Background(), path, "openai")
+	if err != nil {
+		t.Fatalf("RefreshProviderModels() error = %v", err)
+	}
+	if !slices.Equal(diff.Added, []string{"custom"}) || len(diff.Removed) != 0 {
+		t.Errorf("diff = %+v, want added [custom]", diff)
+	}
+	if got := readStoredModelIDs(t, path); !slices.Equal(got, []string{"custom"}) {
+		t.Errorf("stored models = %v, want [custom]", got)
+	}
+}
+
+func TestRefreshProviderModels_Errors(t *testing.T) {
+	server := serveJSON(t, []catwalk.Provider{{ID: "anthropic"}})
+	t.Setenv("CATWALK_URL", server.URL)
+
+	tests := []struct {
+		name       string
+		providerID string
+		wantErr    string
+	}{
+		{name: "not configured", providerID: "groq", wantErr: "not found"},
+		{name: "unknown to catwalk", providerID: "openai", wantErr: "not known to catwalk"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeRefreshConfig(t, `{}`)
+			_, err := RefreshProviderModels(context.Background(), path, tt.providerID)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("RefreshProviderModels() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
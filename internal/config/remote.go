@@ -0,0 +1,133 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// maxRemoteConfigSize caps the size of a downloaded config file.
+const maxRemoteConfigSize = 1 << 20
+
+// IsConfigURL reports whether location is an http(s) URL rather than a path.
+func IsConfigURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// LoadFromLocation loads the config from location, which is either a file
+// path or an http(s) URL.
+func LoadFromLocation(ctx context.Context, location string) (*Config, error) {
+	if IsConfigURL(location) {
+		return LoadFromURL(ctx, location)
+	}
+	return loadFromFileContext(ctx, location)
+}
+
+// LoadFromURL downloads a config file to a temporary file and loads it like
+// LoadFromFile. A remote config must reference secrets through environment
+// variables; configs embedding API keys or OAuth tokens are rejected.
+func LoadFromURL(ctx context.Context, rawURL string) (*Config, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing config URL: %w", err)
+	}
+
+	data, err := downloadConfig(ctx, u.String())
+	if err != nil {
+		return nil, fmt.Errorf("downloading config from %s: %w", u.Redacted(), err)
+	}
+
+	// Keep the extension so the format is detected as for local files.
+	ext := path.Ext(u.Path)
+	if !isYAMLPath(ext) {
+		ext = ".json"
+	}
+	if err := checkNoEmbeddedSecrets(ext, data); err != nil {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp("", "matrix-config-*"+ext)
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary config file: %w", err)
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck
+
+	_, writeErr := f.Write(data)
+	if err := errors.Join(writeErr, f.Close()); err != nil {
+		return nil, fmt.Errorf("writing temporary config file: %w", err)
+	}
+
+	cfg, err := loadFromFileContext(ctx, f.Name())
+	if err != nil {
+		return nil, err
+	}
+	// The temporary file is gone once this returns.
+	for _, providerCfg := range cfg.Providers {
+		providerCfg.SourcePath = ""
+	}
+	for i := range cfg.sources {
+		if cfg.sources[i].Kind == SourceFile {
+			cfg.sources[i].Path = u.Redacted()
+		}
+	}
+	return cfg, nil
+}
+
+// downloadConfig fetches the raw config file at url.
+func downloadConfig(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	client, err := fetchClient(nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteConfigSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(data) > maxRemoteConfigSize {
+		return nil, fmt.Errorf("config is larger than %d bytes", maxRemoteConfigSize)
+	}
+	return data, nil
+}
+
+// checkNoEmbeddedSecrets rejects config data holding literal API keys or
+// OAuth tokens. The format is taken from the extension of path.
+func checkNoEmbeddedSecrets(path string, data []byte) error {
+	var cfg Config
+	if err := unmarshalConfig(path, data, &cfg); err != nil {
+		return fmt.Errorf("parsing config: %w", err)
+	}
+	for _, id := range cfg.ProviderIDs() {
+		p := cfg.Providers[id]
+		if p == nil {
+			continue
+		}
+		if p.OAuthToken != nil {
+			return fmt.Errorf("provider %q: remote configs must not embed OAuth tokens", id)
+		}
+		if p.APIKey != "" && !strings.Contains(p.APIKey, "$") {
+			return fmt.Errorf("provider %q: remote configs must reference the API key through an environment variable", id)
+		}
+	}
+	return nil
+}
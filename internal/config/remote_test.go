@@ -0,0 +1,128 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serveConfig starts a server returning content for path and 404 otherwise.
+func serveConfig(t *testing.T, path, content string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(content)) //nolint:errcheck // Test server.
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestLoadFromURL(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("CATWALK_URL", "http://invalid.invalid.invalid")
+	t.Setenv("TEST_KEY", "sk-test-remote-key-1234")
+
+	tests := []struct {
+		name    string
+		path    string
+		content string
+	}{
+		{
+			name: "json",
+			path: "/matrix.json",
+			content: `{
+				"providers": {"openai": {"api_key": "$TEST_KEY"}},
+				"options": {"data_directory": "` + tempDir + `"}
+			}`,
+		},
+		{
+			name: "yaml",
+			path: "/matrix.yaml",
+			content: "providers:\n  openai:\n    api_key: $TEST_KEY\n" +
+				"options:\n  data_directory: " + tempDir + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := serveConfig(t, tt.path, tt.content)
+
+			cfg, err := LoadFromLocation(context.Background(), server.URL+tt.path)
+			if err != nil {
+				t.Fatalf("LoadFromLocation() error = %v", err)
+			}
+			if got := cfg.Providers["openai"].APIKey; got != "sk-test-remote-key-1234" {
+				t.Errorf("APIKey = %q, want the resolved env value", got)
+			}
+		})
+	}
+}
+
+func TestLoadFromURL_Errors(t *testing.T) {
+	t.Setenv("CATWALK_URL", "http://invalid.invalid.invalid")
+
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "embedded key",
+			path:    "/matrix.json",
+			content: `{"providers": {"openai": {"api_key": "sk-literal-secret"}}}`,
+			wantErr: "environment variable",
+		},
+		{
+			name:    "embedded oauth token",
+			path:    "/matrix.json",
+			content: `{"providers": {"anthropic": {"oauth": {"access_token": "tok"}}}}`,
+			wantErr: "OAuth tokens",
+		},
+		{
+			name:    "not found",
+			path:    "/other.json",
+			wantErr: "unexpected status code: 404",
+		},
+		{
+			name:    "malformed",
+			path:    "/matrix.json",
+			content: `{"providers":`,
+			wantErr: "parsing config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := serveConfig(t, "/matrix.json", tt.content)
+
+			_, err := LoadFromURL(context.Background(), server.URL+tt.path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadFromURL() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsConfigURL(t *testing.T) {
+	tests := []struct {
+		location string
+		want     bool
+	}{
+		{location: "https://example.com/matrix.json", want: true},
+		{location: "http://localhost:8080/matrix.yaml", want: true},
+		{location: "./matrix.json", want: false},
+		{location: "/etc/matrix/matrix.json", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := IsConfigURL(tt.location); got != tt.want {
+			t.Errorf("IsConfigURL(%q) = %v, want %v", tt.location, got, tt.want)
+		}
+	}
+}
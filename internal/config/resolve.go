@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
@@ -28,35 +29,79 @@ func NewResolverWithEnv(env map[string]string) *Resolver {
 	return &Resolver{env: env}
 }
 
-// varPattern matches $VAR and ${VAR} patterns.
-var varPattern = regexp.MustCompile(`\$\{([a-zA-Z_][a-zA-Z0-9_]*)\}|\$([a-zA-Z_][a-zA-Z0-9_]*)`)
+// varPattern matches $VAR, ${VAR}, ${VAR:-default} and ${VAR:?message}
+// patterns, and the $$ escape. The escape is tried first, so "$$VAR" is an
+// escaped dollar followed by the text "VAR".
+var varPattern = regexp.MustCompile(
+	`\$\$|\$\{(?P<braced>[a-zA-Z_][a-zA-Z0-9_]*)(?::(?P<op>[-?])(?P<arg>[^}]*))?\}|\$(?P<bare>[a-zA-Z_][a-zA-Z0-9_]*)`)
+
+// escapedDollar is the varPattern match standing for a literal dollar sign.
+const escapedDollar = "$$"
+
+// varRef is a variable reference matched by varPattern.
+type varRef struct {
+	name string
+	// op is "-" for a default, "?" for a required message, or empty.
+	op string
+	// arg is the default value or the message.
+	arg string
+}
+
+// parseVarRef parses the varPattern submatches of a reference.
+func parseVarRef(groups []string) varRef {
+	ref := varRef{
+		name: groups[varPattern.SubexpIndex("braced")],
+		op:   groups[varPattern.SubexpIndex("op")],
+		arg:  groups[varPattern.SubexpIndex("arg")],
+	}
+	if ref.name == "" {
+		ref.name = groups[varPattern.SubexpIndex("bare")]
+	}
+	return ref
+}
 
 // Resolve expands environment variables in a string.
-// Supports $VAR and ${VAR} syntax.
-// Returns an error if a referenced variable is not set.
+// Supports $VAR, ${VAR}, ${VAR:-default} and ${VAR:?message} syntax. As in
+// the shell, the default is used and the message reported when the variable
+// is unset or empty. $$ stands for a literal dollar sign.
+// Returns an error if a referenced variable without a default is not set.
 func (r *Resolver) Resolve(value string) (string, error) {
 	if !strings.Contains(value, "$") {
 		return value, nil
 	}
 
-	var errs []string
+	var undefined, messages []string
 	result := varPattern.ReplaceAllStringFunc(value, func(match string) string {
-		var name string
-		if strings.HasPrefix(match, "${") {
-			name = match[2 : len(match)-1]
-		} else {
-			name = match[1:]
+		if match == escapedDollar {
+			return "$"
 		}
+		ref := parseVarRef(varPattern.FindStringSubmatch(match))
 
-		if val, ok := r.env[name]; ok {
+		val, ok := r.env[ref.name]
+		switch {
+		case ref.op == "-" && val == "":
+			return ref.arg
+		case ref.op == "?" && val == "" && ref.arg != "":
+			messages = append(messages, fmt.Sprintf("%s: %s", ref.name, ref.arg))
+			return match
+		case ref.op == "?" && val == "":
+			undefined = append(undefined, ref.name)
+			return match
+		case ok:
 			return val
 		}
-		errs = append(errs, name)
+		undefined = append(undefined, ref.name)
 		return match
 	})
 
-	if len(errs) > 0 {
-		return "", fmt.Errorf("undefined environment variables: %s", strings.Join(errs, ", "))
+	if len(messages) > 0 {
+		if len(undefined) > 0 {
+			messages = append(messages, "undefined environment variables: "+strings.Join(undefined, ", "))
+		}
+		return "", errors.New(strings.Join(messages, "; "))
+	}
+	if len(undefined) > 0 {
+		return "", fmt.Errorf("undefined environment variables: %s", strings.Join(undefined, ", "))
 	}
 
 	return result, nil
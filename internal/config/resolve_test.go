@@ -236,3 +236,147 @@ func TestResolver_Resolve_VariableNamePatterns(t *testing.T) {
 		})
 	}
 }
+
+func TestResolver_Resolve_Default(t *testing.T) {
+	r := NewResolverWithEnv(map[string]string{
+		"BASE_URL":  "https://proxy.example.com/v1",
+		"EMPTY_VAR": "",
+	})
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "set with default",
+			input: "${BASE_URL:-https://api.openai.com/v1}",
+			want:  "https://proxy.example.com/v1",
+		},
+		{
+			name:  "unset with default",
+			input: "${OPENAI_BASE_URL:-https://api.openai.com/v1}",
+			want:  "https://api.openai.com/v1",
+		},
+		{
+			name:  "empty value with default",
+			input: "${EMPTY_VAR:-fallback}",
+			want:  "fallback",
+		},
+		{
+			name:  "empty default",
+			input: "${UNSET_VAR:-}",
+			want:  "",
+		},
+		{
+			name:  "default in text",
+			input: "${UNSET_HOST:-localhost}:${UNSET_PORT:-8080}",
+			want:  "localhost:8080",
+		},
+		{
+			name:    "strict form still errors",
+			input:   "${UNSET_VAR:-ok}/${OTHER_UNSET}",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Resolve(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolver_Resolve_Required(t *testing.T) {
+	r := NewResolverWithEnv(map[string]string{
+		"API_KEY":   "secret123",
+		"EMPTY_VAR": "",
+	})
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "set variable resolves",
+			input: "${API_KEY:?please set your API key}",
+			want:  "secret123",
+		},
+		{
+			name:    "unset variable reports the message",
+			input:   "${MISSING_KEY:?please set your API key}",
+			wantErr: "MISSING_KEY: please set your API key",
+		},
+		{
+			name:    "empty variable reports the message",
+			input:   "${EMPTY_VAR:?must not be empty}",
+			wantErr: "EMPTY_VAR: must not be empty",
+		},
+		{
+			name:    "no message falls back to the generic error",
+			input:   "${MISSING_KEY:?}",
+			wantErr: "undefined environment variables: MISSING_KEY",
+		},
+		{
+			name:    "message alongside a strict reference",
+			input:   "${MISSING_KEY:?see README}/$OTHER_MISSING",
+			wantErr: "MISSING_KEY: see README; undefined environment variables: OTHER_MISSING",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Resolve(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Resolve() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolver_Resolve_EscapedDollar(t *testing.T) {
+	r := NewResolverWithEnv(map[string]string{"VAR": "value"})
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "escaped variable", input: "$$VAR", want: "$VAR"},
+		{name: "lone escape", input: "$$", want: "$"},
+		{name: "escaped braces", input: "$${VAR}", want: "${VAR}"},
+		{name: "escape before variable", input: "$$$VAR", want: "$value"},
+		{name: "escape in text", input: "cost: $$5, var: $VAR", want: "cost: $5, var: value"},
+		{name: "escaped unset variable", input: "$$UNSET_VAR", want: "$UNSET_VAR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Resolve(tt.input)
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
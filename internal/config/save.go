@@ -5,6 +5,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/goccy/go-yaml"
 
 	"github.com/guilhermegouw/matrix-cli/internal/oauth"
 )
@@ -20,8 +25,18 @@ type SaveConfig struct {
 // SaveProviderConfig is a minimal provider config for saving.
 // It stores the API key template (e.g., "$OPENAI_API_KEY") rather than resolved values.
 type SaveProviderConfig struct {
-	OAuthToken *oauth.Token `json:"oauth,omitempty"`
-	APIKey     string       `json:"api_key,omitempty"`
+	OAuthToken         *oauth.Token `json:"oauth,omitempty"`
+	APIKey             string       `json:"api_key,omitempty"`
+	BaseURL            string       `json:"base_url,omitempty"`
+	Type               catwalk.Type `json:"type,omitempty"`
+	AuthSource         AuthSource   `json:"auth_source,omitempty"`
+	AuthScheme         AuthScheme   `json:"auth_scheme,omitempty"`
+	AuthHeader         string       `json:"auth_header,omitempty"`
+	MetadataURL        string       `json:"metadata_url,omitempty"`
+	DisableReason      string       `json:"disable_reason,omitempty"`
+	PreserveHeaderCase bool         `json:"preserve_header_case,omitempty"`
+	DefaultThink       bool         `json:"default_think,omitempty"`
+	Disable            bool         `json:"disable,omitempty"`
 }
 
 // Save writes the configuration to the global config file.
@@ -30,7 +45,13 @@ func Save(cfg *Config) error {
 }
 
 // SaveToFile writes the configuration to a specific file path.
+// Paths ending in .yaml or .yml are written as YAML, anything else as JSON.
+// It fails with ErrReadOnly in read-only mode.
 func SaveToFile(cfg *Config, path string) error {
+	if err := checkWritable(cfg, path); err != nil {
+		return err
+	}
+
 	// Ensure the directory exists.
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0o750); err != nil {
@@ -44,22 +65,302 @@ func SaveToFile(cfg *Config, path string) error {
 		Options:   cfg.Options,
 	}
 
-	// Only save provider API key templates and OAuth tokens.
+	// Only save provider API key templates, OAuth tokens, auth and header
+	// settings and disabled state, plus the endpoint of keyless providers
+	// since it is all they have.
 	for id, p := range cfg.Providers {
-		if p.APIKey != "" || p.OAuthToken != nil {
-			saveCfg.Providers[id] = &SaveProviderConfig{
-				APIKey:     p.APIKey,
-				OAuthToken: p.OAuthToken,
+		if p.APIKey != "" || p.OAuthToken != nil || p.Disable || p.Keyless() {
+			saved := &SaveProviderConfig{
+				APIKey:             p.APIKey,
+				OAuthToken:         p.OAuthToken,
+				AuthSource:         p.AuthSource,
+				AuthScheme:         p.AuthScheme,
+				AuthHeader:         p.AuthHeader,
+				MetadataURL:        p.MetadataURL,
+				DisableReason:      p.DisableReason,
+				PreserveHeaderCase: p.PreserveHeaderCase,
+				DefaultThink:       p.DefaultThink,
+				Disable:            p.Disable,
+			}
+			if p.Keyless() {
+				saved.BaseURL = p.BaseURL
+				saved.Type = p.Type
 			}
+			if p.IsOAuth() {
+				// The key is derived from the token on load.
+				saved.APIKey = ""
+			}
+			if err := sealProvider(saved); err != nil {
+				return fmt.Errorf("encrypting provider %q: %w", id, err)
+			}
+			saveCfg.Providers[id] = saved
 		}
 	}
 
-	data, err := json.MarshalIndent(saveCfg, "", "  ")
+	var indentStyle string
+	if cfg.Options != nil {
+		indentStyle = cfg.Options.IndentStyle
+	}
+	data, err := marshalConfig(path, saveCfg, indentStyle)
 	if err != nil {
 		return fmt.Errorf("marshaling config: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0o644); err != nil { //nolint:gosec // Config file permissions are intentional.
+	if err := writeFileAtomic(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing config file: %w", err)
+	}
+
+	return nil
+}
+
+// marshalConfig encodes config data in the format implied by path, indented
+// as indentStyle asks (see Options.IndentStyle). Both encoders emit map keys
+// in sorted order, so saving unchanged data produces byte-identical files and
+// version-controlled configs don't churn.
+func marshalConfig(path string, v any, indentStyle string) ([]byte, error) {
+	indent, err := parseIndentStyle(indentStyle)
+	if err != nil {
+		return nil, err
+	}
+	if isYAMLPath(path) {
+		// YAML can't be indented with tabs, so they keep the default.
+		if indent == "\t" {
+			return yaml.Marshal(v)
+		}
+		return yaml.MarshalWithOptions(v, yaml.Indent(len(indent)))
+	}
+	return json.MarshalIndent(v, "", indent)
+}
+
+// parseIndentStyle returns the indentation for an Options.IndentStyle value.
+func parseIndentStyle(style string) (string, error) {
+	switch style {
+	case "":
+		return defaultIndent, nil
+	case IndentTab:
+		return "\t", nil
+	}
+	n, err := strconv.Atoi(style)
+	if err != nil || n < 1 || n > maxIndentSpaces {
+		return "", fmt.Errorf("invalid indent_style %q: want %q or a number of spaces from 1 to %d", style, IndentTab, maxIndentSpaces)
+	}
+	return strings.Repeat(" ", n), nil
+}
+
+// rawIndentStyle returns the indent_style option of a config decoded into a
+// generic map, so editing the file in place keeps its indentation.
+func rawIndentStyle(raw map[string]any) string {
+	options, _ := raw["options"].(map[string]any)
+	style, _ := options["indent_style"].(string)
+	return style
+}
+
+// SetProviderDisabled disables or re-enables a provider in the config file at
+// path. The file is edited in place so all other settings, including
+// unresolved environment references, are kept as written. Enabling clears
+// any previous reason.
+func SetProviderDisabled(path, providerID string, disabled bool, reason string) error {
+	if err := checkWritable(nil, path); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // Config file paths are trusted.
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	var raw map[string]any
+	if err := unmarshalConfig(path, data, &raw); err != nil {
+		return fmt.Errorf("parsing config file: %w", err)
+	}
+
+	providers, _ := raw["providers"].(map[string]any)
+	entry, ok := providers[providerID].(map[string]any)
+	if !ok {
+		return fmt.Errorf("provider %q not found in %s", providerID, path)
+	}
+
+	delete(entry, "disable")
+	delete(entry, "disable_reason")
+	if disabled {
+		entry["disable"] = true
+		if reason != "" {
+			entry["disable_reason"] = reason
+		}
+	}
+
+	out, err := marshalConfig(path, raw, rawIndentStyle(raw))
+	if err != nil {
+		return fmt.Errorf("marshaling config: %w", err)
+	}
+
+	if err := writeFileAtomic(path, out, 0o644); err != nil {
+		return fmt.Errorf("writing config file: %w", err)
+	}
+
+	return nil
+}
+
+// SaveOAuthToken stores a refreshed OAuth token for providerID in the config
+// file at path. An api_key derived from the previous access token is updated
+// to match; other settings are kept as written.
+func SaveOAuthToken(path, providerID string, token *oauth.Token) error {
+	if err := checkWritable(nil, path); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // Config file paths are trusted.
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	var raw map[string]any
+	if err := unmarshalConfig(path, data, &raw); err != nil {
+		return fmt.Errorf("parsing config file: %w", err)
+	}
+
+	providers, _ := raw["providers"].(map[string]any)
+	entry, ok := providers[providerID].(map[string]any)
+	if !ok {
+		return fmt.Errorf("provider %q not found in %s", providerID, path)
+	}
+
+	oldToken, _ := entry["oauth"].(map[string]any)
+	oldAccess, _ := oldToken["access_token"].(string)
+	if oldAccess, err = openSecret(oldAccess); err != nil {
+		return fmt.Errorf("provider %q: %w", providerID, err)
+	}
+	if oldAccess != "" {
+		apiKey, _ := entry["api_key"].(string)
+		if apiKey, err = openSecret(apiKey); err != nil {
+			return fmt.Errorf("provider %q: %w", providerID, err)
+		}
+		var newKey string
+		switch apiKey {
+		case oldAccess:
+			newKey = token.AccessToken
+		case "Bearer " + oldAccess:
+			newKey = "Bearer " + token.AccessToken
+		}
+		if newKey != "" {
+			if entry["api_key"], err = sealSecret(newKey); err != nil {
+				return fmt.Errorf("encrypting API key: %w", err)
+			}
+		}
+	}
+
+	if token, err = sealToken(token); err != nil {
+		return fmt.Errorf("encrypting OAuth token: %w", err)
+	}
+	// Round-trip through JSON so both config formats see plain values.
+	var generic map[string]any
+	encoded, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("encoding OAuth token: %w", err)
+	}
+	if err := json.Unmarshal(encoded, &generic); err != nil {
+		return fmt.Errorf("encoding OAuth token: %w", err)
+	}
+	entry["oauth"] = generic
+
+	out, err := marshalConfig(path, raw, rawIndentStyle(raw))
+	if err != nil {
+		return fmt.Errorf("marshaling config: %w", err)
+	}
+
+	if err := writeFileAtomic(path, out, 0o644); err != nil {
+		return fmt.Errorf("writing config file: %w", err)
+	}
+
+	return nil
+}
+
+// ProviderUpdate holds the provider settings written by SetProvider. Empty
+// fields leave the existing values unchanged.
+type ProviderUpdate struct {
+	// Type is the provider type.
+	Type catwalk.Type
+	// BaseURL is the API endpoint URL.
+	BaseURL string
+	// APIKey is the key or an environment reference such as "$MY_KEY".
+	APIKey string
+	// LargeModel selects a model of this provider for the large tier.
+	LargeModel string
+	// SmallModel selects a model of this provider for the small tier.
+	SmallModel string
+}
+
+// SetProvider adds providerID to the config file at path, or updates it when
+// already present, creating the file if needed. Other providers and settings
+// are kept as written.
+func SetProvider(path, providerID string, update ProviderUpdate) error {
+	if err := checkWritable(nil, path); err != nil {
+		return err
+	}
+
+	raw := make(map[string]any)
+	data, err := os.ReadFile(path) //nolint:gosec // Config file paths are trusted.
+	switch {
+	case err == nil:
+		if err := unmarshalConfig(path, data, &raw); err != nil {
+			return fmt.Errorf("parsing config file: %w", err)
+		}
+		if raw == nil {
+			raw = make(map[string]any)
+		}
+	case !os.IsNotExist(err):
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	providers, _ := raw["providers"].(map[string]any)
+	if providers == nil {
+		providers = make(map[string]any)
+		raw["providers"] = providers
+	}
+	entry, _ := providers[providerID].(map[string]any)
+	if entry == nil {
+		entry = make(map[string]any)
+		providers[providerID] = entry
+	}
+
+	apiKey, err := sealSecret(update.APIKey)
+	if err != nil {
+		return fmt.Errorf("encrypting API key: %w", err)
+	}
+	for key, value := range map[string]string{
+		"type":     string(update.Type),
+		"base_url": update.BaseURL,
+		"api_key":  apiKey,
+	} {
+		if value != "" {
+			entry[key] = value
+		}
+	}
+
+	for tier, model := range map[SelectedModelType]string{
+		SelectedModelTypeLarge: update.LargeModel,
+		SelectedModelTypeSmall: update.SmallModel,
+	} {
+		if model == "" {
+			continue
+		}
+		models, _ := raw["models"].(map[string]any)
+		if models == nil {
+			models = make(map[string]any)
+			raw["models"] = models
+		}
+		models[string(tier)] = map[string]any{"provider": providerID, "model": model}
+	}
+
+	out, err := marshalConfig(path, raw, rawIndentStyle(raw))
+	if err != nil {
+		return fmt.Errorf("marshaling config: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	if err := writeFileAtomic(path, out, 0o644); err != nil {
 		return fmt.Errorf("writing config file: %w", err)
 	}
 
@@ -72,8 +373,9 @@ func SaveWizardResult(providerID, apiKey, largeModel, smallModel string) error {
 
 	// Set provider with API key (could be actual key or env var reference).
 	cfg.Providers[providerID] = &ProviderConfig{
-		ID:     providerID,
-		APIKey: apiKey,
+		ID:         providerID,
+		APIKey:     apiKey,
+		AuthSource: AuthSourceAPIKey,
 	}
 
 	// Set model selections.
@@ -93,11 +395,12 @@ func SaveWizardResult(providerID, apiKey, largeModel, smallModel string) error {
 func SaveWizardResultWithOAuth(providerID string, token *oauth.Token, largeModel, smallModel string) error {
 	cfg := NewConfig()
 
-	// Set provider with OAuth token.
+	// Set provider with OAuth token. The API key is derived from it on
+	// load, so the access token isn't written twice.
 	cfg.Providers[providerID] = &ProviderConfig{
 		ID:         providerID,
 		OAuthToken: token,
-		APIKey:     token.AccessToken, // Store access token as API key for immediate use.
+		AuthSource: AuthSourceOAuth,
 	}
 
 	// Set model selections.
@@ -2,11 +2,15 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
 	"github.com/guilhermegouw/matrix-cli/internal/oauth"
 )
 
@@ -64,6 +68,63 @@ func TestSaveToFile(t *testing.T) {
 	}
 }
 
+func TestSaveToFile_YAML(t *testing.T) {
+	for _, ext := range []string{".yaml", ".yml"} {
+		t.Run(ext, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			configPath := filepath.Join(tmpDir, "matrix"+ext)
+
+			temp := 0.2
+			cfg := NewConfig()
+			cfg.Providers["anthropic"] = &ProviderConfig{
+				ID:     "anthropic",
+				APIKey: "$ANTHROPIC_API_KEY",
+			}
+			cfg.Models[SelectedModelTypeLarge] = SelectedModel{
+				Model:       "claude-3-opus",
+				Provider:    "anthropic",
+				Temperature: &temp,
+			}
+			cfg.Options = &Options{ContextPaths: []string{"AGENTS.md"}, Debug: true}
+
+			if err := SaveToFile(cfg, configPath); err != nil {
+				t.Fatalf("SaveToFile() error = %v", err)
+			}
+
+			data, err := os.ReadFile(configPath)
+			if err != nil {
+				t.Fatalf("Failed to read config file: %v", err)
+			}
+			if json.Valid(data) {
+				t.Errorf("SaveToFile() wrote JSON to a %s path:\n%s", ext, data)
+			}
+
+			// Read it back through the YAML loader.
+			loaded := NewConfig()
+			if err := loadFile(configPath, loaded); err != nil {
+				t.Fatalf("loadFile() error = %v", err)
+			}
+
+			if loaded.Providers["anthropic"] == nil {
+				t.Fatal("Provider 'anthropic' not loaded")
+			}
+			if loaded.Providers["anthropic"].APIKey != "$ANTHROPIC_API_KEY" {
+				t.Errorf("APIKey = %q, want %q", loaded.Providers["anthropic"].APIKey, "$ANTHROPIC_API_KEY")
+			}
+			large := loaded.Models[SelectedModelTypeLarge]
+			if large.Model != "claude-3-opus" || large.Provider != "anthropic" {
+				t.Errorf("Large model = %+v, want claude-3-opus/anthropic", large)
+			}
+			if large.Temperature == nil || *large.Temperature != 0.2 {
+				t.Errorf("Temperature = %v, want 0.2", large.Temperature)
+			}
+			if !loaded.Options.Debug || len(loaded.Options.ContextPaths) != 1 {
+				t.Errorf("Options = %+v, want debug with one context path", loaded.Options)
+			}
+		})
+	}
+}
+
 func TestSaveToFile_CreatesDirectory(t *testing.T) {
 	tmpDir := t.TempDir()
 	nestedPath := filepath.Join(tmpDir, "nested", "dir", "config.json")
@@ -162,6 +223,9 @@ func TestSaveWizardResult(t *testing.T) {
 	if saved.Providers["openai"].APIKey != "$OPENAI_API_KEY" {
 		t.Errorf("APIKey = %q, want %q", saved.Providers["openai"].APIKey, "$OPENAI_API_KEY")
 	}
+	if saved.Providers["openai"].AuthSource != AuthSourceAPIKey {
+		t.Errorf("AuthSource = %q, want %q", saved.Providers["openai"].AuthSource, AuthSourceAPIKey)
+	}
 
 	// Verify models.
 	if saved.Models[SelectedModelTypeLarge].Model != "gpt-4o" {
@@ -219,9 +283,12 @@ func TestSaveWizardResultWithOAuth(t *testing.T) {
 		t.Errorf("RefreshToken = %q, want %q", saved.Providers["anthropic"].OAuthToken.RefreshToken, "refresh-token-456")
 	}
 
-	// Verify API key is set to access token.
-	if saved.Providers["anthropic"].APIKey != "access-token-123" {
-		t.Errorf("APIKey = %q, want %q", saved.Providers["anthropic"].APIKey, "access-token-123")
+	// The access token is stored once, in the OAuth token.
+	if saved.Providers["anthropic"].APIKey != "" {
+		t.Errorf("APIKey = %q, want empty", saved.Providers["anthropic"].APIKey)
+	}
+	if saved.Providers["anthropic"].AuthSource != AuthSourceOAuth {
+		t.Errorf("AuthSource = %q, want %q", saved.Providers["anthropic"].AuthSource, AuthSourceOAuth)
 	}
 
 	// Verify models.
@@ -303,3 +370,405 @@ func TestSaveConfig_Fields(t *testing.T) {
 		t.Error("Options.Debug = false, want true")
 	}
 }
+
+func TestSaveToFile_AuthSourceRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+	t.Setenv("CATWALK_URL", "http://invalid.invalid.invalid")
+
+	cfg := NewConfig()
+	cfg.Options.DataDir = tmpDir
+	cfg.Providers["openai"] = &ProviderConfig{
+		ID:         "openai",
+		APIKey:     "sk-user-key",
+		AuthSource: AuthSourceAPIKey,
+	}
+	cfg.Providers["anthropic"] = &ProviderConfig{
+		ID:         "anthropic",
+		APIKey:     "oauth-access",
+		OAuthToken: &oauth.Token{AccessToken: "oauth-access", RefreshToken: "oauth-refresh"},
+		AuthSource: AuthSourceOAuth,
+	}
+	cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "gpt-4o", Provider: "openai"}
+
+	if err := SaveToFile(cfg, configPath); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+
+	loaded, err := LoadFromFile(configPath)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+
+	if got := loaded.Providers["openai"]; got.AuthSource != AuthSourceAPIKey || got.IsOAuth() {
+		t.Errorf("openai AuthSource = %q, want %q", got.AuthSource, AuthSourceAPIKey)
+	}
+	if got := loaded.Providers["anthropic"]; got.AuthSource != AuthSourceOAuth || !got.IsOAuth() {
+		t.Errorf("anthropic AuthSource = %q, want %q", got.AuthSource, AuthSourceOAuth)
+	}
+
+	// The derived key isn't written, but is restored from the token on load.
+	data, err := os.ReadFile(configPath) //nolint:gosec // Test file path.
+	if err != nil {
+		t.Fatalf("Failed to read config file: %v", err)
+	}
+	var saved SaveConfig
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("Failed to parse config file: %v", err)
+	}
+	if key := saved.Providers["anthropic"].APIKey; key != "" {
+		t.Errorf("saved anthropic APIKey = %q, want empty", key)
+	}
+	if key := loaded.Providers["anthropic"].APIKey; key != "oauth-access" {
+		t.Errorf("loaded anthropic APIKey = %q, want %q", key, "oauth-access")
+	}
+}
+
+func TestSetProviderDisabled(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+
+	content := `{
+		"providers": {
+			"openai": {"api_key": "$OPENAI_API_KEY", "base_url": "https://example.com/v1"}
+		}
+	}`
+	//nolint:gosec // Test file, permissions not critical.
+	if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := SetProviderDisabled(configPath, "openai", true, "quota exceeded"); err != nil {
+		t.Fatalf("SetProviderDisabled(disable) error = %v", err)
+	}
+
+	cfg := NewConfig()
+	if err := loadFile(configPath, cfg); err != nil {
+		t.Fatalf("loadFile() error = %v", err)
+	}
+	p := cfg.Providers["openai"]
+	if !p.Disable {
+		t.Error("Disable = false, want true")
+	}
+	if p.DisableReason != "quota exceeded" {
+		t.Errorf("DisableReason = %q, want %q", p.DisableReason, "quota exceeded")
+	}
+	if p.APIKey != "$OPENAI_API_KEY" {
+		t.Errorf("APIKey = %q, want unresolved template", p.APIKey)
+	}
+	if p.BaseURL != "https://example.com/v1" {
+		t.Errorf("BaseURL = %q, want it preserved", p.BaseURL)
+	}
+	if got := p.DisabledDescription(); got != "disabled: quota exceeded" {
+		t.Errorf("DisabledDescription() = %q, want %q", got, "disabled: quota exceeded")
+	}
+
+	if err := SetProviderDisabled(configPath, "openai", false, ""); err != nil {
+		t.Fatalf("SetProviderDisabled(enable) error = %v", err)
+	}
+
+	cfg = NewConfig()
+	if err := loadFile(configPath, cfg); err != nil {
+		t.Fatalf("loadFile() error = %v", err)
+	}
+	p = cfg.Providers["openai"]
+	if p.Disable || p.DisableReason != "" {
+		t.Errorf("after enable Disable = %v, DisableReason = %q, want cleared", p.Disable, p.DisableReason)
+	}
+}
+
+func TestSetProviderDisabled_UnknownProvider(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+
+	//nolint:gosec // Test file, permissions not critical.
+	if err := os.WriteFile(configPath, []byte(`{"providers": {}}`), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := SetProviderDisabled(configPath, "missing", true, ""); err == nil {
+		t.Error("SetProviderDisabled() expected error for unknown provider")
+	}
+}
+
+func TestSaveToFile_KeepsDisabledProviders(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+
+	cfg := NewConfig()
+	cfg.Providers["openai"] = &ProviderConfig{ID: "openai", Disable: true, DisableReason: "billing"}
+
+	if err := SaveToFile(cfg, configPath); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+
+	loaded := NewConfig()
+	if err := loadFile(configPath, loaded); err != nil {
+		t.Fatalf("loadFile() error = %v", err)
+	}
+	if p := loaded.Providers["openai"]; p == nil || !p.Disable || p.DisableReason != "billing" {
+		t.Errorf("disabled provider not saved, got %+v", p)
+	}
+}
+
+func TestSaveToFile_Deterministic(t *testing.T) {
+	for _, name := range []string{"config.json", "config.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+
+			cfg := NewConfig()
+			for _, id := range []string{"openai", "anthropic", "groq", "cerebras", "openrouter", "xai", "zai", "venice"} {
+				cfg.Providers[id] = &ProviderConfig{ID: id, APIKey: "$" + strings.ToUpper(id) + "_API_KEY"}
+			}
+			cfg.Models[SelectedModelTypeLarge] = SelectedModel{
+				Model:    "gpt-4o",
+				Provider: "openai",
+				ProviderOptions: map[string]any{
+					"zeta": 1, "alpha": true, "mid": "x", "beta": []any{"b", "a"},
+				},
+			}
+			cfg.Models[SelectedModelTypeSmall] = SelectedModel{Model: "gpt-4o-mini", Provider: "openai"}
+			cfg.Options.TierTemperatures = map[SelectedModelType]float64{
+				SelectedModelTypeSmall: 0, SelectedModelTypeLarge: 0.5,
+			}
+
+			var first []byte
+			for i := range 5 {
+				if err := SaveToFile(cfg, path); err != nil {
+					t.Fatalf("SaveToFile() error = %v", err)
+				}
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("Failed to read config file: %v", err)
+				}
+				if i == 0 {
+					first = data
+					continue
+				}
+				if !bytes.Equal(first, data) {
+					t.Fatalf("save %d differs from the first save:\n%s\n---\n%s", i, first, data)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveToFile_IndentStyle(t *testing.T) {
+	//nolint:govet // Test struct alignment is not critical.
+	tests := []struct {
+		name        string
+		path        string
+		indentStyle string
+		wantLine    string
+	}{
+		{name: "default", path: "config.json", wantLine: "\n  \"models\": {"},
+		{name: "tabs", path: "config.json", indentStyle: IndentTab, wantLine: "\n\t\"models\": {"},
+		{name: "four spaces", path: "config.json", indentStyle: "4", wantLine: "\n    \"models\": {"},
+		{name: "yaml four spaces", path: "config.yaml", indentStyle: "4", wantLine: "\n    large:"},
+		{name: "yaml ignores tabs", path: "config.yaml", indentStyle: IndentTab, wantLine: "\n  large:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.path)
+			cfg := NewConfig()
+			cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "gpt-4o", Provider: "openai"}
+			cfg.Options.IndentStyle = tt.indentStyle
+
+			if err := SaveToFile(cfg, path); err != nil {
+				t.Fatalf("SaveToFile() error = %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read config file: %v", err)
+			}
+			if !strings.Contains(string(data), tt.wantLine) {
+				t.Errorf("saved config = %q, want it to contain %q", data, tt.wantLine)
+			}
+
+			loaded := NewConfig()
+			if err := loadFile(path, loaded); err != nil {
+				t.Fatalf("loadFile() error = %v", err)
+			}
+			if loaded.Models[SelectedModelTypeLarge].Model != "gpt-4o" {
+				t.Errorf("reloaded large model = %q, want %q", loaded.Models[SelectedModelTypeLarge].Model, "gpt-4o")
+			}
+		})
+	}
+}
+
+func TestSaveToFile_InvalidIndentStyle(t *testing.T) {
+	for _, style := range []string{"spaces", "0", "-2", "9"} {
+		t.Run(style, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			cfg := NewConfig()
+			cfg.Options.IndentStyle = style
+
+			if err := SaveToFile(cfg, path); err == nil || !strings.Contains(err.Error(), "indent_style") {
+				t.Errorf("SaveToFile() error = %v, want an indent_style error", err)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Error("an invalid indent_style should not write the file")
+			}
+		})
+	}
+}
+
+func TestSetProviderDisabled_KeepsIndentStyle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{"options": {"indent_style": "tab"}, "providers": {"openai": {"api_key": "$OPENAI_API_KEY"}}}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := SetProviderDisabled(path, "openai", true, ""); err != nil {
+		t.Fatalf("SetProviderDisabled() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read config file: %v", err)
+	}
+	if !strings.Contains(string(data), "\n\t\"options\": {") {
+		t.Errorf("saved config = %q, want tab indentation", data)
+	}
+}
+
+func TestSaveOAuthToken(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+
+	content := `{
+		"models": {"large": {"model": "claude-sonnet-4", "provider": "anthropic"}},
+		"providers": {
+			"anthropic": {
+				"api_key": "old-access",
+				"oauth": {"access_token": "old-access", "refresh_token": "old-refresh", "expires_in": 3600, "expires_at": 1}
+			}
+		}
+	}`
+	//nolint:gosec // Test file, permissions not critical.
+	if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	token := &oauth.Token{AccessToken: "new-access", RefreshToken: "new-refresh", ExpiresIn: 3600, ExpiresAt: 2}
+	if err := SaveOAuthToken(configPath, "anthropic", token); err != nil {
+		t.Fatalf("SaveOAuthToken() error = %v", err)
+	}
+
+	cfg := NewConfig()
+	if err := loadFile(configPath, cfg); err != nil {
+		t.Fatalf("loadFile() error = %v", err)
+	}
+	p := cfg.Providers["anthropic"]
+	if p.OAuthToken == nil || *p.OAuthToken != *token {
+		t.Errorf("OAuthToken = %+v, want %+v", p.OAuthToken, token)
+	}
+	if p.APIKey != "new-access" {
+		t.Errorf("APIKey = %q, want the derived key updated to %q", p.APIKey, "new-access")
+	}
+	if cfg.Models[SelectedModelTypeLarge].Model != "claude-sonnet-4" {
+		t.Error("other settings should be kept")
+	}
+}
+
+func TestSetProvider(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+
+	content := `{
+		"providers": {
+			"openai": {"api_key": "$OPENAI_API_KEY", "base_url": "https://example.com/v1"}
+		},
+		"options": {"debug": true}
+	}`
+	//nolint:gosec // Test file, permissions not critical.
+	if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := SetProvider(configPath, "openai", ProviderUpdate{APIKey: "$OTHER_KEY", SmallModel: "gpt-4o-mini"}); err != nil {
+		t.Fatalf("SetProvider() error = %v", err)
+	}
+
+	cfg := NewConfig()
+	if err := loadFile(configPath, cfg); err != nil {
+		t.Fatalf("loadFile() error = %v", err)
+	}
+	p := cfg.Providers["openai"]
+	if p.APIKey != "$OTHER_KEY" {
+		t.Errorf("APIKey = %q, want the updated template", p.APIKey)
+	}
+	if p.BaseURL != "https://example.com/v1" {
+		t.Errorf("BaseURL = %q, want it preserved", p.BaseURL)
+	}
+	if small := cfg.Models[SelectedModelTypeSmall]; small.Provider != "openai" || small.Model != "gpt-4o-mini" {
+		t.Errorf("small model = %+v, want openai/gpt-4o-mini", small)
+	}
+	if _, ok := cfg.Models[SelectedModelTypeLarge]; ok {
+		t.Error("large model should not be set")
+	}
+	if !cfg.Options.Debug {
+		t.Error("options should be preserved")
+	}
+}
+
+func TestSetProvider_CreatesFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "matrix", "config.json")
+
+	err := SetProvider(configPath, "local", ProviderUpdate{
+		Type:    catwalk.TypeOpenAICompat,
+		BaseURL: "http://localhost:8080/v1",
+	})
+	if err != nil {
+		t.Fatalf("SetProvider() error = %v", err)
+	}
+
+	cfg := NewConfig()
+	if err := loadFile(configPath, cfg); err != nil {
+		t.Fatalf("loadFile() error = %v", err)
+	}
+	if p := cfg.Providers["local"]; p == nil || p.Type != catwalk.TypeOpenAICompat || p.BaseURL != "http://localhost:8080/v1" {
+		t.Errorf("provider = %+v, want the new keyless provider", p)
+	}
+}
+
+func TestSaveToFile_ProviderSettingsRoundTrip(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+
+	cfg := NewConfig()
+	cfg.Providers["proxy"] = &ProviderConfig{
+		ID:                 "proxy",
+		APIKey:             "$PROXY_KEY",
+		AuthHeader:         "X-Proxy-Key",
+		PreserveHeaderCase: true,
+		DefaultThink:       true,
+		MetadataURL:        "https://example.com/providers/proxy.json",
+	}
+
+	if err := SaveToFile(cfg, configPath); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+	loaded := NewConfig()
+	if err := loadFile(configPath, loaded); err != nil {
+		t.Fatalf("loadFile() error = %v", err)
+	}
+
+	got := loaded.Providers["proxy"]
+	if got == nil {
+		t.Fatal("provider not saved")
+	}
+	if got.AuthHeader != "X-Proxy-Key" {
+		t.Errorf("AuthHeader = %q, want %q", got.AuthHeader, "X-Proxy-Key")
+	}
+	if !got.PreserveHeaderCase {
+		t.Error("PreserveHeaderCase = false, want true")
+	}
+	if !got.DefaultThink {
+		t.Error("DefaultThink = false, want true")
+	}
+	if got.MetadataURL != "https://example.com/providers/proxy.json" {
+		t.Errorf("MetadataURL = %q, want it kept", got.MetadataURL)
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import "os"
+
+// SourceKind names a layer of the config precedence chain.
+type SourceKind string
+
+// Config layers, from lowest to highest precedence.
+const (
+	// SourceGlobal is the config file in the user's config directory.
+	SourceGlobal SourceKind = "global"
+	// SourceProject is the matrix.json or .matrix.json found in the current
+	// directory or one of its parents.
+	SourceProject SourceKind = "project"
+	// SourceEnv is an environment variable overriding the config files.
+	SourceEnv SourceKind = "env"
+	// SourceFile is a config file loaded explicitly, e.g. with --config,
+	// in place of the global and project files.
+	SourceFile SourceKind = "file"
+)
+
+// overrideEnvVars lists the environment variables that take precedence over
+// the config files.
+var overrideEnvVars = []string{readOnlyEnv, "CATWALK_URL"}
+
+// Source is one layer of the config precedence chain.
+type Source struct {
+	Kind SourceKind
+	// Path is the config file, or the variable name for SourceEnv. It is
+	// empty for a project layer when no project config was found.
+	Path string
+	// Value is the variable's value for SourceEnv.
+	Value string
+	// Active reports whether the layer applies: the file exists or the
+	// variable is set.
+	Active bool
+}
+
+// SourceChain returns the config layers for the current directory, lowest
+// precedence first: the global file, the project file, then the environment
+// overrides. Layers that don't apply are included with Active false, so
+// callers can show where a file would be picked up.
+func SourceChain() []Source {
+	global := GlobalConfigPath()
+	_, err := os.Stat(global)
+	chain := []Source{{Kind: SourceGlobal, Path: global, Active: err == nil}}
+
+	project := findProjectConfig()
+	chain = append(chain, Source{Kind: SourceProject, Path: project, Active: project != ""})
+
+	for _, name := range overrideEnvVars {
+		value, ok := os.LookupEnv(name)
+		chain = append(chain, Source{Kind: SourceEnv, Path: name, Value: value, Active: ok && value != ""})
+	}
+	return chain
+}
+
+// Sources returns the config files and environment overrides that
+// contributed to c, lowest precedence first.
+func (c *Config) Sources() []Source {
+	return c.sources
+}
+
+// addSource records a layer that contributed to the config.
+func (c *Config) addSource(kind SourceKind, path string) {
+	c.sources = append(c.sources, Source{Kind: kind, Path: path, Active: true})
+}
+
+// addEnvSources records the environment overrides that are set.
+func (c *Config) addEnvSources() {
+	for _, name := range overrideEnvVars {
+		if value := os.Getenv(name); value != "" {
+			c.sources = append(c.sources, Source{Kind: SourceEnv, Path: name, Value: value, Active: true})
+		}
+	}
+}
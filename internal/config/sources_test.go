@@ -0,0 +1,108 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adrg/xdg"
+)
+
+// setupSourceDirs writes a global config and a project config, and changes
+// into a directory nested below the project. It returns both file paths.
+func setupSourceDirs(t *testing.T) (globalPath, projectPath string) {
+	t.Helper()
+	// Resolve symlinks so paths match the working directory.
+	tempDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("EvalSymlinks() error = %v", err)
+	}
+
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+	t.Setenv("CATWALK_URL", "http://invalid.invalid.invalid")
+	t.Setenv(readOnlyEnv, "")
+	xdg.Reload()
+
+	globalPath = GlobalConfigPath()
+	global := `{
+		"providers": {"local": {"type": "openai-compat", "base_url": "http://localhost:11434/v1"}},
+		"models": {
+			"large": {"model": "llama3", "provider": "local"},
+			"small": {"model": "llama3", "provider": "local"}
+		}
+	}`
+	if err := os.MkdirAll(filepath.Dir(globalPath), 0o750); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(globalPath, []byte(global), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	repo := filepath.Join(tempDir, "repo")
+	nested := filepath.Join(repo, "pkg", "sub")
+	if err := os.MkdirAll(nested, 0o750); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	projectPath = filepath.Join(repo, configFileName)
+	if err := os.WriteFile(projectPath, []byte(`{"options": {"debug": true}}`), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	t.Chdir(nested)
+	return globalPath, projectPath
+}
+
+func TestSourceChain(t *testing.T) {
+	globalPath, projectPath := setupSourceDirs(t)
+
+	chain := SourceChain()
+	want := []Source{
+		{Kind: SourceGlobal, Path: globalPath, Active: true},
+		{Kind: SourceProject, Path: projectPath, Active: true},
+		{Kind: SourceEnv, Path: readOnlyEnv},
+		{Kind: SourceEnv, Path: "CATWALK_URL", Value: "http://invalid.invalid.invalid", Active: true},
+	}
+	if len(chain) != len(want) {
+		t.Fatalf("SourceChain() = %+v, want %+v", chain, want)
+	}
+	for i := range want {
+		if chain[i] != want[i] {
+			t.Errorf("SourceChain()[%d] = %+v, want %+v", i, chain[i], want[i])
+		}
+	}
+}
+
+func TestSourceChain_NoProject(t *testing.T) {
+	setupSourceDirs(t)
+	t.Chdir(t.TempDir())
+
+	chain := SourceChain()
+	if project := chain[1]; project.Kind != SourceProject || project.Active || project.Path != "" {
+		t.Errorf("project source = %+v, want an inactive project layer", project)
+	}
+}
+
+func TestLoad_RecordsSources(t *testing.T) {
+	globalPath, projectPath := setupSourceDirs(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := []Source{
+		{Kind: SourceGlobal, Path: globalPath, Active: true},
+		{Kind: SourceProject, Path: projectPath, Active: true},
+		{Kind: SourceEnv, Path: "CATWALK_URL", Value: "http://invalid.invalid.invalid", Active: true},
+	}
+	got := cfg.Sources()
+	if len(got) != len(want) {
+		t.Fatalf("Sources() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Sources()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
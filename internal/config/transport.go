@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// fetchTimeout bounds a whole provider metadata request, so an unresponsive
+// catwalk or metadata_url can't stall startup.
+const fetchTimeout = 30 * time.Second
+
+// NewTransport returns a clone of the default transport honoring the proxy
+// and request timeout in opts, which may be nil. Without a configured proxy
+// the standard proxy environment variables apply.
+func NewTransport(opts *Options) (*http.Transport, error) {
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("unexpected default transport %T", http.DefaultTransport)
+	}
+	transport = transport.Clone()
+
+	if opts == nil {
+		return transport, nil
+	}
+	if opts.Proxy != "" {
+		proxy, err := NewResolver().Resolve(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("resolving proxy: %w", err)
+		}
+		proxyURL, err := url.Parse(proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if opts.RequestTimeout > 0 {
+		transport.ResponseHeaderTimeout = time.Duration(opts.RequestTimeout) * time.Second
+	}
+	return transport, nil
+}
+
+// fetchClient returns the client used to fetch provider metadata and remote
+// configs. Unlike provider clients it has an overall timeout, since these
+// responses are small and never streamed.
+func fetchClient(opts *Options) (*http.Client, error) {
+	transport, err := NewTransport(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport, Timeout: fetchTimeout}, nil
+}
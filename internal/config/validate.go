@@ -0,0 +1,73 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Validate checks that every selected tier can be used: its provider is
+// configured and enabled, has an API key that resolves to a value (or needs
+// none), and still offers the selected model. Every tier is checked, and all
+// problems are reported together. Load validates the config it returns.
+func (c *Config) Validate() error {
+	var errs []error
+	for _, tier := range slices.Sorted(maps.Keys(c.Models)) {
+		if err := c.validateTier(tier); err != nil {
+			errs = append(errs, fmt.Errorf("tier %s: %w", tier, err))
+		}
+	}
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return fmt.Errorf("%d problems with model selection:\n%w", len(errs), errors.Join(errs...))
+	}
+}
+
+// ValidateTier checks one tier's model selection as Validate does. It fails
+// when the tier has no model selected.
+func (c *Config) ValidateTier(tier SelectedModelType) error {
+	if _, ok := c.Models[tier]; !ok {
+		return fmt.Errorf("no %s model selected", tier)
+	}
+	return c.validateTier(tier)
+}
+
+// validateTier checks a single tier's model selection.
+func (c *Config) validateTier(tier SelectedModelType) error {
+	model := c.Models[tier]
+	provider, ok := c.Providers[model.Provider]
+	if !ok {
+		return fmt.Errorf("provider %q not configured", model.Provider)
+	}
+	if provider.Disable {
+		return fmt.Errorf("provider %q is %s", model.Provider, provider.DisabledDescription())
+	}
+	if !provider.HasCredentials() {
+		return fmt.Errorf("provider %q has no API key configured", model.Provider)
+	}
+	if provider.APIKey != "" && !provider.keyResolved {
+		apiKey, err := c.Resolve(provider.APIKey)
+		if err != nil {
+			return fmt.Errorf("provider %q: resolving API key: %w", model.Provider, err)
+		}
+		if apiKey == "" {
+			return fmt.Errorf("provider %q has no API key configured", model.Provider)
+		}
+	}
+
+	fullID, err := c.ResolveModelID(model.Provider, model.Model)
+	if err != nil {
+		return err
+	}
+	// Providers without model metadata accept any ID.
+	if len(provider.Models) > 0 && c.GetModel(model.Provider, fullID) == nil {
+		return fmt.Errorf("model %q is no longer offered by provider %q", model.Model, model.Provider)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adrg/xdg"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	//nolint:govet // Test struct alignment is not critical.
+	tests := []struct {
+		name    string
+		setup   func(*Config)
+		wantErr []string
+	}{
+		{
+			name: "valid config",
+			setup: func(cfg *Config) {
+				cfg.Providers["openai"] = &ProviderConfig{ID: "openai", APIKey: "key"}
+				cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "gpt-4o", Provider: "openai"}
+			},
+		},
+		{
+			name:  "empty config",
+			setup: func(_ *Config) {},
+		},
+		{
+			name: "keyless local provider",
+			setup: func(cfg *Config) {
+				cfg.Providers["local"] = &ProviderConfig{
+					ID:      "local",
+					Type:    catwalk.TypeOpenAICompat,
+					BaseURL: "http://localhost:11434/v1",
+				}
+				cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "llama3", Provider: "local"}
+			},
+		},
+		{
+			name: "unknown provider",
+			setup: func(cfg *Config) {
+				cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "gpt-4o", Provider: "unknown"}
+			},
+			wantErr: []string{`tier large: provider "unknown" not configured`},
+		},
+		{
+			name: "disabled provider",
+			setup: func(cfg *Config) {
+				cfg.Providers["openai"] = &ProviderConfig{ID: "openai", APIKey: "key", Disable: true, DisableReason: "quota exceeded"}
+				cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "gpt-4o", Provider: "openai"}
+			},
+			wantErr: []string{`provider "openai" is disabled: quota exceeded`},
+		},
+		{
+			name: "missing key",
+			setup: func(cfg *Config) {
+				cfg.Providers["anthropic"] = &ProviderConfig{ID: "anthropic"}
+				cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "claude", Provider: "anthropic"}
+			},
+			wantErr: []string{`tier large: provider "anthropic" has no API key configured`},
+		},
+		{
+			name: "key resolving to empty",
+			setup: func(cfg *Config) {
+				cfg.Providers["local"] = &ProviderConfig{ID: "local", APIKey: "$MATRIX_TEST_EMPTY_KEY"}
+				cfg.Models[SelectedModelTypeSmall] = SelectedModel{Model: "llama", Provider: "local"}
+			},
+			wantErr: []string{`tier small: provider "local" has no API key configured`},
+		},
+		{
+			name: "unresolvable key",
+			setup: func(cfg *Config) {
+				cfg.Providers["local"] = &ProviderConfig{ID: "local", APIKey: "${MATRIX_TEST_UNDEFINED_KEY}"}
+				cfg.Models[SelectedModelTypeSmall] = SelectedModel{Model: "llama", Provider: "local"}
+			},
+			wantErr: []string{`tier small: provider "local": resolving API key`},
+		},
+		{
+			name: "ambiguous model",
+			setup: func(cfg *Config) {
+				cfg.Providers["openai"] = &ProviderConfig{
+					ID:     "openai",
+					APIKey: "key",
+					Models: []catwalk.Model{{ID: "gpt-4o-2024-08-06"}, {ID: "gpt-4o-mini"}},
+				}
+				cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "gpt-4o", Provider: "openai"}
+			},
+			wantErr: []string{"tier large"},
+		},
+		{
+			name: "all problems reported together",
+			setup: func(cfg *Config) {
+				cfg.Providers["openai"] = &ProviderConfig{
+					ID:     "openai",
+					APIKey: "key",
+					Models: []catwalk.Model{{ID: "gpt-4o"}},
+				}
+				cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "claude", Provider: "missing"}
+				cfg.Models[SelectedModelTypeSmall] = SelectedModel{Model: "gpt-3.5-turbo", Provider: "openai"}
+			},
+			wantErr: []string{
+				"2 problems with model selection",
+				`tier large: provider "missing" not configured`,
+				`tier small: model "gpt-3.5-turbo" is no longer offered by provider "openai"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MATRIX_TEST_EMPTY_KEY", "")
+			cfg := NewConfig()
+			tt.setup(cfg)
+
+			err := cfg.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Validate() expected an error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() error = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_DoesNotExpandModels(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Providers["openai"] = &ProviderConfig{
+		ID:     "openai",
+		APIKey: "key",
+		Models: []catwalk.Model{{ID: "gpt-4o-2024-08-06"}},
+	}
+	cfg.Models[SelectedModelTypeLarge] = SelectedModel{Model: "gpt-4o-2024", Provider: "openai"}
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if got := cfg.Models[SelectedModelTypeLarge].Model; got != "gpt-4o-2024" {
+		t.Errorf("Large model = %q, want the selection left as written", got)
+	}
+}
+
+func TestLoad_ValidatesModelSelection(t *testing.T) {
+	tempDir := t.TempDir()
+
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+	t.Setenv("CATWALK_URL", "http://invalid.invalid.invalid")
+	xdg.Reload()
+	t.Chdir(tempDir)
+
+	// The large tier's key is a literal written as "$$", so Load resolves it
+	// to "$MATRIX_TEST_LITERAL"; Validate must not resolve it again.
+	content := `{
+  "providers": {
+    "local": {"type": "openai-compat", "base_url": "http://localhost:11434/v1", "api_key": "$$MATRIX_TEST_LITERAL"},
+    "other": {"type": "openai-compat", "base_url": "http://localhost:8080/v1", "api_key": "key", "disable": true}
+  },
+  "models": {
+    "large": {"provider": "local", "model": "llama3"},
+    "small": {"provider": "other", "model": "llama3"}
+  }
+}`
+	path := GlobalConfigPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Load() expected an error for the disabled small tier provider")
+	}
+	if !strings.Contains(err.Error(), `tier small: provider "other" is disabled`) {
+		t.Errorf("Load() error = %q, want the small tier problem", err)
+	}
+	if strings.Contains(err.Error(), "tier large") {
+		t.Errorf("Load() error = %q, the resolved large tier key should be accepted", err)
+	}
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// fileStamp is the state of a watched file used to detect changes.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+// Watcher detects changes to config files by comparing their modification
+// time and size between checks. Files that don't exist yet are watched too,
+// so creating one counts as a change.
+type Watcher struct {
+	stamps map[string]fileStamp
+	paths  []string
+}
+
+// NewWatcher creates a Watcher for paths, recording their current state.
+func NewWatcher(paths ...string) *Watcher {
+	w := &Watcher{paths: paths, stamps: make(map[string]fileStamp, len(paths))}
+	for _, path := range paths {
+		w.stamps[path] = statFile(path)
+	}
+	return w
+}
+
+// Paths returns the watched file paths.
+func (w *Watcher) Paths() []string {
+	return w.paths
+}
+
+// Changed reports whether any watched file changed since the previous call
+// or since the Watcher was created.
+func (w *Watcher) Changed() bool {
+	changed := false
+	for _, path := range w.paths {
+		stamp := statFile(path)
+		if stamp != w.stamps[path] {
+			w.stamps[path] = stamp
+			changed = true
+		}
+	}
+	return changed
+}
+
+func statFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size(), exists: true}
+}
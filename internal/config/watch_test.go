@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcher_Changed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matrix.json")
+	w := NewWatcher(path)
+
+	if w.Changed() {
+		t.Error("Changed() = true before the file exists")
+	}
+
+	//nolint:gosec // Test file, permissions not critical.
+	if err := os.WriteFile(path, []byte(`{}`), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if !w.Changed() {
+		t.Error("Changed() = false after the file was created")
+	}
+	if w.Changed() {
+		t.Error("Changed() = true without a further change")
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+	if !w.Changed() {
+		t.Error("Changed() = false after the file was modified")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if !w.Changed() {
+		t.Error("Changed() = false after the file was removed")
+	}
+}
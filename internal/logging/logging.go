@@ -0,0 +1,62 @@
+// Package logging routes the application log to a file.
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+// Open opens the log file at path according to mode and makes it the
+// destination of the default slog logger, at debug level when debug is set
+// and info level otherwise. The returned function restores the previous
+// logger and closes the file.
+func Open(path string, mode config.LogMode, debug bool) (func() error, error) {
+	flags := os.O_CREATE | os.O_WRONLY
+	switch mode {
+	case "", config.LogModeAppend:
+		flags |= os.O_APPEND
+	case config.LogModeTruncate:
+		flags |= os.O_TRUNC
+	case config.LogModeRotate:
+		if err := rotate(path); err != nil {
+			return nil, err
+		}
+		flags |= os.O_TRUNC
+	default:
+		return nil, fmt.Errorf("unknown log mode %q (want %q, %q or %q)",
+			mode, config.LogModeAppend, config.LogModeTruncate, config.LogModeRotate)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("creating log directory: %w", err)
+	}
+	f, err := os.OpenFile(path, flags, 0o600) //nolint:gosec // Path is chosen by the user.
+	if err != nil {
+		return nil, fmt.Errorf("opening log file: %w", err)
+	}
+
+	level := slog.LevelInfo
+	if debug {
+		level = slog.LevelDebug
+	}
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(f, &slog.HandlerOptions{Level: level})))
+
+	return func() error {
+		slog.SetDefault(previous)
+		return f.Close()
+	}, nil
+}
+
+// rotate moves the log at path to path.1, replacing an older rotated log.
+// A missing log is not an error.
+func rotate(path string) error {
+	if err := os.Rename(path, path+".1"); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("rotating log file: %w", err)
+	}
+	return nil
+}
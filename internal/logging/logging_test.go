@@ -0,0 +1,132 @@
+package logging
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+	"github.com/guilhermegouw/matrix-cli/internal/tui/util"
+)
+
+// readLog returns the content of the log at path.
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path) //nolint:gosec // Test file path.
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	return string(data)
+}
+
+func TestOpen_Level(t *testing.T) {
+	tests := []struct {
+		name      string
+		debug     bool
+		wantDebug bool
+	}{
+		{name: "info by default", debug: false, wantDebug: false},
+		{name: "debug", debug: true, wantDebug: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "logs", "matrix.log")
+
+			closeLog, err := Open(path, "", tt.debug)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			slog.Debug("debug line")
+			slog.Info("info line")
+			util.ReportError(errors.New("reported failure"))
+			if err := closeLog(); err != nil {
+				t.Fatalf("close error = %v", err)
+			}
+
+			out := readLog(t, path)
+			if !strings.Contains(out, "level=INFO msg=\"info line\"") {
+				t.Errorf("log = %q, want the info line", out)
+			}
+			if !strings.Contains(out, "level=ERROR") || !strings.Contains(out, "reported failure") {
+				t.Errorf("log = %q, want the reported error", out)
+			}
+			if got := strings.Contains(out, "debug line"); got != tt.wantDebug {
+				t.Errorf("log has debug line = %v, want %v", got, tt.wantDebug)
+			}
+		})
+	}
+}
+
+func TestOpen_RestoresLogger(t *testing.T) {
+	previous := slog.Default()
+	closeLog, err := Open(filepath.Join(t.TempDir(), "matrix.log"), "", false)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if slog.Default() == previous {
+		t.Error("Open() did not replace the default logger")
+	}
+	if err := closeLog(); err != nil {
+		t.Fatalf("close error = %v", err)
+	}
+	if slog.Default() != previous {
+		t.Error("closing did not restore the default logger")
+	}
+}
+
+func TestOpen_Modes(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        config.LogMode
+		wantPrev    bool
+		wantRotated bool
+	}{
+		{name: "append", mode: config.LogModeAppend, wantPrev: true},
+		{name: "truncate", mode: config.LogModeTruncate},
+		{name: "rotate", mode: config.LogModeRotate, wantRotated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "matrix.log")
+			if err := os.WriteFile(path, []byte("previous run\n"), 0o600); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+
+			closeLog, err := Open(path, tt.mode, false)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			slog.Info("this run")
+			if err := closeLog(); err != nil {
+				t.Fatalf("close error = %v", err)
+			}
+
+			out := readLog(t, path)
+			if !strings.Contains(out, "this run") {
+				t.Errorf("log = %q, want this run's line", out)
+			}
+			if got := strings.Contains(out, "previous run"); got != tt.wantPrev {
+				t.Errorf("log keeps previous run = %v, want %v", got, tt.wantPrev)
+			}
+			_, err = os.Stat(path + ".1")
+			if got := err == nil; got != tt.wantRotated {
+				t.Errorf("rotated log exists = %v, want %v", got, tt.wantRotated)
+			}
+			if tt.wantRotated && readLog(t, path+".1") != "previous run\n" {
+				t.Error("rotated log should hold the previous run")
+			}
+		})
+	}
+}
+
+func TestOpen_UnknownMode(t *testing.T) {
+	_, err := Open(filepath.Join(t.TempDir(), "matrix.log"), "weekly", false)
+	if err == nil || !strings.Contains(err.Error(), `unknown log mode "weekly"`) {
+		t.Errorf("Open() error = %v, want an unknown mode error", err)
+	}
+}
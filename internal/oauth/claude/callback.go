@@ -0,0 +1,121 @@
+package claude
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultCallbackPorts are tried in order when starting the local callback
+// server, so one port already in use doesn't force a manual code paste.
+var DefaultCallbackPorts = []int{54545, 54546, 54547}
+
+// callbackPath is the path the authorization server redirects to.
+const callbackPath = "/callback"
+
+// callbackHost is the loopback address the server binds and the redirect
+// URI names. Using the IP rather than "localhost" keeps the two in step
+// where localhost resolves to ::1 first.
+const callbackHost = "127.0.0.1"
+
+// CallbackServer receives the authorization code on a local redirect URI.
+type CallbackServer struct {
+	listener net.Listener
+	server   *http.Server
+	results  chan callbackResult
+}
+
+type callbackResult struct {
+	err  error
+	code string
+}
+
+// ListenCallback starts a callback server on the first of ports that can be
+// bound on the loopback address. It returns an error if every port is in use.
+func ListenCallback(ports []int) (*CallbackServer, error) {
+	var errs []error
+	for _, port := range ports {
+		lc := net.ListenConfig{}
+		l, err := lc.Listen(context.Background(), "tcp", net.JoinHostPort(callbackHost, strconv.Itoa(port)))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return newCallbackServer(l), nil
+	}
+	return nil, fmt.Errorf("no free callback port: %w", errors.Join(errs...))
+}
+
+func newCallbackServer(l net.Listener) *CallbackServer {
+	s := &CallbackServer{
+		listener: l,
+		results:  make(chan callbackResult, 1),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, s.handleCallback)
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go s.server.Serve(l) //nolint:errcheck // Serve returns ErrServerClosed on Close.
+	return s
+}
+
+// handleCallback delivers the code in the "code#state" form ExchangeToken expects.
+func (s *CallbackServer) handleCallback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var result callbackResult
+	switch {
+	case q.Get("error") != "":
+		result.err = fmt.Errorf("authorization failed: %s", q.Get("error"))
+	case q.Get("code") == "":
+		result.err = errors.New("authorization callback is missing the code")
+	default:
+		result.code = q.Get("code") + "#" + q.Get("state")
+	}
+
+	select {
+	case s.results <- result:
+	default:
+		// A result was already delivered; ignore repeated callbacks.
+	}
+
+	if result.err != nil {
+		http.Error(w, result.err.Error(), http.StatusBadRequest)
+		return
+	}
+	fmt.Fprintln(w, "Authorization complete. You can close this window and return to Matrix.")
+}
+
+// Port returns the port the server is bound to.
+func (s *CallbackServer) Port() int {
+	addr, ok := s.listener.Addr().(*net.TCPAddr)
+	if !ok {
+		return 0
+	}
+	return addr.Port
+}
+
+// RedirectURI returns the redirect URI for the bound port.
+func (s *CallbackServer) RedirectURI() string {
+	return "http://" + net.JoinHostPort(callbackHost, strconv.Itoa(s.Port())) + callbackPath
+}
+
+// Wait blocks until the authorization code arrives or ctx is done.
+func (s *CallbackServer) Wait(ctx context.Context) (string, error) {
+	select {
+	case r := <-s.results:
+		return r.code, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Close stops the server.
+func (s *CallbackServer) Close() error {
+	return s.server.Close()
+}
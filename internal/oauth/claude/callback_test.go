@@ -0,0 +1,117 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// occupiedPort returns a port held by a listener for the rest of the test.
+func occupiedPort(t *testing.T) int {
+	t.Helper()
+	lc := net.ListenConfig{}
+	l, err := lc.Listen(context.Background(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { l.Close() })     //nolint:errcheck,gosec // Test cleanup.
+	return l.Addr().(*net.TCPAddr).Port //nolint:errcheck // Always TCP.
+}
+
+// freePort returns a port that was free a moment ago.
+func freePort(t *testing.T) int {
+	t.Helper()
+	lc := net.ListenConfig{}
+	l, err := lc.Listen(context.Background(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port //nolint:errcheck // Always TCP.
+	l.Close()                            //nolint:errcheck,gosec // Released for the test to bind.
+	return port
+}
+
+func TestListenCallback_SkipsOccupiedPort(t *testing.T) {
+	busy := occupiedPort(t)
+	free := freePort(t)
+
+	s, err := ListenCallback([]int{busy, free})
+	if err != nil {
+		t.Fatalf("ListenCallback() error = %v", err)
+	}
+	defer s.Close() //nolint:errcheck
+
+	if s.Port() != free {
+		t.Errorf("Port() = %d, want %d", s.Port(), free)
+	}
+	wantURI := fmt.Sprintf("http://127.0.0.1:%d/callback", free)
+	if s.RedirectURI() != wantURI {
+		t.Errorf("RedirectURI() = %q, want %q", s.RedirectURI(), wantURI)
+	}
+
+	authURL, err := AuthorizeURLWithRedirect("verifier", "challenge", s.RedirectURI())
+	if err != nil {
+		t.Fatalf("AuthorizeURLWithRedirect() error = %v", err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("Failed to parse auth URL: %v", err)
+	}
+	if got := parsed.Query().Get("redirect_uri"); got != wantURI {
+		t.Errorf("redirect_uri = %q, want %q", got, wantURI)
+	}
+}
+
+func TestListenCallback_AllPortsOccupied(t *testing.T) {
+	if _, err := ListenCallback([]int{occupiedPort(t), occupiedPort(t), occupiedPort(t)}); err == nil {
+		t.Error("ListenCallback() should fail when every port is in use")
+	}
+}
+
+func TestCallbackServer_Wait(t *testing.T) {
+	s, err := ListenCallback([]int{freePort(t)})
+	if err != nil {
+		t.Fatalf("ListenCallback() error = %v", err)
+	}
+	defer s.Close() //nolint:errcheck
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.RedirectURI()+"?code=abc&state=xyz", http.NoBody)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("callback request error = %v", err)
+	}
+	resp.Body.Close() //nolint:errcheck,gosec // Test cleanup.
+
+	code, err := s.Wait(ctx)
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if code != "abc#xyz" {
+		t.Errorf("Wait() = %q, want %q", code, "abc#xyz")
+	}
+}
+
+func TestCallbackServer_WaitCanceled(t *testing.T) {
+	s, err := ListenCallback([]int{freePort(t)})
+	if err != nil {
+		t.Fatalf("ListenCallback() error = %v", err)
+	}
+	defer s.Close() //nolint:errcheck
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := s.Wait(ctx); err == nil {
+		t.Error("Wait() should return the context error")
+	}
+}
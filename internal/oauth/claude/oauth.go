@@ -16,16 +16,71 @@ import (
 
 const clientID = "9d1c250a-e61b-44d9-88ed-5944d1962f5e"
 
+const (
+	// AuthorizeEndpoint is the Claude OAuth2 authorization endpoint.
+	AuthorizeEndpoint = "https://claude.ai/oauth/authorize"
+	// TokenURL is the Claude OAuth2 token endpoint.
+	TokenURL = "https://console.anthropic.com/v1/oauth/token"
+)
+
+// endpoints holds the OAuth2 server settings a request uses.
+type endpoints struct {
+	authorizeURL string
+	tokenURL     string
+	clientID     string
+}
+
+// Option overrides an OAuth2 server setting, e.g. to target a test server.
+type Option func(*endpoints)
+
+// WithAuthorizeURL sets the authorization endpoint.
+func WithAuthorizeURL(u string) Option {
+	return func(e *endpoints) { e.authorizeURL = u }
+}
+
+// WithTokenURL sets the token endpoint used to exchange and refresh tokens.
+func WithTokenURL(u string) Option {
+	return func(e *endpoints) { e.tokenURL = u }
+}
+
+// WithClientID sets the OAuth2 client ID.
+func WithClientID(id string) Option {
+	return func(e *endpoints) { e.clientID = id }
+}
+
+// resolveEndpoints applies opts over the production settings.
+func resolveEndpoints(opts []Option) endpoints {
+	e := endpoints{
+		authorizeURL: AuthorizeEndpoint,
+		tokenURL:     TokenURL,
+		clientID:     clientID,
+	}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	return e
+}
+
+// ConsoleRedirectURI is the redirect URI used when the code is pasted manually.
+const ConsoleRedirectURI = "https://console.anthropic.com/oauth/code/callback"
+
 // AuthorizeURL returns the Claude OAuth2 authorization URL.
-func AuthorizeURL(verifier, challenge string) (string, error) {
-	u, err := url.Parse("https://claude.ai/oauth/authorize")
+func AuthorizeURL(verifier, challenge string, opts ...Option) (string, error) {
+	return AuthorizeURLWithRedirect(verifier, challenge, ConsoleRedirectURI, opts...)
+}
+
+// AuthorizeURLWithRedirect returns the authorization URL for a specific
+// redirect URI, such as a CallbackServer's.
+func AuthorizeURLWithRedirect(verifier, challenge, redirectURI string, opts ...Option) (string, error) {
+	e := resolveEndpoints(opts)
+	u, err := url.Parse(e.authorizeURL)
 	if err != nil {
 		return "", err
 	}
 	q := u.Query()
 	q.Set("response_type", "code")
-	q.Set("client_id", clientID)
-	q.Set("redirect_uri", "https://console.anthropic.com/oauth/code/callback")
+	q.Set("client_id", e.clientID)
+	q.Set("redirect_uri", redirectURI)
 	q.Set("scope", "org:create_api_key user:profile user:inference")
 	q.Set("code_challenge", challenge)
 	q.Set("code_challenge_method", "S256")
@@ -35,7 +90,14 @@ func AuthorizeURL(verifier, challenge string) (string, error) {
 }
 
 // ExchangeToken exchanges the authorization code for an OAuth2 token.
-func ExchangeToken(ctx context.Context, code, verifier string) (*oauth.Token, error) {
+func ExchangeToken(ctx context.Context, code, verifier string, opts ...Option) (*oauth.Token, error) {
+	return ExchangeTokenWithRedirect(ctx, code, verifier, ConsoleRedirectURI, opts...)
+}
+
+// ExchangeTokenWithRedirect exchanges a code obtained with the given redirect
+// URI, which must match the one in the authorization URL.
+func ExchangeTokenWithRedirect(ctx context.Context, code, verifier, redirectURI string, opts ...Option) (*oauth.Token, error) {
+	e := resolveEndpoints(opts)
 	code = strings.TrimSpace(code)
 	parts := strings.SplitN(code, "#", 2)
 	pure := parts[0]
@@ -48,12 +110,12 @@ func ExchangeToken(ctx context.Context, code, verifier string) (*oauth.Token, er
 		"code":          pure,
 		"state":         state,
 		"grant_type":    "authorization_code",
-		"client_id":     clientID,
-		"redirect_uri":  "https://console.anthropic.com/oauth/code/callback",
+		"client_id":     e.clientID,
+		"redirect_uri":  redirectURI,
 		"code_verifier": verifier,
 	}
 
-	resp, err := request(ctx, "https://console.anthropic.com/v1/oauth/token", reqBody)
+	resp, err := request(ctx, e.tokenURL, reqBody)
 	if err != nil {
 		return nil, err
 	}
@@ -77,14 +139,15 @@ func ExchangeToken(ctx context.Context, code, verifier string) (*oauth.Token, er
 }
 
 // RefreshToken refreshes the OAuth2 token using the provided refresh token.
-func RefreshToken(ctx context.Context, refreshToken string) (*oauth.Token, error) {
+func RefreshToken(ctx context.Context, refreshToken string, opts ...Option) (*oauth.Token, error) {
+	e := resolveEndpoints(opts)
 	reqBody := map[string]string{
 		"grant_type":    "refresh_token",
 		"refresh_token": refreshToken,
-		"client_id":     clientID,
+		"client_id":     e.clientID,
 	}
 
-	resp, err := request(ctx, "https://console.anthropic.com/v1/oauth/token", reqBody)
+	resp, err := request(ctx, e.tokenURL, reqBody)
 	if err != nil {
 		return nil, err
 	}
@@ -107,6 +107,9 @@ func TestExchangeToken_Success(t *testing.T) {
 		if body["client_id"] != clientID {
 			t.Errorf("Expected client_id=%s, got %s", clientID, body["client_id"])
 		}
+		if body["code"] != "test-code" || body["state"] != "test-state" || body["code_verifier"] != "test-verifier" {
+			t.Errorf("Expected code, state and verifier from the pasted code, got %v", body)
+		}
 
 		// Return mock token response.
 		response := map[string]any{
@@ -121,9 +124,47 @@ func TestExchangeToken_Success(t *testing.T) {
 	}))
 	defer server.Close()
 
-	// We can't easily test the real endpoint, so this tests the response parsing.
-	// The actual HTTP call is tested indirectly through integration tests.
-	t.Skip("Skipping: ExchangeToken uses hardcoded URL, test verifies parsing logic")
+	token, err := ExchangeToken(context.Background(), "test-code#test-state", "test-verifier", WithTokenURL(server.URL))
+	if err != nil {
+		t.Fatalf("ExchangeToken() error = %v", err)
+	}
+	if token.AccessToken != "mock-access-token" || token.RefreshToken != "mock-refresh-token" {
+		t.Errorf("token = %+v, want the mock tokens", token)
+	}
+	if token.ExpiresAt == 0 {
+		t.Error("ExpiresAt should be set")
+	}
+}
+
+func TestExchangeToken_Failure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	_, err := ExchangeToken(context.Background(), "bad-code", "test-verifier", WithTokenURL(server.URL))
+	if err == nil || !strings.Contains(err.Error(), "status 400") {
+		t.Errorf("ExchangeToken() error = %v, want a status 400 error", err)
+	}
+}
+
+func TestAuthorizeURL_Options(t *testing.T) {
+	authURL, err := AuthorizeURL("verifier", "challenge",
+		WithAuthorizeURL("http://127.0.0.1:9999/authorize"), WithClientID("test-client"))
+	if err != nil {
+		t.Fatalf("AuthorizeURL() error = %v", err)
+	}
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("Failed to parse auth URL: %v", err)
+	}
+	if parsed.Host != "127.0.0.1:9999" || parsed.Path != "/authorize" {
+		t.Errorf("auth URL = %s, want the overridden endpoint", authURL)
+	}
+	if got := parsed.Query().Get("client_id"); got != "test-client" {
+		t.Errorf("client_id = %q, want %q", got, "test-client")
+	}
 }
 
 func TestExchangeToken_CodeParsing(t *testing.T) {
@@ -181,8 +222,39 @@ func TestExchangeToken_CodeParsing(t *testing.T) {
 }
 
 func TestRefreshToken_Success(t *testing.T) {
-	// Similar to ExchangeToken, we can't easily test with hardcoded URL.
-	t.Skip("Skipping: RefreshToken uses hardcoded URL, test verifies parsing logic")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding request body: %v", err)
+		}
+		if body["grant_type"] != "refresh_token" || body["refresh_token"] != "old-refresh" {
+			t.Errorf("request body = %v, want a refresh_token grant for old-refresh", body)
+		}
+		_, _ = w.Write([]byte(`{"access_token":"new-access","refresh_token":"new-refresh","expires_in":3600}`)) //nolint:errcheck // Test server.
+	}))
+	defer server.Close()
+
+	token, err := RefreshToken(context.Background(), "old-refresh", WithTokenURL(server.URL))
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	if token.AccessToken != "new-access" || token.RefreshToken != "new-refresh" {
+		t.Errorf("token = %+v, want the refreshed tokens", token)
+	}
+	if token.ExpiresAt == 0 {
+		t.Error("ExpiresAt should be set")
+	}
+}
+
+func TestRefreshToken_Failure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	if _, err := RefreshToken(context.Background(), "revoked", WithTokenURL(server.URL)); err == nil {
+		t.Error("RefreshToken() should fail on a non-200 response")
+	}
 }
 
 func TestRequest_Headers(t *testing.T) {
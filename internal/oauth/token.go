@@ -13,6 +13,10 @@ type Token struct {
 	ExpiresAt    int64  `json:"expires_at"`
 }
 
+// RefreshWindow is how long after expiry a refresh token is still expected
+// to be accepted. Tokens expired for longer need a new sign-in.
+const RefreshWindow = 30 * 24 * time.Hour
+
 // SetExpiresAt calculates and sets the ExpiresAt field based on the current time and ExpiresIn.
 func (t *Token) SetExpiresAt() {
 	t.ExpiresAt = time.Now().Add(time.Duration(t.ExpiresIn) * time.Second).Unix()
@@ -22,3 +26,13 @@ func (t *Token) SetExpiresAt() {
 func (t *Token) IsExpired() bool {
 	return time.Now().Unix() >= (t.ExpiresAt - int64(t.ExpiresIn)/10)
 }
+
+// NeedsReauth reports whether the token expired more than RefreshWindow
+// before now, so refreshing it is no longer expected to work. Tokens without
+// an expiry never need re-authentication.
+func (t *Token) NeedsReauth(now time.Time) bool {
+	if t.ExpiresAt == 0 {
+		return false
+	}
+	return now.After(time.Unix(t.ExpiresAt, 0).Add(RefreshWindow))
+}
@@ -148,3 +148,27 @@ func TestToken_SetExpiresAt_UpdatesExistingValue(t *testing.T) {
 		t.Error("SetExpiresAt() did not update ExpiresAt")
 	}
 }
+
+func TestToken_NeedsReauth(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		expiresAt int64
+		want      bool
+	}{
+		{name: "valid", expiresAt: now.Add(time.Hour).Unix(), want: false},
+		{name: "recently expired", expiresAt: now.Add(-time.Hour).Unix(), want: false},
+		{name: "past refresh window", expiresAt: now.Add(-RefreshWindow - time.Hour).Unix(), want: true},
+		{name: "no expiry", expiresAt: 0, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := &Token{AccessToken: "test-access-token", ExpiresAt: tt.expiresAt}
+			if got := token.NeedsReauth(now); got != tt.want {
+				t.Errorf("NeedsReauth() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package provider
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/bedrock"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+// Provider options read by the Bedrock provider.
+const (
+	bedrockRegionOption  = "region"
+	bedrockProfileOption = "profile"
+)
+
+// errBedrockNoRegion is returned when no AWS region is configured.
+var errBedrockNoRegion = errors.New("no AWS region: set provider_options.region or AWS_REGION")
+
+// bedrockOptions are the AWS settings of a Bedrock provider.
+type bedrockOptions struct {
+	// Region is the AWS region, from the options or the environment.
+	Region string
+	// Profile is the shared config profile, empty for the default chain.
+	Profile string
+}
+
+// parseBedrockOptions reads the region and profile from provider options,
+// falling back to AWS_REGION and AWS_DEFAULT_REGION for the region.
+func parseBedrockOptions(opts map[string]any) (bedrockOptions, error) {
+	var parsed bedrockOptions
+	for key, dst := range map[string]*string{
+		bedrockRegionOption:  &parsed.Region,
+		bedrockProfileOption: &parsed.Profile,
+	} {
+		raw, ok := opts[key]
+		if !ok {
+			continue
+		}
+		s, ok := raw.(string)
+		if !ok {
+			return bedrockOptions{}, fmt.Errorf("provider_options.%s must be a string, got %T", key, raw)
+		}
+		*dst = s
+	}
+
+	parsed.Region = cmp.Or(parsed.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+	if parsed.Region == "" {
+		return bedrockOptions{}, errBedrockNoRegion
+	}
+	return parsed, nil
+}
+
+// awsEnvMu serializes the AWS environment changes of Bedrock providers.
+var awsEnvMu sync.Mutex
+
+// bedrockProvider scopes a Bedrock provider's region and profile to model
+// creation, the only time the Bedrock client reads them from the
+// environment.
+type bedrockProvider struct {
+	fantasy.Provider
+	opts bedrockOptions
+}
+
+// LanguageModel creates the model with AWS_REGION and AWS_PROFILE set to
+// the provider's values, restoring the previous environment afterwards so
+// providers with different regions do not overwrite each other.
+func (p *bedrockProvider) LanguageModel(ctx context.Context, modelID string) (fantasy.LanguageModel, error) {
+	awsEnvMu.Lock()
+	defer awsEnvMu.Unlock()
+
+	env := map[string]string{"AWS_REGION": p.opts.Region}
+	if p.opts.Profile != "" {
+		env["AWS_PROFILE"] = p.opts.Profile
+	}
+	for name, value := range env {
+		prev, ok := os.LookupEnv(name)
+		if err := os.Setenv(name, value); err != nil {
+			return nil, fmt.Errorf("setting %s: %w", name, err)
+		}
+		defer restoreEnv(name, prev, ok)
+	}
+	return p.Provider.LanguageModel(ctx, modelID)
+}
+
+// restoreEnv sets name back to value, or unsets it when it was not set.
+func restoreEnv(name, value string, set bool) {
+	if set {
+		os.Setenv(name, value) //nolint:errcheck,gosec // Best effort.
+		return
+	}
+	os.Unsetenv(name) //nolint:errcheck,gosec // Best effort.
+}
+
+// buildBedrockProvider creates an AWS Bedrock fantasy provider. An API key
+// is sent as a Bedrock bearer token; without one the standard AWS
+// credential chain is used. The Bedrock client only reads the region and
+// profile from the environment, so the configured values are exported
+// there while each model is created.
+func (b *Builder) buildBedrockProvider(providerCfg *config.ProviderConfig, apiKey string, headers map[string]string, extra ...bedrock.Option) (fantasy.Provider, error) {
+	opts, err := parseBedrockOptions(providerCfg.ProviderOptions)
+	if err != nil {
+		return nil, fmt.Errorf("building provider %q: %w", providerCfg.ID, err)
+	}
+
+	bedrockOpts := extra
+	if apiKey != "" {
+		bedrockOpts = append(bedrockOpts, bedrock.WithAPIKey(apiKey))
+	}
+	if len(headers) > 0 {
+		bedrockOpts = append(bedrockOpts, bedrock.WithHeaders(headers))
+	}
+	provider, err := bedrock.New(bedrockOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &bedrockProvider{Provider: provider, opts: opts}, nil
+}
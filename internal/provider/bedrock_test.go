@@ -0,0 +1,180 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+// clearAWSEnv unsets the AWS variables read by the Bedrock provider for the
+// duration of the test.
+func clearAWSEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"AWS_REGION", "AWS_DEFAULT_REGION", "AWS_PROFILE"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name) //nolint:errcheck,gosec // Restored by t.Setenv.
+	}
+}
+
+func TestParseBedrockOptions(t *testing.T) {
+	//nolint:govet // Field order optimized for test readability.
+	tests := []struct {
+		name      string
+		opts      map[string]any
+		envRegion string
+		want      bedrockOptions
+		wantErr   string
+	}{
+		{
+			name: "region and profile",
+			opts: map[string]any{"region": "eu-west-1", "profile": "work"},
+			want: bedrockOptions{Region: "eu-west-1", Profile: "work"},
+		},
+		{
+			name:      "option wins over environment",
+			opts:      map[string]any{"region": "eu-west-1"},
+			envRegion: "us-east-1",
+			want:      bedrockOptions{Region: "eu-west-1"},
+		},
+		{
+			name:      "environment fallback",
+			envRegion: "us-west-2",
+			want:      bedrockOptions{Region: "us-west-2"},
+		},
+		{
+			name:    "missing region",
+			wantErr: "no AWS region",
+		},
+		{
+			name:    "non-string region",
+			opts:    map[string]any{"region": 42},
+			wantErr: "provider_options.region must be a string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearAWSEnv(t)
+			if tt.envRegion != "" {
+				t.Setenv("AWS_REGION", tt.envRegion)
+			}
+
+			got, err := parseBedrockOptions(tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseBedrockOptions() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseBedrockOptions() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseBedrockOptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuilder_buildProvider_Bedrock(t *testing.T) {
+	clearAWSEnv(t)
+	builder := NewBuilder(config.NewConfig())
+
+	providerCfg := &config.ProviderConfig{
+		ID:              "bedrock",
+		Type:            catwalk.TypeBedrock,
+		ProviderOptions: map[string]any{"region": "eu-central-1", "profile": "matrix"},
+	}
+	modelCfg := config.SelectedModel{
+		Model:    "anthropic.claude-sonnet-4-5-20250929-v1:0",
+		Provider: "bedrock",
+	}
+
+	provider, err := builder.buildProvider(context.Background(), providerCfg, modelCfg)
+	if err != nil {
+		t.Fatalf("buildProvider() error = %v", err)
+	}
+	if provider == nil {
+		t.Error("buildProvider() returned nil provider")
+	}
+	for _, name := range []string{"AWS_REGION", "AWS_PROFILE"} {
+		if got, ok := os.LookupEnv(name); ok {
+			t.Errorf("%s = %q after buildProvider(), want it unset", name, got)
+		}
+	}
+}
+
+func TestBedrockProvider_LanguageModelScopesEnv(t *testing.T) {
+	clearAWSEnv(t)
+	t.Setenv("AWS_REGION", "us-west-2")
+	builder := NewBuilder(config.NewConfig())
+
+	build := func(region string) fantasy.Provider {
+		t.Helper()
+		providerCfg := &config.ProviderConfig{
+			ID:              "bedrock-" + region,
+			Type:            catwalk.TypeBedrock,
+			APIKey:          "bedrock-token",
+			ProviderOptions: map[string]any{"region": region, "profile": "matrix"},
+		}
+		provider, err := builder.buildProvider(context.Background(), providerCfg, config.SelectedModel{Provider: providerCfg.ID})
+		if err != nil {
+			t.Fatalf("buildProvider() error = %v", err)
+		}
+		return provider
+	}
+	eu, ap := build("eu-central-1"), build("ap-southeast-2")
+
+	// The model ID is prefixed from AWS_REGION, so each provider must see
+	// its own region.
+	for _, tt := range []struct {
+		provider fantasy.Provider
+		want     string
+	}{
+		{eu, "eu.anthropic.claude-sonnet-4-5-20250929-v1:0"},
+		{ap, "ap.anthropic.claude-sonnet-4-5-20250929-v1:0"},
+	} {
+		model, err := tt.provider.LanguageModel(context.Background(), "anthropic.claude-sonnet-4-5-20250929-v1:0")
+		if err != nil {
+			t.Fatalf("LanguageModel() error = %v", err)
+		}
+		if got := model.Model(); got != tt.want {
+			t.Errorf("Model() = %q, want %q", got, tt.want)
+		}
+	}
+
+	if got := os.Getenv("AWS_REGION"); got != "us-west-2" {
+		t.Errorf("AWS_REGION = %q, want the previous value restored", got)
+	}
+	if got, ok := os.LookupEnv("AWS_PROFILE"); ok {
+		t.Errorf("AWS_PROFILE = %q, want it unset again", got)
+	}
+}
+
+func TestBuilder_buildProvider_BedrockNoRegion(t *testing.T) {
+	clearAWSEnv(t)
+	builder := NewBuilder(config.NewConfig())
+
+	providerCfg := &config.ProviderConfig{ID: "bedrock", Type: catwalk.TypeBedrock}
+	_, err := builder.buildProvider(context.Background(), providerCfg, config.SelectedModel{Provider: "bedrock"})
+	if !errors.Is(err, errBedrockNoRegion) {
+		t.Errorf("buildProvider() error = %v, want errBedrockNoRegion", err)
+	}
+}
+
+func TestBuilder_buildProvider_UnsupportedCatwalkType(t *testing.T) {
+	builder := NewBuilder(config.NewConfig())
+
+	providerCfg := &config.ProviderConfig{ID: "azure", Type: catwalk.TypeAzure, APIKey: "key"}
+	_, err := builder.buildProvider(context.Background(), providerCfg, config.SelectedModel{Provider: "azure"})
+	if err == nil || !strings.Contains(err.Error(), "unsupported provider type") {
+		t.Errorf("buildProvider() error = %v, want an unsupported provider type error", err)
+	}
+}
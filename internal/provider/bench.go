@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"charm.land/fantasy"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+// benchPrompt is the fixed prompt timed by benchmarks. It asks for a tiny
+// reply so the round trip dominates the measurement.
+const benchPrompt = "Reply with the single word: pong"
+
+// benchMaxOutputTokens caps the benchmark reply length.
+const benchMaxOutputTokens int64 = 16
+
+// BenchResult holds the latency of one tier's model over several runs.
+type BenchResult struct {
+	// Err is set when the model could not be built or a run failed.
+	Err error
+	// Tier is the benchmarked tier.
+	Tier config.SelectedModelType
+	// Provider is the provider ID.
+	Provider string
+	// Model is the model ID.
+	Model string
+	// Min is the fastest run.
+	Min time.Duration
+	// Avg is the mean run duration.
+	Avg time.Duration
+	// Max is the slowest run.
+	Max time.Duration
+	// TokensPerSec is the output tokens produced per second over all runs.
+	TokensPerSec float64
+	// Runs is the number of completed runs.
+	Runs int
+}
+
+// Bench times runs round trips of a short fixed prompt against each
+// configured tier's model. Each run is bounded by timeout. A failing tier is
+// reported in its result and does not stop the others.
+func (b *Builder) Bench(ctx context.Context, runs int, timeout time.Duration) []BenchResult {
+	var results []BenchResult
+	for _, tier := range AllTiers() {
+		modelCfg, ok := b.cfg.Models[tier]
+		if !ok {
+			continue
+		}
+
+		model, err := b.buildModel(ctx, modelCfg)
+		if err != nil {
+			results = append(results, BenchResult{
+				Err:      fmt.Errorf("building model: %w", err),
+				Tier:     tier,
+				Provider: modelCfg.Provider,
+				Model:    modelCfg.Model,
+			})
+			continue
+		}
+
+		result := BenchModel(ctx, model.Model, runs, timeout)
+		result.Tier = tier
+		result.Provider = modelCfg.Provider
+		result.Model = model.ModelCfg.Model
+		results = append(results, result)
+	}
+	return results
+}
+
+// BenchModel times runs round trips of the benchmark prompt against lm,
+// each bounded by timeout. It stops at the first failed run.
+func BenchModel(ctx context.Context, lm fantasy.LanguageModel, runs int, timeout time.Duration) BenchResult {
+	var (
+		result       BenchResult
+		total        time.Duration
+		outputTokens int64
+	)
+
+	maxTokens := benchMaxOutputTokens
+	call := fantasy.Call{
+		Prompt:          fantasy.Prompt{fantasy.NewUserMessage(benchPrompt)},
+		MaxOutputTokens: &maxTokens,
+	}
+
+	for i := range runs {
+		elapsed, resp, err := timeCall(ctx, lm, call, timeout)
+		if err != nil {
+			result.Err = fmt.Errorf("run %d: %w", i+1, err)
+			break
+		}
+
+		if result.Runs == 0 || elapsed < result.Min {
+			result.Min = elapsed
+		}
+		result.Max = max(result.Max, elapsed)
+		total += elapsed
+		outputTokens += resp.Usage.OutputTokens
+		result.Runs++
+	}
+
+	if result.Runs > 0 {
+		result.Avg = total / time.Duration(result.Runs)
+	}
+	if total > 0 {
+		result.TokensPerSec = float64(outputTokens) / total.Seconds()
+	}
+	return result
+}
+
+// timeCall runs a single generation bounded by timeout and measures it.
+func timeCall(ctx context.Context, lm fantasy.LanguageModel, call fantasy.Call, timeout time.Duration) (time.Duration, *fantasy.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := lm.Generate(ctx, call)
+	elapsed := time.Since(start)
+	if err != nil {
+		return 0, nil, err
+	}
+	return elapsed, resp, nil
+}
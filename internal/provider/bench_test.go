@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+// delayedModel is a language model that answers each call after the next
+// delay in its list, or fails with err.
+type delayedModel struct {
+	fantasy.LanguageModel
+	err          error
+	delays       []time.Duration
+	outputTokens int64
+	calls        int
+}
+
+func (m *delayedModel) Generate(ctx context.Context, _ fantasy.Call) (*fantasy.Response, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	delay := m.delays[m.calls%len(m.delays)]
+	m.calls++
+	select {
+	case <-time.After(delay):
+		return &fantasy.Response{Usage: fantasy.Usage{OutputTokens: m.outputTokens}}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestBenchModel_Aggregation(t *testing.T) {
+	lm := &delayedModel{
+		delays:       []time.Duration{20 * time.Millisecond, 60 * time.Millisecond, 40 * time.Millisecond},
+		outputTokens: 6,
+	}
+
+	result := BenchModel(context.Background(), lm, 3, time.Second)
+	if result.Err != nil {
+		t.Fatalf("BenchModel() error = %v", result.Err)
+	}
+	if result.Runs != 3 {
+		t.Fatalf("Runs = %d, want 3", result.Runs)
+	}
+
+	const slack = 25 * time.Millisecond
+	within := func(got, want time.Duration) bool {
+		return got >= want && got < want+slack
+	}
+	if !within(result.Min, 20*time.Millisecond) {
+		t.Errorf("Min = %v, want about 20ms", result.Min)
+	}
+	if !within(result.Max, 60*time.Millisecond) {
+		t.Errorf("Max = %v, want about 60ms", result.Max)
+	}
+	if !within(result.Avg, 40*time.Millisecond) {
+		t.Errorf("Avg = %v, want about 40ms", result.Avg)
+	}
+
+	// 18 tokens over roughly 120ms of calls.
+	if result.TokensPerSec < 100 || result.TokensPerSec > 150 {
+		t.Errorf("TokensPerSec = %.1f, want about 150", result.TokensPerSec)
+	}
+}
+
+func TestBenchModel_Timeout(t *testing.T) {
+	lm := &delayedModel{delays: []time.Duration{time.Second}}
+
+	result := BenchModel(context.Background(), lm, 3, 10*time.Millisecond)
+	if !errors.Is(result.Err, context.DeadlineExceeded) {
+		t.Fatalf("Err = %v, want deadline exceeded", result.Err)
+	}
+	if result.Runs != 0 || lm.calls != 1 {
+		t.Errorf("Runs = %d, calls = %d, want the tier to stop after the first failure", result.Runs, lm.calls)
+	}
+}
+
+func TestBuilder_Bench_FailedTierDoesNotAbort(t *testing.T) {
+	server, _ := captureRequestBody(t)
+
+	cfg := config.NewConfig()
+	cfg.Providers["openai"] = &config.ProviderConfig{
+		ID:      "openai",
+		Type:    catwalk.TypeOpenAI,
+		APIKey:  "sk-test",
+		BaseURL: server.URL,
+	}
+	cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{Model: "gpt-4o", Provider: "missing"}
+	cfg.Models[config.SelectedModelTypeSmall] = config.SelectedModel{Model: "gpt-4o-mini", Provider: "openai"}
+
+	results := NewBuilder(cfg).Bench(context.Background(), 2, time.Second)
+	if len(results) != 2 {
+		t.Fatalf("Bench() returned %d results, want 2", len(results))
+	}
+
+	large, small := results[0], results[1]
+	if large.Tier != config.SelectedModelTypeLarge || large.Err == nil ||
+		!strings.Contains(large.Err.Error(), `provider "missing" not configured`) {
+		t.Errorf("large result = %+v, want a build failure", large)
+	}
+	if small.Tier != config.SelectedModelTypeSmall || small.Err != nil || small.Runs != 2 {
+		t.Errorf("small result = %+v, want 2 successful runs", small)
+	}
+}
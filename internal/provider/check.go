@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"charm.land/fantasy"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+// CheckResult is the outcome of a minimal authenticated call to one
+// configured provider.
+type CheckResult struct {
+	// Err is set when the model could not be built or the call failed.
+	Err error
+	// Provider is the provider ID.
+	Provider string
+	// Model is the model ID the call used.
+	Model string
+	// Latency is how long the call took.
+	Latency time.Duration
+}
+
+// CheckProviders sends the benchmark prompt once to every enabled provider
+// with credentials, running at most workers checks at a time, each bounded
+// by timeout. A provider is checked with the model a tier selects from it,
+// else its default small model or its first model. Calls aren't retried.
+// Results are in provider ID order; a failing provider doesn't stop the
+// others.
+func (b *Builder) CheckProviders(ctx context.Context, workers int, timeout time.Duration) []CheckResult {
+	var ids []string
+	for _, id := range b.cfg.ProviderIDs() {
+		if p := b.cfg.Providers[id]; !p.Disable && p.HasCredentials() {
+			ids = append(ids, id)
+		}
+	}
+
+	results := make([]CheckResult, len(ids))
+	sem := make(chan struct{}, max(workers, 1))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = b.checkProvider(ctx, id, timeout)
+		})
+	}
+	wg.Wait()
+	return results
+}
+
+// checkProvider checks a single provider for CheckProviders.
+func (b *Builder) checkProvider(ctx context.Context, id string, timeout time.Duration) CheckResult {
+	result := CheckResult{Provider: id}
+	modelCfg, ok := checkModel(b.cfg, id)
+	if !ok {
+		result.Err = errors.New("no model to check with")
+		return result
+	}
+	result.Model = modelCfg.Model
+
+	// Building may refresh an OAuth token, so it shares the timeout.
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	model, err := b.buildModel(ctx, modelCfg)
+	if err != nil {
+		result.Err = fmt.Errorf("building model: %w", err)
+		return result
+	}
+	result.Model = model.ModelCfg.Model
+
+	maxTokens := benchMaxOutputTokens
+	start := time.Now()
+	_, err = model.Model.Generate(ctx, fantasy.Call{
+		Prompt:          fantasy.Prompt{fantasy.NewUserMessage(benchPrompt)},
+		MaxOutputTokens: &maxTokens,
+	})
+	result.Latency = time.Since(start)
+	result.Err = err
+	return result
+}
+
+// checkModel picks the model a provider is checked with: a tier's selection
+// using the provider, else the provider's default small model from catwalk,
+// else the first model it lists.
+func checkModel(cfg *config.Config, id string) (config.SelectedModel, bool) {
+	for _, tier := range AllTiers() {
+		if model, ok := cfg.Models[tier]; ok && model.Provider == id {
+			return model, true
+		}
+	}
+	for _, p := range cfg.KnownProviders() {
+		if string(p.ID) == id && p.DefaultSmallModelID != "" {
+			return config.SelectedModel{Provider: id, Model: p.DefaultSmallModelID}, true
+		}
+	}
+	if models := cfg.Providers[id].Models; len(models) > 0 {
+		return config.SelectedModel{Provider: id, Model: models[0].ID}, true
+	}
+	return config.SelectedModel{}, false
+}
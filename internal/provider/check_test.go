@@ -0,0 +1,137 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+// concurrencyServer answers chat completions after a short delay, or with
+// 401 when unauthorized is set, recording the most requests in flight.
+type concurrencyServer struct {
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (s *concurrencyServer) start(t *testing.T, unauthorized bool) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body) //nolint:errcheck // Test server.
+		s.mu.Lock()
+		s.inFlight++
+		s.maxInFlight = max(s.maxInFlight, s.inFlight)
+		s.mu.Unlock()
+		defer func() {
+			s.mu.Lock()
+			s.inFlight--
+			s.mu.Unlock()
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		if unauthorized {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, `{"error":{"message":"invalid api key","type":"invalid_request_error"}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"id":"1","object":"chat.completion","model":"gpt-4o",`+
+			`"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"pong"}}]}`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBuilder_CheckProviders(t *testing.T) {
+	var counter concurrencyServer
+	good := counter.start(t, false)
+	bad := counter.start(t, true)
+
+	cfg := config.NewConfig()
+	for _, id := range []string{"alpha", "beta", "gamma"} {
+		cfg.Providers[id] = &config.ProviderConfig{
+			ID:      id,
+			Type:    catwalk.TypeOpenAI,
+			APIKey:  "sk-test",
+			BaseURL: good.URL,
+			Models:  []catwalk.Model{{ID: "gpt-4o-mini"}},
+		}
+	}
+	cfg.Providers["broken"] = &config.ProviderConfig{
+		ID:      "broken",
+		Type:    catwalk.TypeOpenAI,
+		APIKey:  "sk-wrong",
+		BaseURL: bad.URL,
+		Models:  []catwalk.Model{{ID: "gpt-4o-mini"}},
+	}
+	cfg.Providers["off"] = &config.ProviderConfig{ID: "off", Type: catwalk.TypeOpenAI, APIKey: "sk-test", BaseURL: good.URL, Disable: true}
+	cfg.Providers["nomodels"] = &config.ProviderConfig{ID: "nomodels", Type: catwalk.TypeOpenAI, APIKey: "sk-test", BaseURL: good.URL}
+	cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{Model: "gpt-4o", Provider: "alpha"}
+
+	results := NewBuilder(cfg).CheckProviders(context.Background(), 2, time.Second)
+
+	var ids []string
+	failed := map[string]error{}
+	for _, r := range results {
+		ids = append(ids, r.Provider)
+		if r.Err != nil {
+			failed[r.Provider] = r.Err
+		} else if r.Latency <= 0 {
+			t.Errorf("%s: Latency = %v, want the call measured", r.Provider, r.Latency)
+		}
+	}
+	if got := strings.Join(ids, ","); got != "alpha,beta,broken,gamma,nomodels" {
+		t.Errorf("checked providers = %s, want the enabled ones in ID order", got)
+	}
+	if len(failed) != 2 || failed["broken"] == nil || failed["nomodels"] == nil {
+		t.Errorf("failed = %v, want broken and nomodels", failed)
+	}
+	if results[0].Model != "gpt-4o" {
+		t.Errorf("alpha was checked with %q, want its tier's model", results[0].Model)
+	}
+	if results[1].Model != "gpt-4o-mini" {
+		t.Errorf("beta was checked with %q, want its first model", results[1].Model)
+	}
+	if counter.maxInFlight > 2 {
+		t.Errorf("max requests in flight = %d, want at most 2 workers", counter.maxInFlight)
+	}
+}
+
+func TestBuilder_CheckProviders_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	cfg := config.NewConfig()
+	cfg.Providers["slow"] = &config.ProviderConfig{
+		ID:      "slow",
+		Type:    catwalk.TypeOpenAI,
+		APIKey:  "sk-test",
+		BaseURL: server.URL,
+		Models:  []catwalk.Model{{ID: "gpt-4o-mini"}},
+	}
+
+	start := time.Now()
+	results := NewBuilder(cfg).CheckProviders(context.Background(), 1, 50*time.Millisecond)
+	if len(results) != 1 || results[0].Err == nil {
+		t.Fatalf("results = %+v, want the slow provider failed", results)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("CheckProviders() took %v, want the timeout to cut it short", elapsed)
+	}
+}
@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+// DaysPerMonth is the number of days used for monthly cost estimates.
+const DaysPerMonth = 30
+
+// TierCost is the estimated monthly cost for a single tier.
+type TierCost struct {
+	// Tier is the model tier being estimated.
+	Tier config.SelectedModelType
+	// Provider is the provider ID serving the tier.
+	Provider string
+	// Model is the model ID serving the tier.
+	Model string
+	// Monthly is the estimated monthly cost in USD.
+	Monthly float64
+	// Known is false when the model has no pricing metadata.
+	Known bool
+}
+
+// CostEstimate summarizes the estimated monthly cost across tiers.
+type CostEstimate struct {
+	// Tiers holds the per-tier estimates in tier order.
+	Tiers []TierCost
+	// Total is the sum of all tiers with known pricing.
+	Total float64
+	// Partial is true when at least one tier has unknown pricing.
+	Partial bool
+}
+
+// EstimateMonthlyCost estimates the monthly cost of each configured tier.
+// Each tier is assumed to process tokensPerDay tokens, split evenly between
+// input and output, so the estimate uses the average of both prices.
+func EstimateMonthlyCost(cfg *config.Config, tokensPerDay int64) CostEstimate {
+	var estimate CostEstimate
+
+	for _, tier := range AllTiers() {
+		selected, ok := cfg.Models[tier]
+		if !ok {
+			continue
+		}
+
+		tc := TierCost{
+			Tier:     tier,
+			Provider: selected.Provider,
+			Model:    selected.Model,
+		}
+
+		model := cfg.GetModel(selected.Provider, selected.Model)
+		if model != nil && (model.CostPer1MIn > 0 || model.CostPer1MOut > 0) {
+			pricePer1M := (model.CostPer1MIn + model.CostPer1MOut) / 2
+			tc.Monthly = float64(tokensPerDay) * DaysPerMonth / 1_000_000 * pricePer1M
+			tc.Known = true
+			estimate.Total += tc.Monthly
+		} else {
+			estimate.Partial = true
+		}
+
+		estimate.Tiers = append(estimate.Tiers, tc)
+	}
+
+	return estimate
+}
@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"math"
+	"testing"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+func TestEstimateMonthlyCost(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Providers["anthropic"] = &config.ProviderConfig{
+		ID: "anthropic",
+		Models: []catwalk.Model{
+			{ID: "claude-sonnet", CostPer1MIn: 3, CostPer1MOut: 15},
+			{ID: "claude-haiku", CostPer1MIn: 1, CostPer1MOut: 5},
+		},
+	}
+	cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{Model: "claude-sonnet", Provider: "anthropic"}
+	cfg.Models[config.SelectedModelTypeSmall] = config.SelectedModel{Model: "claude-haiku", Provider: "anthropic"}
+
+	estimate := EstimateMonthlyCost(cfg, 1_000_000)
+
+	if len(estimate.Tiers) != 2 {
+		t.Fatalf("len(Tiers) = %d, want 2", len(estimate.Tiers))
+	}
+
+	// 1M tokens/day * 30 days at an average of $9/1M.
+	large := estimate.Tiers[0]
+	if large.Tier != config.SelectedModelTypeLarge || !large.Known {
+		t.Errorf("Tiers[0] = %+v, want known large tier", large)
+	}
+	if math.Abs(large.Monthly-270) > 1e-9 {
+		t.Errorf("large Monthly = %v, want 270", large.Monthly)
+	}
+
+	// 1M tokens/day * 30 days at an average of $3/1M.
+	small := estimate.Tiers[1]
+	if math.Abs(small.Monthly-90) > 1e-9 {
+		t.Errorf("small Monthly = %v, want 90", small.Monthly)
+	}
+
+	if math.Abs(estimate.Total-360) > 1e-9 {
+		t.Errorf("Total = %v, want 360", estimate.Total)
+	}
+	if estimate.Partial {
+		t.Error("Partial = true, want false when all tiers are priced")
+	}
+}
+
+func TestEstimateMonthlyCost_UnknownPricing(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Providers["local"] = &config.ProviderConfig{
+		ID:     "local",
+		Models: []catwalk.Model{{ID: "llama", CostPer1MIn: 2, CostPer1MOut: 2}, {ID: "free-model"}},
+	}
+	cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{Model: "llama", Provider: "local"}
+	cfg.Models[config.SelectedModelTypeSmall] = config.SelectedModel{Model: "free-model", Provider: "local"}
+
+	estimate := EstimateMonthlyCost(cfg, 500_000)
+
+	if !estimate.Partial {
+		t.Error("Partial = false, want true when a tier has no pricing")
+	}
+	if estimate.Tiers[1].Known {
+		t.Error("small tier Known = true, want false")
+	}
+	// Only the priced tier contributes: 0.5M * 30 * $2.
+	if math.Abs(estimate.Total-30) > 1e-9 {
+		t.Errorf("Total = %v, want 30", estimate.Total)
+	}
+}
+
+func TestEstimateMonthlyCost_MissingModelMetadata(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Providers["openai"] = &config.ProviderConfig{ID: "openai"}
+	cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{Model: "gpt-4o", Provider: "openai"}
+
+	estimate := EstimateMonthlyCost(cfg, 1_000_000)
+
+	if len(estimate.Tiers) != 1 {
+		t.Fatalf("len(Tiers) = %d, want 1 (unconfigured small tier skipped)", len(estimate.Tiers))
+	}
+	if estimate.Tiers[0].Known {
+		t.Error("Known = true, want false for model without metadata")
+	}
+	if estimate.Total != 0 {
+		t.Errorf("Total = %v, want 0", estimate.Total)
+	}
+}
@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+)
+
+// addDefaultHeaders adds the configured default headers to headers, resolving
+// environment references. Headers already present win, compared
+// case-insensitively.
+func (b *Builder) addDefaultHeaders(headers map[string]string) error {
+	if b.cfg.Options == nil {
+		return nil
+	}
+
+	existing := make(map[string]bool, len(headers))
+	for name := range headers {
+		existing[http.CanonicalHeaderKey(name)] = true
+	}
+
+	defaults := b.cfg.Options.DefaultHeaders
+	for _, name := range slices.Sorted(maps.Keys(defaults)) {
+		if existing[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+		value, err := b.cfg.Resolve(defaults[name])
+		if err != nil {
+			return fmt.Errorf("resolving default header %q: %w", name, err)
+		}
+		headers[name] = value
+	}
+	return nil
+}
+
+// rawHeaderTransport sets headers using their exact names, bypassing the
+// canonicalization applied by http.Header.Set. Some strict gateways reject
+// requests whose header casing differs from what they expect.
+type rawHeaderTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+// newRawHeaderClient returns an HTTP client that sends headers verbatim,
+// making its requests through base.
+func newRawHeaderClient(base *http.Client, headers map[string]string) *http.Client {
+	transport := base.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &http.Client{
+		Transport: &rawHeaderTransport{
+			base:    transport,
+			headers: headers,
+		},
+		Timeout: base.Timeout,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *rawHeaderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, value := range t.headers {
+		req.Header.Del(name)
+		req.Header[name] = []string{value}
+	}
+	return t.base.RoundTrip(req)
+}
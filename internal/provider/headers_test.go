@@ -0,0 +1,157 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+// recordingTransport captures the last request it was asked to send.
+type recordingTransport struct {
+	req *http.Request
+}
+
+func (r *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.req = req
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+func TestRawHeaderTransport_PreservesCase(t *testing.T) {
+	rec := &recordingTransport{}
+	transport := &rawHeaderTransport{
+		base:    rec,
+		headers: map[string]string{"x-lowercase-key": "secret"},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", http.NoBody)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	// Simulate an SDK that already set the canonical form.
+	req.Header.Set("x-lowercase-key", "secret")
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	got := rec.req.Header
+	if v := got["x-lowercase-key"]; len(v) != 1 || v[0] != "secret" {
+		t.Errorf("header[%q] = %v, want [secret]", "x-lowercase-key", v)
+	}
+	if _, ok := got["X-Lowercase-Key"]; ok {
+		t.Error("canonicalized header should have been removed")
+	}
+	if _, ok := req.Header["x-lowercase-key"]; ok {
+		t.Error("RoundTrip() should not mutate the original request")
+	}
+}
+
+func TestBuilder_buildProvider_PreserveHeaderCase(t *testing.T) {
+	cfg := config.NewConfig()
+	builder := NewBuilder(cfg)
+
+	providerCfg := &config.ProviderConfig{
+		ID:                 "gateway",
+		Type:               catwalk.TypeOpenAICompat,
+		APIKey:             "gw-key",
+		BaseURL:            "http://localhost:8080",
+		ExtraHeaders:       map[string]string{"x-tenant": "acme"},
+		PreserveHeaderCase: true,
+	}
+	modelCfg := config.SelectedModel{
+		Model:    "local-model",
+		Provider: "gateway",
+	}
+
+	provider, err := builder.buildProvider(context.Background(), providerCfg, modelCfg)
+	if err != nil {
+		t.Fatalf("buildProvider() error = %v", err)
+	}
+	if provider == nil {
+		t.Error("buildProvider() returned nil provider")
+	}
+}
+
+// captureRequestHeaders starts a server that records the headers of each
+// request and replies with a minimal chat completion.
+func captureRequestHeaders(t *testing.T) (*httptest.Server, *http.Header) {
+	t.Helper()
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"1","object":"chat.completion","model":"gpt-4o",`+
+			`"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"ok"}}]}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, &headers
+}
+
+func TestBuilder_buildModel_DefaultHeaders(t *testing.T) {
+	tests := []struct {
+		name         string
+		extraHeaders map[string]string
+		want         string
+	}{
+		{name: "default applies", extraHeaders: nil, want: "team-42"},
+		{name: "provider header wins", extraHeaders: map[string]string{"x-cost-center": "team-7"}, want: "team-7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MATRIX_TEST_COST_CENTER", "team-42")
+			server, headers := captureRequestHeaders(t)
+
+			cfg := config.NewConfig()
+			cfg.Options.DefaultHeaders = map[string]string{"X-Cost-Center": "$MATRIX_TEST_COST_CENTER"}
+			cfg.Providers["openai"] = &config.ProviderConfig{
+				ID:           "openai",
+				Type:         catwalk.TypeOpenAI,
+				APIKey:       "sk-test",
+				BaseURL:      server.URL,
+				ExtraHeaders: tt.extraHeaders,
+			}
+
+			model, err := NewBuilder(cfg).buildModel(context.Background(), config.SelectedModel{
+				Model:    "gpt-4o",
+				Provider: "openai",
+			})
+			if err != nil {
+				t.Fatalf("buildModel() error = %v", err)
+			}
+			if _, err := model.Model.Generate(context.Background(), fantasy.Call{
+				Prompt: fantasy.Prompt{fantasy.NewUserMessage("hi")},
+			}); err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			if got := headers.Values("X-Cost-Center"); len(got) != 1 || got[0] != tt.want {
+				t.Errorf("X-Cost-Center = %v, want [%s]", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuilder_buildProvider_DefaultHeaderUnresolved(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Options.DefaultHeaders = map[string]string{"X-Trace": "${MATRIX_TEST_UNDEFINED_TRACE}"}
+
+	_, err := NewBuilder(cfg).buildProvider(context.Background(), &config.ProviderConfig{
+		ID:     "openai",
+		Type:   catwalk.TypeOpenAI,
+		APIKey: "sk-test",
+	}, config.SelectedModel{Model: "gpt-4o"})
+	if err == nil || !strings.Contains(err.Error(), `resolving default header "X-Trace"`) {
+		t.Errorf("buildProvider() error = %v, want a default header resolution error", err)
+	}
+}
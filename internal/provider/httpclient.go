@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+// maxIdleConnsPerHost keeps enough idle connections to a provider for
+// concurrent requests from both tiers.
+const maxIdleConnsPerHost = 8
+
+// Middleware wraps an HTTP transport, e.g. to record or rewrite outbound
+// provider requests.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// httpClient returns the HTTP client shared by every provider the Builder
+// creates, so they reuse one connection pool. Its transport is wrapped with
+// the Builder's middleware. Callers must hold b.mu.
+func (b *Builder) httpClient() (*http.Client, error) {
+	if b.client != nil {
+		return b.client, nil
+	}
+	client, err := newHTTPClient(b.cfg)
+	if err != nil {
+		return nil, err
+	}
+	client.Transport = wrapTransport(client.Transport, b.middleware)
+	b.client = client
+	return client, nil
+}
+
+// wrapTransport applies middleware to transport so the first middleware is
+// the outermost.
+func wrapTransport(transport http.RoundTripper, middleware []Middleware) http.RoundTripper {
+	for _, mw := range slices.Backward(middleware) {
+		transport = mw(transport)
+	}
+	return transport
+}
+
+// newHTTPClient creates a client with a dedicated transport honoring the
+// configured proxy and request timeout. It has no overall timeout, so long
+// streamed responses aren't cut off.
+func newHTTPClient(cfg *config.Config) (*http.Client, error) {
+	transport, err := config.NewTransport(cfg.Options)
+	if err != nil {
+		return nil, err
+	}
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	return &http.Client{Transport: transport}, nil
+}
+
+// providerClient returns the client a provider should use: the shared one,
+// or a wrapper over it when the provider's headers must keep their case.
+func providerClient(client *http.Client, providerCfg *config.ProviderConfig, headers map[string]string) *http.Client {
+	if providerCfg.PreserveHeaderCase {
+		return newRawHeaderClient(client, headers)
+	}
+	return client
+}
@@ -0,0 +1,217 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+// countingTransport counts the requests made through it.
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestBuilder_httpClient_Shared(t *testing.T) {
+	builder := NewBuilder(config.NewConfig())
+
+	first, err := builder.httpClient()
+	if err != nil {
+		t.Fatalf("httpClient() error = %v", err)
+	}
+	second, err := builder.httpClient()
+	if err != nil {
+		t.Fatalf("httpClient() error = %v", err)
+	}
+	if first != second {
+		t.Error("httpClient() returned different clients")
+	}
+}
+
+func TestBuilder_buildModel_SharedHTTPClient(t *testing.T) {
+	serverA, _ := captureRequestHeaders(t)
+	serverB, _ := captureRequestHeaders(t)
+
+	cfg := config.NewConfig()
+	cfg.Providers["a"] = &config.ProviderConfig{
+		ID: "a", Type: catwalk.TypeOpenAI, APIKey: "sk-a", BaseURL: serverA.URL,
+	}
+	cfg.Providers["b"] = &config.ProviderConfig{
+		ID: "b", Type: catwalk.TypeOpenAICompat, APIKey: "sk-b", BaseURL: serverB.URL,
+		ExtraHeaders: map[string]string{"x-team": "matrix"}, PreserveHeaderCase: true,
+	}
+
+	transport := &countingTransport{}
+	builder := NewBuilder(cfg)
+	builder.client = &http.Client{Transport: transport}
+
+	for _, id := range []string{"a", "b"} {
+		model, err := builder.buildModel(context.Background(), config.SelectedModel{Model: "gpt-4o", Provider: id})
+		if err != nil {
+			t.Fatalf("buildModel(%s) error = %v", id, err)
+		}
+		if _, err := model.Model.Generate(context.Background(), fantasy.Call{
+			Prompt: fantasy.Prompt{fantasy.NewUserMessage("hi")},
+		}); err != nil {
+			t.Fatalf("Generate(%s) error = %v", id, err)
+		}
+	}
+
+	if got := transport.requests.Load(); got != 2 {
+		t.Errorf("requests through the shared client = %d, want 2", got)
+	}
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	t.Setenv("MATRIX_TEST_PROXY", "http://proxy.example:3128")
+
+	cfg := config.NewConfig()
+	cfg.Options.Proxy = "$MATRIX_TEST_PROXY"
+	cfg.Options.RequestTimeout = 45
+
+	client, err := newHTTPClient(cfg)
+	if err != nil {
+		t.Fatalf("newHTTPClient() error = %v", err)
+	}
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Transport = %T, want *http.Transport", client.Transport)
+	}
+	if transport == http.DefaultTransport {
+		t.Error("Transport is http.DefaultTransport, want a dedicated transport")
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://api.openai.com/v1/models", http.NoBody)
+	proxy, err := transport.Proxy(req)
+	if err != nil {
+		t.Fatalf("Proxy() error = %v", err)
+	}
+	if proxy == nil || proxy.String() != "http://proxy.example:3128" {
+		t.Errorf("Proxy() = %v, want the configured proxy", proxy)
+	}
+	if transport.ResponseHeaderTimeout != 45*time.Second {
+		t.Errorf("ResponseHeaderTimeout = %v, want 45s", transport.ResponseHeaderTimeout)
+	}
+	if client.Timeout != 0 {
+		t.Errorf("Timeout = %v, want 0 so streams aren't cut off", client.Timeout)
+	}
+}
+
+func TestNewHTTPClient_InvalidProxy(t *testing.T) {
+	tests := []struct {
+		name  string
+		proxy string
+		want  string
+	}{
+		{name: "not a URL", proxy: "proxy.example", want: "invalid proxy URL"},
+		{name: "unresolved", proxy: "$MATRIX_TEST_UNSET_PROXY", want: "resolving proxy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.Options.Proxy = tt.proxy
+
+			_, err := newHTTPClient(cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("newHTTPClient() error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// requestRecorder is a middleware transport recording the requests that
+// pass through it.
+type requestRecorder struct {
+	next     http.RoundTripper
+	order    *[]string
+	name     string
+	requests []*http.Request
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *requestRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.requests = append(r.requests, req)
+	if r.order != nil {
+		*r.order = append(*r.order, r.name)
+	}
+	return r.next.RoundTrip(req)
+}
+
+// middleware installs r in front of the transport it wraps.
+func (r *requestRecorder) middleware(next http.RoundTripper) http.RoundTripper {
+	r.next = next
+	return r
+}
+
+func TestBuilder_WithMiddleware_RecordsRequests(t *testing.T) {
+	server, _ := captureRequestHeaders(t)
+
+	cfg := config.NewConfig()
+	cfg.Providers["openai"] = &config.ProviderConfig{
+		ID: "openai", Type: catwalk.TypeOpenAI, APIKey: "sk-test", BaseURL: server.URL + "/v1",
+	}
+
+	rec := &requestRecorder{}
+	builder := NewBuilder(cfg, WithMiddleware(rec.middleware))
+
+	model, err := builder.buildModel(context.Background(), config.SelectedModel{Model: "gpt-4o", Provider: "openai"})
+	if err != nil {
+		t.Fatalf("buildModel() error = %v", err)
+	}
+	if _, err := model.Model.Generate(context.Background(), fantasy.Call{
+		Prompt: fantasy.Prompt{fantasy.NewUserMessage("hi")},
+	}); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if len(rec.requests) != 1 {
+		t.Fatalf("recorded %d requests, want 1", len(rec.requests))
+	}
+	req := rec.requests[0]
+	if got := req.Header.Get("Authorization"); got != "Bearer sk-test" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer sk-test")
+	}
+	if got := req.URL.String(); !strings.HasPrefix(got, server.URL+"/v1/") {
+		t.Errorf("URL = %q, want under %q", got, server.URL+"/v1/")
+	}
+}
+
+func TestWrapTransport_Order(t *testing.T) {
+	var order []string
+	outer := &requestRecorder{name: "outer", order: &order}
+	inner := &requestRecorder{name: "inner", order: &order}
+	base := &recordingTransport{}
+
+	transport := wrapTransport(base, []Middleware{outer.middleware, inner.middleware})
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", http.NoBody)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if got := strings.Join(order, ","); got != "outer,inner" {
+		t.Errorf("order = %s, want outer,inner", got)
+	}
+	if base.req != req {
+		t.Error("the request should reach the wrapped transport")
+	}
+}
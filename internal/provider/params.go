@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/openai/openai-go/v2/option"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+// modelParamsOption is the provider_options key whose entries are forwarded
+// to the model request without needing a dedicated config field.
+const modelParamsOption = "model_params"
+
+// openAIModelParams maps recognized model_params keys to the OpenAI request
+// field they set.
+var openAIModelParams = map[string]string{
+	"seed":                "seed",
+	"user":                "user",
+	"logit_bias":          "logit_bias",
+	"logprobs":            "logprobs",
+	"top_logprobs":        "top_logprobs",
+	"parallel_tool_calls": "parallel_tool_calls",
+	"service_tier":        "service_tier",
+	"verbosity":           "verbosity",
+	"max_output_tokens":   "max_completion_tokens",
+}
+
+// modelParams returns the model_params object from the model's provider
+// options, or nil when it is missing or not an object.
+func modelParams(modelCfg config.SelectedModel) map[string]any {
+	raw, ok := modelCfg.ProviderOptions[modelParamsOption]
+	if !ok {
+		return nil
+	}
+	params, ok := raw.(map[string]any)
+	if !ok {
+		slog.Debug("Ignoring model_params: expected an object", "model", modelCfg.Model)
+		return nil
+	}
+	return params
+}
+
+// modelParamsKey returns a stable encoding of the model params for use in
+// the provider cache key.
+func modelParamsKey(params map[string]any) string {
+	if len(params) == 0 {
+		return ""
+	}
+	// Map keys are sorted by encoding/json, so the encoding is stable.
+	data, err := json.Marshal(params)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// openAIParamOptions translates model params into request options. Unknown
+// keys are logged and skipped.
+func openAIParamOptions(params map[string]any) []option.RequestOption {
+	var opts []option.RequestOption
+	for _, key := range slices.Sorted(maps.Keys(params)) {
+		field, ok := openAIModelParams[key]
+		if !ok {
+			slog.Debug("Ignoring unknown model param", "param", key)
+			continue
+		}
+		opts = append(opts, option.WithJSONSet(field, params[key]))
+	}
+	return opts
+}
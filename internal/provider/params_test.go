@@ -0,0 +1,114 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"charm.land/fantasy"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+func TestBuilder_buildModel_ModelParams(t *testing.T) {
+	server, body := captureRequestBody(t)
+
+	cfg := config.NewConfig()
+	cfg.Providers["openai"] = &config.ProviderConfig{
+		ID:      "openai",
+		Type:    catwalk.TypeOpenAI,
+		APIKey:  "sk-test",
+		BaseURL: server.URL,
+	}
+	builder := NewBuilder(cfg)
+
+	model, err := builder.buildModel(context.Background(), config.SelectedModel{
+		Model:    "gpt-4o",
+		Provider: "openai",
+		ProviderOptions: map[string]any{
+			"model_params": map[string]any{
+				"seed":              42,
+				"max_output_tokens": 256,
+				"not_a_real_param":  true,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("buildModel() error = %v", err)
+	}
+
+	_, err = model.Model.Generate(context.Background(), fantasy.Call{
+		Prompt: fantasy.Prompt{fantasy.NewUserMessage("hi")},
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	for _, want := range []string{`"seed":42`, `"max_completion_tokens":256`} {
+		if !strings.Contains(*body, want) {
+			t.Errorf("request body should contain %s, got: %s", want, *body)
+		}
+	}
+	if strings.Contains(*body, "not_a_real_param") {
+		t.Errorf("unknown param should not be forwarded, got: %s", *body)
+	}
+}
+
+func TestModelParams(t *testing.T) {
+	tests := []struct { //nolint:govet // Test struct alignment is not critical.
+		name    string
+		options map[string]any
+		wantLen int
+	}{
+		{name: "missing", options: nil, wantLen: 0},
+		{name: "not an object", options: map[string]any{"model_params": "seed=1"}, wantLen: 0},
+		{name: "object", options: map[string]any{"model_params": map[string]any{"seed": 1}}, wantLen: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := modelParams(config.SelectedModel{ProviderOptions: tt.options})
+			if len(got) != tt.wantLen {
+				t.Errorf("modelParams() len = %d, want %d", len(got), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestOpenAIParamOptions_SkipsUnknown(t *testing.T) {
+	opts := openAIParamOptions(map[string]any{
+		"seed":    1,
+		"user":    "me",
+		"unknown": "x",
+	})
+	if len(opts) != 2 {
+		t.Errorf("openAIParamOptions() len = %d, want 2", len(opts))
+	}
+}
+
+func TestBuilder_getOrBuildProvider_ModelParamsNotShared(t *testing.T) {
+	builder := NewBuilder(config.NewConfig())
+	providerCfg := &config.ProviderConfig{
+		ID:     "openai",
+		Type:   catwalk.TypeOpenAI,
+		APIKey: "sk-test",
+	}
+
+	plain, err := builder.getOrBuildProvider(context.Background(), providerCfg, config.SelectedModel{Model: "gpt-4o"})
+	if err != nil {
+		t.Fatalf("getOrBuildProvider() error = %v", err)
+	}
+	seeded, err := builder.getOrBuildProvider(context.Background(), providerCfg, config.SelectedModel{
+		Model:           "gpt-4o-mini",
+		ProviderOptions: map[string]any{"model_params": map[string]any{"seed": 7}},
+	})
+	if err != nil {
+		t.Fatalf("getOrBuildProvider() error = %v", err)
+	}
+
+	if plain == seeded {
+		t.Error("models with different model params should not share a provider")
+	}
+}
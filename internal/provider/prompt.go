@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+const (
+	// maxPromptFileSize caps each context or prompt file.
+	maxPromptFileSize = 64 << 10
+	// maxSystemPromptSize caps the assembled system prompt.
+	maxSystemPromptSize = 256 << 10
+)
+
+// assembleSystemPrompt builds a tier's system prompt: the global context
+// files in order, then the tier's prompt file, separated by blank lines.
+// Missing context files are skipped, but a missing prompt file is an error
+// since it is set for a specific tier.
+func assembleSystemPrompt(contextPaths []string, promptFile string) (string, error) {
+	var sections []string
+	for _, path := range contextPaths {
+		content, err := readPromptFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			slog.Debug("Skipping missing context file", "path", path)
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		sections = append(sections, content)
+	}
+
+	if promptFile != "" {
+		content, err := readPromptFile(promptFile)
+		if err != nil {
+			return "", err
+		}
+		sections = append(sections, content)
+	}
+
+	prompt := strings.Join(sections, "\n\n")
+	if len(prompt) > maxSystemPromptSize {
+		return "", fmt.Errorf("system prompt is %d bytes, more than the %d byte limit", len(prompt), maxSystemPromptSize)
+	}
+	return prompt, nil
+}
+
+// readPromptFile returns the trimmed content of a prompt or context file.
+func readPromptFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", path, err)
+	}
+	if info.Size() > maxPromptFileSize {
+		return "", fmt.Errorf("%s is %d bytes, more than the %d byte limit", path, info.Size(), maxPromptFileSize)
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // Prompt paths come from the user's config.
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", path, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+func writePromptFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestBuilder_buildModel_SystemPrompt(t *testing.T) {
+	dir := t.TempDir()
+	agents := writePromptFile(t, dir, "AGENTS.md", "Use tabs.\n")
+	style := writePromptFile(t, dir, "STYLE.md", "Keep functions short.")
+	planner := writePromptFile(t, dir, "large.md", "\nYou plan before coding.\n")
+
+	cfg := config.NewConfig()
+	cfg.Options.ContextPaths = []string{agents, filepath.Join(dir, "missing.md"), style}
+	cfg.Providers["openai"] = &config.ProviderConfig{
+		ID:     "openai",
+		Type:   catwalk.TypeOpenAI,
+		APIKey: "sk-test",
+	}
+	builder := NewBuilder(cfg)
+
+	large, err := builder.buildModel(context.Background(), config.SelectedModel{
+		Model:      "gpt-4o",
+		Provider:   "openai",
+		PromptFile: planner,
+	})
+	if err != nil {
+		t.Fatalf("buildModel() error = %v", err)
+	}
+	want := "Use tabs.\n\nKeep functions short.\n\nYou plan before coding."
+	if large.SystemPrompt != want {
+		t.Errorf("SystemPrompt = %q, want %q", large.SystemPrompt, want)
+	}
+
+	small, err := builder.buildModel(context.Background(), config.SelectedModel{
+		Model:    "gpt-4o-mini",
+		Provider: "openai",
+	})
+	if err != nil {
+		t.Fatalf("buildModel() error = %v", err)
+	}
+	if want := "Use tabs.\n\nKeep functions short."; small.SystemPrompt != want {
+		t.Errorf("SystemPrompt without a prompt file = %q, want %q", small.SystemPrompt, want)
+	}
+}
+
+func TestAssembleSystemPrompt_Errors(t *testing.T) {
+	dir := t.TempDir()
+	big := writePromptFile(t, dir, "big.md", strings.Repeat("x", maxPromptFileSize+1))
+	chunk := writePromptFile(t, dir, "chunk.md", strings.Repeat("x", maxPromptFileSize))
+
+	tests := []struct {
+		name         string
+		contextPaths []string
+		promptFile   string
+		wantErr      string
+	}{
+		{name: "missing prompt file", promptFile: filepath.Join(dir, "missing.md"), wantErr: "missing.md"},
+		{name: "file too large", contextPaths: []string{big}, wantErr: "byte limit"},
+		{name: "total too large", contextPaths: []string{chunk, chunk, chunk, chunk}, promptFile: chunk, wantErr: "system prompt is"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := assembleSystemPrompt(tt.contextPaths, tt.promptFile)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("assembleSystemPrompt() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -3,17 +3,28 @@ package provider
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"log/slog"
 	"maps"
+	"net/http"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 
 	"charm.land/fantasy"
 	"charm.land/fantasy/providers/anthropic"
+	"charm.land/fantasy/providers/bedrock"
+	"charm.land/fantasy/providers/google"
 	"charm.land/fantasy/providers/openai"
+	"github.com/openai/openai-go/v2/option"
 
 	"github.com/guilhermegouw/matrix-cli/internal/config"
+	"github.com/guilhermegouw/matrix-cli/internal/oauth"
+	"github.com/guilhermegouw/matrix-cli/internal/oauth/claude"
 )
 
 // Model wraps a fantasy language model with its metadata.
@@ -24,25 +35,69 @@ type Model struct {
 	CatwalkCfg catwalk.Model
 	// ModelCfg holds the user's selected configuration.
 	ModelCfg config.SelectedModel
+	// SystemPrompt is the global context followed by the tier's prompt file.
+	SystemPrompt string
+
+	// callOpts holds the tier's sampling settings, see CallOptions.
+	callOpts fantasy.Call
+	// maxRetries is how many times Generate retries a transient failure.
+	maxRetries int
 }
 
 // Builder creates fantasy providers from configuration.
 type Builder struct {
-	cfg   *config.Config
+	cfg *config.Config
+	// mu guards cache and client, so models can be built concurrently.
+	mu    sync.Mutex
 	cache map[string]fantasy.Provider
-	debug bool
+	// refreshMu serializes OAuth token refreshes, see refreshIfNeeded.
+	refreshMu sync.Mutex
+	// refreshOAuth exchanges a refresh token for a new OAuth token.
+	refreshOAuth func(ctx context.Context, refreshToken string) (*oauth.Token, error)
+	// saveOAuth persists a refreshed OAuth token for a provider in the
+	// config file at path.
+	saveOAuth func(path, providerID string, token *oauth.Token) error
+	// client is the HTTP client shared by every built provider, created on
+	// first use.
+	client *http.Client
+	// middleware wraps client's transport, see WithMiddleware.
+	middleware []Middleware
+	debug      bool
+}
+
+// BuilderOption configures a Builder.
+type BuilderOption func(*Builder)
+
+// WithMiddleware wraps the transport of the HTTP client shared by every built
+// provider with mw, so all outbound provider requests pass through it. The
+// first middleware is the outermost and sees each request first.
+func WithMiddleware(mw ...Middleware) BuilderOption {
+	return func(b *Builder) {
+		b.middleware = append(b.middleware, mw...)
+	}
 }
 
 // NewBuilder creates a new provider Builder.
-func NewBuilder(cfg *config.Config) *Builder {
-	return &Builder{
+func NewBuilder(cfg *config.Config, opts ...BuilderOption) *Builder {
+	b := &Builder{
 		cfg:   cfg,
 		cache: make(map[string]fantasy.Provider),
-		debug: cfg.Options != nil && cfg.Options.Debug,
+		refreshOAuth: func(ctx context.Context, refreshToken string) (*oauth.Token, error) {
+			return claude.RefreshToken(ctx, refreshToken)
+		},
+		saveOAuth: config.SaveOAuthToken,
+		debug:     cfg.Options != nil && cfg.Options.Debug,
+	}
+	for _, opt := range opts {
+		opt(b)
 	}
+	return b
 }
 
-// BuildModels creates the large and small models from configuration.
+// BuildModels creates the large and small models from configuration. When
+// the small model can't be built and options.small_fallback is
+// SmallFallbackLarge, the large model is used for the small tier with a
+// warning instead of failing.
 func (b *Builder) BuildModels(ctx context.Context) (large, small Model, err error) {
 	// Build large model.
 	largeCfg, ok := b.cfg.Models[config.SelectedModelTypeLarge]
@@ -62,13 +117,51 @@ func (b *Builder) BuildModels(ctx context.Context) (large, small Model, err erro
 	} else {
 		small, err = b.buildModel(ctx, smallCfg)
 		if err != nil {
-			return Model{}, Model{}, fmt.Errorf("building small model: %w", err)
+			fallback, policyErr := b.smallFallsBack(ctx)
+			if policyErr != nil {
+				return Model{}, Model{}, policyErr
+			}
+			if !fallback {
+				return Model{}, Model{}, fmt.Errorf("building small model: %w", err)
+			}
+			slog.Warn("Small model unavailable, using the large model instead",
+				"small", smallCfg.Provider+"/"+smallCfg.Model, "large", largeCfg.Provider+"/"+largeCfg.Model, "error", err)
+			small = large
 		}
 	}
 
+	// The usage log is best effort and never fails the build.
+	if err := b.recordUsage(map[config.SelectedModelType]config.SelectedModel{
+		config.SelectedModelTypeLarge: large.ModelCfg,
+		config.SelectedModelTypeSmall: small.ModelCfg,
+	}); err != nil {
+		slog.Warn("Failed to record model usage", "error", err)
+	}
+
 	return large, small, nil
 }
 
+// smallFallsBack reports whether a small model that failed to build is
+// replaced by the large one, per options.small_fallback. A canceled build
+// never falls back, so the cancellation isn't hidden.
+func (b *Builder) smallFallsBack(ctx context.Context) (bool, error) {
+	if ctx.Err() != nil {
+		return false, nil
+	}
+	var policy config.SmallFallback
+	if b.cfg.Options != nil {
+		policy = b.cfg.Options.SmallFallback
+	}
+	switch policy {
+	case "", config.SmallFallbackNone:
+		return false, nil
+	case config.SmallFallbackLarge:
+		return true, nil
+	}
+	return false, fmt.Errorf("unknown small_fallback %q (want %q or %q)",
+		policy, config.SmallFallbackNone, config.SmallFallbackLarge)
+}
+
 // buildModel creates a Model from a selected model configuration.
 func (b *Builder) buildModel(ctx context.Context, modelCfg config.SelectedModel) (Model, error) {
 	providerCfg, ok := b.cfg.Providers[modelCfg.Provider]
@@ -76,8 +169,28 @@ func (b *Builder) buildModel(ctx context.Context, modelCfg config.SelectedModel)
 		return Model{}, fmt.Errorf("provider %q not configured", modelCfg.Provider)
 	}
 
+	// Model IDs may reference environment variables, e.g. a deployment name.
+	modelID, err := b.cfg.Resolve(modelCfg.Model)
+	if err != nil {
+		return Model{}, fmt.Errorf("resolving model %q: %w", modelCfg.Model, err)
+	}
+	modelCfg.Model = modelID
+
+	if err := b.refreshIfNeeded(ctx, providerCfg); err != nil {
+		return Model{}, err
+	}
+
+	var contextPaths []string
+	if b.cfg.Options != nil {
+		contextPaths = b.cfg.Options.ContextPaths
+	}
+	systemPrompt, err := assembleSystemPrompt(contextPaths, modelCfg.PromptFile)
+	if err != nil {
+		return Model{}, fmt.Errorf("building system prompt: %w", err)
+	}
+
 	// Build or get cached fantasy provider.
-	provider, err := b.getOrBuildProvider(providerCfg, modelCfg)
+	provider, err := b.getOrBuildProvider(ctx, providerCfg, modelCfg)
 	if err != nil {
 		return Model{}, err
 	}
@@ -95,36 +208,84 @@ func (b *Builder) buildModel(ctx context.Context, modelCfg config.SelectedModel)
 	}
 
 	return Model{
-		Model:      lm,
-		CatwalkCfg: catwalkModel,
-		ModelCfg:   modelCfg,
+		Model:        lm,
+		CatwalkCfg:   catwalkModel,
+		ModelCfg:     modelCfg,
+		SystemPrompt: systemPrompt,
+		callOpts:     callOptions(modelCfg),
+		maxRetries:   modelCfg.Retries(b.cfg.Options),
 	}, nil
 }
 
-// getOrBuildProvider returns a cached provider or builds a new one.
-func (b *Builder) getOrBuildProvider(providerCfg *config.ProviderConfig, modelCfg config.SelectedModel) (fantasy.Provider, error) {
-	if p, ok := b.cache[providerCfg.ID]; ok {
+// getOrBuildProvider returns a cached provider or builds a new one. The
+// lock is held while building so concurrent callers get the same instance.
+func (b *Builder) getOrBuildProvider(ctx context.Context, providerCfg *config.ProviderConfig, modelCfg config.SelectedModel) (fantasy.Provider, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := providerCacheKey(providerCfg, modelCfg)
+	if p, ok := b.cache[key]; ok {
 		return p, nil
 	}
 
-	p, err := b.buildProvider(providerCfg, modelCfg)
+	p, err := b.buildProvider(ctx, providerCfg, modelCfg)
 	if err != nil {
 		return nil, err
 	}
 
-	b.cache[providerCfg.ID] = p
+	b.cache[key] = p
 	return p, nil
 }
 
+// providerCacheKey returns the cache key for a provider. It starts with the
+// provider ID and includes a hash of the settings the provider is built
+// from, so a provider whose base URL or key changed isn't reused. Stop
+// sequences and model params are applied at the provider level, so models
+// that differ in either need their own provider instance.
+func providerCacheKey(providerCfg *config.ProviderConfig, modelCfg config.SelectedModel) string {
+	key := providerCfg.ID + "\x00cfg=" + providerConfigHash(providerCfg, modelCfg)
+	if len(modelCfg.Stop) > 0 {
+		key += "\x00stop=" + strings.Join(modelCfg.Stop, "\x00")
+	}
+	if modelCfg.ReasoningEffort != "" {
+		key += "\x00effort=" + modelCfg.ReasoningEffort
+	}
+	if params := modelParamsKey(modelParams(modelCfg)); params != "" {
+		key += "\x00params=" + params
+	}
+	return key
+}
+
+// providerConfigHash hashes the settings buildProvider reads, keeping the
+// API key out of the cache key itself. Thinking mode is included because it
+// adds an Anthropic beta header.
+func providerConfigHash(providerCfg *config.ProviderConfig, modelCfg config.SelectedModel) string {
+	h := sha256.New()
+	// fmt prints maps sorted by key, so the encoding is stable.
+	fmt.Fprintf(h, "%q\x00%q\x00%q\x00%q\x00%q\x00%t\x00%v\x00%v\x00%t", //nolint:errcheck // Hash writes never fail.
+		providerCfg.Type, providerCfg.BaseURL, providerCfg.APIKey,
+		providerCfg.AuthHeader, providerCfg.AuthScheme, providerCfg.PreserveHeaderCase,
+		providerCfg.ExtraHeaders, providerCfg.ProviderOptions, modelCfg.ThinkEnabled())
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
 // buildProvider creates a fantasy provider from configuration.
-func (b *Builder) buildProvider(providerCfg *config.ProviderConfig, modelCfg config.SelectedModel) (fantasy.Provider, error) {
+// It returns early if ctx is already canceled.
+func (b *Builder) buildProvider(ctx context.Context, providerCfg *config.ProviderConfig, modelCfg config.SelectedModel) (fantasy.Provider, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("building provider %q: %w", providerCfg.ID, err)
+	}
+
 	headers := maps.Clone(providerCfg.ExtraHeaders)
 	if headers == nil {
 		headers = make(map[string]string)
 	}
+	if err := b.addDefaultHeaders(headers); err != nil {
+		return nil, fmt.Errorf("building provider %q: %w", providerCfg.ID, err)
+	}
 
 	// Handle special headers for anthropic thinking mode.
-	if providerCfg.Type == anthropic.Name && modelCfg.Think {
+	if providerCfg.Type == anthropic.Name && modelCfg.ThinkEnabled() {
 		if v, ok := headers["anthropic-beta"]; ok {
 			headers["anthropic-beta"] = v + ",interleaved-thinking-2025-05-14"
 		} else {
@@ -132,26 +293,137 @@ func (b *Builder) buildProvider(providerCfg *config.ProviderConfig, modelCfg con
 		}
 	}
 
+	client, err := b.httpClient()
+	if err != nil {
+		return nil, fmt.Errorf("building provider %q: %w", providerCfg.ID, err)
+	}
+
 	apiKey := providerCfg.APIKey
 	baseURL := providerCfg.BaseURL
 
-	//nolint:exhaustive // Only openai and anthropic are supported initially.
+	//nolint:exhaustive // Only openai, anthropic, gemini and bedrock are supported.
 	switch providerCfg.Type {
 	case openai.Name, catwalk.TypeOpenAICompat:
-		return b.buildOpenAIProvider(baseURL, apiKey, headers)
+		apiKey = applyAuthHeader(headers, providerCfg.AuthHeader, apiKey)
+		var opts []openai.Option
+		opts = append(opts, openai.WithHTTPClient(providerClient(client, providerCfg, headers)))
+		if len(modelCfg.Stop) > 0 {
+			opts = append(opts, openai.WithSDKOptions(option.WithJSONSet("stop", modelCfg.Stop)))
+		}
+		if modelCfg.ReasoningEffort != "" {
+			if err := validateReasoningEffort(modelCfg.ReasoningEffort); err != nil {
+				return nil, fmt.Errorf("building provider %q: %w", providerCfg.ID, err)
+			}
+			opts = append(opts, openai.WithSDKOptions(option.WithJSONSet("reasoning_effort", modelCfg.ReasoningEffort)))
+		}
+		if paramOpts := openAIParamOptions(modelParams(modelCfg)); len(paramOpts) > 0 {
+			opts = append(opts, openai.WithSDKOptions(paramOpts...))
+		}
+		return b.buildOpenAIProvider(baseURL, apiKey, headers, opts...)
 	case anthropic.Name:
-		return b.buildAnthropicProvider(baseURL, apiKey, headers)
+		var opts []anthropic.Option
+		opts = append(opts, anthropic.WithHTTPClient(providerClient(client, providerCfg, headers)))
+		if len(modelCfg.Stop) > 0 {
+			slog.Debug("Stop sequences are not supported for this provider type; ignoring",
+				"provider", providerCfg.ID, "type", providerCfg.Type)
+		}
+		if modelCfg.ReasoningEffort != "" {
+			slog.Debug("Reasoning effort is not supported for this provider type; ignoring",
+				"provider", providerCfg.ID, "type", providerCfg.Type)
+		}
+		if len(modelParams(modelCfg)) > 0 {
+			slog.Debug("Model params are not supported for this provider type; ignoring",
+				"provider", providerCfg.ID, "type", providerCfg.Type)
+		}
+		return b.buildAnthropicProvider(baseURL, apiKey, providerCfg.AuthScheme, headers, opts...)
+	case catwalk.TypeGoogle:
+		geminiURL, err := b.geminiBaseURL(providerCfg)
+		if err != nil {
+			return nil, fmt.Errorf("building provider %q: %w", providerCfg.ID, err)
+		}
+		// The Gemini client refuses to start without a key, so it keeps the
+		// key even when auth_header also carries it.
+		applyAuthHeader(headers, providerCfg.AuthHeader, apiKey)
+		var opts []google.Option
+		opts = append(opts, google.WithHTTPClient(providerClient(client, providerCfg, headers)))
+		if len(modelCfg.Stop) > 0 {
+			slog.Debug("Stop sequences are not supported for this provider type; ignoring",
+				"provider", providerCfg.ID, "type", providerCfg.Type)
+		}
+		if modelCfg.ReasoningEffort != "" {
+			slog.Debug("Reasoning effort is not supported for this provider type; ignoring",
+				"provider", providerCfg.ID, "type", providerCfg.Type)
+		}
+		if len(modelParams(modelCfg)) > 0 {
+			slog.Debug("Model params are not supported for this provider type; ignoring",
+				"provider", providerCfg.ID, "type", providerCfg.Type)
+		}
+		return b.buildGeminiProvider(geminiURL, apiKey, headers, opts...)
+	case catwalk.TypeBedrock:
+		return b.buildBedrockProvider(providerCfg, apiKey, headers, bedrock.WithHTTPClient(providerClient(client, providerCfg, headers)))
 	default:
 		return nil, fmt.Errorf("unsupported provider type: %q", providerCfg.Type)
 	}
 }
 
-// buildOpenAIProvider creates an OpenAI fantasy provider.
-func (b *Builder) buildOpenAIProvider(baseURL, apiKey string, headers map[string]string) (fantasy.Provider, error) {
-	var opts []openai.Option
+// supportedTypes are the provider types buildProvider can build.
+var supportedTypes = []catwalk.Type{
+	catwalk.TypeOpenAI,
+	catwalk.TypeOpenAICompat,
+	catwalk.TypeAnthropic,
+	catwalk.TypeGoogle,
+	catwalk.TypeBedrock,
+}
+
+// SupportedTypes returns the provider types that can be built.
+func SupportedTypes() []catwalk.Type {
+	return slices.Clone(supportedTypes)
+}
+
+// reasoningEfforts are the accepted reasoning_effort values.
+var reasoningEfforts = []string{"low", "medium", "high"}
+
+// validateReasoningEffort checks effort against the accepted values.
+func validateReasoningEffort(effort string) error {
+	if !slices.Contains(reasoningEfforts, effort) {
+		return fmt.Errorf("invalid reasoning_effort %q (want one of %s)", effort, strings.Join(reasoningEfforts, ", "))
+	}
+	return nil
+}
+
+// applyAuthHeader places the API key in a custom auth header when one is
+// configured. It returns the key to use for default bearer auth, which is
+// empty when the key has been moved into the custom header.
+func applyAuthHeader(headers map[string]string, authHeader, apiKey string) string {
+	if authHeader == "" || apiKey == "" {
+		return apiKey
+	}
+	headers[authHeader] = apiKey
+	return ""
+}
+
+// hasHeader reports whether headers sets name, compared case-insensitively.
+func hasHeader(headers map[string]string, name string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildOpenAIProvider creates an OpenAI fantasy provider. Without an API
+// key, as for keyless providers or one moved into a custom auth header, no
+// Authorization header is sent unless headers sets one.
+func (b *Builder) buildOpenAIProvider(baseURL, apiKey string, headers map[string]string, extra ...openai.Option) (fantasy.Provider, error) {
+	opts := extra
 
 	if apiKey != "" {
 		opts = append(opts, openai.WithAPIKey(apiKey))
+	} else if !hasHeader(headers, "Authorization") {
+		// openai-go otherwise sends $OPENAI_API_KEY as a bearer token, which
+		// must not reach a gateway or a keyless server.
+		opts = append(opts, openai.WithSDKOptions(option.WithHeaderDel("Authorization")))
 	}
 	if len(headers) > 0 {
 		opts = append(opts, openai.WithHeaders(headers))
@@ -164,14 +436,24 @@ func (b *Builder) buildOpenAIProvider(baseURL, apiKey string, headers map[string
 }
 
 // buildAnthropicProvider creates an Anthropic fantasy provider.
-func (b *Builder) buildAnthropicProvider(baseURL, apiKey string, headers map[string]string) (fantasy.Provider, error) {
-	var opts []anthropic.Option
+// The scheme forces bearer or x-api-key auth; when empty it is inferred from
+// a "Bearer " prefix on the key, as used by OAuth tokens.
+func (b *Builder) buildAnthropicProvider(baseURL, apiKey string, scheme config.AuthScheme, headers map[string]string, extra ...anthropic.Option) (fantasy.Provider, error) {
+	opts := extra
 
-	// Handle OAuth token format.
-	if strings.HasPrefix(apiKey, "Bearer ") {
-		headers["Authorization"] = apiKey
-	} else if apiKey != "" {
-		opts = append(opts, anthropic.WithAPIKey(apiKey))
+	key, hasBearerPrefix := strings.CutPrefix(apiKey, "Bearer ")
+	if scheme == "" {
+		scheme = config.AuthSchemeAPIKey
+		if hasBearerPrefix {
+			scheme = config.AuthSchemeBearer
+		}
+	}
+	switch {
+	case key == "":
+	case scheme == config.AuthSchemeBearer:
+		headers["Authorization"] = "Bearer " + key
+	default:
+		opts = append(opts, anthropic.WithAPIKey(key))
 	}
 
 	if len(headers) > 0 {
@@ -183,3 +465,42 @@ func (b *Builder) buildAnthropicProvider(baseURL, apiKey string, headers map[str
 
 	return anthropic.New(opts...)
 }
+
+// buildGeminiProvider creates a Gemini fantasy provider. An empty baseURL
+// uses the Gemini API's default endpoint.
+func (b *Builder) buildGeminiProvider(baseURL, apiKey string, headers map[string]string, extra ...google.Option) (fantasy.Provider, error) {
+	opts := extra
+
+	if apiKey != "" {
+		opts = append(opts, google.WithGeminiAPIKey(apiKey))
+	}
+	if len(headers) > 0 {
+		opts = append(opts, google.WithHeaders(headers))
+	}
+	if baseURL != "" {
+		opts = append(opts, google.WithBaseURL(baseURL))
+	}
+
+	return google.New(opts...)
+}
+
+// geminiBaseURL returns the base URL of a Gemini provider. Catwalk's default
+// endpoint names an optional variable, so when that variable is unset the
+// Gemini API's default endpoint is used. Any other base URL that doesn't
+// resolve is an error.
+func (b *Builder) geminiBaseURL(providerCfg *config.ProviderConfig) (string, error) {
+	baseURL := providerCfg.BaseURL
+	if !strings.Contains(baseURL, "$") {
+		return baseURL, nil
+	}
+	resolved, err := b.cfg.Resolve(baseURL)
+	if err == nil {
+		return resolved, nil
+	}
+	for _, known := range b.cfg.KnownProviders() {
+		if string(known.ID) == providerCfg.ID && known.APIEndpoint == baseURL {
+			return "", nil
+		}
+	}
+	return "", fmt.Errorf("resolving base_url: %w", err)
+}
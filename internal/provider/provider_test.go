@@ -3,10 +3,20 @@ package provider
 
 import (
 	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 
+	"charm.land/fantasy"
+
 	"github.com/guilhermegouw/matrix-cli/internal/config"
 )
 
@@ -131,7 +141,7 @@ func TestBuilder_buildProvider_OpenAI(t *testing.T) {
 		Provider: "openai",
 	}
 
-	provider, err := builder.buildProvider(providerCfg, modelCfg)
+	provider, err := builder.buildProvider(context.Background(), providerCfg, modelCfg)
 	if err != nil {
 		t.Fatalf("buildProvider() error = %v", err)
 	}
@@ -154,7 +164,7 @@ func TestBuilder_buildProvider_OpenAICompat(t *testing.T) {
 		Provider: "local",
 	}
 
-	provider, err := builder.buildProvider(providerCfg, modelCfg)
+	provider, err := builder.buildProvider(context.Background(), providerCfg, modelCfg)
 	if err != nil {
 		t.Fatalf("buildProvider() error = %v", err)
 	}
@@ -177,7 +187,7 @@ func TestBuilder_buildProvider_Anthropic(t *testing.T) {
 		Provider: "anthropic",
 	}
 
-	provider, err := builder.buildProvider(providerCfg, modelCfg)
+	provider, err := builder.buildProvider(context.Background(), providerCfg, modelCfg)
 	if err != nil {
 		t.Fatalf("buildProvider() error = %v", err)
 	}
@@ -187,6 +197,7 @@ func TestBuilder_buildProvider_Anthropic(t *testing.T) {
 }
 
 func TestBuilder_buildProvider_AnthropicWithThink(t *testing.T) {
+	think := true
 	cfg := config.NewConfig()
 	builder := NewBuilder(cfg)
 
@@ -198,10 +209,10 @@ func TestBuilder_buildProvider_AnthropicWithThink(t *testing.T) {
 	modelCfg := config.SelectedModel{
 		Model:    "claude-3-opus",
 		Provider: "anthropic",
-		Think:    true,
+		Think:    &think,
 	}
 
-	provider, err := builder.buildProvider(providerCfg, modelCfg)
+	provider, err := builder.buildProvider(context.Background(), providerCfg, modelCfg)
 	if err != nil {
 		t.Fatalf("buildProvider() error = %v", err)
 	}
@@ -211,6 +222,7 @@ func TestBuilder_buildProvider_AnthropicWithThink(t *testing.T) {
 }
 
 func TestBuilder_buildProvider_AnthropicWithExistingBetaHeader(t *testing.T) {
+	think := true
 	cfg := config.NewConfig()
 	builder := NewBuilder(cfg)
 
@@ -225,10 +237,10 @@ func TestBuilder_buildProvider_AnthropicWithExistingBetaHeader(t *testing.T) {
 	modelCfg := config.SelectedModel{
 		Model:    "claude-3-opus",
 		Provider: "anthropic",
-		Think:    true,
+		Think:    &think,
 	}
 
-	provider, err := builder.buildProvider(providerCfg, modelCfg)
+	provider, err := builder.buildProvider(context.Background(), providerCfg, modelCfg)
 	if err != nil {
 		t.Fatalf("buildProvider() error = %v", err)
 	}
@@ -251,7 +263,7 @@ func TestBuilder_buildProvider_AnthropicWithBearerToken(t *testing.T) {
 		Provider: "anthropic",
 	}
 
-	provider, err := builder.buildProvider(providerCfg, modelCfg)
+	provider, err := builder.buildProvider(context.Background(), providerCfg, modelCfg)
 	if err != nil {
 		t.Fatalf("buildProvider() error = %v", err)
 	}
@@ -273,7 +285,7 @@ func TestBuilder_buildProvider_UnsupportedType(t *testing.T) {
 		Provider: "custom",
 	}
 
-	_, err := builder.buildProvider(providerCfg, modelCfg)
+	_, err := builder.buildProvider(context.Background(), providerCfg, modelCfg)
 	if err == nil {
 		t.Error("buildProvider() expected error for unsupported type")
 	}
@@ -296,7 +308,7 @@ func TestBuilder_buildProvider_WithExtraHeaders(t *testing.T) {
 		Provider: "openai",
 	}
 
-	provider, err := builder.buildProvider(providerCfg, modelCfg)
+	provider, err := builder.buildProvider(context.Background(), providerCfg, modelCfg)
 	if err != nil {
 		t.Fatalf("buildProvider() error = %v", err)
 	}
@@ -305,6 +317,139 @@ func TestBuilder_buildProvider_WithExtraHeaders(t *testing.T) {
 	}
 }
 
+func TestApplyAuthHeader(t *testing.T) {
+	t.Run("custom header carries the key", func(t *testing.T) {
+		headers := map[string]string{}
+		got := applyAuthHeader(headers, "X-API-Key", "sk-test")
+
+		if got != "" {
+			t.Errorf("applyAuthHeader() = %q, want empty bearer key", got)
+		}
+		if headers["X-API-Key"] != "sk-test" {
+			t.Errorf("headers[X-API-Key] = %q, want %q", headers["X-API-Key"], "sk-test")
+		}
+	})
+
+	t.Run("unset header keeps bearer auth", func(t *testing.T) {
+		headers := map[string]string{}
+		got := applyAuthHeader(headers, "", "sk-test")
+
+		if got != "sk-test" {
+			t.Errorf("applyAuthHeader() = %q, want %q", got, "sk-test")
+		}
+		if len(headers) != 0 {
+			t.Errorf("headers = %v, want empty", headers)
+		}
+	})
+
+	t.Run("empty key leaves headers untouched", func(t *testing.T) {
+		headers := map[string]string{}
+		applyAuthHeader(headers, "X-API-Key", "")
+
+		if _, ok := headers["X-API-Key"]; ok {
+			t.Error("headers should not contain X-API-Key for an empty key")
+		}
+	})
+}
+
+func TestBuilder_buildProvider_OpenAICompatWithAuthHeader(t *testing.T) {
+	cfg := config.NewConfig()
+	builder := NewBuilder(cfg)
+
+	providerCfg := &config.ProviderConfig{
+		ID:         "gateway",
+		Type:       catwalk.TypeOpenAICompat,
+		APIKey:     "gw-key",
+		AuthHeader: "X-API-Key",
+		BaseURL:    "http://localhost:8080",
+	}
+	modelCfg := config.SelectedModel{
+		Model:    "local-model",
+		Provider: "gateway",
+	}
+
+	provider, err := builder.buildProvider(context.Background(), providerCfg, modelCfg)
+	if err != nil {
+		t.Fatalf("buildProvider() error = %v", err)
+	}
+	if provider == nil {
+		t.Error("buildProvider() returned nil provider")
+	}
+	if _, ok := providerCfg.ExtraHeaders["X-API-Key"]; ok {
+		t.Error("buildProvider() should not mutate the provider's ExtraHeaders")
+	}
+}
+
+func TestBuilder_buildModel_AuthHeaderSendsNoBearer(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "sk-real-openai-key")
+	server, header := captureRequestHeaders(t)
+
+	cfg := config.NewConfig()
+	cfg.Providers["gateway"] = &config.ProviderConfig{
+		ID:         "gateway",
+		Type:       catwalk.TypeOpenAICompat,
+		APIKey:     "gw-key",
+		AuthHeader: "X-API-Key",
+		BaseURL:    server.URL,
+	}
+
+	model, err := NewBuilder(cfg).buildModel(context.Background(), config.SelectedModel{Model: "local-model", Provider: "gateway"})
+	if err != nil {
+		t.Fatalf("buildModel() error = %v", err)
+	}
+	if _, err := model.Model.Generate(context.Background(), fantasy.Call{
+		Prompt: fantasy.Prompt{fantasy.NewUserMessage("hi")},
+	}); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if got := header.Get("X-API-Key"); got != "gw-key" {
+		t.Errorf("X-API-Key = %q, want the gateway key", got)
+	}
+	if got := header.Get("Authorization"); got != "" {
+		t.Errorf("Authorization = %q, want none sent to the gateway", got)
+	}
+}
+
+func TestBuilder_buildModel_KeylessSendsNoBearer(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "sk-real-openai-key")
+
+	tests := []struct {
+		name          string
+		headers       map[string]string
+		wantAuthValue string
+	}{
+		{name: "no auth headers"},
+		{name: "own Authorization header", headers: map[string]string{"authorization": "Bearer local-token"}, wantAuthValue: "Bearer local-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, header := captureRequestHeaders(t)
+
+			cfg := config.NewConfig()
+			cfg.Providers["local"] = &config.ProviderConfig{
+				ID:           "local",
+				Type:         catwalk.TypeOpenAICompat,
+				BaseURL:      server.URL,
+				ExtraHeaders: tt.headers,
+			}
+
+			model, err := NewBuilder(cfg).buildModel(context.Background(), config.SelectedModel{Model: "llama3", Provider: "local"})
+			if err != nil {
+				t.Fatalf("buildModel() error = %v", err)
+			}
+			if _, err := model.Model.Generate(context.Background(), fantasy.Call{
+				Prompt: fantasy.Prompt{fantasy.NewUserMessage("hi")},
+			}); err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if got := header.Get("Authorization"); got != tt.wantAuthValue {
+				t.Errorf("Authorization = %q, want %q", got, tt.wantAuthValue)
+			}
+		})
+	}
+}
+
 func TestBuilder_getOrBuildProvider_Caching(t *testing.T) {
 	cfg := config.NewConfig()
 	builder := NewBuilder(cfg)
@@ -320,13 +465,13 @@ func TestBuilder_getOrBuildProvider_Caching(t *testing.T) {
 	}
 
 	// First call should build.
-	p1, err := builder.getOrBuildProvider(providerCfg, modelCfg)
+	p1, err := builder.getOrBuildProvider(context.Background(), providerCfg, modelCfg)
 	if err != nil {
 		t.Fatalf("getOrBuildProvider() first call error = %v", err)
 	}
 
 	// Second call should return cached.
-	p2, err := builder.getOrBuildProvider(providerCfg, modelCfg)
+	p2, err := builder.getOrBuildProvider(context.Background(), providerCfg, modelCfg)
 	if err != nil {
 		t.Fatalf("getOrBuildProvider() second call error = %v", err)
 	}
@@ -337,6 +482,99 @@ func TestBuilder_getOrBuildProvider_Caching(t *testing.T) {
 	}
 }
 
+func TestBuilder_getOrBuildProvider_Concurrent(t *testing.T) {
+	builder := NewBuilder(config.NewConfig())
+	providerCfg := &config.ProviderConfig{
+		ID:     "openai",
+		Type:   catwalk.TypeOpenAI,
+		APIKey: "sk-test",
+	}
+
+	const goroutines = 16
+	providers := make([]fantasy.Provider, goroutines)
+	var wg sync.WaitGroup
+	for i := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, err := builder.getOrBuildProvider(context.Background(), providerCfg, config.SelectedModel{Model: "gpt-4o"})
+			if err != nil {
+				t.Errorf("getOrBuildProvider() error = %v", err)
+				return
+			}
+			providers[i] = p
+		}()
+	}
+	wg.Wait()
+
+	for i, p := range providers {
+		if p == nil || p != providers[0] {
+			t.Fatalf("goroutine %d got provider %v, want the single shared instance", i, p)
+		}
+	}
+	if len(builder.cache) != 1 {
+		t.Errorf("cache has %d providers, want 1", len(builder.cache))
+	}
+}
+
+func TestBuilder_getOrBuildProvider_CanceledContext(t *testing.T) {
+	cfg := config.NewConfig()
+	builder := NewBuilder(cfg)
+
+	providerCfg := &config.ProviderConfig{
+		ID:     "openai",
+		Type:   catwalk.TypeOpenAI,
+		APIKey: "sk-test",
+	}
+	modelCfg := config.SelectedModel{
+		Model:    "gpt-4o",
+		Provider: "openai",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := builder.getOrBuildProvider(ctx, providerCfg, modelCfg)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("getOrBuildProvider() error = %v, want context.Canceled", err)
+	}
+	if len(builder.cache) != 0 {
+		t.Error("getOrBuildProvider() should not cache a provider for a canceled context")
+	}
+}
+
+func TestBuilder_BuildModels_CanceledContext(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := config.NewConfig()
+	cfg.Providers["openai"] = &config.ProviderConfig{
+		ID:      "openai",
+		Type:    catwalk.TypeOpenAI,
+		APIKey:  "sk-test",
+		BaseURL: server.URL,
+	}
+	cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{
+		Model:    "gpt-4o",
+		Provider: "openai",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := NewBuilder(cfg).BuildModels(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("BuildModels() error = %v, want context.Canceled", err)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("server received %d requests, want 0", n)
+	}
+}
+
 func TestBuilder_buildOpenAIProvider_MinimalConfig(t *testing.T) {
 	cfg := config.NewConfig()
 	builder := NewBuilder(cfg)
@@ -356,7 +594,7 @@ func TestBuilder_buildAnthropicProvider_MinimalConfig(t *testing.T) {
 	builder := NewBuilder(cfg)
 
 	// Test with minimal config.
-	provider, err := builder.buildAnthropicProvider("", "", nil)
+	provider, err := builder.buildAnthropicProvider("", "", "", nil)
 	if err != nil {
 		t.Fatalf("buildAnthropicProvider() error = %v", err)
 	}
@@ -369,7 +607,7 @@ func TestBuilder_buildAnthropicProvider_WithBaseURL(t *testing.T) {
 	cfg := config.NewConfig()
 	builder := NewBuilder(cfg)
 
-	provider, err := builder.buildAnthropicProvider("https://custom.api.com", "sk-ant-test", nil)
+	provider, err := builder.buildAnthropicProvider("https://custom.api.com", "sk-ant-test", "", nil)
 	if err != nil {
 		t.Fatalf("buildAnthropicProvider() error = %v", err)
 	}
@@ -438,6 +676,54 @@ func TestBuilder_BuildModels_Success(t *testing.T) {
 	}
 }
 
+func TestBuilder_BuildModels_EnvModelID(t *testing.T) {
+	t.Setenv("MATRIX_TEST_DEPLOYMENT", "gpt-4o")
+
+	cfg := config.NewConfig()
+	cfg.Providers["openai"] = &config.ProviderConfig{
+		ID:     "openai",
+		Type:   catwalk.TypeOpenAI,
+		APIKey: "sk-test",
+		Models: []catwalk.Model{{ID: "gpt-4o", Name: "GPT-4o"}},
+	}
+	cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{
+		Model:    "$MATRIX_TEST_DEPLOYMENT",
+		Provider: "openai",
+	}
+
+	large, _, err := NewBuilder(cfg).BuildModels(context.Background())
+	if err != nil {
+		t.Fatalf("BuildModels() error = %v", err)
+	}
+	if large.ModelCfg.Model != "gpt-4o" {
+		t.Errorf("large.ModelCfg.Model = %q, want %q", large.ModelCfg.Model, "gpt-4o")
+	}
+	if large.CatwalkCfg.ID != "gpt-4o" {
+		t.Errorf("large.CatwalkCfg.ID = %q, want %q", large.CatwalkCfg.ID, "gpt-4o")
+	}
+}
+
+func TestBuilder_BuildModels_UndefinedEnvModelID(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Providers["openai"] = &config.ProviderConfig{
+		ID:     "openai",
+		Type:   catwalk.TypeOpenAI,
+		APIKey: "sk-test",
+	}
+	cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{
+		Model:    "${MATRIX_TEST_UNDEFINED_DEPLOYMENT}",
+		Provider: "openai",
+	}
+
+	_, _, err := NewBuilder(cfg).BuildModels(context.Background())
+	if err == nil {
+		t.Fatal("BuildModels() expected error for undefined model variable")
+	}
+	if !strings.Contains(err.Error(), "MATRIX_TEST_UNDEFINED_DEPLOYMENT") {
+		t.Errorf("BuildModels() error = %q, want it to name the variable", err)
+	}
+}
+
 func TestBuilder_BuildModels_FallbackSmallToLarge(t *testing.T) {
 	cfg := config.NewConfig()
 
@@ -496,6 +782,53 @@ func TestBuilder_BuildModels_SmallModelError(t *testing.T) {
 	}
 }
 
+func TestBuilder_BuildModels_SmallFallback(t *testing.T) {
+	tests := []struct { //nolint:govet // Test struct alignment is not critical.
+		name    string
+		policy  config.SmallFallback
+		wantErr string
+	}{
+		{name: "unset fails", wantErr: "building small model"},
+		{name: "none fails", policy: config.SmallFallbackNone, wantErr: "building small model"},
+		{name: "large falls back", policy: config.SmallFallbackLarge},
+		{name: "unknown policy", policy: "tiny", wantErr: `unknown small_fallback "tiny"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.Options = &config.Options{SmallFallback: tt.policy}
+			cfg.Providers["openai"] = &config.ProviderConfig{
+				ID:     "openai",
+				Type:   catwalk.TypeOpenAI,
+				APIKey: "sk-test",
+			}
+			// The small tier's provider can't be constructed.
+			cfg.Providers["broken"] = &config.ProviderConfig{
+				ID:     "broken",
+				Type:   "unsupported-type",
+				APIKey: "sk-test",
+			}
+			cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{Model: "gpt-4o", Provider: "openai"}
+			cfg.Models[config.SelectedModelTypeSmall] = config.SelectedModel{Model: "tiny", Provider: "broken"}
+
+			_, small, err := NewBuilder(cfg).BuildModels(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("BuildModels() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BuildModels() error = %v", err)
+			}
+			if small.ModelCfg.Provider != "openai" || small.ModelCfg.Model != "gpt-4o" {
+				t.Errorf("small = %s/%s, want the large model", small.ModelCfg.Provider, small.ModelCfg.Model)
+			}
+		})
+	}
+}
+
 func TestBuilder_buildModel_WithCatwalkMetadata(t *testing.T) {
 	cfg := config.NewConfig()
 
@@ -557,3 +890,412 @@ func TestBuilder_BuildModels_Anthropic(t *testing.T) {
 		t.Error("large.Model is nil")
 	}
 }
+
+// captureRequestBody starts a server that records each request body and
+// replies with a minimal chat completion.
+func captureRequestBody(t *testing.T) (*httptest.Server, *string) {
+	t.Helper()
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading request body: %v", err)
+		}
+		body = string(data)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"1","object":"chat.completion","model":"gpt-4o",`+
+			`"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"ok"}}]}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, &body
+}
+
+func TestBuilder_buildModel_StopSequences(t *testing.T) {
+	tests := []struct { //nolint:govet // Test struct alignment is not critical.
+		name     string
+		stop     []string
+		wantStop bool
+	}{
+		{name: "forwarded", stop: []string{"END", "\n\n"}, wantStop: true},
+		{name: "empty slice is a no-op", stop: []string{}, wantStop: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, body := captureRequestBody(t)
+
+			cfg := config.NewConfig()
+			cfg.Providers["openai"] = &config.ProviderConfig{
+				ID:      "openai",
+				Type:    catwalk.TypeOpenAI,
+				APIKey:  "sk-test",
+				BaseURL: server.URL,
+			}
+			builder := NewBuilder(cfg)
+
+			model, err := builder.buildModel(context.Background(), config.SelectedModel{
+				Model:    "gpt-4o",
+				Provider: "openai",
+				Stop:     tt.stop,
+			})
+			if err != nil {
+				t.Fatalf("buildModel() error = %v", err)
+			}
+
+			_, err = model.Model.Generate(context.Background(), fantasy.Call{
+				Prompt: fantasy.Prompt{fantasy.NewUserMessage("hi")},
+			})
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			hasStop := strings.Contains(*body, `"stop":`)
+			if hasStop != tt.wantStop {
+				t.Errorf("request body has stop = %v, want %v; body: %s", hasStop, tt.wantStop, *body)
+			}
+			if tt.wantStop && !strings.Contains(*body, `"stop":["END","\n\n"]`) {
+				t.Errorf("request body should forward stop sequences, got: %s", *body)
+			}
+		})
+	}
+}
+
+func TestBuilder_getOrBuildProvider_StopSequencesNotShared(t *testing.T) {
+	cfg := config.NewConfig()
+	builder := NewBuilder(cfg)
+
+	providerCfg := &config.ProviderConfig{
+		ID:     "openai",
+		Type:   catwalk.TypeOpenAI,
+		APIKey: "sk-test",
+	}
+
+	plain, err := builder.getOrBuildProvider(context.Background(), providerCfg, config.SelectedModel{Model: "gpt-4o"})
+	if err != nil {
+		t.Fatalf("getOrBuildProvider() error = %v", err)
+	}
+	withStop, err := builder.getOrBuildProvider(context.Background(), providerCfg, config.SelectedModel{
+		Model: "gpt-4o-mini",
+		Stop:  []string{"END"},
+	})
+	if err != nil {
+		t.Fatalf("getOrBuildProvider() error = %v", err)
+	}
+
+	if plain == withStop {
+		t.Error("models with different stop sequences should not share a provider")
+	}
+}
+
+func TestBuilder_getOrBuildProvider_SameIDDifferentSettings(t *testing.T) {
+	builder := NewBuilder(config.NewConfig())
+
+	first := &config.ProviderConfig{
+		ID:      "openai",
+		Type:    catwalk.TypeOpenAI,
+		APIKey:  "sk-test",
+		BaseURL: "https://one.example/v1",
+	}
+	second := *first
+	second.BaseURL = "https://two.example/v1"
+
+	large, err := builder.getOrBuildProvider(context.Background(), first, config.SelectedModel{Model: "gpt-4o", Provider: "openai"})
+	if err != nil {
+		t.Fatalf("getOrBuildProvider() error = %v", err)
+	}
+	small, err := builder.getOrBuildProvider(context.Background(), &second, config.SelectedModel{Model: "gpt-4o-mini", Provider: "openai"})
+	if err != nil {
+		t.Fatalf("getOrBuildProvider() error = %v", err)
+	}
+
+	if large == small {
+		t.Error("providers with the same ID but different base URLs should not be shared")
+	}
+	if len(builder.cache) != 2 {
+		t.Errorf("cache has %d providers, want 2", len(builder.cache))
+	}
+}
+
+func TestProviderCacheKey(t *testing.T) {
+	base := config.ProviderConfig{ID: "anthropic", Type: catwalk.TypeAnthropic, APIKey: "sk-ant-key"}
+	think := true
+
+	tests := []struct { //nolint:govet // Test struct alignment is not critical.
+		name     string
+		change   func(pc *config.ProviderConfig, mc *config.SelectedModel)
+		wantSame bool
+	}{
+		{name: "identical", change: func(*config.ProviderConfig, *config.SelectedModel) {}, wantSame: true},
+		{name: "other model", change: func(_ *config.ProviderConfig, mc *config.SelectedModel) { mc.Model = "claude-haiku" }, wantSame: true},
+		{name: "API key", change: func(pc *config.ProviderConfig, _ *config.SelectedModel) { pc.APIKey = "Bearer oauth" }},
+		{name: "base URL", change: func(pc *config.ProviderConfig, _ *config.SelectedModel) { pc.BaseURL = "https://proxy.example" }},
+		{name: "type", change: func(pc *config.ProviderConfig, _ *config.SelectedModel) { pc.Type = catwalk.TypeOpenAICompat }},
+		{name: "headers", change: func(pc *config.ProviderConfig, _ *config.SelectedModel) {
+			pc.ExtraHeaders = map[string]string{"X-Team": "matrix"}
+		}},
+		{name: "think", change: func(_ *config.ProviderConfig, mc *config.SelectedModel) { mc.Think = &think }},
+	}
+
+	baseModel := config.SelectedModel{Model: "claude-sonnet-4", Provider: "anthropic"}
+	want := providerCacheKey(&base, baseModel)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pc, mc := base, baseModel
+			tt.change(&pc, &mc)
+
+			got := providerCacheKey(&pc, mc)
+			if (got == want) != tt.wantSame {
+				t.Errorf("providerCacheKey() same = %v, want %v", got == want, tt.wantSame)
+			}
+			if strings.Contains(got, pc.APIKey) {
+				t.Errorf("providerCacheKey() = %q contains the API key", got)
+			}
+		})
+	}
+}
+
+func TestBuilder_buildProvider_Gemini(t *testing.T) {
+	cfg := config.NewConfig()
+	builder := NewBuilder(cfg)
+
+	providerCfg := &config.ProviderConfig{
+		ID:      "gemini",
+		Type:    catwalk.TypeGoogle,
+		APIKey:  "gemini-test",
+		BaseURL: "https://generativelanguage.googleapis.com",
+	}
+	modelCfg := config.SelectedModel{
+		Model:    "gemini-2.5-pro",
+		Provider: "gemini",
+	}
+
+	provider, err := builder.buildProvider(context.Background(), providerCfg, modelCfg)
+	if err != nil {
+		t.Fatalf("buildProvider() error = %v", err)
+	}
+	if provider == nil {
+		t.Error("buildProvider() returned nil provider")
+	}
+}
+
+func TestBuilder_buildProvider_GeminiMinimalConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	// Catwalk's default endpoint references an unset variable.
+	cfg.SetKnownProviders([]catwalk.Provider{{
+		ID:          "gemini",
+		Type:        catwalk.TypeGoogle,
+		APIEndpoint: "$MATRIX_TEST_UNSET_GEMINI_ENDPOINT",
+	}})
+	builder := NewBuilder(cfg)
+
+	providerCfg := &config.ProviderConfig{
+		ID:      "gemini",
+		Type:    catwalk.TypeGoogle,
+		BaseURL: "$MATRIX_TEST_UNSET_GEMINI_ENDPOINT",
+	}
+	modelCfg := config.SelectedModel{
+		Model:    "gemini-2.5-flash",
+		Provider: "gemini",
+	}
+
+	provider, err := builder.buildProvider(context.Background(), providerCfg, modelCfg)
+	if err != nil {
+		t.Fatalf("buildProvider() error = %v", err)
+	}
+	if provider == nil {
+		t.Error("buildProvider() returned nil provider")
+	}
+}
+
+func TestBuilder_buildProvider_GeminiUnresolvedBaseURL(t *testing.T) {
+	builder := NewBuilder(config.NewConfig())
+
+	// A base URL the user wrote must resolve.
+	providerCfg := &config.ProviderConfig{
+		ID:      "gemini",
+		Type:    catwalk.TypeGoogle,
+		APIKey:  "gemini-test",
+		BaseURL: "$MATRIX_TEST_UNSET_GEMINI_PROXY",
+	}
+
+	_, err := builder.buildProvider(context.Background(), providerCfg, config.SelectedModel{Model: "gemini-2.5-pro"})
+	if err == nil || !strings.Contains(err.Error(), "resolving base_url") {
+		t.Errorf("buildProvider() error = %v, want a base_url resolution error", err)
+	}
+}
+
+// captureGeminiRequest starts a server answering Gemini generateContent
+// calls. It returns the server and the last request's path and headers.
+func captureGeminiRequest(t *testing.T) (*httptest.Server, *string, *http.Header) {
+	t.Helper()
+	var path string
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		header = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":1,"candidatesTokenCount":1,"totalTokenCount":2}}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, &path, &header
+}
+
+func TestBuilder_buildModel_GeminiRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		authHeader string
+		wantHeader string
+	}{
+		{name: "api key header", wantHeader: "X-Goog-Api-Key"},
+		{name: "custom auth header", authHeader: "X-Proxy-Key", wantHeader: "X-Proxy-Key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, path, header := captureGeminiRequest(t)
+
+			cfg := config.NewConfig()
+			cfg.Providers["gemini"] = &config.ProviderConfig{
+				ID:         "gemini",
+				Type:       catwalk.TypeGoogle,
+				APIKey:     "gemini-test",
+				BaseURL:    server.URL,
+				AuthHeader: tt.authHeader,
+			}
+			builder := NewBuilder(cfg)
+
+			model, err := builder.buildModel(context.Background(), config.SelectedModel{Model: "gemini-2.5-pro", Provider: "gemini"})
+			if err != nil {
+				t.Fatalf("buildModel() error = %v", err)
+			}
+			if _, err := model.Model.Generate(context.Background(), fantasy.Call{
+				Prompt: fantasy.Prompt{fantasy.NewUserMessage("hi")},
+			}); err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if !strings.Contains(*path, "models/gemini-2.5-pro:generateContent") {
+				t.Errorf("request path = %q, want the model's generateContent call", *path)
+			}
+			if got := header.Get(tt.wantHeader); got != "gemini-test" {
+				t.Errorf("%s header = %q, want the API key", tt.wantHeader, got)
+			}
+		})
+	}
+}
+
+func TestBuilder_buildModel_AnthropicAuthScheme(t *testing.T) {
+	//nolint:govet // Field order optimized for test readability.
+	tests := []struct {
+		name       string
+		apiKey     string
+		scheme     config.AuthScheme
+		wantAuth   string
+		wantAPIKey string
+	}{
+		{name: "inferred api key", apiKey: "sk-ant-test", wantAPIKey: "sk-ant-test"},
+		{name: "inferred bearer", apiKey: "Bearer oauth-token", wantAuth: "Bearer oauth-token"},
+		{name: "forced bearer", apiKey: "oauth-token", scheme: config.AuthSchemeBearer, wantAuth: "Bearer oauth-token"},
+		{name: "forced api key", apiKey: "Bearer sk-ant-test", scheme: config.AuthSchemeAPIKey, wantAPIKey: "sk-ant-test"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The Anthropic SDK falls back to these when they are set, even
+			// to an empty value, so unset them for the test.
+			for _, name := range []string{"ANTHROPIC_API_KEY", "ANTHROPIC_AUTH_TOKEN"} {
+				t.Setenv(name, "")
+				os.Unsetenv(name) //nolint:errcheck,gosec // Restored by t.Setenv.
+			}
+			server, headers := captureRequestHeaders(t)
+
+			cfg := config.NewConfig()
+			cfg.Providers["anthropic"] = &config.ProviderConfig{
+				ID:         "anthropic",
+				Type:       catwalk.TypeAnthropic,
+				APIKey:     tt.apiKey,
+				AuthScheme: tt.scheme,
+				BaseURL:    server.URL,
+			}
+
+			model, err := NewBuilder(cfg).buildModel(context.Background(), config.SelectedModel{
+				Model:    "claude-sonnet-4",
+				Provider: "anthropic",
+			})
+			if err != nil {
+				t.Fatalf("buildModel() error = %v", err)
+			}
+			// The canned response is not an Anthropic one; only the request
+			// headers matter here.
+			_, _ = model.Model.Generate(context.Background(), fantasy.Call{ //nolint:errcheck // See above.
+				Prompt: fantasy.Prompt{fantasy.NewUserMessage("hi")},
+			})
+
+			if got := headers.Get("Authorization"); got != tt.wantAuth {
+				t.Errorf("Authorization = %q, want %q", got, tt.wantAuth)
+			}
+			if got := headers.Get("X-Api-Key"); got != tt.wantAPIKey {
+				t.Errorf("X-Api-Key = %q, want %q", got, tt.wantAPIKey)
+			}
+		})
+	}
+}
+
+func TestBuilder_buildModel_ReasoningEffort(t *testing.T) {
+	tests := []struct { //nolint:govet // Test struct alignment is not critical.
+		name    string
+		effort  string
+		want    string
+		wantErr bool
+	}{
+		{name: "forwarded", effort: "high", want: `"reasoning_effort":"high"`},
+		{name: "unset is a no-op", effort: ""},
+		{name: "invalid is rejected", effort: "extreme", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, body := captureRequestBody(t)
+
+			cfg := config.NewConfig()
+			cfg.Providers["openai"] = &config.ProviderConfig{
+				ID:      "openai",
+				Type:    catwalk.TypeOpenAI,
+				APIKey:  "sk-test",
+				BaseURL: server.URL,
+			}
+
+			model, err := NewBuilder(cfg).buildModel(context.Background(), config.SelectedModel{
+				Model:           "o3",
+				Provider:        "openai",
+				ReasoningEffort: tt.effort,
+			})
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), `invalid reasoning_effort "extreme"`) {
+					t.Fatalf("buildModel() error = %v, want an invalid reasoning_effort error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildModel() error = %v", err)
+			}
+
+			_, err = model.Model.Generate(context.Background(), fantasy.Call{
+				Prompt: fantasy.Prompt{fantasy.NewUserMessage("hi")},
+			})
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			if tt.want == "" {
+				if strings.Contains(*body, `"reasoning_effort"`) {
+					t.Errorf("request body should not set reasoning_effort, got: %s", *body)
+				}
+				return
+			}
+			if !strings.Contains(*body, tt.want) {
+				t.Errorf("request body should contain %s, got: %s", tt.want, *body)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+// refreshIfNeeded refreshes the provider's OAuth token when it has expired,
+// updating the API key derived from it and persisting the new token in the
+// config file the provider came from. Cached providers built with the old
+// token are dropped. Failing to persist the token is logged, since the
+// refreshed token still works for this session.
+//
+// The check and refresh run under refreshMu, so tiers built concurrently
+// refresh a shared provider once; a second refresh would present a refresh
+// token the server has already rotated.
+func (b *Builder) refreshIfNeeded(ctx context.Context, providerCfg *config.ProviderConfig) error {
+	b.refreshMu.Lock()
+	defer b.refreshMu.Unlock()
+
+	token := providerCfg.OAuthToken
+	if token == nil || !token.IsExpired() {
+		return nil
+	}
+	if token.RefreshToken == "" {
+		return fmt.Errorf("OAuth token for %q expired and has no refresh token; run setup again to sign in", providerCfg.ID)
+	}
+
+	fresh, err := b.refreshOAuth(ctx, token.RefreshToken)
+	if err != nil {
+		return fmt.Errorf("refreshing OAuth token for %q (run setup again to sign in): %w", providerCfg.ID, err)
+	}
+	if fresh.RefreshToken == "" {
+		// Keep the current refresh token when the server doesn't rotate it.
+		fresh.RefreshToken = token.RefreshToken
+	}
+
+	// buildProvider reads the provider config under mu.
+	b.mu.Lock()
+	if strings.HasPrefix(providerCfg.APIKey, "Bearer ") {
+		providerCfg.APIKey = "Bearer " + fresh.AccessToken
+	} else {
+		providerCfg.APIKey = fresh.AccessToken
+	}
+	providerCfg.OAuthToken = fresh
+	b.dropCachedProviders(providerCfg.ID)
+	b.mu.Unlock()
+
+	if providerCfg.SourcePath == "" {
+		slog.Debug("Not saving refreshed OAuth token; the provider has no config file", "provider", providerCfg.ID)
+		return nil
+	}
+	if err := b.saveOAuth(providerCfg.SourcePath, providerCfg.ID, fresh); err != nil {
+		level := slog.LevelWarn
+		if errors.Is(err, config.ErrReadOnly) {
+			level = slog.LevelDebug
+		}
+		slog.Log(ctx, level, "Failed to save refreshed OAuth token", "provider", providerCfg.ID, "error", err)
+	}
+	return nil
+}
+
+// dropCachedProviders removes the cached providers built for providerID.
+// The caller must hold mu.
+func (b *Builder) dropCachedProviders(providerID string) {
+	for key := range b.cache {
+		if key == providerID || strings.HasPrefix(key, providerID+"\x00") {
+			delete(b.cache, key)
+		}
+	}
+}
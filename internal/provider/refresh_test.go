@@ -0,0 +1,175 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+	"github.com/guilhermegouw/matrix-cli/internal/oauth"
+	"github.com/guilhermegouw/matrix-cli/internal/oauth/claude"
+)
+
+// newRefreshBuilder returns a builder with an Anthropic OAuth provider using
+// token, whose refreshes go to a test server answering with status and body.
+// It also returns the number of refresh requests and the saved tokens.
+func newRefreshBuilder(t *testing.T, token *oauth.Token, status int, body string) (*Builder, *atomic.Int32, *[]*oauth.Token) {
+	t.Helper()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body)) //nolint:errcheck // Test server.
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := config.NewConfig()
+	cfg.Providers["anthropic"] = &config.ProviderConfig{
+		ID:         "anthropic",
+		Type:       catwalk.TypeAnthropic,
+		APIKey:     "Bearer " + token.AccessToken,
+		OAuthToken: token,
+		SourcePath: "project/matrix.json",
+	}
+	builder := NewBuilder(cfg)
+	builder.refreshOAuth = func(ctx context.Context, refreshToken string) (*oauth.Token, error) {
+		return claude.RefreshToken(ctx, refreshToken, claude.WithTokenURL(server.URL))
+	}
+	var saved []*oauth.Token
+	builder.saveOAuth = func(path, _ string, token *oauth.Token) error {
+		if path != "project/matrix.json" {
+			t.Errorf("token saved to %q, want the provider's config file", path)
+		}
+		saved = append(saved, token)
+		return nil
+	}
+	return builder, &requests, &saved
+}
+
+func TestBuilder_buildModel_RefreshesExpiredToken(t *testing.T) {
+	expired := &oauth.Token{
+		AccessToken:  "old-access",
+		RefreshToken: "old-refresh",
+		ExpiresIn:    3600,
+		ExpiresAt:    time.Now().Add(-time.Hour).Unix(),
+	}
+	builder, requests, saved := newRefreshBuilder(t, expired, http.StatusOK,
+		`{"access_token":"new-access","refresh_token":"new-refresh","expires_in":3600}`)
+
+	// Prime the cache with a provider built from the stale token.
+	modelCfg := config.SelectedModel{Model: "claude-sonnet-4", Provider: "anthropic"}
+	staleKey := providerCacheKey(builder.cfg.Providers["anthropic"], modelCfg)
+	builder.cache[staleKey] = nil
+
+	_, err := builder.buildModel(context.Background(), modelCfg)
+	if err != nil {
+		t.Fatalf("buildModel() error = %v", err)
+	}
+
+	if requests.Load() != 1 {
+		t.Errorf("refresh requests = %d, want 1", requests.Load())
+	}
+	providerCfg := builder.cfg.Providers["anthropic"]
+	if providerCfg.APIKey != "Bearer new-access" {
+		t.Errorf("APIKey = %q, want %q", providerCfg.APIKey, "Bearer new-access")
+	}
+	if providerCfg.OAuthToken.IsExpired() {
+		t.Error("OAuthToken should no longer be expired")
+	}
+	if len(*saved) != 1 || (*saved)[0].AccessToken != "new-access" {
+		t.Errorf("saved tokens = %v, want the refreshed token", *saved)
+	}
+	if _, ok := builder.cache[staleKey]; ok {
+		t.Error("the provider built with the stale token should be dropped")
+	}
+	if builder.cache[providerCacheKey(providerCfg, modelCfg)] == nil {
+		t.Error("a provider should be cached for the refreshed token")
+	}
+}
+
+func TestBuilder_buildModel_RefreshFailure(t *testing.T) {
+	expired := &oauth.Token{
+		AccessToken:  "old-access",
+		RefreshToken: "revoked",
+		ExpiresIn:    3600,
+		ExpiresAt:    time.Now().Add(-time.Hour).Unix(),
+	}
+	builder, _, saved := newRefreshBuilder(t, expired, http.StatusBadRequest, `{"error":"invalid_grant"}`)
+
+	_, err := builder.buildModel(context.Background(), config.SelectedModel{Model: "claude-sonnet-4", Provider: "anthropic"})
+	if err == nil {
+		t.Fatal("buildModel() should fail when the token can't be refreshed")
+	}
+
+	if got := builder.cfg.Providers["anthropic"].APIKey; got != "Bearer old-access" {
+		t.Errorf("APIKey = %q, want it unchanged", got)
+	}
+	if len(*saved) != 0 {
+		t.Errorf("saved tokens = %v, want none", *saved)
+	}
+}
+
+func TestBuilder_buildModel_TokenNotExpired(t *testing.T) {
+	valid := &oauth.Token{
+		AccessToken:  "current-access",
+		RefreshToken: "current-refresh",
+		ExpiresIn:    3600,
+		ExpiresAt:    time.Now().Add(time.Hour).Unix(),
+	}
+	builder, requests, saved := newRefreshBuilder(t, valid, http.StatusOK, `{}`)
+
+	_, err := builder.buildModel(context.Background(), config.SelectedModel{Model: "claude-sonnet-4", Provider: "anthropic"})
+	if err != nil {
+		t.Fatalf("buildModel() error = %v", err)
+	}
+
+	if requests.Load() != 0 {
+		t.Errorf("refresh requests = %d, want 0", requests.Load())
+	}
+	if len(*saved) != 0 {
+		t.Errorf("saved tokens = %v, want none", *saved)
+	}
+}
+
+func TestBuilder_buildModel_ConcurrentRefresh(t *testing.T) {
+	expired := &oauth.Token{
+		AccessToken:  "old-access",
+		RefreshToken: "old-refresh",
+		ExpiresIn:    3600,
+		ExpiresAt:    time.Now().Add(-time.Hour).Unix(),
+	}
+	builder, requests, saved := newRefreshBuilder(t, expired, http.StatusOK,
+		`{"access_token":"new-access","refresh_token":"new-refresh","expires_in":3600}`)
+
+	// Both tiers use the same provider, as when they are built together.
+	const goroutines = 8
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := builder.buildModel(context.Background(), config.SelectedModel{Model: "claude-sonnet-4", Provider: "anthropic"})
+			if err != nil {
+				t.Errorf("buildModel() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if requests.Load() != 1 {
+		t.Errorf("refresh requests = %d, want 1", requests.Load())
+	}
+	if len(*saved) != 1 {
+		t.Errorf("saved tokens = %d, want 1", len(*saved))
+	}
+	if got := builder.cfg.Providers["anthropic"].APIKey; got != "Bearer new-access" {
+		t.Errorf("APIKey = %q, want %q", got, "Bearer new-access")
+	}
+}
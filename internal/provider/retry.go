@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"syscall"
+	"time"
+
+	"charm.land/fantasy"
+)
+
+// Backoff between retried model calls: the delay doubles after each failed
+// attempt, up to retryMaxDelay.
+var (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 8 * time.Second
+)
+
+// Generate calls the model, retrying transient failures up to the tier's
+// configured number of retries with exponential backoff. Other errors, and
+// the last transient one, are returned as they are. Waiting between
+// attempts stops early when ctx is done.
+func (m Model) Generate(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	delay := retryBaseDelay
+	for attempt := 0; ; attempt++ {
+		resp, err := m.Model.Generate(ctx, call)
+		if err == nil || attempt >= m.maxRetries || !isRetryable(ctx, err) {
+			return resp, err
+		}
+
+		slog.Debug("Retrying model call", "model", m.ModelCfg.Model, "attempt", attempt+1, "delay", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, retryMaxDelay)
+	}
+}
+
+// retryableError is implemented by provider errors that know whether the
+// request may succeed when sent again, e.g. after a rate limit or an
+// overloaded server.
+type retryableError interface {
+	IsRetryable() bool
+}
+
+// isRetryable reports whether err is a transient failure worth retrying:
+// a provider error marked retryable, a timeout, or a dropped connection.
+// Nothing is retried once ctx is done.
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var re retryableError
+	if errors.As(err, &re) {
+		return re.IsRetryable()
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
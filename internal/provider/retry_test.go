@@ -0,0 +1,116 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+)
+
+// statusError is a provider error reporting whether it may be retried.
+type statusError struct {
+	retryable bool
+}
+
+func (e *statusError) Error() string {
+	return "provider error"
+}
+
+func (e *statusError) IsRetryable() bool {
+	return e.retryable
+}
+
+// flakyModel is a language model failing with errs in turn before it
+// succeeds.
+type flakyModel struct {
+	fantasy.LanguageModel
+	errs  []error
+	calls int
+}
+
+func (m *flakyModel) Generate(context.Context, fantasy.Call) (*fantasy.Response, error) {
+	m.calls++
+	if m.calls <= len(m.errs) {
+		return nil, m.errs[m.calls-1]
+	}
+	return &fantasy.Response{}, nil
+}
+
+// fastRetries shortens the retry backoff for the test.
+func fastRetries(t *testing.T) {
+	t.Helper()
+	base, maxDelay := retryBaseDelay, retryMaxDelay
+	retryBaseDelay, retryMaxDelay = time.Millisecond, 2*time.Millisecond
+	t.Cleanup(func() {
+		retryBaseDelay, retryMaxDelay = base, maxDelay
+	})
+}
+
+func TestModel_Generate_RetriesTransientErrors(t *testing.T) {
+	fastRetries(t)
+	lm := &flakyModel{errs: []error{
+		&statusError{retryable: true},
+		context.DeadlineExceeded,
+	}}
+	model := Model{Model: lm, maxRetries: 3}
+
+	resp, err := model.Generate(context.Background(), fantasy.Call{})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if resp == nil {
+		t.Fatal("Generate() returned no response")
+	}
+	if lm.calls != 3 {
+		t.Errorf("calls = %d, want 3", lm.calls)
+	}
+}
+
+func TestModel_Generate_NonRetryableErrorReturnedImmediately(t *testing.T) {
+	fastRetries(t)
+	want := &statusError{retryable: false}
+	lm := &flakyModel{errs: []error{want}}
+	model := Model{Model: lm, maxRetries: 3}
+
+	_, err := model.Generate(context.Background(), fantasy.Call{})
+	if !errors.Is(err, want) {
+		t.Errorf("Generate() error = %v, want %v", err, want)
+	}
+	if lm.calls != 1 {
+		t.Errorf("calls = %d, want 1", lm.calls)
+	}
+}
+
+func TestModel_Generate_GivesUpAfterMaxRetries(t *testing.T) {
+	fastRetries(t)
+	lm := &flakyModel{errs: []error{
+		&statusError{retryable: true},
+		&statusError{retryable: true},
+		&statusError{retryable: true},
+	}}
+	model := Model{Model: lm, maxRetries: 2}
+
+	if _, err := model.Generate(context.Background(), fantasy.Call{}); err == nil {
+		t.Error("Generate() expected an error after the retries ran out")
+	}
+	if lm.calls != 3 {
+		t.Errorf("calls = %d, want 3", lm.calls)
+	}
+}
+
+func TestModel_Generate_CanceledContextNotRetried(t *testing.T) {
+	fastRetries(t)
+	lm := &flakyModel{errs: []error{context.Canceled}}
+	model := Model{Model: lm, maxRetries: 3}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := model.Generate(ctx, fantasy.Call{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Generate() error = %v, want context.Canceled", err)
+	}
+	if lm.calls != 1 {
+		t.Errorf("calls = %d, want 1", lm.calls)
+	}
+}
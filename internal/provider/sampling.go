@@ -0,0 +1,47 @@
+package provider
+
+import (
+	"charm.land/fantasy"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+// callOptions collects the sampling settings configured for a tier into a
+// call template. Unset fields stay nil so the provider's defaults apply.
+func callOptions(modelCfg config.SelectedModel) fantasy.Call {
+	call := fantasy.Call{
+		Temperature:      clonePtr(modelCfg.Temperature),
+		TopP:             clonePtr(modelCfg.TopP),
+		TopK:             clonePtr(modelCfg.TopK),
+		FrequencyPenalty: clonePtr(modelCfg.FrequencyPenalty),
+		PresencePenalty:  clonePtr(modelCfg.PresencePenalty),
+	}
+	if modelCfg.MaxTokens > 0 {
+		maxTokens := modelCfg.MaxTokens
+		call.MaxOutputTokens = &maxTokens
+	}
+	return call
+}
+
+// CallOptions returns a call carrying the tier's configured sampling
+// settings. Callers set the prompt and tools on the returned value; each
+// call returns a fresh copy.
+func (m Model) CallOptions() fantasy.Call {
+	call := m.callOpts
+	call.Temperature = clonePtr(call.Temperature)
+	call.TopP = clonePtr(call.TopP)
+	call.TopK = clonePtr(call.TopK)
+	call.FrequencyPenalty = clonePtr(call.FrequencyPenalty)
+	call.PresencePenalty = clonePtr(call.PresencePenalty)
+	call.MaxOutputTokens = clonePtr(call.MaxOutputTokens)
+	return call
+}
+
+// clonePtr returns a pointer to a copy of *p, or nil when p is nil.
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
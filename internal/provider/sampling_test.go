@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+func TestBuilder_BuildModels_CallOptions(t *testing.T) {
+	temperature := 0.2
+	cfg := config.NewConfig()
+	cfg.Providers["openai"] = &config.ProviderConfig{
+		ID:     "openai",
+		Type:   catwalk.TypeOpenAI,
+		APIKey: "sk-test",
+		Models: []catwalk.Model{{ID: "gpt-4o", Name: "GPT-4o"}},
+	}
+	cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{
+		Model:       "gpt-4o",
+		Provider:    "openai",
+		Temperature: &temperature,
+		MaxTokens:   1000,
+	}
+
+	large, _, err := NewBuilder(cfg).BuildModels(context.Background())
+	if err != nil {
+		t.Fatalf("BuildModels() error = %v", err)
+	}
+
+	call := large.CallOptions()
+	if call.Temperature == nil || *call.Temperature != 0.2 {
+		t.Errorf("Temperature = %v, want 0.2", call.Temperature)
+	}
+	if call.MaxOutputTokens == nil || *call.MaxOutputTokens != 1000 {
+		t.Errorf("MaxOutputTokens = %v, want 1000", call.MaxOutputTokens)
+	}
+	if call.TopP != nil || call.TopK != nil || call.FrequencyPenalty != nil || call.PresencePenalty != nil {
+		t.Errorf("unset sampling fields = %+v, want nil", call)
+	}
+}
+
+func TestCallOptions(t *testing.T) {
+	topP, penalty := 0.9, 0.5
+	topK := int64(40)
+
+	call := callOptions(config.SelectedModel{
+		TopP:             &topP,
+		TopK:             &topK,
+		FrequencyPenalty: &penalty,
+		PresencePenalty:  &penalty,
+	})
+
+	if call.TopP == nil || *call.TopP != 0.9 {
+		t.Errorf("TopP = %v, want 0.9", call.TopP)
+	}
+	if call.TopK == nil || *call.TopK != 40 {
+		t.Errorf("TopK = %v, want 40", call.TopK)
+	}
+	if call.FrequencyPenalty == nil || *call.FrequencyPenalty != 0.5 {
+		t.Errorf("FrequencyPenalty = %v, want 0.5", call.FrequencyPenalty)
+	}
+	if call.PresencePenalty == nil || *call.PresencePenalty != 0.5 {
+		t.Errorf("PresencePenalty = %v, want 0.5", call.PresencePenalty)
+	}
+	if call.Temperature != nil || call.MaxOutputTokens != nil {
+		t.Errorf("unset fields = %+v, want nil", call)
+	}
+
+	// The call must not alias the config.
+	topP = 0.1
+	if *call.TopP != 0.9 {
+		t.Errorf("TopP changed with the config to %v", *call.TopP)
+	}
+}
+
+func TestModel_CallOptions_ReturnsCopy(t *testing.T) {
+	temperature := 0.7
+	m := Model{callOpts: callOptions(config.SelectedModel{Temperature: &temperature, MaxTokens: 512})}
+
+	first := m.CallOptions()
+	*first.Temperature = 1.5
+	*first.MaxOutputTokens = 1
+
+	second := m.CallOptions()
+	if *second.Temperature != 0.7 || *second.MaxOutputTokens != 512 {
+		t.Errorf("CallOptions() = temperature %v, max tokens %v after mutating a previous result",
+			*second.Temperature, *second.MaxOutputTokens)
+	}
+}
@@ -27,20 +27,29 @@ func GetProviderForModel(cfg *config.Config, model *config.SelectedModel) (*conf
 	}
 
 	if provider.Disable {
-		return nil, fmt.Errorf("provider %q is disabled", model.Provider)
+		return nil, fmt.Errorf("provider %q is %s", model.Provider, provider.DisabledDescription())
 	}
 
 	return provider, nil
 }
 
-// ValidateConfig checks that all configured tiers have valid providers.
+// ValidateConfig checks that all configured tiers can be used; see
+// config.Config.Validate.
 func ValidateConfig(cfg *config.Config) error {
-	for tier, model := range cfg.Models {
-		if _, ok := cfg.Providers[model.Provider]; !ok {
-			return fmt.Errorf("tier %s references unknown provider %q", tier, model.Provider)
-		}
+	return cfg.Validate()
+}
+
+// SharedModelWarning describes the large and small tiers using the same model
+// ID under different providers, which is easy to mix up. It returns "" when
+// that is not the case.
+func SharedModelWarning(cfg *config.Config) string {
+	large, okLarge := cfg.Models[config.SelectedModelTypeLarge]
+	small, okSmall := cfg.Models[config.SelectedModelTypeSmall]
+	if !okLarge || !okSmall || large.Model != small.Model || large.Provider == small.Provider {
+		return ""
 	}
-	return nil
+	return fmt.Sprintf("large and small tiers both use model %q but from different providers (%s and %s)",
+		large.Model, large.Provider, small.Provider)
 }
 
 // AllTiers returns all available tier types.
@@ -1,8 +1,11 @@
 package provider
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
 	"github.com/guilhermegouw/matrix-cli/internal/config"
 )
 
@@ -142,7 +145,7 @@ func TestValidateConfig(t *testing.T) {
 		{
 			name: "valid config",
 			setup: func(cfg *config.Config) {
-				cfg.Providers["openai"] = &config.ProviderConfig{ID: "openai"}
+				cfg.Providers["openai"] = &config.ProviderConfig{ID: "openai", APIKey: "key"}
 				cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{
 					Model:    "gpt-4o",
 					Provider: "openai",
@@ -170,8 +173,8 @@ func TestValidateConfig(t *testing.T) {
 		{
 			name: "multiple tiers all valid",
 			setup: func(cfg *config.Config) {
-				cfg.Providers["openai"] = &config.ProviderConfig{ID: "openai"}
-				cfg.Providers["anthropic"] = &config.ProviderConfig{ID: "anthropic"}
+				cfg.Providers["openai"] = &config.ProviderConfig{ID: "openai", APIKey: "key"}
+				cfg.Providers["anthropic"] = &config.ProviderConfig{ID: "anthropic", APIKey: "key"}
 				cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{
 					Model:    "gpt-4o",
 					Provider: "openai",
@@ -186,7 +189,7 @@ func TestValidateConfig(t *testing.T) {
 		{
 			name: "one tier invalid",
 			setup: func(cfg *config.Config) {
-				cfg.Providers["openai"] = &config.ProviderConfig{ID: "openai"}
+				cfg.Providers["openai"] = &config.ProviderConfig{ID: "openai", APIKey: "key"}
 				cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{
 					Model:    "gpt-4o",
 					Provider: "openai",
@@ -239,3 +242,110 @@ func TestAllTiers(t *testing.T) {
 		t.Error("AllTiers() missing small tier")
 	}
 }
+
+func TestValidateConfig_SmallTierWithoutKey(t *testing.T) {
+	//nolint:govet // Test struct field order optimized for readability.
+	tests := []struct {
+		name    string
+		apiKey  string
+		wantErr string
+	}{
+		{
+			name:    "empty key",
+			apiKey:  "",
+			wantErr: `tier small: provider "local" has no API key configured`,
+		},
+		{
+			name:    "env var resolves to empty",
+			apiKey:  "$MATRIX_TEST_EMPTY_KEY",
+			wantErr: `tier small: provider "local" has no API key configured`,
+		},
+		{
+			name:    "undefined env var",
+			apiKey:  "${MATRIX_TEST_UNDEFINED_KEY}",
+			wantErr: `tier small: provider "local": resolving API key`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MATRIX_TEST_EMPTY_KEY", "")
+
+			cfg := config.NewConfig()
+			cfg.Providers["openai"] = &config.ProviderConfig{ID: "openai", APIKey: "sk-test"}
+			cfg.Providers["local"] = &config.ProviderConfig{ID: "local", APIKey: tt.apiKey}
+			cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{
+				Model:    "gpt-4o",
+				Provider: "openai",
+			}
+			cfg.Models[config.SelectedModelTypeSmall] = config.SelectedModel{
+				Model:    "llama",
+				Provider: "local",
+			}
+
+			err := ValidateConfig(cfg)
+			if err == nil {
+				t.Fatal("ValidateConfig() should fail for a small tier without an API key")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateConfig() error = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_KeylessLocalProvider(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Providers["local"] = &config.ProviderConfig{
+		ID:      "local",
+		Type:    catwalk.TypeOpenAICompat,
+		BaseURL: "http://localhost:11434/v1",
+	}
+	cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{Model: "llama3", Provider: "local"}
+
+	if err := ValidateConfig(cfg); err != nil {
+		t.Errorf("ValidateConfig() error = %v, want keyless provider accepted", err)
+	}
+}
+
+func TestSharedModelWarning(t *testing.T) {
+	tests := []struct {
+		name        string
+		large       config.SelectedModel
+		small       config.SelectedModel
+		wantWarning bool
+	}{
+		{
+			name:        "same model different providers",
+			large:       config.SelectedModel{Model: "gpt-4o", Provider: "openai"},
+			small:       config.SelectedModel{Model: "gpt-4o", Provider: "openrouter"},
+			wantWarning: true,
+		},
+		{
+			name:  "same model same provider",
+			large: config.SelectedModel{Model: "gpt-4o", Provider: "openai"},
+			small: config.SelectedModel{Model: "gpt-4o", Provider: "openai"},
+		},
+		{
+			name:  "different models",
+			large: config.SelectedModel{Model: "gpt-4o", Provider: "openai"},
+			small: config.SelectedModel{Model: "gpt-4o-mini", Provider: "openrouter"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.Models[config.SelectedModelTypeLarge] = tt.large
+			cfg.Models[config.SelectedModelTypeSmall] = tt.small
+
+			got := SharedModelWarning(cfg)
+			if (got != "") != tt.wantWarning {
+				t.Fatalf("SharedModelWarning() = %q, want warning %v", got, tt.wantWarning)
+			}
+			if tt.wantWarning && (!strings.Contains(got, "openai") || !strings.Contains(got, "openrouter")) {
+				t.Errorf("SharedModelWarning() = %q, want both providers named", got)
+			}
+		})
+	}
+}
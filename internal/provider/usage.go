@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+// usageLogFile is the name of the local usage log in the data directory.
+const usageLogFile = "usage.jsonl"
+
+// UsageEntry records a single model being built for a tier.
+type UsageEntry struct {
+	// Time is when the model was built.
+	Time time.Time `json:"time"`
+	// Tier is the model tier.
+	Tier config.SelectedModelType `json:"tier"`
+	// Provider is the provider ID.
+	Provider string `json:"provider"`
+	// Model is the model ID.
+	Model string `json:"model"`
+}
+
+// UsageSummary aggregates usage entries for one provider and model.
+type UsageSummary struct {
+	// LastUsed is the most recent use.
+	LastUsed time.Time
+	// Provider is the provider ID.
+	Provider string
+	// Model is the model ID.
+	Model string
+	// Count is how many times the model was used.
+	Count int
+}
+
+// UsageLogPath returns the path of the local usage log.
+func UsageLogPath(cfg *config.Config) string {
+	return filepath.Join(cfg.DataDir(), usageLogFile)
+}
+
+// AppendUsage appends entries to the usage log at path as JSON lines.
+func AppendUsage(path string, entries ...UsageEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("creating data directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) //nolint:gosec // Path is derived from the data directory.
+	if err != nil {
+		return fmt.Errorf("opening usage log: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	enc := json.NewEncoder(f)
+	for i := range entries {
+		if err := enc.Encode(entries[i]); err != nil {
+			return fmt.Errorf("writing usage log: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReadUsage reads all entries from the usage log at path.
+// Malformed lines are skipped so a partial write doesn't break the log.
+func ReadUsage(path string) ([]UsageEntry, error) {
+	f, err := os.Open(path) //nolint:gosec // Path is derived from the data directory.
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck
+
+	var entries []UsageEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry UsageEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading usage log: %w", err)
+	}
+	return entries, nil
+}
+
+// SummarizeUsage aggregates entries per provider and model, most used first.
+func SummarizeUsage(entries []UsageEntry) []UsageSummary {
+	type key struct{ provider, model string }
+
+	index := make(map[key]int)
+	var summaries []UsageSummary
+	for _, entry := range entries {
+		k := key{entry.Provider, entry.Model}
+		i, ok := index[k]
+		if !ok {
+			i = len(summaries)
+			index[k] = i
+			summaries = append(summaries, UsageSummary{Provider: entry.Provider, Model: entry.Model})
+		}
+		summaries[i].Count++
+		if entry.Time.After(summaries[i].LastUsed) {
+			summaries[i].LastUsed = entry.Time
+		}
+	}
+
+	slices.SortFunc(summaries, func(a, b UsageSummary) int {
+		return cmp.Or(
+			cmp.Compare(b.Count, a.Count),
+			cmp.Compare(a.Provider, b.Provider),
+			cmp.Compare(a.Model, b.Model),
+		)
+	})
+	return summaries
+}
+
+// recordUsage appends the built models to the usage log when enabled.
+func (b *Builder) recordUsage(models map[config.SelectedModelType]config.SelectedModel) error {
+	if b.cfg.Options == nil || !b.cfg.Options.UsageLog {
+		return nil
+	}
+
+	now := time.Now()
+	var entries []UsageEntry
+	for _, tier := range AllTiers() {
+		selected, ok := models[tier]
+		if !ok {
+			continue
+		}
+		entries = append(entries, UsageEntry{
+			Time:     now,
+			Tier:     tier,
+			Provider: selected.Provider,
+			Model:    selected.Model,
+		})
+	}
+	return AppendUsage(UsageLogPath(b.cfg), entries...)
+}
@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+func TestAppendUsage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", usageLogFile)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	if err := AppendUsage(path, UsageEntry{Time: now, Tier: config.SelectedModelTypeLarge, Provider: "openai", Model: "gpt-4o"}); err != nil {
+		t.Fatalf("AppendUsage() error = %v", err)
+	}
+	if err := AppendUsage(path, UsageEntry{Time: now, Tier: config.SelectedModelTypeSmall, Provider: "openai", Model: "gpt-4o-mini"}); err != nil {
+		t.Fatalf("AppendUsage() second call error = %v", err)
+	}
+
+	entries, err := ReadUsage(path)
+	if err != nil {
+		t.Fatalf("ReadUsage() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("ReadUsage() returned %d entries, want 2", len(entries))
+	}
+	if entries[1].Model != "gpt-4o-mini" || entries[1].Tier != config.SelectedModelTypeSmall {
+		t.Errorf("entries[1] = %+v, want small gpt-4o-mini", entries[1])
+	}
+	if !entries[0].Time.Equal(now) {
+		t.Errorf("entries[0].Time = %v, want %v", entries[0].Time, now)
+	}
+}
+
+func TestReadUsage_SkipsMalformedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), usageLogFile)
+	content := `{"provider":"openai","model":"gpt-4o"}
+not json
+{"provider":"anthropic","model":"claude"}
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+
+	entries, err := ReadUsage(path)
+	if err != nil {
+		t.Fatalf("ReadUsage() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("ReadUsage() returned %d entries, want 2", len(entries))
+	}
+}
+
+func TestSummarizeUsage(t *testing.T) {
+	day1 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+
+	entries := []UsageEntry{
+		{Time: day1, Provider: "openai", Model: "gpt-4o"},
+		{Time: day1, Provider: "anthropic", Model: "claude"},
+		{Time: day2, Provider: "openai", Model: "gpt-4o"},
+		{Time: day1, Provider: "openai", Model: "gpt-4o-mini"},
+	}
+
+	got := SummarizeUsage(entries)
+	if len(got) != 3 {
+		t.Fatalf("SummarizeUsage() returned %d summaries, want 3", len(got))
+	}
+	if got[0].Model != "gpt-4o" || got[0].Count != 2 {
+		t.Errorf("got[0] = %+v, want gpt-4o used twice", got[0])
+	}
+	if !got[0].LastUsed.Equal(day2) {
+		t.Errorf("got[0].LastUsed = %v, want %v", got[0].LastUsed, day2)
+	}
+	// Ties are ordered by provider, then model.
+	if got[1].Provider != "anthropic" || got[2].Model != "gpt-4o-mini" {
+		t.Errorf("tie order = %s/%s, %s/%s", got[1].Provider, got[1].Model, got[2].Provider, got[2].Model)
+	}
+}
+
+func TestBuilder_BuildModels_RecordsUsage(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		wantRows int
+	}{
+		{"disabled by default", false, 0},
+		{"enabled", true, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.Options.DataDir = t.TempDir()
+			cfg.Options.UsageLog = tt.enabled
+			cfg.Providers["openai"] = &config.ProviderConfig{
+				ID:     "openai",
+				Type:   catwalk.TypeOpenAI,
+				APIKey: "sk-test",
+			}
+			cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{Model: "gpt-4o", Provider: "openai"}
+
+			if _, _, err := NewBuilder(cfg).BuildModels(context.Background()); err != nil {
+				t.Fatalf("BuildModels() error = %v", err)
+			}
+
+			entries, err := ReadUsage(UsageLogPath(cfg))
+			if err != nil && !os.IsNotExist(err) {
+				t.Fatalf("ReadUsage() error = %v", err)
+			}
+			if len(entries) != tt.wantRows {
+				t.Errorf("usage log has %d entries, want %d", len(entries), tt.wantRows)
+			}
+		})
+	}
+}
@@ -2,8 +2,11 @@
 package welcome
 
 import (
+	"context"
+
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
 
 	"github.com/guilhermegouw/matrix-cli/internal/tui/components/logo"
 	"github.com/guilhermegouw/matrix-cli/internal/tui/styles"
@@ -13,10 +16,22 @@ import (
 // StartWizardMsg is sent when the user wants to start the wizard.
 type StartWizardMsg struct{}
 
+// ProvidersLoadedMsg is sent when provider metadata has finished loading.
+type ProvidersLoadedMsg struct {
+	Err       error
+	Providers []catwalk.Provider
+}
+
+// LoadFunc loads provider metadata, returning early when ctx is canceled.
+type LoadFunc func(ctx context.Context) ([]catwalk.Provider, error)
+
 // Welcome displays the welcome screen with Matrix branding.
 type Welcome struct {
-	width  int
-	height int
+	load    LoadFunc
+	cancel  context.CancelFunc
+	width   int
+	height  int
+	loading bool
 }
 
 // New creates a new welcome screen.
@@ -24,24 +39,63 @@ func New() *Welcome {
 	return &Welcome{}
 }
 
+// NewLoading creates a welcome screen that loads providers in the background
+// while it is shown. The load can be canceled by quitting.
+func NewLoading(load LoadFunc) *Welcome {
+	return &Welcome{load: load}
+}
+
 // Init initializes the welcome screen.
 func (w *Welcome) Init() tea.Cmd {
-	return nil
+	if w.load == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.loading = true
+
+	load := w.load
+	return func() tea.Msg {
+		providers, err := load(ctx)
+		return ProvidersLoadedMsg{Providers: providers, Err: err}
+	}
 }
 
 // Update handles messages.
 func (w *Welcome) Update(msg tea.Msg) (util.Model, tea.Cmd) {
-	if keyMsg, ok := msg.(tea.KeyMsg); ok {
-		switch keyMsg.String() {
+	switch msg := msg.(type) {
+	case ProvidersLoadedMsg:
+		w.loading = false
+		w.Cancel()
+	case tea.KeyMsg:
+		switch msg.String() {
 		case "enter", " ":
+			if w.loading {
+				return w, nil
+			}
 			return w, util.CmdHandler(StartWizardMsg{})
 		case "q", "ctrl+c":
+			w.Cancel()
 			return w, tea.Quit
 		}
 	}
 	return w, nil
 }
 
+// Cancel stops an in-flight provider load, if any.
+func (w *Welcome) Cancel() {
+	if w.cancel != nil {
+		w.cancel()
+		w.cancel = nil
+	}
+}
+
+// IsLoading returns true while providers are being loaded.
+func (w *Welcome) IsLoading() bool {
+	return w.loading
+}
+
 // View renders the welcome screen.
 func (w *Welcome) View() string {
 	t := styles.CurrentTheme()
@@ -64,6 +118,9 @@ func (w *Welcome) View() string {
 
 	// Instructions.
 	instructions := t.S().Muted.Render("Press Enter to begin setup • q to quit")
+	if w.loading {
+		instructions = t.S().Muted.Render("Loading providers... • q to cancel")
+	}
 
 	// Combine everything.
 	content := lipgloss.JoinVertical(lipgloss.Center,
@@ -0,0 +1,109 @@
+package welcome
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+)
+
+func TestWelcome_CancelDuringLoading(t *testing.T) {
+	w := NewLoading(func(ctx context.Context) ([]catwalk.Provider, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	loadCmd := w.Init()
+	if loadCmd == nil {
+		t.Fatal("Init() should return a load command")
+	}
+	if !w.IsLoading() {
+		t.Fatal("IsLoading() should be true after Init()")
+	}
+
+	loaded := make(chan tea.Msg, 1)
+	go func() { loaded <- loadCmd() }()
+
+	_, cmd := w.Update(tea.KeyPressMsg(tea.Key{Code: 'q', Text: "q"}))
+	if cmd == nil {
+		t.Fatal("Update(q) should return a quit command")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("Update(q) should quit")
+	}
+
+	select {
+	case msg := <-loaded:
+		loadedMsg, ok := msg.(ProvidersLoadedMsg)
+		if !ok {
+			t.Fatalf("load command returned %T, want ProvidersLoadedMsg", msg)
+		}
+		if !errors.Is(loadedMsg.Err, context.Canceled) {
+			t.Errorf("ProvidersLoadedMsg.Err = %v, want context.Canceled", loadedMsg.Err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("load was not canceled")
+	}
+}
+
+func TestWelcome_CtrlCCancelsLoading(t *testing.T) {
+	canceled := make(chan struct{})
+	w := NewLoading(func(ctx context.Context) ([]catwalk.Provider, error) {
+		<-ctx.Done()
+		close(canceled)
+		return nil, ctx.Err()
+	})
+
+	loadCmd := w.Init()
+	go loadCmd()
+
+	_, cmd := w.Update(tea.KeyPressMsg(tea.Key{Code: 'c', Mod: tea.ModCtrl}))
+	if cmd == nil {
+		t.Fatal("Update(ctrl+c) should return a quit command")
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("load was not canceled")
+	}
+}
+
+func TestWelcome_EnterIgnoredWhileLoading(t *testing.T) {
+	providers := []catwalk.Provider{{ID: "openai"}}
+	w := NewLoading(func(_ context.Context) ([]catwalk.Provider, error) {
+		return providers, nil
+	})
+	w.Init()
+
+	enter := tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter})
+	if _, cmd := w.Update(enter); cmd != nil {
+		t.Error("Update(enter) should be ignored while loading")
+	}
+
+	w.Update(ProvidersLoadedMsg{Providers: providers})
+	if w.IsLoading() {
+		t.Error("IsLoading() should be false after ProvidersLoadedMsg")
+	}
+
+	_, cmd := w.Update(enter)
+	if cmd == nil {
+		t.Fatal("Update(enter) should start the wizard after loading")
+	}
+	if _, ok := cmd().(StartWizardMsg); !ok {
+		t.Error("Update(enter) should send StartWizardMsg")
+	}
+}
+
+func TestWelcome_NewHasNoLoad(t *testing.T) {
+	w := New()
+	if cmd := w.Init(); cmd != nil {
+		t.Error("Init() should return nil without a loader")
+	}
+	if w.IsLoading() {
+		t.Error("IsLoading() should be false without a loader")
+	}
+}
@@ -91,10 +91,6 @@ func (a *APIKeyInput) View() string {
 
 	inputView := a.input.View()
 
-	// Help text.
-	helpParts := []string{"Enter to confirm", "Tab to show/hide"}
-	help := t.S().Muted.Render(strings.Join(helpParts, " | "))
-
 	// Hint about env vars.
 	hint := t.S().Subtle.Render("Tip: Use $ENV_VAR to reference an environment variable")
 
@@ -109,8 +105,6 @@ func (a *APIKeyInput) View() string {
 		"",
 		hint,
 		"",
-		help,
-		"",
 		configInfo,
 	)
 }
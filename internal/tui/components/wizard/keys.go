@@ -7,4 +7,37 @@ const (
 	keyDown  = "down"
 	keyK     = "k"
 	keyJ     = "j"
+
+	keyToggleURL = "u"
+	keyPasteCode = "ctrl+v"
+	keyRetry     = "r"
+	keyResume    = "r"
+
+	keyChangeProvider = "ctrl+p"
+
+	keyCopyPath   = "c"
+	keyRevealPath = "o"
+)
+
+// keyHint describes a key binding shown in the wizard footer.
+type keyHint struct {
+	key  string
+	desc string
+}
+
+// String renders the hint as "key description".
+func (h keyHint) String() string {
+	return h.key + " " + h.desc
+}
+
+// Common footer hints shared across wizard steps.
+var (
+	hintNavigate = keyHint{key: "↑/↓", desc: "navigate"}
+	hintSelect   = keyHint{key: "Enter", desc: "select"}
+	hintConfirm  = keyHint{key: "Enter", desc: "confirm"}
+	hintBack     = keyHint{key: "Esc", desc: "back"}
+	hintQuit     = keyHint{key: "Ctrl+C", desc: "quit"}
+
+	hintChangeProvider = keyHint{key: "Ctrl+P", desc: "change provider"}
+	hintResume         = keyHint{key: "r", desc: "resume previous selection"}
 )
@@ -108,14 +108,10 @@ func (a *AuthMethodChooser) View() string {
 
 	boxes := lipgloss.JoinHorizontal(lipgloss.Center, oauthBox, "  ", apiKeyBox)
 
-	help := t.S().Muted.Render("Use Tab or ←/→ to switch, Enter to select")
-
 	return lipgloss.JoinVertical(lipgloss.Center,
 		title,
 		"",
 		boxes,
-		"",
-		help,
 	)
 }
 
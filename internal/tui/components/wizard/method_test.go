@@ -152,11 +152,6 @@ func TestAuthMethodChooser_View(t *testing.T) {
 	if !strings.Contains(view, "API Key") {
 		t.Error("View() should contain 'API Key' option")
 	}
-
-	// Should contain help text.
-	if !strings.Contains(view, "Tab") {
-		t.Error("View() should contain help text mentioning Tab")
-	}
 }
 
 func TestAuthMethodChooser_SetWidth(t *testing.T) {
@@ -83,7 +83,6 @@ func (m *ModelList) View() string {
 
 	title := t.S().Title.Render(fmt.Sprintf("Select %s Model", tierDisplay))
 	subtitle := t.S().Muted.Render(fmt.Sprintf("(%s)", tierDesc))
-	help := t.S().Muted.Render("Use ↑/↓ to navigate, Enter to select")
 
 	items := make([]string, 0, len(m.models))
 	for i := range m.models {
@@ -107,8 +106,6 @@ func (m *ModelList) View() string {
 		subtitle,
 		"",
 		list,
-		"",
-		help,
 	)
 }
 
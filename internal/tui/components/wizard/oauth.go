@@ -2,10 +2,10 @@ package wizard
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"net/url"
-	"os"
-	"os/exec"
-	"runtime"
+	"strings"
 
 	"charm.land/bubbles/v2/spinner"
 	"charm.land/bubbles/v2/textinput"
@@ -49,6 +49,17 @@ type OAuthCompleteMsg struct {
 	Token *oauth.Token
 }
 
+// OAuthCallbackMsg is sent when the local callback server receives the
+// authorization code, or fails to.
+type OAuthCallbackMsg struct {
+	Err  error
+	Code string
+
+	// server is the callback server the result came from, so results from
+	// a server that has since been stopped are ignored.
+	server *claude.CallbackServer
+}
+
 // OAuth2Flow handles the OAuth2 authentication flow.
 //
 //nolint:govet // Field order optimized for readability over memory.
@@ -65,6 +76,29 @@ type OAuth2Flow struct {
 	authURL   string
 	width     int
 
+	// openURL opens the authorization URL in a browser.
+	openURL func(string) error
+	// readClipboard returns the clipboard text for pasting the code.
+	readClipboard func() (string, error)
+	// listenCallback starts the local server receiving the authorization
+	// code from the browser.
+	listenCallback func() (*claude.CallbackServer, error)
+
+	// callback is the running callback server, nil when the code is pasted.
+	callback     *claude.CallbackServer
+	stopCallback context.CancelFunc
+	// redirectURI is the redirect URI in authURL, which the token exchange
+	// must repeat.
+	redirectURI string
+	// fallbackNotice explains why the URL must be opened manually and the
+	// code pasted, shown above the code input.
+	fallbackNotice string
+
+	// showFullURL displays the authorization URL including query params.
+	showFullURL bool
+	// openFailed is set when the browser could not be opened.
+	openFailed bool
+
 	state           OAuthState
 	validationState OAuthValidationState
 }
@@ -72,7 +106,13 @@ type OAuth2Flow struct {
 // NewOAuth2Flow creates a new OAuth2 flow component.
 func NewOAuth2Flow() *OAuth2Flow {
 	return &OAuth2Flow{
-		state: OAuthStateURL,
+		state:         OAuthStateURL,
+		openURL:       openPath,
+		readClipboard: readClipboard,
+		listenCallback: func() (*claude.CallbackServer, error) {
+			return claude.ListenCallback(claude.DefaultCallbackPorts)
+		},
+		redirectURI: claude.ConsoleRedirectURI,
 	}
 }
 
@@ -118,6 +158,26 @@ func (o *OAuth2Flow) Init() tea.Cmd {
 func (o *OAuth2Flow) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
+	// A resize only reflows the view. It must not reach the spinner or the
+	// input, so an in-flight validation carries on undisturbed.
+	if m, ok := msg.(tea.WindowSizeMsg); ok {
+		o.SetWidth(m.Width)
+		return o, nil
+	}
+
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && o.state == OAuthStateURL && keyMsg.String() == keyToggleURL {
+		o.showFullURL = !o.showFullURL
+		return o, nil
+	}
+
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == keyPasteCode && o.canEditCode() {
+		return o, o.pasteCode()
+	}
+
+	if m, ok := msg.(OAuthCallbackMsg); ok {
+		return o, o.handleCallback(m)
+	}
+
 	if m, ok := msg.(OAuthValidationCompletedMsg); ok {
 		o.validationState = m.State
 		o.token = m.Token
@@ -147,11 +207,18 @@ func (o *OAuth2Flow) HandleConfirm() (util.Model, tea.Cmd) {
 
 	switch {
 	case o.state == OAuthStateURL:
-		// Open URL in browser and move to code input.
-		// Use silent open to avoid disrupting the TUI.
-		openBrowserSilent(o.authURL)
+		// Open URL in browser and move to code input. If that fails, keep
+		// the full URL on screen so it can be opened manually. That browser
+		// may be on another machine, out of the callback server's reach, so
+		// the code is pasted instead.
+		wait := o.startCallback()
+		if err := o.openURL(o.authURL); err != nil {
+			o.usePasteFlow("Couldn't open a browser. Open this URL manually:")
+			o.openFailed = true
+			wait = nil
+		}
 		o.state = OAuthStateCode
-		cmds = append(cmds, o.codeInput.Focus())
+		cmds = append(cmds, o.codeInput.Focus(), wait)
 
 	case o.validationState == OAuthValidationStateNone || o.validationState == OAuthValidationStateError:
 		// Validate the code.
@@ -179,13 +246,10 @@ func (o *OAuth2Flow) View() string {
 	switch o.state {
 	case OAuthStateURL:
 		heading := t.S().Title.Render("Press Enter to open the authorization URL in your browser:")
-		displayURL := o.displayURL()
-		urlText := t.S().Muted.Render(displayURL)
-
 		return lipgloss.JoinVertical(lipgloss.Left,
 			heading,
 			"",
-			urlText,
+			o.urlView(),
 		)
 
 	case OAuthStateCode:
@@ -196,6 +260,9 @@ func (o *OAuth2Flow) View() string {
 			heading = t.S().Title.Render("Enter the ") +
 				t.S().Success.Render("code") +
 				t.S().Title.Render(" you received:")
+			if o.callback != nil {
+				heading = t.S().Title.Render("Waiting for you to sign in in your browser...")
+			}
 		case OAuthValidationStateVerifying:
 			heading = t.S().Title.Render("Verifying...")
 		case OAuthValidationStateValid:
@@ -204,21 +271,27 @@ func (o *OAuth2Flow) View() string {
 			heading = t.S().Error.Render("Invalid code. Try again?")
 		}
 
-		return lipgloss.JoinVertical(lipgloss.Left,
-			heading,
-			"",
-			o.codeInput.View(),
-		)
+		parts := []string{heading, "", o.codeInput.View()}
+		if o.fallbackNotice != "" {
+			parts = append([]string{
+				t.S().Warning.Render(o.fallbackNotice),
+				"",
+				o.urlView(),
+				"",
+			}, parts...)
+		}
+		return lipgloss.JoinVertical(lipgloss.Left, parts...)
 
 	default:
 		return "Unknown state"
 	}
 }
 
-// SetWidth sets the component width.
+// SetWidth sets the component width. The prompt, which shows the spinner
+// while verifying, is left as it is.
 func (o *OAuth2Flow) SetWidth(w int) {
 	o.width = w
-	o.codeInput.SetWidth(w - 4)
+	o.codeInput.SetWidth(max(w-4, 0))
 }
 
 // Token returns the OAuth token if validated.
@@ -244,14 +317,125 @@ func (o *OAuth2Flow) Cursor() *tea.Cursor {
 	return nil
 }
 
+// canEditCode reports whether the code input accepts changes.
+func (o *OAuth2Flow) canEditCode() bool {
+	return o.state == OAuthStateCode &&
+		o.validationState != OAuthValidationStateVerifying &&
+		o.validationState != OAuthValidationStateValid
+}
+
+// pasteCode fills the code input from the clipboard. The "#state" suffix
+// of the copied value is dropped, after checking it belongs to this flow.
+func (o *OAuth2Flow) pasteCode() tea.Cmd {
+	text, err := o.readClipboard()
+	if err != nil {
+		return util.ReportWarn(fmt.Sprintf("Couldn't read the clipboard (%v). Paste the code manually.", err))
+	}
+
+	code, state, _ := strings.Cut(strings.TrimSpace(text), "#")
+	switch {
+	case code == "":
+		return util.ReportWarn("The clipboard doesn't contain a code")
+	case state != "" && state != o.verifier:
+		return util.ReportWarn("The clipboard code is from a different sign-in attempt")
+	}
+
+	o.codeInput.SetValue(code)
+	o.codeInput.CursorEnd()
+	return util.ReportInfo("Code pasted from the clipboard. Press Enter to verify.")
+}
+
 func (o *OAuth2Flow) validateCode() tea.Msg {
-	token, err := claude.ExchangeToken(context.Background(), o.codeInput.Value(), o.verifier)
+	// The state sent with the authorization URL is the verifier, so a code
+	// entered without its "#state" suffix gets it back.
+	code := o.codeInput.Value()
+	if !strings.Contains(code, "#") {
+		code += "#" + o.verifier
+	}
+	token, err := claude.ExchangeTokenWithRedirect(context.Background(), code, o.verifier, o.redirectURI)
 	if err != nil || token == nil {
 		return OAuthValidationCompletedMsg{State: OAuthValidationStateError}
 	}
 	return OAuthValidationCompletedMsg{State: OAuthValidationStateValid, Token: token}
 }
 
+// startCallback starts the local callback server and points the
+// authorization URL at it, returning the command that waits for the code.
+// When no port can be bound the code is pasted as before and it returns nil.
+func (o *OAuth2Flow) startCallback() tea.Cmd {
+	server, err := o.listenCallback()
+	if err != nil {
+		slog.Debug("OAuth callback server unavailable; the code will be pasted", "error", err)
+		return nil
+	}
+	authURL, err := claude.AuthorizeURLWithRedirect(o.verifier, o.challenge, server.RedirectURI())
+	if err != nil {
+		_ = server.Close() //nolint:errcheck // Nothing was served yet.
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	o.callback, o.stopCallback = server, cancel
+	o.redirectURI = server.RedirectURI()
+	o.authURL = authURL
+	return func() tea.Msg {
+		code, err := server.Wait(ctx)
+		return OAuthCallbackMsg{Code: code, Err: err, server: server}
+	}
+}
+
+// handleCallback verifies a code delivered to the callback server. When the
+// callback failed, the flow falls back to pasting the code.
+func (o *OAuth2Flow) handleCallback(m OAuthCallbackMsg) tea.Cmd {
+	if m.server == nil || m.server != o.callback || !o.canEditCode() {
+		return nil
+	}
+	o.Close()
+
+	code, state, _ := strings.Cut(m.Code, "#")
+	switch {
+	case m.Err != nil:
+		o.usePasteFlow("Automatic sign-in failed. Open this URL and paste the code:")
+		return util.ReportWarn(fmt.Sprintf("Sign-in callback failed: %v", m.Err))
+	case state != o.verifier:
+		o.usePasteFlow("Automatic sign-in failed. Open this URL and paste the code:")
+		return util.ReportWarn("The sign-in callback is from a different sign-in attempt")
+	}
+
+	o.codeInput.SetValue(code)
+	o.codeInput.Blur()
+	o.validationState = OAuthValidationStateVerifying
+	o.updatePrompt()
+	return tea.Batch(o.spinner.Tick, o.validateCode)
+}
+
+// usePasteFlow stops the callback server and points the authorization URL
+// back at the console page that shows a code to paste. The notice and the
+// full URL are shown so it can be opened manually.
+func (o *OAuth2Flow) usePasteFlow(notice string) {
+	o.Close()
+	authURL, err := claude.AuthorizeURL(o.verifier, o.challenge)
+	if err != nil {
+		o.err = err
+		return
+	}
+	o.authURL = authURL
+	o.redirectURI = claude.ConsoleRedirectURI
+	o.fallbackNotice = notice
+	o.showFullURL = true
+}
+
+// Close stops the callback server, if one is running. It is safe to call on
+// a nil flow.
+func (o *OAuth2Flow) Close() {
+	if o == nil || o.callback == nil {
+		return
+	}
+	o.stopCallback()
+	_ = o.callback.Close() //nolint:errcheck // The server is being discarded.
+	o.callback, o.stopCallback = nil, nil
+}
+
 func (o *OAuth2Flow) updatePrompt() {
 	switch o.validationState {
 	case OAuthValidationStateNone:
@@ -265,49 +449,42 @@ func (o *OAuth2Flow) updatePrompt() {
 	}
 }
 
-// displayURL returns a shortened URL for display (without query params).
+// displayURL returns the URL for display. Query params are elided unless the
+// full URL has been toggled on.
 func (o *OAuth2Flow) displayURL() string {
-	parsed, err := url.Parse(o.authURL)
-	if err != nil {
+	if o.showFullURL {
 		return o.authURL
 	}
 
-	if parsed.RawQuery != "" {
-		parsed.RawQuery = ""
-		return parsed.String() + "..."
+	parsed, err := url.Parse(o.authURL)
+	if err != nil || parsed.RawQuery == "" {
+		return o.authURL
 	}
 
-	return o.authURL
+	parsed.RawQuery = ""
+	return parsed.String() + "..."
 }
 
-// openBrowserSilent opens a URL in the browser without outputting to stdout/stderr.
-// This prevents disruption to the TUI.
-func openBrowserSilent(targetURL string) {
-	var cmd *exec.Cmd
-	ctx := context.Background()
-
-	switch runtime.GOOS {
-	case "linux":
-		cmd = exec.CommandContext(ctx, "xdg-open", targetURL)
-	case "darwin":
-		cmd = exec.CommandContext(ctx, "open", targetURL)
-	case "windows":
-		cmd = exec.CommandContext(ctx, "rundll32", "url.dll,FileProtocolHandler", targetURL)
-	default:
-		return
+// urlView renders the display URL. A URL without params is marked as
+// complete on a line of its own, so copying the URL line copies only the URL.
+func (o *OAuth2Flow) urlView() string {
+	t := styles.CurrentTheme()
+	urlText := t.S().Muted.Render(o.displayURL())
+	if o.showFullURL {
+		return urlText
 	}
-
-	// Redirect stdout and stderr to /dev/null to avoid TUI disruption.
-	cmd.Stdout = nil
-	cmd.Stderr = nil
-	cmd.Stdin = nil
-
-	// Detach from the process group so it doesn't receive signals.
-	if f, err := os.Open(os.DevNull); err == nil {
-		cmd.Stdout = f
-		cmd.Stderr = f
-		defer f.Close() //nolint:errcheck // Best effort close.
+	if parsed, err := url.Parse(o.authURL); err != nil || parsed.RawQuery != "" {
+		return urlText
 	}
+	return lipgloss.JoinVertical(lipgloss.Left, urlText, t.S().Subtle.Render("(full URL)"))
+}
+
+// ShowsFullURL returns true if the full authorization URL is displayed.
+func (o *OAuth2Flow) ShowsFullURL() bool {
+	return o.showFullURL
+}
 
-	_ = cmd.Start() //nolint:errcheck // Best effort open.
+// OpenFailed returns true if the browser could not be opened.
+func (o *OAuth2Flow) OpenFailed() bool {
+	return o.openFailed
 }
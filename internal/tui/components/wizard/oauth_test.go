@@ -1,10 +1,16 @@
 package wizard
 
 import (
+	"errors"
+	"net/url"
 	"strings"
 	"testing"
 
+	tea "charm.land/bubbletea/v2"
+
 	"github.com/guilhermegouw/matrix-cli/internal/oauth"
+	"github.com/guilhermegouw/matrix-cli/internal/oauth/claude"
+	"github.com/guilhermegouw/matrix-cli/internal/tui/util"
 )
 
 func TestNewOAuth2Flow(t *testing.T) {
@@ -182,6 +188,7 @@ func TestOAuth2Flow_View_WithError(t *testing.T) {
 func TestOAuth2Flow_HandleConfirm_URLState(t *testing.T) {
 	flow := NewOAuth2Flow()
 	_ = flow.Init()
+	t.Cleanup(flow.Close)
 
 	_, cmd := flow.HandleConfirm()
 
@@ -306,6 +313,252 @@ func TestOAuth2Flow_DisplayURL_NoQueryParams(t *testing.T) {
 	if strings.HasSuffix(displayURL, "...") {
 		t.Error("displayURL() should not end with ... when no query params")
 	}
+
+	// The URL stays copyable, with the full URL marker on its own line.
+	if displayURL != "https://example.com/path" {
+		t.Errorf("displayURL() = %q, want the bare URL", displayURL)
+	}
+	lines := strings.Split(flow.View(), "\n")
+	var urlLine int
+	for i, line := range lines {
+		if strings.Contains(line, "https://example.com/path") {
+			urlLine = i
+		}
+	}
+	if strings.Contains(lines[urlLine], "(full URL)") {
+		t.Errorf("URL line = %q, want only the URL", lines[urlLine])
+	}
+	if urlLine+1 >= len(lines) || !strings.Contains(lines[urlLine+1], "(full URL)") {
+		t.Errorf("View() should mark the URL as full on the next line, got:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestOAuth2Flow_ToggleFullURL(t *testing.T) {
+	flow := NewOAuth2Flow()
+	_ = flow.Init()
+
+	flow.Update(tea.KeyPressMsg(tea.Key{Code: 'u', Text: "u"}))
+
+	if !flow.ShowsFullURL() {
+		t.Fatal("ShowsFullURL() = false after pressing u")
+	}
+	if got := flow.displayURL(); got != flow.authURL {
+		t.Errorf("displayURL() = %q, want full URL %q", got, flow.authURL)
+	}
+	if !strings.Contains(flow.View(), "code_challenge=") {
+		t.Error("View() should show query params when full URL is toggled on")
+	}
+
+	flow.Update(tea.KeyPressMsg(tea.Key{Code: 'u', Text: "u"}))
+
+	if flow.ShowsFullURL() {
+		t.Error("ShowsFullURL() = true after pressing u twice")
+	}
+	if !strings.HasSuffix(flow.displayURL(), "...") {
+		t.Error("displayURL() should be shortened again after toggling off")
+	}
+	if strings.Contains(flow.View(), "code_challenge=") {
+		t.Error("View() should hide query params when full URL is toggled off")
+	}
+}
+
+func TestOAuth2Flow_HandleConfirm_OpenFailedShowsFullURL(t *testing.T) {
+	flow := NewOAuth2Flow()
+	_ = flow.Init()
+	t.Cleanup(flow.Close)
+	flow.openURL = func(string) error { return errors.New("no browser") }
+
+	flow.HandleConfirm()
+
+	if flow.state != OAuthStateCode {
+		t.Errorf("state = %d, want %d after confirm", flow.state, OAuthStateCode)
+	}
+	if !flow.OpenFailed() {
+		t.Error("OpenFailed() = false, want true when the browser can't be opened")
+	}
+	view := flow.View()
+	if !strings.Contains(view, "Open this URL manually") || !strings.Contains(view, "code_challenge=") {
+		t.Errorf("View() should show the full URL after a failed open, got:\n%s", view)
+	}
+}
+
+func TestOAuth2Flow_HandleConfirm_OpenSucceeded(t *testing.T) {
+	flow := NewOAuth2Flow()
+	_ = flow.Init()
+	t.Cleanup(flow.Close)
+	var opened string
+	flow.openURL = func(u string) error {
+		opened = u
+		return nil
+	}
+
+	flow.HandleConfirm()
+
+	if opened != flow.authURL {
+		t.Errorf("opened %q, want %q", opened, flow.authURL)
+	}
+	if flow.OpenFailed() {
+		t.Error("OpenFailed() = true after a successful open")
+	}
+	if strings.Contains(flow.View(), "Open this URL manually") {
+		t.Error("View() should not show the manual URL after a successful open")
+	}
+}
+
+// newCallbackFlow returns an initialized flow whose callback server binds a
+// free port and whose browser opens always succeed.
+func newCallbackFlow(t *testing.T) *OAuth2Flow {
+	t.Helper()
+	flow := NewOAuth2Flow()
+	flow.listenCallback = func() (*claude.CallbackServer, error) {
+		return claude.ListenCallback([]int{0})
+	}
+	flow.openURL = func(string) error { return nil }
+	_ = flow.Init()
+	t.Cleanup(flow.Close)
+	return flow
+}
+
+func TestOAuth2Flow_HandleConfirm_UsesCallbackServer(t *testing.T) {
+	flow := newCallbackFlow(t)
+
+	flow.HandleConfirm()
+
+	if flow.callback == nil {
+		t.Fatal("HandleConfirm() should start the callback server")
+	}
+	if flow.redirectURI != flow.callback.RedirectURI() {
+		t.Errorf("redirectURI = %q, want the callback server's %q", flow.redirectURI, flow.callback.RedirectURI())
+	}
+	if !strings.Contains(flow.authURL, url.QueryEscape(flow.callback.RedirectURI())) {
+		t.Errorf("authURL = %q, want it to redirect to the callback server", flow.authURL)
+	}
+	if !strings.Contains(flow.View(), "Waiting for you to sign in") {
+		t.Errorf("View() should say it is waiting for the browser, got:\n%s", flow.View())
+	}
+}
+
+func TestOAuth2Flow_Callback_VerifiesCode(t *testing.T) {
+	flow := newCallbackFlow(t)
+	flow.HandleConfirm()
+	server := flow.callback
+
+	_, cmd := flow.Update(OAuthCallbackMsg{Code: "abc#" + flow.verifier, server: server})
+
+	if cmd == nil {
+		t.Fatal("a callback code should start verification")
+	}
+	if flow.validationState != OAuthValidationStateVerifying {
+		t.Errorf("validationState = %d, want verifying", flow.validationState)
+	}
+	if flow.codeInput.Value() != "abc" {
+		t.Errorf("code = %q, want %q", flow.codeInput.Value(), "abc")
+	}
+	if flow.callback != nil {
+		t.Error("the callback server should be stopped once the code arrives")
+	}
+	if flow.redirectURI != server.RedirectURI() {
+		t.Error("the token exchange should keep the callback redirect URI")
+	}
+}
+
+func TestOAuth2Flow_Callback_FallsBackToPaste(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  func(flow *OAuth2Flow) OAuthCallbackMsg
+	}{
+		{name: "callback error", msg: func(flow *OAuth2Flow) OAuthCallbackMsg {
+			return OAuthCallbackMsg{Err: errors.New("access_denied"), server: flow.callback}
+		}},
+		{name: "foreign state", msg: func(flow *OAuth2Flow) OAuthCallbackMsg {
+			return OAuthCallbackMsg{Code: "abc#other", server: flow.callback}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flow := newCallbackFlow(t)
+			flow.HandleConfirm()
+
+			flow.Update(tt.msg(flow))
+
+			if flow.callback != nil {
+				t.Error("the callback server should be stopped")
+			}
+			if flow.redirectURI != claude.ConsoleRedirectURI {
+				t.Errorf("redirectURI = %q, want the console redirect for pasting", flow.redirectURI)
+			}
+			if flow.validationState != OAuthValidationStateNone {
+				t.Errorf("validationState = %d, want none", flow.validationState)
+			}
+			view := flow.View()
+			if !strings.Contains(view, "Automatic sign-in failed") || !strings.Contains(view, "code_challenge=") {
+				t.Errorf("View() should ask to paste the code from the full URL, got:\n%s", view)
+			}
+		})
+	}
+}
+
+func TestOAuth2Flow_Callback_IgnoresStaleServer(t *testing.T) {
+	flow := newCallbackFlow(t)
+	flow.HandleConfirm()
+
+	stale, err := claude.ListenCallback([]int{0})
+	if err != nil {
+		t.Fatalf("ListenCallback() error = %v", err)
+	}
+	t.Cleanup(func() { _ = stale.Close() })
+
+	_, cmd := flow.Update(OAuthCallbackMsg{Code: "abc#" + flow.verifier, server: stale})
+
+	if cmd != nil || flow.validationState != OAuthValidationStateNone {
+		t.Error("a result from another callback server should be ignored")
+	}
+}
+
+func TestOAuth2Flow_HandleConfirm_NoCallbackPort(t *testing.T) {
+	flow := newCallbackFlow(t)
+	flow.listenCallback = func() (*claude.CallbackServer, error) {
+		return nil, errors.New("no free callback port")
+	}
+
+	flow.HandleConfirm()
+
+	if flow.callback != nil {
+		t.Error("no callback server should be running")
+	}
+	if flow.redirectURI != claude.ConsoleRedirectURI {
+		t.Errorf("redirectURI = %q, want the console redirect for pasting", flow.redirectURI)
+	}
+	if !strings.Contains(flow.authURL, url.QueryEscape(claude.ConsoleRedirectURI)) {
+		t.Errorf("authURL = %q, want the console redirect", flow.authURL)
+	}
+}
+
+func TestOAuth2Flow_HandleConfirm_OpenFailedStopsCallback(t *testing.T) {
+	flow := newCallbackFlow(t)
+	flow.openURL = func(string) error { return errors.New("no browser") }
+
+	flow.HandleConfirm()
+
+	if flow.callback != nil {
+		t.Error("the callback server should be stopped when the browser can't be opened")
+	}
+	if !strings.Contains(flow.authURL, url.QueryEscape(claude.ConsoleRedirectURI)) {
+		t.Errorf("authURL = %q, want the console redirect for pasting", flow.authURL)
+	}
+}
+
+func TestOAuth2Flow_ToggleFullURL_IgnoredInCodeState(t *testing.T) {
+	flow := NewOAuth2Flow()
+	_ = flow.Init()
+	flow.state = OAuthStateCode
+
+	flow.Update(tea.KeyPressMsg(tea.Key{Code: 'u', Text: "u"}))
+
+	if flow.ShowsFullURL() {
+		t.Error("pressing u in code state should not toggle the URL")
+	}
 }
 
 func TestOAuth2Flow_DisplayURL_InvalidURL(t *testing.T) {
@@ -376,3 +629,119 @@ type testError struct {
 func (e *testError) Error() string {
 	return e.msg
 }
+
+func TestOAuth2Flow_Update_PasteCode(t *testing.T) {
+	tests := []struct { //nolint:govet // Test struct alignment is not critical.
+		name      string
+		clipboard string
+		clipErr   error
+		wantCode  string
+		wantType  util.InfoType
+	}{
+		{name: "code with state", clipboard: "abc123#verifier\n", wantCode: "abc123", wantType: util.InfoTypeInfo},
+		{name: "code only", clipboard: "  abc123  ", wantCode: "abc123", wantType: util.InfoTypeInfo},
+		{name: "other attempt", clipboard: "abc123#stale", wantCode: "", wantType: util.InfoTypeWarn},
+		{name: "empty", clipboard: "", wantCode: "", wantType: util.InfoTypeWarn},
+		{name: "unavailable", clipErr: errors.New("no clipboard utility available"), wantCode: "", wantType: util.InfoTypeWarn},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flow := NewOAuth2Flow()
+			_ = flow.Init()
+			flow.state = OAuthStateCode
+			flow.verifier = "verifier"
+			flow.readClipboard = func() (string, error) {
+				return tt.clipboard, tt.clipErr
+			}
+
+			_, cmd := flow.Update(tea.KeyPressMsg(tea.Key{Code: 'v', Mod: tea.ModCtrl}))
+
+			if got := flow.codeInput.Value(); got != tt.wantCode {
+				t.Errorf("codeInput.Value() = %q, want %q", got, tt.wantCode)
+			}
+			if cmd == nil {
+				t.Fatal("Update() should return a command")
+			}
+			info, ok := cmd().(util.InfoMsg)
+			if !ok {
+				t.Fatal("cmd() msg is not util.InfoMsg")
+			}
+			if info.Type != tt.wantType {
+				t.Errorf("InfoMsg.Type = %v, want %v (%s)", info.Type, tt.wantType, info.Msg)
+			}
+		})
+	}
+}
+
+func TestOAuth2Flow_Update_ResizeWhileVerifying(t *testing.T) {
+	flow := NewOAuth2Flow()
+	_ = flow.Init()
+	flow.codeInput.SetValue("abc123")
+	flow.state = OAuthStateCode
+	flow.validationState = OAuthValidationStateVerifying
+	flow.updatePrompt()
+	prompt := flow.codeInput.Prompt
+
+	_, cmd := flow.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+
+	if cmd != nil {
+		t.Error("Update() should not return a command for a resize")
+	}
+	if flow.width != 100 {
+		t.Errorf("width = %d, want 100", flow.width)
+	}
+	if got := flow.codeInput.Width(); got != 96 {
+		t.Errorf("code input width = %d, want 96", got)
+	}
+	if flow.validationState != OAuthValidationStateVerifying {
+		t.Errorf("validationState = %v, want verifying", flow.validationState)
+	}
+	if flow.state != OAuthStateCode {
+		t.Errorf("state = %v, want code", flow.state)
+	}
+	if flow.codeInput.Value() != "abc123" {
+		t.Errorf("code = %q, want %q", flow.codeInput.Value(), "abc123")
+	}
+	if flow.codeInput.Prompt != prompt {
+		t.Errorf("prompt = %q, want %q", flow.codeInput.Prompt, prompt)
+	}
+	if !strings.Contains(flow.View(), "Verifying") {
+		t.Error("View() should still show 'Verifying' after a resize")
+	}
+
+	// The validation result still lands after the resize.
+	_, _ = flow.Update(OAuthValidationCompletedMsg{State: OAuthValidationStateValid, Token: &oauth.Token{}})
+	if !flow.IsComplete() {
+		t.Error("IsComplete() = false after validation completed")
+	}
+}
+
+func TestOAuth2Flow_Update_ResizeToNarrowWidth(t *testing.T) {
+	flow := NewOAuth2Flow()
+	_ = flow.Init()
+	flow.state = OAuthStateCode
+	flow.validationState = OAuthValidationStateVerifying
+
+	_, _ = flow.Update(tea.WindowSizeMsg{Width: 2, Height: 10})
+
+	if got := flow.codeInput.Width(); got != 0 {
+		t.Errorf("code input width = %d, want 0", got)
+	}
+	if flow.validationState != OAuthValidationStateVerifying {
+		t.Errorf("validationState = %v, want verifying", flow.validationState)
+	}
+}
+
+func TestOAuth2Flow_Update_PasteCodeIgnoredWhenVerifying(t *testing.T) {
+	flow := NewOAuth2Flow()
+	_ = flow.Init()
+	flow.state = OAuthStateCode
+	flow.validationState = OAuthValidationStateVerifying
+	flow.readClipboard = func() (string, error) {
+		t.Error("clipboard should not be read while verifying")
+		return "", nil
+	}
+
+	_, _ = flow.Update(tea.KeyPressMsg(tea.Key{Code: 'v', Mod: tea.ModCtrl}))
+}
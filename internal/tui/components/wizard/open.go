@@ -0,0 +1,62 @@
+package wizard
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/atotto/clipboard"
+)
+
+// errOpenUnsupported is returned when there is no known opener for the OS.
+var errOpenUnsupported = errors.New("opening files is not supported on " + runtime.GOOS)
+
+// openPath opens a URL or file path with the OS default handler, without
+// writing to stdout/stderr. Directories open in the file manager.
+func openPath(target string) error {
+	var cmd *exec.Cmd
+	ctx := context.Background()
+
+	switch runtime.GOOS {
+	case "linux":
+		cmd = exec.CommandContext(ctx, "xdg-open", target)
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "open", target)
+	case "windows":
+		cmd = exec.CommandContext(ctx, "rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		return errOpenUnsupported
+	}
+
+	// Redirect stdout and stderr to /dev/null to avoid TUI disruption.
+	cmd.Stdout = nil
+	cmd.Stderr = nil
+	cmd.Stdin = nil
+
+	// Detach from the process group so it doesn't receive signals.
+	if f, err := os.Open(os.DevNull); err == nil {
+		cmd.Stdout = f
+		cmd.Stderr = f
+		defer f.Close() //nolint:errcheck // Best effort close.
+	}
+
+	return cmd.Start()
+}
+
+// readClipboard returns the text on the system clipboard.
+func readClipboard() (string, error) {
+	if clipboard.Unsupported {
+		return "", errors.New("no clipboard utility available")
+	}
+	return clipboard.ReadAll()
+}
+
+// copyToClipboard writes text to the system clipboard.
+func copyToClipboard(text string) error {
+	if clipboard.Unsupported {
+		return errors.New("no clipboard utility available")
+	}
+	return clipboard.WriteAll(text)
+}
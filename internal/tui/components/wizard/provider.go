@@ -69,7 +69,6 @@ func (p *ProviderList) View() string {
 	t := styles.CurrentTheme()
 
 	title := t.S().Title.Render("Select a Provider")
-	help := t.S().Muted.Render("Use ↑/↓ to navigate, Enter to select")
 
 	items := make([]string, 0, len(p.providers))
 	for i := range p.providers {
@@ -92,8 +91,6 @@ func (p *ProviderList) View() string {
 		title,
 		"",
 		list,
-		"",
-		help,
 	)
 }
 
@@ -110,3 +107,13 @@ func (p *ProviderList) SelectedProvider() *catwalk.Provider {
 	}
 	return &p.providers[p.cursor]
 }
+
+// SetCursorToProvider moves cursor to a specific provider by ID.
+func (p *ProviderList) SetCursorToProvider(providerID string) {
+	for i := range p.providers {
+		if string(p.providers[i].ID) == providerID {
+			p.cursor = i
+			return
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package wizard
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/oauth"
+)
+
+// WizardState is a snapshot of the wizard's progress that can be stored and
+// later restored, e.g. to resume setup after quitting.
+//
+//nolint:revive // WizardState reads better than State at call sites.
+type WizardState struct {
+	// OAuthToken is the token obtained by the OAuth step, if any.
+	OAuthToken *oauth.Token `json:"oauth,omitempty"`
+	// ProviderID is the selected provider.
+	ProviderID string `json:"provider_id,omitempty"`
+	// APIKey is the entered API key, or the OAuth access token.
+	APIKey string `json:"api_key,omitempty"`
+	// LargeModelID is the selected large model.
+	LargeModelID string `json:"large_model_id,omitempty"`
+	// SmallModelID is the selected small model.
+	SmallModelID string `json:"small_model_id,omitempty"`
+	// Step is the step the wizard is on.
+	Step Step `json:"step"`
+	// AuthMethod is the chosen authentication method.
+	AuthMethod AuthMethod `json:"auth_method"`
+	// Quick records that the models were chosen up front.
+	Quick bool `json:"quick,omitempty"`
+}
+
+// Snapshot captures the wizard's current progress.
+func (w *Wizard) Snapshot() WizardState {
+	state := WizardState{
+		OAuthToken: w.oauthToken,
+		APIKey:     w.apiKey,
+		Step:       w.step,
+		AuthMethod: w.authMethod,
+		Quick:      w.quick,
+	}
+	if w.selectedProvider != nil {
+		state.ProviderID = string(w.selectedProvider.ID)
+	}
+	if w.selectedLarge != nil {
+		state.LargeModelID = w.selectedLarge.ID
+	}
+	if w.selectedSmall != nil {
+		state.SmallModelID = w.selectedSmall.ID
+	}
+	return state
+}
+
+// Restore resumes the wizard from a snapshot. The provider and models must
+// still be known, and the state must have what its step depends on. An OAuth
+// step restarts the browser flow. Call Init afterwards to start the step.
+func (w *Wizard) Restore(state WizardState) error {
+	if err := state.validate(); err != nil {
+		return err
+	}
+
+	restored := *w
+	restored.step = state.Step
+	restored.authMethod = state.AuthMethod
+	restored.apiKey = state.APIKey
+	restored.oauthToken = state.OAuthToken
+	restored.quick = state.Quick
+	restored.saveErr = nil
+	restored.saving = false
+	restored.selectedProvider = nil
+	restored.selectedLarge = nil
+	restored.selectedSmall = nil
+
+	if state.ProviderID != "" {
+		idx := slices.IndexFunc(w.providers, func(p catwalk.Provider) bool {
+			return string(p.ID) == state.ProviderID
+		})
+		if idx < 0 {
+			return fmt.Errorf("unknown provider %q", state.ProviderID)
+		}
+		restored.selectedProvider = &w.providers[idx]
+		if err := state.validateAuth(restored.selectedProvider); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if state.LargeModelID != "" {
+		if restored.selectedLarge, err = findModel(restored.selectedProvider, state.LargeModelID, ""); err != nil {
+			return err
+		}
+	}
+	if state.SmallModelID != "" {
+		if restored.selectedSmall, err = findModel(restored.selectedProvider, state.SmallModelID, ""); err != nil {
+			return err
+		}
+	}
+
+	restored.rebuildSteps()
+	*w = restored
+	return nil
+}
+
+// validate checks that the state has what its step depends on.
+func (s WizardState) validate() error {
+	if s.Step < StepProvider || s.Step > StepComplete {
+		return fmt.Errorf("unknown wizard step %d", s.Step)
+	}
+	if s.Step > StepProvider && s.ProviderID == "" {
+		return errors.New("wizard state has no provider")
+	}
+	if (s.LargeModelID != "" || s.SmallModelID != "") && s.ProviderID == "" {
+		return errors.New("wizard state has models but no provider")
+	}
+	if s.Step >= StepLargeModel && !s.Quick && s.APIKey == "" && s.OAuthToken == nil {
+		return errors.New("wizard state has no credentials")
+	}
+	if s.Step >= StepSmallModel && s.LargeModelID == "" {
+		return errors.New("wizard state has no large model")
+	}
+	if s.Step == StepComplete && s.SmallModelID == "" {
+		return errors.New("wizard state has no small model")
+	}
+	if s.Quick && (s.LargeModelID == "" || s.SmallModelID == "") {
+		return errors.New("quick wizard state needs both models")
+	}
+	return nil
+}
+
+// validateAuth checks that the state's auth steps fit the provider. Only
+// Anthropic offers the auth method choice and OAuth.
+func (s WizardState) validateAuth(provider *catwalk.Provider) error {
+	oauthSteps := s.Step == StepAuthMethod || s.Step == StepOAuth || s.OAuthToken != nil
+	if oauthSteps && (provider.ID != catwalk.InferenceProviderAnthropic || s.Quick) {
+		return fmt.Errorf("provider %q does not offer OAuth setup", provider.ID)
+	}
+	if s.Step == StepOAuth && s.AuthMethod != AuthMethodOAuth2 {
+		return errors.New("wizard state is on the OAuth step without OAuth chosen")
+	}
+	return nil
+}
+
+// rebuildSteps recreates the components of the current step and the steps
+// before it, so both the current view and going back work after a restore.
+func (w *Wizard) rebuildSteps() {
+	w.authMethodChoice = nil
+	w.oauthFlow = nil
+	w.apiKeyInput = nil
+	w.largeModel = nil
+	w.smallModel = nil
+
+	provider := w.selectedProvider
+	if provider == nil {
+		return
+	}
+
+	if provider.ID == catwalk.InferenceProviderAnthropic && !w.quick {
+		w.authMethodChoice = NewAuthMethodChooser(provider.Name)
+		w.authMethodChoice.SetWidth(w.width)
+	}
+	usedOAuth := w.oauthToken != nil || (w.authMethod == AuthMethodOAuth2 && w.step >= StepOAuth)
+	if w.authMethodChoice != nil && usedOAuth {
+		w.oauthFlow = NewOAuth2Flow()
+		w.oauthFlow.SetWidth(w.width)
+	}
+	w.apiKeyInput = NewAPIKeyInput(provider.Name)
+	w.apiKeyInput.SetWidth(w.width)
+
+	if w.step >= StepLargeModel {
+		w.initModelLists()
+		if w.selectedLarge != nil {
+			w.largeModel.SetCursorToModel(w.selectedLarge.ID)
+		}
+		if w.selectedSmall != nil {
+			w.smallModel.SetCursorToModel(w.selectedSmall.ID)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package wizard
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/oauth"
+)
+
+func TestWizard_SnapshotRestore_ContinuesFlow(t *testing.T) {
+	// Walk a wizard to the small model step.
+	w := NewWizard(quickProviders())
+	w.Update(ProviderSelectedMsg{Provider: quickProviders()[0]})
+	w.Update(APIKeyEnteredMsg{APIKey: "$OPENAI_API_KEY"})
+	w.Update(ModelSelectedMsg{Model: catwalk.Model{ID: "o3"}})
+	if w.step != StepSmallModel {
+		t.Fatalf("Step() = %v, want StepSmallModel", w.step)
+	}
+
+	// Round-trip the snapshot through JSON as a saved progress file would.
+	data, err := json.Marshal(w.Snapshot())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var state WizardState
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	resumed := NewWizard(quickProviders())
+	var saved bool
+	resumed.save = func() error {
+		saved = true
+		return nil
+	}
+	if err := resumed.Restore(state); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if got := resumed.Snapshot(); got != w.Snapshot() {
+		t.Errorf("Snapshot() after Restore = %+v, want %+v", got, w.Snapshot())
+	}
+	if !strings.Contains(resumed.View(), "Select Small Model") {
+		t.Error("restored wizard should render the small model step")
+	}
+
+	// Continue where the original left off.
+	_, cmd := resumed.Update(ModelSelectedMsg{Model: catwalk.Model{ID: "gpt-4o-mini"}})
+	if resumed.step != StepComplete {
+		t.Fatalf("Step() = %v, want StepComplete", resumed.step)
+	}
+	msg, ok := cmd().(CompleteMsg)
+	if !ok {
+		t.Fatal("save cmd should return CompleteMsg")
+	}
+	want := CompleteMsg{ProviderID: "openai", APIKey: "$OPENAI_API_KEY", LargeModelID: "o3", SmallModelID: "gpt-4o-mini"}
+	if msg != want || !saved {
+		t.Errorf("CompleteMsg = %+v (saved %v), want %+v", msg, saved, want)
+	}
+}
+
+func TestWizard_Restore_GoBackAfterRestore(t *testing.T) {
+	providers := append(quickProviders(), catwalk.Provider{
+		ID:     catwalk.InferenceProviderAnthropic,
+		Name:   "Anthropic",
+		Models: []catwalk.Model{{ID: "claude-sonnet"}},
+	})
+	w := NewWizard(providers)
+	err := w.Restore(WizardState{
+		ProviderID: string(catwalk.InferenceProviderAnthropic),
+		OAuthToken: &oauth.Token{AccessToken: "token"},
+		APIKey:     "token",
+		Step:       StepLargeModel,
+		AuthMethod: AuthMethodOAuth2,
+	})
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	for _, want := range []Step{StepOAuth, StepAuthMethod, StepProvider} {
+		w.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyEscape}))
+		if w.step != want {
+			t.Fatalf("Step() = %v, want %v", w.step, want)
+		}
+		_ = w.View()
+	}
+}
+
+func TestWizard_Restore_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		state   WizardState
+		wantErr string
+	}{
+		{
+			name:    "unknown provider",
+			state:   WizardState{ProviderID: "nope", Step: StepAPIKey},
+			wantErr: `unknown provider "nope"`,
+		},
+		{
+			name:    "unknown model",
+			state:   WizardState{ProviderID: "openai", APIKey: "k", LargeModelID: "gpt-9", Step: StepSmallModel},
+			wantErr: `has no model "gpt-9"`,
+		},
+		{
+			name:    "models without credentials",
+			state:   WizardState{ProviderID: "openai", Step: StepLargeModel},
+			wantErr: "no credentials",
+		},
+		{
+			name:    "oauth for non-anthropic provider",
+			state:   WizardState{ProviderID: "openai", Step: StepOAuth},
+			wantErr: "does not offer OAuth",
+		},
+		{
+			name:    "unknown step",
+			state:   WizardState{Step: Step(42)},
+			wantErr: "unknown wizard step",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWizard(quickProviders())
+			err := w.Restore(tt.state)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Restore() error = %v, want %q", err, tt.wantErr)
+			}
+			if w.step != StepProvider {
+				t.Errorf("failed Restore() changed the step to %v", w.step)
+			}
+		})
+	}
+}
@@ -2,7 +2,12 @@
 package wizard
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
@@ -36,6 +41,11 @@ type CompleteMsg struct {
 	SmallModelID string
 }
 
+// SaveFailedMsg is sent when saving the wizard result fails.
+type SaveFailedMsg struct {
+	Err error
+}
+
 // Wizard manages the setup wizard flow.
 type Wizard struct {
 	providerList     *ProviderList
@@ -48,35 +58,168 @@ type Wizard struct {
 	selectedLarge    *catwalk.Model
 	selectedSmall    *catwalk.Model
 	oauthToken       *oauth.Token
+	save             func() error
+	copyText         func(string) error
+	reveal           func(string) error
+	saveErr          error
 	apiKey           string
 	providers        []catwalk.Provider
 	height           int
 	width            int
 	step             Step
 	authMethod       AuthMethod
+	quick            bool
+	saving           bool
+	// previous is the selection last used in this project, offered for
+	// resuming. Nil when there is none.
+	previous *QuickSelection
+	// simulated marks a preview run whose config is written to a temporary
+	// directory and discarded.
+	simulated bool
+}
+
+// QuickSelection preselects the provider and models so the wizard only
+// asks for the API key. Empty model IDs use the provider's defaults.
+type QuickSelection struct {
+	ProviderID   string
+	LargeModelID string
+	SmallModelID string
 }
 
 // NewWizard creates a new wizard instance.
 func NewWizard(providers []catwalk.Provider) *Wizard {
-	return &Wizard{
+	w := &Wizard{
 		step:         StepProvider,
 		providers:    providers,
 		providerList: NewProviderList(providers),
 	}
+	w.save = w.persist
+	w.copyText = copyToClipboard
+	w.reveal = openPath
+	return w
+}
+
+// NewQuickWizard creates a wizard that starts at API key entry with the
+// provider and models from sel already chosen, then saves directly.
+func NewQuickWizard(providers []catwalk.Provider, sel QuickSelection) (*Wizard, error) {
+	w := NewWizard(providers)
+	if err := w.preselect(sel); err != nil {
+		return nil, err
+	}
+	return w, nil
 }
 
-// Init initializes the wizard.
+// preselect chooses the provider and models from sel and moves to API key
+// entry in quick mode, as NewQuickWizard does.
+func (w *Wizard) preselect(sel QuickSelection) error {
+	provider, large, small, err := w.findSelection(sel)
+	if err != nil {
+		return err
+	}
+
+	w.selectedProvider = provider
+	w.selectedLarge = large
+	w.selectedSmall = small
+	w.apiKeyInput = NewAPIKeyInput(provider.Name)
+	w.apiKeyInput.SetWidth(w.width)
+	w.step = StepAPIKey
+	w.quick = true
+	return nil
+}
+
+// findSelection looks up the provider and models of sel. Empty model IDs
+// use the provider's defaults.
+func (w *Wizard) findSelection(sel QuickSelection) (*catwalk.Provider, *catwalk.Model, *catwalk.Model, error) {
+	idx := slices.IndexFunc(w.providers, func(p catwalk.Provider) bool {
+		return string(p.ID) == sel.ProviderID
+	})
+	if idx < 0 {
+		return nil, nil, nil, fmt.Errorf("unknown provider %q", sel.ProviderID)
+	}
+	provider := &w.providers[idx]
+
+	large, err := findModel(provider, sel.LargeModelID, provider.DefaultLargeModelID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	small, err := findModel(provider, sel.SmallModelID, provider.DefaultSmallModelID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return provider, large, small, nil
+}
+
+// SetPreviousSelection offers sel, the selection last used in this project:
+// the provider list starts on its provider, the model lists of that provider
+// on its models, and the resume key jumps to API key entry with it chosen.
+// A selection naming a provider or model that is no longer known is ignored.
+func (w *Wizard) SetPreviousSelection(sel QuickSelection) {
+	provider, large, small, err := w.findSelection(sel)
+	if err != nil {
+		w.previous = nil
+		return
+	}
+	w.previous = &QuickSelection{
+		ProviderID:   string(provider.ID),
+		LargeModelID: large.ID,
+		SmallModelID: small.ID,
+	}
+	w.providerList.SetCursorToProvider(w.previous.ProviderID)
+}
+
+// resumePrevious chooses the previous selection and asks for its API key.
+func (w *Wizard) resumePrevious() tea.Cmd {
+	if err := w.preselect(*w.previous); err != nil {
+		return util.ReportWarn(fmt.Sprintf("Could not resume previous selection: %v", err))
+	}
+	return w.apiKeyInput.Init()
+}
+
+// findModel returns the provider model with the given ID, or fallbackID
+// when id is empty.
+func findModel(provider *catwalk.Provider, id, fallbackID string) (*catwalk.Model, error) {
+	if id == "" {
+		id = fallbackID
+	}
+	for i := range provider.Models {
+		if provider.Models[i].ID == id {
+			return &provider.Models[i], nil
+		}
+	}
+	return nil, fmt.Errorf("provider %q has no model %q", provider.ID, id)
+}
+
+// Init initializes the wizard's current step.
 func (w *Wizard) Init() tea.Cmd {
+	switch w.step {
+	case StepAuthMethod:
+		return w.authMethodChoice.Init()
+	case StepOAuth:
+		return w.oauthFlow.Init()
+	case StepAPIKey:
+		return w.apiKeyInput.Init()
+	case StepLargeModel:
+		return w.largeModel.Init()
+	case StepSmallModel:
+		return w.smallModel.Init()
+	case StepComplete:
+		return nil
+	case StepProvider:
+	}
 	return w.providerList.Init()
 }
 
 // Update handles messages.
 func (w *Wizard) Update(msg tea.Msg) (util.Model, tea.Cmd) {
-	// Handle escape to go back.
+	// Handle escape to go back, and the jump back to provider selection.
 	if keyMsg, ok := msg.(tea.KeyMsg); ok {
-		if keyMsg.String() == "esc" {
+		switch {
+		case keyMsg.String() == "esc":
 			w.goBack()
 			return w, nil
+		case keyMsg.String() == keyChangeProvider && w.onModelStep():
+			w.changeProvider()
+			return w, nil
 		}
 	}
 
@@ -94,13 +237,16 @@ func (w *Wizard) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	case StepSmallModel:
 		return w.updateSmallModel(msg)
 	case StepComplete:
-		return w, nil
+		return w.updateComplete(msg)
 	}
 
 	return w, nil
 }
 
 func (w *Wizard) updateProvider(msg tea.Msg) (util.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == keyResume && w.previous != nil {
+		return w, w.resumePrevious()
+	}
 	if m, ok := msg.(ProviderSelectedMsg); ok {
 		w.selectedProvider = &m.Provider
 
@@ -152,24 +298,11 @@ func (w *Wizard) updateOAuth(msg tea.Msg) (util.Model, tea.Cmd) {
 	}
 
 	if m, ok := msg.(OAuthCompleteMsg); ok {
+		// Only the token is kept; the API key is derived from it on load.
 		w.oauthToken = m.Token
-		w.apiKey = m.Token.AccessToken
-
-		// Create model lists with provider's models.
-		models := w.selectedProvider.Models
-		w.largeModel = NewModelList(models, "large", w.selectedProvider.Name)
-		w.smallModel = NewModelList(models, "small", w.selectedProvider.Name)
-		w.largeModel.SetSize(w.width, w.height)
-		w.smallModel.SetSize(w.width, w.height)
-
-		// Pre-select default models if available.
-		if w.selectedProvider.DefaultLargeModelID != "" {
-			w.largeModel.SetCursorToModel(w.selectedProvider.DefaultLargeModelID)
-		}
-		if w.selectedProvider.DefaultSmallModelID != "" {
-			w.smallModel.SetCursorToModel(w.selectedProvider.DefaultSmallModelID)
-		}
+		w.apiKey = ""
 
+		w.initModelLists()
 		w.step = StepLargeModel
 		return w, w.largeModel.Init()
 	}
@@ -182,21 +315,13 @@ func (w *Wizard) updateAPIKey(msg tea.Msg) (util.Model, tea.Cmd) {
 	if m, ok := msg.(APIKeyEnteredMsg); ok {
 		w.apiKey = m.APIKey
 
-		// Create model lists with provider's models.
-		models := w.selectedProvider.Models
-		w.largeModel = NewModelList(models, "large", w.selectedProvider.Name)
-		w.smallModel = NewModelList(models, "small", w.selectedProvider.Name)
-		w.largeModel.SetSize(w.width, w.height)
-		w.smallModel.SetSize(w.width, w.height)
-
-		// Pre-select default models if available.
-		if w.selectedProvider.DefaultLargeModelID != "" {
-			w.largeModel.SetCursorToModel(w.selectedProvider.DefaultLargeModelID)
-		}
-		if w.selectedProvider.DefaultSmallModelID != "" {
-			w.smallModel.SetCursorToModel(w.selectedProvider.DefaultSmallModelID)
+		// Models were chosen up front in quick mode.
+		if w.quick {
+			w.step = StepComplete
+			return w, w.saveConfig()
 		}
 
+		w.initModelLists()
 		w.step = StepLargeModel
 		return w, w.largeModel.Init()
 	}
@@ -205,6 +330,30 @@ func (w *Wizard) updateAPIKey(msg tea.Msg) (util.Model, tea.Cmd) {
 	return w, cmd
 }
 
+// initModelLists creates the model lists for the selected provider with
+// the provider's default models pre-selected.
+func (w *Wizard) initModelLists() {
+	models := w.selectedProvider.Models
+	w.largeModel = NewModelList(models, "large", w.selectedProvider.Name)
+	w.smallModel = NewModelList(models, "small", w.selectedProvider.Name)
+	w.largeModel.SetSize(w.width, w.height)
+	w.smallModel.SetSize(w.width, w.height)
+
+	// Pre-select the models last used in this project with the provider,
+	// else its default models if available.
+	if w.previous != nil && w.previous.ProviderID == string(w.selectedProvider.ID) {
+		w.largeModel.SetCursorToModel(w.previous.LargeModelID)
+		w.smallModel.SetCursorToModel(w.previous.SmallModelID)
+		return
+	}
+	if w.selectedProvider.DefaultLargeModelID != "" {
+		w.largeModel.SetCursorToModel(w.selectedProvider.DefaultLargeModelID)
+	}
+	if w.selectedProvider.DefaultSmallModelID != "" {
+		w.smallModel.SetCursorToModel(w.selectedProvider.DefaultSmallModelID)
+	}
+}
+
 func (w *Wizard) updateLargeModel(msg tea.Msg) (util.Model, tea.Cmd) {
 	if m, ok := msg.(ModelSelectedMsg); ok {
 		w.selectedLarge = &m.Model
@@ -228,6 +377,76 @@ func (w *Wizard) updateSmallModel(msg tea.Msg) (util.Model, tea.Cmd) {
 	return w, cmd
 }
 
+func (w *Wizard) updateComplete(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch m := msg.(type) {
+	case CompleteMsg:
+		w.saving = false
+	case SaveFailedMsg:
+		w.saving = false
+		w.saveErr = m.Err
+		return w, util.CmdHandler(util.InfoMsg{
+			Type: util.InfoTypeError,
+			Msg:  fmt.Sprintf("Failed to save config: %v", m.Err),
+		})
+	case tea.KeyMsg:
+		if w.saving {
+			return w, nil
+		}
+		if w.canRetrySave() && m.String() == keyRetry {
+			w.saveErr = nil
+			return w, w.saveConfig()
+		}
+		if w.saveErr == nil {
+			return w, w.handleCompleteAction(m.String())
+		}
+	}
+	return w, nil
+}
+
+// canRetrySave reports whether the last save failed in a way that retrying
+// could fix. A read-only config or a permission error fails the same way
+// every time until the user changes something outside the wizard.
+func (w *Wizard) canRetrySave() bool {
+	if w.saveErr == nil {
+		return false
+	}
+	return !errors.Is(w.saveErr, config.ErrReadOnly) && !errors.Is(w.saveErr, fs.ErrPermission)
+}
+
+// HandlesCompleteKey reports whether the completion screen has an action
+// bound to the key, so the caller should not treat it as "continue".
+func (w *Wizard) HandlesCompleteKey(msg tea.KeyMsg) bool {
+	if !w.IsComplete() {
+		return false
+	}
+	switch msg.String() {
+	case keyCopyPath, keyRevealPath:
+		return true
+	}
+	return false
+}
+
+// handleCompleteAction runs the completion screen action bound to key.
+// Failures are reported as a status message rather than an error screen.
+func (w *Wizard) handleCompleteAction(key string) tea.Cmd {
+	configPath := config.GlobalConfigPath()
+
+	switch key {
+	case keyCopyPath:
+		if err := w.copyText(configPath); err != nil {
+			return util.ReportWarn(fmt.Sprintf("Could not copy config path: %v", err))
+		}
+		return util.ReportInfo("Config path copied to clipboard")
+	case keyRevealPath:
+		dir := filepath.Dir(configPath)
+		if err := w.reveal(dir); err != nil {
+			return util.ReportWarn(fmt.Sprintf("Could not open file manager: %v", err))
+		}
+		return util.ReportInfo(fmt.Sprintf("Opened %s", dir))
+	}
+	return nil
+}
+
 func (w *Wizard) goBack() {
 	switch w.step {
 	case StepAuthMethod:
@@ -235,16 +454,19 @@ func (w *Wizard) goBack() {
 		w.authMethodChoice = nil
 	case StepOAuth:
 		w.step = StepAuthMethod
+		w.oauthFlow.Close()
 		w.oauthFlow = nil
 	case StepAPIKey:
-		// If we came from auth method choice, go back there.
-		if w.selectedProvider.ID == catwalk.InferenceProviderAnthropic {
+		// If we came from auth method choice, go back there. Quick mode
+		// skipped it, so going back leaves quick mode for the full flow.
+		if w.selectedProvider.ID == catwalk.InferenceProviderAnthropic && !w.quick {
 			w.step = StepAuthMethod
 			w.apiKeyInput = nil
 		} else {
 			w.step = StepProvider
 			w.apiKeyInput = nil
 		}
+		w.quick = false
 	case StepLargeModel:
 		// Go back to API key or OAuth depending on auth method.
 		if w.oauthToken != nil {
@@ -262,33 +484,37 @@ func (w *Wizard) goBack() {
 	}
 }
 
-func (w *Wizard) saveConfig() tea.Cmd {
-	return func() tea.Msg {
-		var err error
+// onModelStep reports whether the wizard is choosing a model.
+func (w *Wizard) onModelStep() bool {
+	return w.step == StepLargeModel || w.step == StepSmallModel
+}
 
-		if w.oauthToken != nil {
-			// Save with OAuth token.
-			err = config.SaveWizardResultWithOAuth(
-				string(w.selectedProvider.ID),
-				w.oauthToken,
-				w.selectedLarge.ID,
-				w.selectedSmall.ID,
-			)
-		} else {
-			// Save with API key.
-			err = config.SaveWizardResult(
-				string(w.selectedProvider.ID),
-				w.apiKey,
-				w.selectedLarge.ID,
-				w.selectedSmall.ID,
-			)
-		}
+// changeProvider returns to provider selection, discarding everything chosen
+// for the previous provider: its credentials and models.
+func (w *Wizard) changeProvider() {
+	w.oauthFlow.Close()
+	w.step = StepProvider
+	w.selectedProvider = nil
+	w.selectedLarge = nil
+	w.selectedSmall = nil
+	w.authMethod = AuthMethodOAuth2
+	w.authMethodChoice = nil
+	w.oauthFlow = nil
+	w.oauthToken = nil
+	w.apiKeyInput = nil
+	w.apiKey = ""
+	w.largeModel = nil
+	w.smallModel = nil
+	w.quick = false
+}
 
-		if err != nil {
-			return util.InfoMsg{
-				Type: util.InfoTypeError,
-				Msg:  fmt.Sprintf("Failed to save config: %v", err),
-			}
+// saveConfig starts saving the wizard result. The wizard isn't complete
+// until the save reports back with CompleteMsg or SaveFailedMsg.
+func (w *Wizard) saveConfig() tea.Cmd {
+	w.saving = true
+	return func() tea.Msg {
+		if err := w.save(); err != nil {
+			return SaveFailedMsg{Err: err}
 		}
 		return CompleteMsg{
 			ProviderID:   string(w.selectedProvider.ID),
@@ -299,10 +525,29 @@ func (w *Wizard) saveConfig() tea.Cmd {
 	}
 }
 
+// persist writes the wizard result to the global config file.
+func (w *Wizard) persist() error {
+	if w.oauthToken != nil {
+		// Save with OAuth token.
+		return config.SaveWizardResultWithOAuth(
+			string(w.selectedProvider.ID),
+			w.oauthToken,
+			w.selectedLarge.ID,
+			w.selectedSmall.ID,
+		)
+	}
+
+	// Save with API key.
+	return config.SaveWizardResult(
+		string(w.selectedProvider.ID),
+		w.apiKey,
+		w.selectedLarge.ID,
+		w.selectedSmall.ID,
+	)
+}
+
 // View renders the current wizard step.
 func (w *Wizard) View() string {
-	t := styles.CurrentTheme()
-
 	// Progress indicator.
 	progress := w.renderProgress()
 
@@ -310,6 +555,9 @@ func (w *Wizard) View() string {
 	switch w.step {
 	case StepProvider:
 		content = w.providerList.View()
+		if w.previous != nil {
+			content = lipgloss.JoinVertical(lipgloss.Left, content, "", w.renderPrevious())
+		}
 	case StepAuthMethod:
 		content = w.authMethodChoice.View()
 	case StepOAuth:
@@ -324,21 +572,73 @@ func (w *Wizard) View() string {
 		content = w.renderComplete()
 	}
 
-	// Back hint (except on first step).
-	backHint := ""
-	if w.step > StepProvider && w.step < StepComplete {
-		backHint = t.S().Subtle.Render("Press Esc to go back")
-	}
-
 	return lipgloss.JoinVertical(lipgloss.Left,
 		progress,
 		"",
 		content,
 		"",
-		backHint,
+		w.renderFooter(),
 	)
 }
 
+// renderPrevious describes the previous selection offered for resuming.
+func (w *Wizard) renderPrevious() string {
+	t := styles.CurrentTheme()
+	return t.S().Muted.Render(fmt.Sprintf("Last used in this project: %s, %s / %s",
+		w.previous.ProviderID, w.previous.LargeModelID, w.previous.SmallModelID))
+}
+
+// footerHints returns the key bindings relevant to the current step.
+func (w *Wizard) footerHints() []keyHint {
+	switch w.step {
+	case StepProvider:
+		if w.previous != nil {
+			return []keyHint{hintNavigate, hintSelect, hintResume, hintQuit}
+		}
+		return []keyHint{hintNavigate, hintSelect, hintQuit}
+	case StepAuthMethod:
+		return []keyHint{{key: "Tab/←/→", desc: "switch"}, hintSelect, hintBack, hintQuit}
+	case StepOAuth:
+		if w.oauthFlow != nil && w.oauthFlow.IsURLState() {
+			toggle := keyHint{key: "u", desc: "show full URL"}
+			if w.oauthFlow.ShowsFullURL() {
+				toggle.desc = "shorten URL"
+			}
+			return []keyHint{hintConfirm, toggle, hintBack, hintQuit}
+		}
+		return []keyHint{hintConfirm, {key: "Ctrl+V", desc: "paste code"}, hintBack, hintQuit}
+	case StepAPIKey:
+		return []keyHint{hintConfirm, {key: "Tab", desc: "show/hide"}, hintBack, hintQuit}
+	case StepLargeModel, StepSmallModel:
+		return []keyHint{hintNavigate, hintSelect, hintBack, hintChangeProvider, hintQuit}
+	case StepComplete:
+		if w.canRetrySave() {
+			return []keyHint{{key: "r", desc: "retry"}, hintQuit}
+		}
+		if w.saving || w.saveErr != nil {
+			return []keyHint{hintQuit}
+		}
+		// The completion screen has its own "press any key" prompt.
+	}
+	return nil
+}
+
+// renderFooter renders the key binding reference for the current step.
+func (w *Wizard) renderFooter() string {
+	hints := w.footerHints()
+	if len(hints) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(hints))
+	for _, h := range hints {
+		parts = append(parts, h.String())
+	}
+
+	t := styles.CurrentTheme()
+	return t.S().Subtle.Render(strings.Join(parts, " "+styles.Bullet+" "))
+}
+
 func (w *Wizard) renderProgress() string {
 	t := styles.CurrentTheme()
 
@@ -374,6 +674,24 @@ func (w *Wizard) renderProgress() string {
 func (w *Wizard) renderComplete() string {
 	t := styles.CurrentTheme()
 
+	if w.saving {
+		return t.S().Muted.Render("Saving configuration...")
+	}
+
+	if w.saveErr != nil {
+		hint := "Press r to retry saving"
+		if !w.canRetrySave() {
+			hint = "Fix the problem above and run setup again"
+		}
+		return lipgloss.JoinVertical(lipgloss.Left,
+			t.S().Error.Bold(true).Render("Saving Failed"),
+			"",
+			t.S().Text.Render(w.saveErr.Error()),
+			"",
+			t.S().Info.Render(hint),
+		)
+	}
+
 	title := t.S().Success.Bold(true).Render("Setup Complete!")
 
 	authType := "API Key"
@@ -381,15 +699,24 @@ func (w *Wizard) renderComplete() string {
 		authType = "OAuth (Claude Account)"
 	}
 
-	summary := lipgloss.JoinVertical(lipgloss.Left,
+	lines := []string{
 		t.S().Text.Render(fmt.Sprintf("Provider: %s", w.selectedProvider.Name)),
+	}
+	if baseURL := w.resolvedBaseURL(); baseURL != "" {
+		lines = append(lines, t.S().Text.Render(fmt.Sprintf("Base URL: %s", baseURL)))
+	}
+	lines = append(lines,
 		t.S().Text.Render(fmt.Sprintf("Authentication: %s", authType)),
 		t.S().Text.Render(fmt.Sprintf("Large Model: %s", w.selectedLarge.Name)),
 		t.S().Text.Render(fmt.Sprintf("Small Model: %s", w.selectedSmall.Name)),
 	)
+	summary := lipgloss.JoinVertical(lipgloss.Left, lines...)
 
 	configPath := config.GlobalConfigPath()
 	saved := t.S().Muted.Render(fmt.Sprintf("Configuration saved to: %s", configPath))
+	if w.simulated {
+		saved = t.S().Warning.Render(fmt.Sprintf("Simulated setup: %s is discarded on exit. Your real config was not changed.", configPath))
+	}
 
 	return lipgloss.JoinVertical(lipgloss.Left,
 		title,
@@ -398,10 +725,21 @@ func (w *Wizard) renderComplete() string {
 		"",
 		saved,
 		"",
-		t.S().Info.Render("Press any key to continue..."),
+		t.S().Info.Render("Press c to copy the path, o to open its folder, any other key to continue..."),
 	)
 }
 
+// resolvedBaseURL returns the selected provider's endpoint with environment
+// variables expanded, so typos in a custom URL are visible before use.
+func (w *Wizard) resolvedBaseURL() string {
+	endpoint := w.selectedProvider.APIEndpoint
+	resolved, err := config.NewResolver().Resolve(endpoint)
+	if err != nil {
+		return fmt.Sprintf("%s (%v)", endpoint, err)
+	}
+	return resolved
+}
+
 // SetSize sets the wizard size.
 func (w *Wizard) SetSize(width, height int) {
 	w.width = width
@@ -427,9 +765,21 @@ func (w *Wizard) SetSize(width, height int) {
 	}
 }
 
-// IsComplete returns true if the wizard is complete.
+// SetSimulated marks the wizard as a preview whose saved config is
+// discarded, so the completion screen says so.
+func (w *Wizard) SetSimulated(simulated bool) {
+	w.simulated = simulated
+}
+
+// Step returns the current wizard step.
+func (w *Wizard) Step() Step {
+	return w.step
+}
+
+// IsComplete returns true if the wizard is complete and its result saved.
+// A save still running or failed keeps the wizard open.
 func (w *Wizard) IsComplete() bool {
-	return w.step == StepComplete
+	return w.step == StepComplete && !w.saving && w.saveErr == nil
 }
 
 // Cursor returns the cursor position for the current step.
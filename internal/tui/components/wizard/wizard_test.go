@@ -1,10 +1,20 @@
 package wizard
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	tea "charm.land/bubbletea/v2"
+	"github.com/adrg/xdg"
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+	"github.com/guilhermegouw/matrix-cli/internal/oauth"
+	"github.com/guilhermegouw/matrix-cli/internal/tui/util"
 )
 
 func TestNewWizard(t *testing.T) {
@@ -323,3 +333,591 @@ func TestWizard_APIKeyStepIndex(t *testing.T) {
 		}
 	}
 }
+
+func TestWizard_RenderFooter(t *testing.T) {
+	providers := []catwalk.Provider{
+		{ID: catwalk.InferenceProviderAnthropic, Name: "Anthropic"},
+	}
+
+	//nolint:govet // Field order optimized for test readability.
+	tests := []struct {
+		name    string
+		step    Step
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "provider step has no back",
+			step:    StepProvider,
+			want:    []string{"↑/↓ navigate", "Enter select", "Ctrl+C quit"},
+			notWant: []string{"Esc back"},
+		},
+		{
+			name: "auth method step",
+			step: StepAuthMethod,
+			want: []string{"Tab/←/→ switch", "Enter select", "Esc back", "Ctrl+C quit"},
+		},
+		{
+			name:    "oauth step",
+			step:    StepOAuth,
+			want:    []string{"Enter confirm", "Esc back"},
+			notWant: []string{"navigate"},
+		},
+		{
+			name:    "api key step",
+			step:    StepAPIKey,
+			want:    []string{"Enter confirm", "Tab show/hide", "Esc back"},
+			notWant: []string{"navigate"},
+		},
+		{
+			name: "large model step",
+			step: StepLargeModel,
+			want: []string{"↑/↓ navigate", "Enter select", "Esc back"},
+		},
+		{
+			name: "small model step",
+			step: StepSmallModel,
+			want: []string{"↑/↓ navigate", "Enter select", "Esc back"},
+		},
+		{
+			name:    "complete step has no footer",
+			step:    StepComplete,
+			notWant: []string{"Esc back", "quit"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWizard(providers)
+			w.step = tt.step
+
+			footer := w.renderFooter()
+			for _, s := range tt.want {
+				if !strings.Contains(footer, s) {
+					t.Errorf("renderFooter() = %q, want it to contain %q", footer, s)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(footer, s) {
+					t.Errorf("renderFooter() = %q, should not contain %q", footer, s)
+				}
+			}
+		})
+	}
+}
+
+func TestWizard_View_ProviderStepFooter(t *testing.T) {
+	providers := []catwalk.Provider{
+		{ID: "openai", Name: "OpenAI"},
+	}
+
+	w := NewWizard(providers)
+	w.SetSize(80, 24)
+
+	view := w.View()
+
+	if !strings.Contains(view, "Enter select") {
+		t.Error("View() should include the key binding footer")
+	}
+	if strings.Contains(view, "Esc back") {
+		t.Error("View() should not offer going back on the first step")
+	}
+}
+
+func newCompletedWizard(t *testing.T) *Wizard {
+	t.Helper()
+
+	providers := []catwalk.Provider{
+		{ID: "openai", Name: "OpenAI"},
+	}
+
+	w := NewWizard(providers)
+	w.step = StepComplete
+	w.selectedProvider = &providers[0]
+	w.selectedLarge = &catwalk.Model{ID: "gpt-4o", Name: "GPT-4o"}
+	w.selectedSmall = &catwalk.Model{ID: "gpt-4o-mini", Name: "GPT-4o Mini"}
+	w.apiKey = "$OPENAI_API_KEY"
+	return w
+}
+
+func TestWizard_OAuthComplete_KeepsOnlyToken(t *testing.T) {
+	providers := []catwalk.Provider{{ID: catwalk.InferenceProviderAnthropic, Name: "Anthropic"}}
+	w := NewWizard(providers)
+	w.step = StepOAuth
+	w.selectedProvider = &providers[0]
+	w.apiKey = "sk-stale"
+
+	token := &oauth.Token{AccessToken: "oauth-access"}
+	w.Update(OAuthCompleteMsg{Token: token})
+
+	if w.oauthToken != token {
+		t.Error("the OAuth token should be kept")
+	}
+	if w.apiKey != "" {
+		t.Errorf("apiKey = %q, want empty so the token isn't saved as a key", w.apiKey)
+	}
+}
+
+func TestWizard_SaveFailure_ShowsRetry(t *testing.T) {
+	w := newCompletedWizard(t)
+	w.save = func() error { return errors.New("disk full") }
+
+	msg := w.saveConfig()()
+	failed, ok := msg.(SaveFailedMsg)
+	if !ok {
+		t.Fatalf("saveConfig() msg = %T, want SaveFailedMsg", msg)
+	}
+
+	_, cmd := w.Update(failed)
+	if cmd == nil {
+		t.Fatal("Update(SaveFailedMsg) should report the error")
+	}
+	if info, ok := cmd().(util.InfoMsg); !ok || info.Type != util.InfoTypeError {
+		t.Errorf("Update(SaveFailedMsg) cmd msg = %v, want error InfoMsg", info)
+	}
+
+	if w.IsComplete() {
+		t.Error("IsComplete() = true, want false after a failed save")
+	}
+
+	view := w.View()
+	if !strings.Contains(view, "Press r to retry saving") {
+		t.Error("View() should offer retrying the save")
+	}
+	if !strings.Contains(view, "disk full") {
+		t.Error("View() should show the save error")
+	}
+}
+
+func TestWizard_SaveRetry_Succeeds(t *testing.T) {
+	w := newCompletedWizard(t)
+	w.save = func() error { return errors.New("disk full") }
+	w.Update(SaveFailedMsg{Err: errors.New("disk full")})
+
+	saves := 0
+	w.save = func() error {
+		saves++
+		return nil
+	}
+
+	_, cmd := w.Update(tea.KeyPressMsg(tea.Key{Code: 'r', Text: "r"}))
+	if cmd == nil {
+		t.Fatal("pressing r should retry the save")
+	}
+
+	if w.IsComplete() {
+		t.Error("IsComplete() = true while the retried save is running")
+	}
+	if !strings.Contains(w.View(), "Saving configuration...") {
+		t.Error("View() should show the save in progress")
+	}
+
+	msg := cmd()
+	complete, ok := msg.(CompleteMsg)
+	if !ok {
+		t.Fatalf("retry msg = %T, want CompleteMsg", msg)
+	}
+	if complete.ProviderID != "openai" || complete.LargeModelID != "gpt-4o" {
+		t.Errorf("CompleteMsg = %+v, want openai/gpt-4o", complete)
+	}
+	if saves != 1 {
+		t.Errorf("save called %d times, want 1", saves)
+	}
+	w.Update(msg)
+	if !w.IsComplete() {
+		t.Error("IsComplete() = false, want true after a successful retry")
+	}
+}
+
+func TestWizard_SaveFailure_NoRetryWhenReadOnly(t *testing.T) {
+	w := newCompletedWizard(t)
+	w.save = func() error {
+		t.Error("a read-only config should not be saved again")
+		return nil
+	}
+	w.Update(SaveFailedMsg{Err: fmt.Errorf("%w: options.read_only is set", config.ErrReadOnly)})
+
+	if _, cmd := w.Update(tea.KeyPressMsg(tea.Key{Code: 'r', Text: "r"})); cmd != nil {
+		t.Error("pressing r should not retry a read-only save")
+	}
+	view := w.View()
+	if strings.Contains(view, "Press r to retry saving") {
+		t.Error("View() should not offer retrying a read-only save")
+	}
+	if !strings.Contains(view, "read_only") {
+		t.Error("View() should show the save error")
+	}
+	if w.IsComplete() {
+		t.Error("IsComplete() = true, want false after a failed save")
+	}
+}
+
+func TestWizard_RetryKeyIgnoredWithoutFailure(t *testing.T) {
+	w := newCompletedWizard(t)
+	w.save = func() error {
+		t.Error("save should not be called without a prior failure")
+		return nil
+	}
+
+	_, cmd := w.Update(tea.KeyPressMsg(tea.Key{Code: 'r', Text: "r"}))
+	if cmd != nil {
+		t.Error("pressing r without a failed save should do nothing")
+	}
+}
+
+func TestWizard_RenderComplete_ResolvedBaseURL(t *testing.T) {
+	t.Setenv("MATRIX_TEST_GATEWAY", "https://gateway.example.com")
+
+	w := newCompletedWizard(t)
+	w.selectedProvider.APIEndpoint = "$MATRIX_TEST_GATEWAY/v1"
+
+	view := w.renderComplete()
+	if !strings.Contains(view, "Base URL: https://gateway.example.com/v1") {
+		t.Errorf("renderComplete() should show the resolved base URL, got:\n%s", view)
+	}
+}
+
+func TestWizard_RenderComplete_UnresolvedBaseURL(t *testing.T) {
+	w := newCompletedWizard(t)
+	w.selectedProvider.APIEndpoint = "${MATRIX_TEST_UNDEFINED_GATEWAY}/v1"
+
+	view := w.renderComplete()
+	if !strings.Contains(view, "MATRIX_TEST_UNDEFINED_GATEWAY") || !strings.Contains(view, "undefined") {
+		t.Errorf("renderComplete() should flag the unresolved variable, got:\n%s", view)
+	}
+}
+
+func TestWizard_RenderComplete_NoBaseURL(t *testing.T) {
+	w := newCompletedWizard(t)
+
+	if view := w.renderComplete(); strings.Contains(view, "Base URL") {
+		t.Errorf("renderComplete() should omit an empty base URL, got:\n%s", view)
+	}
+}
+
+func TestWizard_CompleteActions(t *testing.T) {
+	tests := []struct { //nolint:govet // Test struct alignment is not critical.
+		name       string
+		key        rune
+		failWith   error
+		wantCopied string
+		wantOpened string
+		wantType   util.InfoType
+	}{
+		{name: "copy path", key: 'c', wantCopied: config.GlobalConfigPath(), wantType: util.InfoTypeInfo},
+		{name: "reveal folder", key: 'o', wantOpened: filepath.Dir(config.GlobalConfigPath()), wantType: util.InfoTypeInfo},
+		{name: "copy unavailable", key: 'c', failWith: errors.New("no clipboard"), wantType: util.InfoTypeWarn},
+		{name: "open unavailable", key: 'o', failWith: errors.New("no opener"), wantType: util.InfoTypeWarn},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newCompletedWizard(t)
+			var copied, opened string
+			w.copyText = func(s string) error {
+				if tt.failWith != nil {
+					return tt.failWith
+				}
+				copied = s
+				return nil
+			}
+			w.reveal = func(s string) error {
+				if tt.failWith != nil {
+					return tt.failWith
+				}
+				opened = s
+				return nil
+			}
+
+			key := tea.KeyPressMsg(tea.Key{Code: tt.key, Text: string(tt.key)})
+			if !w.HandlesCompleteKey(key) {
+				t.Errorf("HandlesCompleteKey(%q) = false, want true", tt.key)
+			}
+
+			_, cmd := w.Update(key)
+			if cmd == nil {
+				t.Fatal("Update() should report the action result")
+			}
+			info, ok := cmd().(util.InfoMsg)
+			if !ok {
+				t.Fatalf("cmd() msg is not util.InfoMsg")
+			}
+			if info.Type != tt.wantType {
+				t.Errorf("InfoMsg.Type = %v, want %v (%s)", info.Type, tt.wantType, info.Msg)
+			}
+			if copied != tt.wantCopied {
+				t.Errorf("copied = %q, want %q", copied, tt.wantCopied)
+			}
+			if opened != tt.wantOpened {
+				t.Errorf("opened = %q, want %q", opened, tt.wantOpened)
+			}
+		})
+	}
+}
+
+func TestWizard_HandlesCompleteKey_OtherKeys(t *testing.T) {
+	w := newCompletedWizard(t)
+	if w.HandlesCompleteKey(tea.KeyPressMsg(tea.Key{Code: 'x', Text: "x"})) {
+		t.Error("HandlesCompleteKey(x) = true, want false")
+	}
+
+	w.step = StepSmallModel
+	if w.HandlesCompleteKey(tea.KeyPressMsg(tea.Key{Code: 'c', Text: "c"})) {
+		t.Error("HandlesCompleteKey(c) should be false before the wizard completes")
+	}
+}
+
+func quickProviders() []catwalk.Provider {
+	return []catwalk.Provider{
+		{
+			ID:                  "openai",
+			Name:                "OpenAI",
+			DefaultLargeModelID: "gpt-4o",
+			DefaultSmallModelID: "gpt-4o-mini",
+			Models: []catwalk.Model{
+				{ID: "gpt-4o", Name: "GPT-4o"},
+				{ID: "gpt-4o-mini", Name: "GPT-4o Mini"},
+				{ID: "o3", Name: "o3"},
+			},
+		},
+	}
+}
+
+func TestNewQuickWizard(t *testing.T) {
+	//nolint:govet // Field order optimized for test readability.
+	tests := []struct {
+		name      string
+		sel       QuickSelection
+		wantLarge string
+		wantSmall string
+		wantErr   string
+	}{
+		{
+			name:      "explicit models",
+			sel:       QuickSelection{ProviderID: "openai", LargeModelID: "o3", SmallModelID: "gpt-4o"},
+			wantLarge: "o3",
+			wantSmall: "gpt-4o",
+		},
+		{
+			name:      "provider defaults",
+			sel:       QuickSelection{ProviderID: "openai"},
+			wantLarge: "gpt-4o",
+			wantSmall: "gpt-4o-mini",
+		},
+		{
+			name:    "unknown provider",
+			sel:     QuickSelection{ProviderID: "nope"},
+			wantErr: `unknown provider "nope"`,
+		},
+		{
+			name:    "unknown model",
+			sel:     QuickSelection{ProviderID: "openai", LargeModelID: "gpt-9"},
+			wantErr: `provider "openai" has no model "gpt-9"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := NewQuickWizard(quickProviders(), tt.sel)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("NewQuickWizard() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewQuickWizard() error = %v", err)
+			}
+
+			if w.step != StepAPIKey {
+				t.Errorf("Step() = %v, want StepAPIKey", w.step)
+			}
+			if w.selectedLarge.ID != tt.wantLarge || w.selectedSmall.ID != tt.wantSmall {
+				t.Errorf("models = %s/%s, want %s/%s", w.selectedLarge.ID, w.selectedSmall.ID, tt.wantLarge, tt.wantSmall)
+			}
+		})
+	}
+}
+
+func TestQuickWizard_SavesAfterAPIKey(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+	xdg.Reload()
+
+	w, err := NewQuickWizard(quickProviders(), QuickSelection{ProviderID: "openai", LargeModelID: "o3"})
+	if err != nil {
+		t.Fatalf("NewQuickWizard() error = %v", err)
+	}
+
+	_, cmd := w.Update(APIKeyEnteredMsg{APIKey: "$OPENAI_API_KEY"})
+	if w.step != StepComplete {
+		t.Fatalf("Step() = %v, want StepComplete", w.step)
+	}
+	if cmd == nil {
+		t.Fatal("Update(APIKeyEnteredMsg) should save the config")
+	}
+
+	msg, ok := cmd().(CompleteMsg)
+	if !ok {
+		t.Fatal("save cmd should return CompleteMsg")
+	}
+	want := CompleteMsg{ProviderID: "openai", APIKey: "$OPENAI_API_KEY", LargeModelID: "o3", SmallModelID: "gpt-4o-mini"}
+	if msg != want {
+		t.Errorf("CompleteMsg = %+v, want %+v", msg, want)
+	}
+
+	data, err := os.ReadFile(config.GlobalConfigPath())
+	if err != nil {
+		t.Fatalf("reading saved config: %v", err)
+	}
+	for _, s := range []string{`"api_key": "$OPENAI_API_KEY"`, `"model": "o3"`, `"model": "gpt-4o-mini"`} {
+		if !strings.Contains(string(data), s) {
+			t.Errorf("saved config missing %s:\n%s", s, data)
+		}
+	}
+}
+
+func TestQuickWizard_BackLeavesQuickMode(t *testing.T) {
+	providers := append(quickProviders(), catwalk.Provider{ID: catwalk.InferenceProviderAnthropic, Name: "Anthropic"})
+	w, err := NewQuickWizard(providers, QuickSelection{ProviderID: "openai"})
+	if err != nil {
+		t.Fatalf("NewQuickWizard() error = %v", err)
+	}
+
+	w.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyEscape}))
+	if w.step != StepProvider {
+		t.Errorf("Step() = %v, want StepProvider", w.step)
+	}
+	if w.quick {
+		t.Error("going back should leave quick mode")
+	}
+}
+
+func TestWizard_ChangeProviderFromModelSteps(t *testing.T) {
+	ctrlP := tea.KeyPressMsg(tea.Key{Code: 'p', Mod: tea.ModCtrl})
+
+	for _, step := range []Step{StepLargeModel, StepSmallModel} {
+		t.Run(fmt.Sprintf("step %d", step), func(t *testing.T) {
+			providers := quickProviders()
+			w := NewWizard(providers)
+			w.Update(ProviderSelectedMsg{Provider: providers[0]})
+			w.Update(APIKeyEnteredMsg{APIKey: "sk-test"})
+			if step == StepSmallModel {
+				w.Update(ModelSelectedMsg{Model: providers[0].Models[0]})
+			}
+			if w.step != step {
+				t.Fatalf("step = %d, want %d", w.step, step)
+			}
+
+			w.Update(ctrlP)
+
+			if w.step != StepProvider {
+				t.Errorf("step = %d, want StepProvider", w.step)
+			}
+			if w.selectedProvider != nil || w.selectedLarge != nil || w.selectedSmall != nil {
+				t.Error("changing the provider should reset the provider and model selections")
+			}
+			if w.apiKey != "" || w.apiKeyInput != nil || w.oauthToken != nil {
+				t.Error("changing the provider should discard its credentials")
+			}
+			if w.largeModel != nil || w.smallModel != nil {
+				t.Error("changing the provider should drop the model lists")
+			}
+			if state := w.Snapshot(); state.ProviderID != "" || state.LargeModelID != "" {
+				t.Errorf("Snapshot() = %+v, want no provider or models", state)
+			}
+		})
+	}
+}
+
+func TestWizard_ChangeProviderOnlyOnModelSteps(t *testing.T) {
+	providers := quickProviders()
+	w := NewWizard(providers)
+	w.Update(ProviderSelectedMsg{Provider: providers[0]})
+
+	w.Update(tea.KeyPressMsg(tea.Key{Code: 'p', Mod: tea.ModCtrl}))
+
+	if w.step != StepAPIKey {
+		t.Errorf("step = %d, want StepAPIKey", w.step)
+	}
+	if w.selectedProvider == nil {
+		t.Error("Ctrl+P outside the model steps should keep the provider")
+	}
+}
+
+func TestWizard_ChangeProviderClearsOAuth(t *testing.T) {
+	providers := []catwalk.Provider{{
+		ID:     catwalk.InferenceProviderAnthropic,
+		Name:   "Anthropic",
+		Models: []catwalk.Model{{ID: "claude", Name: "Claude"}},
+	}}
+	w := NewWizard(providers)
+	w.Update(ProviderSelectedMsg{Provider: providers[0]})
+	w.Update(AuthMethodSelectedMsg{Method: AuthMethodOAuth2})
+	w.Update(OAuthCompleteMsg{Token: &oauth.Token{AccessToken: "token"}})
+	if w.step != StepLargeModel {
+		t.Fatalf("step = %d, want StepLargeModel", w.step)
+	}
+
+	w.Update(tea.KeyPressMsg(tea.Key{Code: 'p', Mod: tea.ModCtrl}))
+
+	if w.oauthToken != nil || w.oauthFlow != nil {
+		t.Error("changing the provider should discard the OAuth token and flow")
+	}
+	if w.authMethod != AuthMethodOAuth2 {
+		t.Errorf("authMethod = %d, want the default", w.authMethod)
+	}
+	if view := w.View(); !strings.Contains(view, "Provider") {
+		t.Errorf("View() = %q, want the provider step", view)
+	}
+}
+
+func TestWizard_PreviousSelection(t *testing.T) {
+	providers := append([]catwalk.Provider{{ID: catwalk.InferenceProviderAnthropic, Name: "Anthropic"}}, quickProviders()...)
+	w := NewWizard(providers)
+	w.SetPreviousSelection(QuickSelection{ProviderID: "openai", LargeModelID: "o3", SmallModelID: "gpt-4o"})
+
+	if p := w.providerList.SelectedProvider(); p.ID != "openai" {
+		t.Errorf("provider cursor on %q, want the previous provider", p.ID)
+	}
+	if view := w.View(); !strings.Contains(view, "openai, o3 / gpt-4o") || !strings.Contains(view, "resume previous selection") {
+		t.Errorf("View() = %q, want the previous selection offered", view)
+	}
+
+	// Choosing the provider by hand starts its lists on the previous models.
+	w.Update(ProviderSelectedMsg{Provider: providers[1]})
+	w.Update(APIKeyEnteredMsg{APIKey: "sk-test"})
+	if m := w.largeModel.SelectedModel(); m.ID != "o3" {
+		t.Errorf("large model cursor on %q, want o3", m.ID)
+	}
+	if m := w.smallModel.SelectedModel(); m.ID != "gpt-4o" {
+		t.Errorf("small model cursor on %q, want gpt-4o", m.ID)
+	}
+}
+
+func TestWizard_ResumePreviousSelection(t *testing.T) {
+	w := NewWizard(quickProviders())
+	w.SetPreviousSelection(QuickSelection{ProviderID: "openai", LargeModelID: "o3"})
+
+	w.Update(tea.KeyPressMsg(tea.Key{Code: 'r', Text: "r"}))
+	if w.step != StepAPIKey || !w.quick {
+		t.Fatalf("step = %v, quick = %v, want quick API key entry", w.step, w.quick)
+	}
+	if w.selectedLarge.ID != "o3" || w.selectedSmall.ID != "gpt-4o-mini" {
+		t.Errorf("models = %s/%s, want o3 and the default small model", w.selectedLarge.ID, w.selectedSmall.ID)
+	}
+}
+
+func TestWizard_PreviousSelectionIgnoredWhenUnknown(t *testing.T) {
+	w := NewWizard(quickProviders())
+	w.SetPreviousSelection(QuickSelection{ProviderID: "openai", LargeModelID: "gpt-9"})
+
+	w.Update(tea.KeyPressMsg(tea.Key{Code: 'r', Text: "r"}))
+	if w.step != StepProvider {
+		t.Errorf("step = %v, want the resume key ignored", w.step)
+	}
+	if strings.Contains(w.View(), "resume previous selection") {
+		t.Error("View() offers resuming a selection that no longer exists")
+	}
+}
@@ -3,6 +3,8 @@ package styles
 import (
 	"fmt"
 	"image/color"
+	"log/slog"
+	"strconv"
 	"strings"
 
 	"charm.land/bubbles/v2/textinput"
@@ -164,12 +166,34 @@ func (m *Manager) SetTheme(name string) error {
 	return fmt.Errorf("theme %s not found", name)
 }
 
-// ParseHex converts hex string to color.
+// FallbackColor is used when a hex color can't be parsed. White stays
+// readable on the dark backgrounds the themes use.
+var FallbackColor color.Color = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+// ParseHex converts a "#rrggbb" or "#rgb" hex string to a color.
+// Malformed input returns FallbackColor.
 func ParseHex(hex string) color.Color {
-	var r, g, b uint8
-	//nolint:errcheck // Simple hex parsing; invalid input returns black.
-	fmt.Sscanf(hex, "#%02x%02x%02x", &r, &g, &b)
-	return color.RGBA{R: r, G: g, B: b, A: 255}
+	c, ok := parseHex(hex)
+	if !ok {
+		slog.Debug("Invalid hex color, using fallback", "hex", hex)
+		return FallbackColor
+	}
+	return c
+}
+
+// parseHex converts a hex string to a color, reporting whether it was valid.
+func parseHex(hex string) (color.Color, bool) {
+	digits := strings.TrimPrefix(hex, "#")
+	if len(digits) == 3 {
+		digits = string([]byte{digits[0], digits[0], digits[1], digits[1], digits[2], digits[2]})
+	}
+
+	v, err := strconv.ParseUint(digits, 16, 32)
+	if len(digits) != 6 || err != nil {
+		return nil, false
+	}
+
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, true //nolint:gosec // Masked to 24 bits above.
 }
 
 // ForegroundGrad creates a gradient across the string.
@@ -0,0 +1,32 @@
+package styles
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestParseHex(t *testing.T) {
+	tests := []struct {
+		name string
+		hex  string
+		want color.Color
+	}{
+		{"valid", "#00ff41", color.RGBA{R: 0x00, G: 0xff, B: 0x41, A: 255}},
+		{"uppercase", "#0D0D0D", color.RGBA{R: 0x0d, G: 0x0d, B: 0x0d, A: 255}},
+		{"without hash", "1a1a1a", color.RGBA{R: 0x1a, G: 0x1a, B: 0x1a, A: 255}},
+		{"short form", "#0f4", color.RGBA{R: 0x00, G: 0xff, B: 0x44, A: 255}},
+		{"truncated", "#00ff4", FallbackColor},
+		{"too long", "#00ff4100", FallbackColor},
+		{"garbage", "not-a-color", FallbackColor},
+		{"invalid digits", "#zzzzzz", FallbackColor},
+		{"empty", "", FallbackColor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseHex(tt.hex); got != tt.want {
+				t.Errorf("ParseHex(%q) = %v, want %v", tt.hex, got, tt.want)
+			}
+		})
+	}
+}
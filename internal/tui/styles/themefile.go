@@ -0,0 +1,123 @@
+package styles
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// ThemeFile is the JSON representation of a custom theme. Every color is a
+// "#rrggbb" or "#rgb" hex string and all of them are required.
+type ThemeFile struct {
+	Name        string `json:"name"`
+	IsDark      bool   `json:"is_dark"`
+	Primary     string `json:"primary"`
+	Secondary   string `json:"secondary"`
+	Tertiary    string `json:"tertiary"`
+	Accent      string `json:"accent"`
+	BgBase      string `json:"bg_base"`
+	BgSubtle    string `json:"bg_subtle"`
+	BgOverlay   string `json:"bg_overlay"`
+	FgBase      string `json:"fg_base"`
+	FgMuted     string `json:"fg_muted"`
+	FgSubtle    string `json:"fg_subtle"`
+	Border      string `json:"border"`
+	BorderFocus string `json:"border_focus"`
+	Success     string `json:"success"`
+	Error       string `json:"error"`
+	Warning     string `json:"warning"`
+	Info        string `json:"info"`
+}
+
+// LoadThemeFile reads a custom theme from a JSON file.
+func LoadThemeFile(path string) (*Theme, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // User-provided theme path is trusted.
+	if err != nil {
+		return nil, fmt.Errorf("reading theme file: %w", err)
+	}
+
+	var tf ThemeFile
+	if err := json.Unmarshal(data, &tf); err != nil {
+		return nil, fmt.Errorf("parsing theme file: %w", err)
+	}
+
+	return tf.Theme()
+}
+
+// Theme converts the file representation into a Theme, failing if a color
+// is missing or malformed.
+func (tf *ThemeFile) Theme() (*Theme, error) {
+	if tf.Name == "" {
+		return nil, fmt.Errorf("theme name is required")
+	}
+
+	t := &Theme{Name: tf.Name, IsDark: tf.IsDark}
+	fields := []struct {
+		dst  *color.Color
+		name string
+		hex  string
+	}{
+		{&t.Primary, "primary", tf.Primary},
+		{&t.Secondary, "secondary", tf.Secondary},
+		{&t.Tertiary, "tertiary", tf.Tertiary},
+		{&t.Accent, "accent", tf.Accent},
+		{&t.BgBase, "bg_base", tf.BgBase},
+		{&t.BgSubtle, "bg_subtle", tf.BgSubtle},
+		{&t.BgOverlay, "bg_overlay", tf.BgOverlay},
+		{&t.FgBase, "fg_base", tf.FgBase},
+		{&t.FgMuted, "fg_muted", tf.FgMuted},
+		{&t.FgSubtle, "fg_subtle", tf.FgSubtle},
+		{&t.Border, "border", tf.Border},
+		{&t.BorderFocus, "border_focus", tf.BorderFocus},
+		{&t.Success, "success", tf.Success},
+		{&t.Error, "error", tf.Error},
+		{&t.Warning, "warning", tf.Warning},
+		{&t.Info, "info", tf.Info},
+	}
+
+	var missing, invalid []string
+	for _, f := range fields {
+		if f.hex == "" {
+			missing = append(missing, f.name)
+			continue
+		}
+		c, ok := parseHex(f.hex)
+		if !ok {
+			invalid = append(invalid, f.name)
+			continue
+		}
+		*f.dst = c
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("theme %q is missing colors: %s", tf.Name, strings.Join(missing, ", "))
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("theme %q has invalid colors: %s", tf.Name, strings.Join(invalid, ", "))
+	}
+
+	return t, nil
+}
+
+// NewManagerWithThemeFile creates a theme manager that uses the custom theme
+// at path. If path is empty or the theme can't be loaded, the Matrix theme
+// is used instead.
+func NewManagerWithThemeFile(path string) *Manager {
+	m := NewManager()
+	if path == "" {
+		return m
+	}
+
+	theme, err := LoadThemeFile(path)
+	if err != nil {
+		slog.Warn("Failed to load theme file, using default theme", "path", path, "error", err)
+		return m
+	}
+
+	m.Register(theme)
+	m.current = theme
+	return m
+}
@@ -0,0 +1,108 @@
+package styles
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const validThemeJSON = `{
+	"name": "solarized",
+	"is_dark": true,
+	"primary": "#268bd2",
+	"secondary": "#2aa198",
+	"tertiary": "#073642",
+	"accent": "#b58900",
+	"bg_base": "#002b36",
+	"bg_subtle": "#073642",
+	"bg_overlay": "#586e75",
+	"fg_base": "#839496",
+	"fg_muted": "#657b83",
+	"fg_subtle": "#586e75",
+	"border": "#073642",
+	"border_focus": "#268bd2",
+	"success": "#859900",
+	"error": "#dc322f",
+	"warning": "#cb4b16",
+	"info": "#6c71c4"
+}`
+
+func writeThemeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "theme.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write theme file: %v", err)
+	}
+	return path
+}
+
+func TestLoadThemeFile(t *testing.T) {
+	theme, err := LoadThemeFile(writeThemeFile(t, validThemeJSON))
+	if err != nil {
+		t.Fatalf("LoadThemeFile() error = %v", err)
+	}
+
+	if theme.Name != "solarized" {
+		t.Errorf("Name = %q, want %q", theme.Name, "solarized")
+	}
+	if !theme.IsDark {
+		t.Error("IsDark = false, want true")
+	}
+	want := color.RGBA{R: 0x26, G: 0x8b, B: 0xd2, A: 255}
+	if theme.Primary != want {
+		t.Errorf("Primary = %v, want %v", theme.Primary, want)
+	}
+}
+
+func TestLoadThemeFile_MissingColors(t *testing.T) {
+	content := strings.Replace(validThemeJSON, `"warning": "#cb4b16",`, "", 1)
+	content = strings.Replace(content, `"bg_base": "#002b36",`, "", 1)
+
+	_, err := LoadThemeFile(writeThemeFile(t, content))
+	if err == nil {
+		t.Fatal("LoadThemeFile() expected error for missing colors")
+	}
+	if !strings.Contains(err.Error(), "bg_base") || !strings.Contains(err.Error(), "warning") {
+		t.Errorf("LoadThemeFile() error = %q, want it to list the missing colors", err)
+	}
+}
+
+func TestLoadThemeFile_InvalidColor(t *testing.T) {
+	content := strings.Replace(validThemeJSON, `"#dc322f"`, `"red"`, 1)
+
+	if _, err := LoadThemeFile(writeThemeFile(t, content)); err == nil {
+		t.Error("LoadThemeFile() expected error for invalid color")
+	}
+}
+
+func TestNewManagerWithThemeFile(t *testing.T) {
+	m := NewManagerWithThemeFile(writeThemeFile(t, validThemeJSON))
+	if got := m.Current().Name; got != "solarized" {
+		t.Errorf("Current().Name = %q, want %q", got, "solarized")
+	}
+	if err := m.SetTheme("matrix"); err != nil {
+		t.Errorf("SetTheme(matrix) error = %v, built-in theme should stay registered", err)
+	}
+}
+
+func TestNewManagerWithThemeFile_FallsBackToMatrix(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"no theme file", ""},
+		{"nonexistent file", filepath.Join(t.TempDir(), "missing.json")},
+		{"incomplete theme", writeThemeFile(t, `{"name": "broken", "primary": "#ffffff"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManagerWithThemeFile(tt.path)
+			if got := m.Current().Name; got != "matrix" {
+				t.Errorf("Current().Name = %q, want %q", got, "matrix")
+			}
+		})
+	}
+}
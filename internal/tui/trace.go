@@ -0,0 +1,99 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// redacted replaces secret values in trace entries.
+const redacted = "[REDACTED]"
+
+// headerFields are the field names, lowercased without underscores, of
+// header maps. Their values may hold a key moved into a custom auth header
+// or a resolved environment secret, so all of them are redacted.
+var headerFields = map[string]bool{
+	"extraheaders":   true,
+	"defaultheaders": true,
+}
+
+// isSecretName reports whether a field or header name marks a secret value:
+// Authorization, names ending in "key" and names containing "token". Go
+// field names (APIKey), JSON tags (api_key) and header names (X-API-Key)
+// all match.
+func isSecretName(name string) bool {
+	name = strings.ToLower(name)
+	return name == "authorization" || strings.HasSuffix(name, "key") || strings.Contains(name, "token")
+}
+
+// WithTrace writes every message the TUI receives to w, one per line, for
+// debugging. Secret fields and header values are redacted, and key presses
+// and pastes are recorded by type only since they may be a key being entered.
+func WithTrace(w io.Writer) Option {
+	return func(m *Model) {
+		m.trace = w
+	}
+}
+
+// traceMsg writes msg to the trace, if one is set.
+func (m *Model) traceMsg(msg tea.Msg) {
+	if m.trace == nil {
+		return
+	}
+	fmt.Fprintln(m.trace, traceEntry(msg)) //nolint:errcheck // Tracing is best effort.
+}
+
+// traceEntry formats msg as its type followed by its redacted JSON form.
+// Messages that can't be encoded are recorded by type only.
+func traceEntry(msg tea.Msg) string {
+	name := fmt.Sprintf("%T", msg)
+	switch msg.(type) {
+	case tea.KeyMsg, tea.PasteMsg:
+		return name
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return name
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return name
+	}
+	data, err = json.Marshal(redactSecrets(v))
+	if err != nil {
+		return name
+	}
+	return name + " " + string(data)
+}
+
+// redactSecrets replaces the non-empty string values of secret fields, and
+// every value of header maps, in a decoded JSON value, at any depth.
+func redactSecrets(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if headers, ok := value.(map[string]any); ok && headerFields[strings.ToLower(strings.ReplaceAll(key, "_", ""))] {
+				for name, header := range headers {
+					if header != "" {
+						headers[name] = redacted
+					}
+				}
+				continue
+			}
+			if s, ok := value.(string); ok && s != "" && isSecretName(key) {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redactSecrets(value)
+		}
+	case []any:
+		for i := range v {
+			v[i] = redactSecrets(v[i])
+		}
+	}
+	return v
+}
@@ -0,0 +1,112 @@
+package tui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+	"github.com/guilhermegouw/matrix-cli/internal/oauth"
+	"github.com/guilhermegouw/matrix-cli/internal/tui/components/wizard"
+)
+
+func TestModel_Trace_RedactsOAuthToken(t *testing.T) {
+	var trace bytes.Buffer
+	m := New(nil, true, WithTrace(&trace))
+
+	m.Update(wizard.OAuthCompleteMsg{Token: &oauth.Token{
+		AccessToken:  "sk-ant-oat01-secret-access",
+		RefreshToken: "sk-ant-REDACTED",
+		ExpiresIn:    3600,
+	}})
+
+	out := trace.String()
+	if !strings.Contains(out, "wizard.OAuthCompleteMsg") {
+		t.Errorf("trace = %q, want an OAuthCompleteMsg entry", out)
+	}
+	if !strings.Contains(out, `"access_token":"[REDACTED]"`) || !strings.Contains(out, `"refresh_token":"[REDACTED]"`) {
+		t.Errorf("trace = %q, want redacted token fields", out)
+	}
+	if !strings.Contains(out, `"expires_in":3600`) {
+		t.Errorf("trace = %q, want non-secret fields kept", out)
+	}
+	for _, secret := range []string{"secret-access", "secret-refresh"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("trace contains raw token %q: %s", secret, out)
+		}
+	}
+}
+
+func TestTraceEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     tea.Msg
+		want    string
+		wantNot string
+	}{
+		{
+			name:    "complete message API key",
+			msg:     wizard.CompleteMsg{ProviderID: "openai", APIKey: "sk-secret-key"},
+			want:    `"APIKey":"[REDACTED]"`,
+			wantNot: "sk-secret-key",
+		},
+		{
+			name: "empty secret left as is",
+			msg:  wizard.CompleteMsg{ProviderID: "ollama"},
+			want: `"APIKey":""`,
+		},
+		{
+			name:    "key press by type only",
+			msg:     tea.KeyPressMsg{Code: 's', Text: "s"},
+			want:    "tea.KeyPressMsg",
+			wantNot: `"s"`,
+		},
+		{
+			name:    "paste by type only",
+			msg:     tea.PasteMsg{Content: "sk-pasted-key"},
+			want:    "tea.PasteMsg",
+			wantNot: "sk-pasted-key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := traceEntry(tt.msg)
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("traceEntry() = %q, want containing %q", got, tt.want)
+			}
+			if tt.wantNot != "" && strings.Contains(got, tt.wantNot) {
+				t.Errorf("traceEntry() = %q, must not contain %q", got, tt.wantNot)
+			}
+		})
+	}
+}
+
+func TestTraceEntry_RedactsConfigHeaders(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Options.DefaultHeaders = map[string]string{"X-Team": "team-secret"}
+	cfg.Providers["gateway"] = &config.ProviderConfig{
+		ID:         "gateway",
+		APIKey:     "gw-api-key-secret",
+		AuthHeader: "X-API-Key",
+		ExtraHeaders: map[string]string{
+			"Authorization": "Bearer super-secret-header",
+			"X-API-Key":     "gw-secret",
+		},
+	}
+	cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{Provider: "gateway", Model: "gpt-4o", MaxTokens: 4096}
+
+	got := traceEntry(ConfigReloadedMsg{Config: cfg})
+	for _, secret := range []string{"super-secret-header", "gw-secret", "gw-api-key-secret", "team-secret"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("traceEntry() = %q, must not contain %q", got, secret)
+		}
+	}
+	for _, want := range []string{`"Authorization":"[REDACTED]"`, `"auth_header":"X-API-Key"`, `"max_tokens":4096`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("traceEntry() = %q, want containing %q", got, want)
+		}
+	}
+}
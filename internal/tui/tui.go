@@ -2,12 +2,18 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 
+	"github.com/guilhermegouw/matrix-cli/internal/config"
 	"github.com/guilhermegouw/matrix-cli/internal/tui/components/welcome"
 	"github.com/guilhermegouw/matrix-cli/internal/tui/components/wizard"
 	"github.com/guilhermegouw/matrix-cli/internal/tui/page"
@@ -23,25 +29,115 @@ type Model struct {
 	statusMsg   string
 	keyMap      KeyMap
 	providers   []catwalk.Provider
+	quick       *wizard.QuickSelection
+	previous    *wizard.QuickSelection
+	record      func(wizard.QuickSelection) error
+	config      *config.Config
+	watcher     *config.Watcher
+	reload      ReloadFunc
+	trace       io.Writer
 	width       int
 	height      int
 	isFirstRun  bool
 	ready       bool
+	inline      bool
+	simulated   bool
+}
+
+// Option configures the TUI model.
+type Option func(*Model)
+
+// WithInline renders in the normal terminal buffer without mouse tracking,
+// for terminals that don't support the alternate screen.
+func WithInline(inline bool) Option {
+	return func(m *Model) {
+		m.inline = inline
+	}
+}
+
+// WithQuickSetup opens the wizard directly at API key entry with the
+// provider and models from sel, skipping the welcome screen.
+func WithQuickSetup(sel wizard.QuickSelection) Option {
+	return func(m *Model) {
+		m.quick = &sel
+	}
+}
+
+// WithSelectionMemory offers previous, the selection last used in this
+// project, for resuming in the wizard, and passes the wizard's result to
+// record so the next run can offer it. previous may be nil.
+func WithSelectionMemory(previous *wizard.QuickSelection, record func(wizard.QuickSelection) error) Option {
+	return func(m *Model) {
+		m.previous = previous
+		m.record = record
+	}
+}
+
+// WithSimulatedFirstRun marks a preview of first-run setup whose config is
+// written to a temporary directory, so the wizard reports it as discarded.
+func WithSimulatedFirstRun() Option {
+	return func(m *Model) {
+		m.simulated = true
+	}
+}
+
+// DetectInline reports whether the terminal likely can't handle the
+// alternate screen or mouse tracking, based on the environment.
+// MATRIX_INLINE is parsed as a boolean, with unrecognized values counting
+// as enabled.
+func DetectInline() bool {
+	if envInline() || os.Getenv("CI") != "" {
+		return true
+	}
+	term := os.Getenv("TERM")
+	return term == "" || term == "dumb"
+}
+
+// envInline reports whether MATRIX_INLINE asks for inline mode.
+func envInline() bool {
+	value := os.Getenv("MATRIX_INLINE")
+	if value == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(value)
+	return err != nil || enabled
 }
 
 // New creates a new TUI model.
-func New(providers []catwalk.Provider, isFirstRun bool) *Model {
-	return &Model{
+func New(providers []catwalk.Provider, isFirstRun bool, opts ...Option) *Model {
+	m := &Model{
 		keyMap:      DefaultKeyMap(),
 		providers:   providers,
 		isFirstRun:  isFirstRun,
 		currentPage: page.Welcome,
 		welcome:     welcome.New(),
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// NewLoading creates a TUI model that loads providers in the background
+// while the welcome screen is shown.
+func NewLoading(load welcome.LoadFunc, isFirstRun bool, opts ...Option) *Model {
+	m := New(nil, isFirstRun, opts...)
+	m.welcome = welcome.NewLoading(load)
+	return m
 }
 
 // Init initializes the TUI.
 func (m *Model) Init() tea.Cmd {
+	return tea.Batch(m.initPage(), m.watchTick())
+}
+
+func (m *Model) initPage() tea.Cmd {
+	if m.quick != nil && m.providers != nil {
+		if cmd := m.startQuickSetup(); m.currentPage == page.Wizard {
+			return cmd
+		}
+	}
+
 	// If not first run, we could skip to main page.
 	// For now, always show welcome on first run.
 	if m.isFirstRun {
@@ -55,6 +151,7 @@ func (m *Model) Init() tea.Cmd {
 
 // Update handles messages.
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m.traceMsg(msg)
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.handleWindowSize(msg)
@@ -63,10 +160,33 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if cmd := m.handleGlobalKeys(msg); cmd != nil {
 			return m, cmd
 		}
+	case welcome.ProvidersLoadedMsg:
+		m.handleProvidersLoaded(msg)
+		// The welcome screen stops loading first, so it still starts the
+		// wizard if quick setup can't.
+		_, cmd := m.welcome.Update(msg)
+		if m.quick != nil && msg.Err == nil {
+			return m, m.startQuickSetup()
+		}
+		return m, cmd
 	case welcome.StartWizardMsg:
 		return m.handleStartWizard()
+	case watchTickMsg:
+		return m, m.checkConfig()
+	case ConfigReloadedMsg:
+		m.handleConfigReloaded(msg)
+		return m, nil
 	case wizard.CompleteMsg:
 		m.statusMsg = "Configuration saved successfully!"
+		if m.simulated {
+			m.statusMsg = "Simulated setup finished; the configuration will be discarded."
+		} else {
+			m.recordSelection(msg)
+		}
+		// The wizard waits for the save to finish before completing.
+		if m.wizard != nil {
+			m.wizard.Update(msg)
+		}
 		return m, nil
 	case util.InfoMsg:
 		m.statusMsg = msg.Msg
@@ -87,18 +207,27 @@ func (m *Model) handleWindowSize(msg tea.WindowSizeMsg) {
 	m.updateComponentSizes()
 }
 
+func (m *Model) handleProvidersLoaded(msg welcome.ProvidersLoadedMsg) {
+	m.providers = msg.Providers
+	if msg.Err != nil && !errors.Is(msg.Err, context.Canceled) {
+		m.statusMsg = fmt.Sprintf("Failed to load providers: %v", msg.Err)
+	}
+}
+
 func (m *Model) handleGlobalKeys(msg tea.KeyMsg) tea.Cmd {
 	if msg.String() == "ctrl+c" {
+		m.welcome.Cancel()
 		return tea.Quit
 	}
 	if msg.String() == "q" && m.canQuit() {
+		m.welcome.Cancel()
 		return tea.Quit
 	}
 	return nil
 }
 
 func (m *Model) canQuit() bool {
-	if m.currentPage == page.Welcome {
+	if m.currentPage == page.Welcome || m.currentPage == page.Main {
 		return true
 	}
 	return m.currentPage == page.Wizard && m.wizard != nil && m.wizard.IsComplete()
@@ -106,11 +235,50 @@ func (m *Model) canQuit() bool {
 
 func (m *Model) handleStartWizard() (*Model, tea.Cmd) {
 	m.wizard = wizard.NewWizard(m.providers)
+	m.wizard.SetSimulated(m.simulated)
+	if m.previous != nil {
+		m.wizard.SetPreviousSelection(*m.previous)
+	}
 	m.currentPage = page.Wizard
 	m.updateComponentSizes()
 	return m, m.wizard.Init()
 }
 
+// recordSelection remembers the wizard's result for this project. A failure
+// only costs the next run its suggestion, so it is reported in the status.
+func (m *Model) recordSelection(msg wizard.CompleteMsg) {
+	if m.record == nil {
+		return
+	}
+	err := m.record(wizard.QuickSelection{
+		ProviderID:   msg.ProviderID,
+		LargeModelID: msg.LargeModelID,
+		SmallModelID: msg.SmallModelID,
+	})
+	if err != nil {
+		m.statusMsg = fmt.Sprintf("Configuration saved, but the selection could not be remembered: %v", err)
+	}
+}
+
+// startQuickSetup opens the quick wizard, staying on the welcome screen
+// with a status message if the selection doesn't match a known provider.
+func (m *Model) startQuickSetup() tea.Cmd {
+	sel := *m.quick
+	m.quick = nil
+
+	w, err := wizard.NewQuickWizard(m.providers, sel)
+	if err != nil {
+		m.statusMsg = fmt.Sprintf("Quick setup unavailable: %v", err)
+		return nil
+	}
+
+	m.wizard = w
+	m.wizard.SetSimulated(m.simulated)
+	m.currentPage = page.Wizard
+	m.updateComponentSizes()
+	return m.wizard.Init()
+}
+
 func (m *Model) routeToPage(msg tea.Msg) tea.Cmd {
 	switch m.currentPage {
 	case page.Welcome:
@@ -129,8 +297,9 @@ func (m *Model) updateWizard(msg tea.Msg) tea.Cmd {
 		return nil
 	}
 	if m.wizard.IsComplete() {
-		if _, ok := msg.(tea.KeyMsg); ok {
-			return tea.Quit
+		if keyMsg, ok := msg.(tea.KeyMsg); ok && !m.wizard.HandlesCompleteKey(keyMsg) {
+			// "Press any key to continue" moves on to the main page.
+			return util.CmdHandler(page.ChangeMsg{Page: page.Main})
 		}
 	}
 	_, cmd := m.wizard.Update(msg)
@@ -142,9 +311,11 @@ func (m *Model) View() tea.View {
 	t := styles.CurrentTheme()
 
 	var view tea.View
-	view.AltScreen = true
-	view.MouseMode = tea.MouseModeCellMotion
-	view.BackgroundColor = t.BgBase
+	if !m.inline {
+		view.AltScreen = true
+		view.MouseMode = tea.MouseModeCellMotion
+		view.BackgroundColor = t.BgBase
+	}
 
 	if !m.ready {
 		view.Content = "Loading..."
@@ -184,27 +355,48 @@ func (m *Model) View() tea.View {
 func (m *Model) renderMain() string {
 	t := styles.CurrentTheme()
 	return lipgloss.Place(
-		m.width, m.height,
+		m.width, m.contentHeight(),
 		lipgloss.Center, lipgloss.Center,
-		t.S().Title.Render("Matrix CLI - Ready"),
+		lipgloss.JoinVertical(lipgloss.Center,
+			t.S().Title.Render("Matrix CLI - Ready"),
+			"",
+			t.S().Muted.Render("The chat interface isn't available yet. Press q to quit."),
+		),
 	)
 }
 
+// contentHeight is the height pages may fill. Inline mode doesn't pad pages
+// to the full terminal height, since they render in the normal buffer.
+func (m *Model) contentHeight() int {
+	if m.inline {
+		return 0
+	}
+	return m.height
+}
+
 func (m *Model) updateComponentSizes() {
 	if m.welcome != nil {
-		m.welcome.SetSize(m.width, m.height)
+		m.welcome.SetSize(m.width, m.contentHeight())
 	}
 	if m.wizard != nil {
-		m.wizard.SetSize(m.width, m.height)
+		m.wizard.SetSize(m.width, m.contentHeight())
 	}
 }
 
 // Run starts the TUI program.
-func Run(providers []catwalk.Provider, isFirstRun bool) error {
+func Run(providers []catwalk.Provider, isFirstRun bool, opts ...Option) error {
+	return run(New(providers, isFirstRun, opts...))
+}
+
+// RunLoading starts the TUI program, loading providers in the background.
+func RunLoading(load welcome.LoadFunc, isFirstRun bool, opts ...Option) error {
+	return run(NewLoading(load, isFirstRun, opts...))
+}
+
+func run(model *Model) error {
 	// Initialize theme.
 	styles.NewManager()
 
-	model := New(providers, isFirstRun)
 	// In Bubble Tea v2, AltScreen and MouseMode are set in View()
 	p := tea.NewProgram(model)
 
@@ -0,0 +1,231 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+
+	"github.com/guilhermegouw/matrix-cli/internal/tui/components/welcome"
+	"github.com/guilhermegouw/matrix-cli/internal/tui/components/wizard"
+	"github.com/guilhermegouw/matrix-cli/internal/tui/page"
+)
+
+func TestModel_View_AltScreenByDefault(t *testing.T) {
+	m := New(nil, true)
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	view := m.View()
+	if !view.AltScreen {
+		t.Error("View().AltScreen = false, want true")
+	}
+	if view.MouseMode != tea.MouseModeCellMotion {
+		t.Errorf("View().MouseMode = %v, want cell motion", view.MouseMode)
+	}
+}
+
+func TestModel_View_Inline(t *testing.T) {
+	m := New(nil, true, WithInline(true))
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	view := m.View()
+	if view.AltScreen {
+		t.Error("View().AltScreen = true, want false in inline mode")
+	}
+	if view.MouseMode != tea.MouseModeNone {
+		t.Errorf("View().MouseMode = %v, want none in inline mode", view.MouseMode)
+	}
+	if view.Content == "" {
+		t.Error("View().Content is empty in inline mode")
+	}
+}
+
+func TestDetectInline(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    map[string]string
+		inline bool
+	}{
+		{"capable terminal", map[string]string{"TERM": "xterm-256color"}, false},
+		{"dumb terminal", map[string]string{"TERM": "dumb"}, true},
+		{"no terminal", map[string]string{"TERM": ""}, true},
+		{"ci", map[string]string{"TERM": "xterm-256color", "CI": "true"}, true},
+		{"explicit", map[string]string{"TERM": "xterm-256color", "MATRIX_INLINE": "1"}, true},
+		{"explicit true", map[string]string{"TERM": "xterm-256color", "MATRIX_INLINE": "true"}, true},
+		{"explicit off", map[string]string{"TERM": "xterm-256color", "MATRIX_INLINE": "0"}, false},
+		{"explicit false", map[string]string{"TERM": "xterm-256color", "MATRIX_INLINE": "false"}, false},
+		{"unrecognized", map[string]string{"TERM": "xterm-256color", "MATRIX_INLINE": "yes"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CI", "")
+			t.Setenv("MATRIX_INLINE", "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if got := DetectInline(); got != tt.inline {
+				t.Errorf("DetectInline() = %v, want %v", got, tt.inline)
+			}
+		})
+	}
+}
+
+func TestModel_Init_QuickSetup(t *testing.T) {
+	providers := []catwalk.Provider{{
+		ID:                  "openai",
+		Name:                "OpenAI",
+		DefaultLargeModelID: "gpt-4o",
+		DefaultSmallModelID: "gpt-4o",
+		Models:              []catwalk.Model{{ID: "gpt-4o", Name: "GPT-4o"}},
+	}}
+
+	m := New(providers, true, WithQuickSetup(wizard.QuickSelection{ProviderID: "openai"}))
+	m.Init()
+
+	if m.currentPage != page.Wizard {
+		t.Errorf("currentPage = %v, want wizard", m.currentPage)
+	}
+	if m.wizard == nil || m.wizard.Step() != wizard.StepAPIKey {
+		t.Error("quick setup should start the wizard at API key entry")
+	}
+}
+
+func TestModel_Init_QuickSetupUnknownProvider(t *testing.T) {
+	m := New([]catwalk.Provider{{ID: "openai"}}, true, WithQuickSetup(wizard.QuickSelection{ProviderID: "nope"}))
+	m.Init()
+
+	if m.currentPage != page.Welcome {
+		t.Errorf("currentPage = %v, want welcome", m.currentPage)
+	}
+	if !strings.Contains(m.statusMsg, `unknown provider "nope"`) {
+		t.Errorf("statusMsg = %q, want the quick setup error", m.statusMsg)
+	}
+}
+
+func TestModel_ProvidersLoaded_QuickSetupUnknownProvider(t *testing.T) {
+	load := func(context.Context) ([]catwalk.Provider, error) {
+		return []catwalk.Provider{{ID: "openai"}}, nil
+	}
+	m := NewLoading(load, true, WithQuickSetup(wizard.QuickSelection{ProviderID: "nope"}))
+	cmd := m.Init()
+	if cmd == nil {
+		t.Fatal("Init() should start loading providers")
+	}
+	m.Update(cmd())
+
+	if m.currentPage != page.Welcome {
+		t.Fatalf("currentPage = %v, want welcome", m.currentPage)
+	}
+	if m.welcome.IsLoading() {
+		t.Fatal("welcome screen should stop loading once providers arrive")
+	}
+
+	_, cmd = m.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+	if cmd == nil {
+		t.Fatal("Enter should start the wizard")
+	}
+	m.Update(cmd())
+	if m.currentPage != page.Wizard {
+		t.Errorf("currentPage = %v, want wizard", m.currentPage)
+	}
+}
+
+func TestModel_WizardComplete_KeyContinuesToMain(t *testing.T) {
+	m := New(nil, true)
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m.wizard = newCompletedWizard(t)
+	m.currentPage = page.Wizard
+
+	if !strings.Contains(m.View().Content, "any other key to continue") {
+		t.Fatal("completion screen should prompt to continue")
+	}
+
+	_, cmd := m.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+	if cmd == nil {
+		t.Fatal("Update() should return a page change command")
+	}
+	msg := cmd()
+	if _, ok := msg.(tea.QuitMsg); ok {
+		t.Fatal("a key at completion should continue, not quit")
+	}
+	m.Update(msg)
+
+	if m.currentPage != page.Main {
+		t.Errorf("currentPage = %v, want main", m.currentPage)
+	}
+	if !strings.Contains(m.View().Content, "Press q to quit") {
+		t.Error("main page should explain how to quit")
+	}
+
+	_, cmd = m.Update(tea.KeyPressMsg(tea.Key{Code: 'q', Text: "q"}))
+	if cmd == nil {
+		t.Fatal("q on the main page should quit")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("q on the main page should quit")
+	}
+}
+
+func TestModel_SimulatedFirstRun(t *testing.T) {
+	providers := []catwalk.Provider{{
+		ID:     "openai",
+		Name:   "OpenAI",
+		Models: []catwalk.Model{{ID: "gpt-4o", Name: "GPT-4o"}},
+	}}
+	m := New(providers, true, WithSimulatedFirstRun())
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+
+	m.Update(welcome.StartWizardMsg{})
+	if m.currentPage != page.Wizard || m.wizard == nil {
+		t.Fatalf("currentPage = %v, want the wizard", m.currentPage)
+	}
+
+	// The save command the wizard returns is never run, so nothing is
+	// written; its success is reported directly.
+	m.Update(wizard.ProviderSelectedMsg{Provider: providers[0]})
+	m.Update(wizard.APIKeyEnteredMsg{APIKey: "$OPENAI_API_KEY"})
+	m.Update(wizard.ModelSelectedMsg{Model: providers[0].Models[0]})
+	m.Update(wizard.ModelSelectedMsg{Model: providers[0].Models[0]})
+	m.Update(wizard.CompleteMsg{ProviderID: "openai"})
+
+	if !m.wizard.IsComplete() {
+		t.Fatal("wizard should be complete")
+	}
+	if !strings.Contains(m.statusMsg, "discarded") {
+		t.Errorf("statusMsg = %q, want it to say the config is discarded", m.statusMsg)
+	}
+	if content := m.View().Content; !strings.Contains(content, "Simulated setup") {
+		t.Errorf("completion screen = %q, want the simulation notice", content)
+	}
+}
+
+// newCompletedWizard returns a wizard at the completion step. The save
+// command is never run, so nothing is written to disk; its success is
+// reported to the wizard directly.
+func newCompletedWizard(t *testing.T) *wizard.Wizard {
+	t.Helper()
+
+	providers := []catwalk.Provider{{
+		ID:                  "openai",
+		Name:                "OpenAI",
+		DefaultLargeModelID: "gpt-4o",
+		DefaultSmallModelID: "gpt-4o",
+		Models:              []catwalk.Model{{ID: "gpt-4o", Name: "GPT-4o"}},
+	}}
+	w, err := wizard.NewQuickWizard(providers, wizard.QuickSelection{ProviderID: "openai"})
+	if err != nil {
+		t.Fatalf("NewQuickWizard() error = %v", err)
+	}
+	w.Update(wizard.APIKeyEnteredMsg{APIKey: "$OPENAI_API_KEY"})
+	if w.IsComplete() {
+		t.Fatal("wizard should not be complete while the save is running")
+	}
+	w.Update(wizard.CompleteMsg{ProviderID: "openai"})
+	if !w.IsComplete() {
+		t.Fatal("wizard should be complete once the save finishes")
+	}
+	return w
+}
@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+// watchInterval is how often the watched config files are checked.
+const watchInterval = time.Second
+
+// ConfigReloadedMsg is sent after a watched config file changed and the
+// config was loaded again. Err is set when the new config failed to load,
+// in which case the previous config stays in use.
+type ConfigReloadedMsg struct {
+	Config *config.Config
+	Err    error
+}
+
+// ReloadFunc loads the config again after a watched file changed.
+type ReloadFunc func() (*config.Config, error)
+
+// watchTickMsg asks the model to check the watched config files.
+type watchTickMsg struct{}
+
+// WithConfigWatch reloads the config whenever one of the files in paths
+// changes, replacing cfg and the known providers with the reloaded ones.
+func WithConfigWatch(cfg *config.Config, reload ReloadFunc, paths ...string) Option {
+	return func(m *Model) {
+		m.config = cfg
+		m.reload = reload
+		m.watcher = config.NewWatcher(paths...)
+	}
+}
+
+// watchTick schedules the next check of the watched config files.
+func (m *Model) watchTick() tea.Cmd {
+	if m.watcher == nil {
+		return nil
+	}
+	return tea.Tick(watchInterval, func(time.Time) tea.Msg {
+		return watchTickMsg{}
+	})
+}
+
+// checkConfig reloads the config if a watched file changed, and schedules
+// the next check.
+func (m *Model) checkConfig() tea.Cmd {
+	if !m.watcher.Changed() {
+		return m.watchTick()
+	}
+	reload := m.reload
+	return tea.Batch(m.watchTick(), func() tea.Msg {
+		cfg, err := reload()
+		return ConfigReloadedMsg{Config: cfg, Err: err}
+	})
+}
+
+func (m *Model) handleConfigReloaded(msg ConfigReloadedMsg) {
+	if msg.Err != nil {
+		m.statusMsg = fmt.Sprintf("Config reload failed: %v", msg.Err)
+		return
+	}
+	m.config = msg.Config
+	m.providers = msg.Config.KnownProviders()
+
+	large := msg.Config.Models[config.SelectedModelTypeLarge]
+	small := msg.Config.Models[config.SelectedModelTypeSmall]
+	m.statusMsg = fmt.Sprintf("Config reloaded (large: %s/%s, small: %s/%s)",
+		large.Provider, large.Model, small.Provider, small.Model)
+}
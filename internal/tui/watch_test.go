@@ -0,0 +1,122 @@
+package tui
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/guilhermegouw/matrix-cli/internal/config"
+)
+
+// writeWatchedConfig writes a config selecting model for the large tier and
+// bumps its modification time so the change is seen even within the
+// filesystem's timestamp resolution.
+func writeWatchedConfig(t *testing.T, path, model string, mtime time.Time) {
+	t.Helper()
+	content := `{
+		"providers": {"openai": {"api_key": "sk-test-watch-0123456789"}},
+		"models": {
+			"large": {"provider": "openai", "model": "` + model + `"},
+			"small": {"provider": "openai", "model": "gpt-4o-mini"}
+		},
+		"options": {"data_directory": "` + filepath.Dir(path) + `", "watch": true}
+	}`
+	//nolint:gosec // Test file, permissions not critical.
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+}
+
+// runCmd executes cmd and any batched commands, returning the messages
+// they produce other than the scheduled watch ticks.
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	switch msg := cmd().(type) {
+	case watchTickMsg:
+		return nil
+	case tea.BatchMsg:
+		var msgs []tea.Msg
+		for _, c := range msg {
+			msgs = append(msgs, runCmd(c)...)
+		}
+		return msgs
+	default:
+		return []tea.Msg{msg}
+	}
+}
+
+func TestModel_ConfigWatch_ReloadsOnChange(t *testing.T) {
+	t.Setenv("CATWALK_URL", "http://invalid.invalid.invalid")
+	path := filepath.Join(t.TempDir(), "matrix.json")
+	start := time.Now().Add(-time.Hour)
+	writeWatchedConfig(t, path, "gpt-4o", start)
+
+	cfg, err := config.LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	reload := func() (*config.Config, error) { return config.LoadFromFile(path) }
+	m := New(cfg.KnownProviders(), false, WithConfigWatch(cfg, reload, path))
+
+	// Nothing changed yet, so a tick only schedules the next one.
+	if msgs := runCmd(m.checkConfig()); len(msgs) != 0 {
+		t.Fatalf("checkConfig() without a change produced %v", msgs)
+	}
+
+	writeWatchedConfig(t, path, "gpt-4.1", start.Add(time.Minute))
+	_, cmd := m.Update(watchTickMsg{})
+	msgs := runCmd(cmd)
+	if len(msgs) != 1 {
+		t.Fatalf("tick after a change produced %d messages, want 1", len(msgs))
+	}
+	reloaded, ok := msgs[0].(ConfigReloadedMsg)
+	if !ok {
+		t.Fatalf("tick after a change produced %T, want ConfigReloadedMsg", msgs[0])
+	}
+	if reloaded.Err != nil {
+		t.Fatalf("ConfigReloadedMsg.Err = %v", reloaded.Err)
+	}
+
+	m.Update(reloaded)
+	if got := m.config.Models[config.SelectedModelTypeLarge].Model; got != "gpt-4.1" {
+		t.Errorf("large model after reload = %q, want gpt-4.1", got)
+	}
+	if len(m.providers) == 0 {
+		t.Error("providers are empty after reload")
+	}
+	if !strings.Contains(m.statusMsg, "openai/gpt-4.1") {
+		t.Errorf("statusMsg = %q, want the reloaded large model", m.statusMsg)
+	}
+}
+
+func TestModel_ConfigWatch_ReloadErrorKeepsConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Models[config.SelectedModelTypeLarge] = config.SelectedModel{Provider: "openai", Model: "gpt-4o"}
+	m := New(nil, false, WithConfigWatch(cfg, nil))
+
+	m.Update(ConfigReloadedMsg{Err: errors.New("invalid JSON")})
+
+	if m.config != cfg {
+		t.Error("config was replaced after a failed reload")
+	}
+	if !strings.Contains(m.statusMsg, "invalid JSON") {
+		t.Errorf("statusMsg = %q, want the reload error", m.statusMsg)
+	}
+}
+
+func TestModel_Init_NoWatchWithoutOption(t *testing.T) {
+	m := New(nil, true)
+	if m.watchTick() != nil {
+		t.Error("watchTick() scheduled a check without WithConfigWatch")
+	}
+}